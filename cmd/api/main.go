@@ -2,8 +2,18 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"flag"
 	"log"
 	"net/http"
+	"net/netip"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	_ "example.com/notes-api/docs" // swagger docs
 
@@ -11,28 +21,369 @@ import (
 	httpx "example.com/notes-api/internal/http"
 	"example.com/notes-api/internal/http/handlers"
 	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/search"
 )
 
 // @title Notes API
 // @version 1.0
 // @description Учебный REST API для заметок (CRUD) для практического занятия №12.
 // @description Демонстрация code-first подхода с генерацией Swagger документации через swag.
+// @description Ошибки возвращаются в формате RFC 7807 (application/problem+json, схема handlers.Problem):
+// @description type, title, status, detail, instance (ID запроса) и для неверных данных — список нарушенных правил errors.
+// @description Сообщения об ошибках — на русском или английском по заголовку Accept-Language (по умолчанию английский);
+// @description язык ответа — в заголовке Content-Language.
 
 // @host localhost:8080
 // @BasePath /api/v1
 
 // @schemes http
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Токен сессии из POST /auth/login или JWT доверенного сервиса в виде "Bearer <token>"
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API-ключ из POST /keys; доступ ограничен областями ключа
+
 func main() {
-	// Инициализация репозитория и сервиса
-	rp := repo.NewNoteRepoMem()
-	svc := service.NewNoteService(rp)
+	storage := flag.String("storage", "memory", "хранилище заметок: memory, journal или sqlite")
+	dbPath := flag.String("db", "notes.db", "путь к файлу SQLite (для -storage=sqlite)")
+	dataDir := flag.String("data-dir", "data", "каталог журнала и снимков (для -storage=journal)")
+	snapshotEvery := flag.Int("snapshot-every", 1000, "через сколько записей журнал сжимается в снимок")
+	requireIfMatch := flag.Bool("require-if-match", false, "отклонять PATCH/DELETE без If-Match (428)")
+	revisionsKeep := flag.Int("revisions-keep", 50, "сколько последних ревизий заметки хранить (0 — все)")
+	revisionsMaxAge := flag.Duration("revisions-max-age", 0, "сколько хранить ревизии, например 720h (0 — бессрочно)")
+	trashRetention := flag.Duration("trash-retention", 30*24*time.Hour, "сколько заметка хранится в корзине до удаления насовсем")
+	purgeInterval := flag.Duration("purge-interval", time.Hour, "как часто очищать корзину (0 — не очищать)")
+	sessionTTL := flag.Duration("session-ttl", service.DefaultSessionTTL, "время жизни токена сессии")
+	jwtHMACKeyFile := flag.String("jwt-hmac-key-file", "", "файл с общим секретом для JWT HS256")
+	jwtPublicKey := flag.String("jwt-public-key", "", "PEM-файл открытого ключа для JWT RS256 или EdDSA")
+	jwtJWKS := flag.String("jwt-jwks", "", "локальный файл JWK Set с ключами для JWT")
+	jwtIssuer := flag.String("jwt-issuer", "", "обязательное значение iss в JWT")
+	jwtAudience := flag.String("jwt-audience", "", "обязательное значение aud в JWT")
+	jwtLeeway := flag.Duration("jwt-leeway", 30*time.Second, "допустимое расхождение часов при проверке exp и nbf")
+	auditLogPath := flag.String("audit-log", "audit.jsonl", "файл журнала аудита в формате JSON Lines (\"\" — хранить в памяти)")
+	admins := flag.String("admins", "", "имена пользователей через запятую, которым доступен журнал аудита")
+	webhookAttempts := flag.Int("webhook-max-attempts", 8, "сколько раз пытаться доставить событие веб-хуку до dead letter")
+	webhookBackoff := flag.Duration("webhook-backoff", 30*time.Second, "пауза перед повторной доставкой; удваивается с каждой попыткой, но не больше часа")
+	webhookTimeout := flag.Duration("webhook-timeout", 10*time.Second, "сколько ждать ответа получателя веб-хука")
+	webhookRetention := flag.Duration("webhook-log-retention", 7*24*time.Hour, "сколько хранить завершённые доставки веб-хуков")
+	webhookAllowNets := flag.String("webhook-allow-nets", "", "внутренние сети через запятую (CIDR или IP), куда можно доставлять веб-хуки, например 10.0.5.0/24")
+	eventsReplay := flag.Int("events-replay", service.DefaultEventReplay, "сколько последних изменений заметок хранить для переподключений к /notes/events")
+	eventsHeartbeat := flag.Duration("events-heartbeat", 15*time.Second, "период пингов в потоке /notes/events")
+	collabSave := flag.Duration("collab-save-interval", service.DefaultCollabSaveInterval, "как часто сохранять заметки, которые редактируют совместно")
+	collabOrigins := flag.String("collab-origins", "", "Origin сторонних страниц через запятую, которым можно подключаться к совместному редактированию, например https://app.example.com")
+	quotaMaxNotes := flag.Int("quota-max-notes", 0, "сколько заметок может хранить пользователь, включая корзину (0 — без ограничения)")
+	quotaMaxBytes := flag.Int64("quota-max-bytes", 0, "суммарный размер заголовков и текстов заметок пользователя в байтах (0 — без ограничения)")
+	titleMaxLength := flag.Int("title-max-length", service.DefaultMaxTitleLength, "максимальная длина заголовка заметки в символах (0 — без ограничения)")
+	contentMaxBytes := flag.Int("content-max-bytes", service.DefaultMaxContentBytes, "максимальный размер текста заметки в байтах (0 — без ограничения)")
+	titleForbidden := flag.String("title-forbidden-chars", "", "символы, запрещённые в заголовке заметки, например <>")
+	normalizeNFC := flag.Bool("normalize-nfc", true, "приводить заголовки и тексты заметок к форме Unicode NFC")
+	limits := httpx.RateLimits{
+		IP:     httpx.Limit{Requests: 600, Per: time.Minute},
+		API:    httpx.Limit{Requests: 300, Per: time.Minute},
+		Write:  httpx.Limit{Requests: 60, Per: time.Minute},
+		Public: httpx.Limit{Requests: 20, Per: time.Minute},
+		Routes: httpx.RouteLimits{"POST /api/v1/notes": {Requests: 30, Per: time.Minute}},
+	}
+	flag.Var(&limits.IP, "rate-limit-ip", "лимит всех запросов к API на IP-адрес, до аутентификации")
+	flag.Var(&limits.API, "rate-limit", "лимит запросов к API на клиента, например 300/1m (0 — без ограничения)")
+	flag.Var(&limits.Write, "rate-limit-write", "отдельный лимит изменяющих запросов на клиента")
+	flag.Var(&limits.Public, "rate-limit-public", "лимит регистрации, входа и публичных ссылок на IP-адрес")
+	flag.Var(&limits.Routes, "rate-limit-route", `лимит отдельного маршрута на клиента, например "POST /api/v1/notes=30/1m"; флаг можно повторять`)
+	flag.Parse()
+
+	// Инициализация репозитория и сервиса.
+	// В режимах memory и journal история ревизий, сессии, API-ключи,
+	// доступы, публичные ссылки и веб-хуки хранятся только в памяти;
+	// пользователи и блокноты в режиме journal сохраняются в каталог
+	// данных, чтобы их ID не выдавались заново.
+	var (
+		rp        repo.NoteRepository
+		revs      repo.RevisionRepository  = repo.NewRevisionRepoMem()
+		notebooks repo.NotebookRepository  = repo.NewNotebookRepoMem()
+		users     repo.UserRepository      = repo.NewUserRepoMem()
+		sessions  repo.SessionRepository   = repo.NewSessionRepoMem()
+		apiKeys   repo.APIKeyRepository    = repo.NewAPIKeyRepoMem()
+		shares    repo.ShareRepository     = repo.NewShareRepoMem()
+		links     repo.ShareLinkRepository = repo.NewShareLinkRepoMem()
+		webhooks  repo.WebhookRepository   = repo.NewWebhookRepoMem()
+	)
+	switch *storage {
+	case "memory":
+		rp = repo.NewNoteRepoMem()
+	case "journal":
+		memRepo, err := repo.OpenNoteRepoMem(*dataDir, repo.JournalOptions{SnapshotEvery: *snapshotEvery})
+		if err != nil {
+			log.Fatalf("open journal in %s: %v", *dataDir, err)
+		}
+		defer memRepo.Close()
+		rp = memRepo
+
+		fileUsers, err := repo.OpenUserRepoMem(*dataDir)
+		if err != nil {
+			log.Fatalf("open users in %s: %v", *dataDir, err)
+		}
+		users = fileUsers
+
+		fileNotebooks, err := repo.OpenNotebookRepoMem(*dataDir)
+		if err != nil {
+			log.Fatalf("open notebooks in %s: %v", *dataDir, err)
+		}
+		notebooks = fileNotebooks
+	case "sqlite":
+		db, err := repo.OpenSQLite(*dbPath)
+		if err != nil {
+			log.Fatalf("open sqlite %s: %v", *dbPath, err)
+		}
+		defer db.Close()
+
+		sqliteRepo, err := repo.NewNoteRepoSQLite(db)
+		if err != nil {
+			log.Fatalf("init sqlite schema: %v", err)
+		}
+		rp = sqliteRepo
+
+		sqliteRevs, err := repo.NewRevisionRepoSQLite(db)
+		if err != nil {
+			log.Fatalf("init sqlite schema: %v", err)
+		}
+		revs = sqliteRevs
+
+		sqliteNotebooks, err := repo.NewNotebookRepoSQLite(db)
+		if err != nil {
+			log.Fatalf("init sqlite schema: %v", err)
+		}
+		notebooks = sqliteNotebooks
+
+		sqliteUsers, err := repo.NewUserRepoSQLite(db)
+		if err != nil {
+			log.Fatalf("init sqlite schema: %v", err)
+		}
+		users = sqliteUsers
+
+		sqliteSessions, err := repo.NewSessionRepoSQLite(db)
+		if err != nil {
+			log.Fatalf("init sqlite schema: %v", err)
+		}
+		sessions = sqliteSessions
+
+		sqliteKeys, err := repo.NewAPIKeyRepoSQLite(db)
+		if err != nil {
+			log.Fatalf("init sqlite schema: %v", err)
+		}
+		apiKeys = sqliteKeys
+
+		sqliteShares, err := repo.NewShareRepoSQLite(db)
+		if err != nil {
+			log.Fatalf("init sqlite schema: %v", err)
+		}
+		shares = sqliteShares
+
+		sqliteLinks, err := repo.NewShareLinkRepoSQLite(db)
+		if err != nil {
+			log.Fatalf("init sqlite schema: %v", err)
+		}
+		links = sqliteLinks
+
+		sqliteWebhooks, err := repo.NewWebhookRepoSQLite(db)
+		if err != nil {
+			log.Fatalf("init sqlite schema: %v", err)
+		}
+		webhooks = sqliteWebhooks
+	default:
+		log.Fatalf("unknown storage %q (expected memory, journal or sqlite)", *storage)
+	}
+
+	var auditLog repo.AuditLog = repo.NewAuditLogMem()
+	if *auditLogPath != "" {
+		fileLog, err := repo.OpenAuditLogFile(*auditLogPath)
+		if err != nil {
+			log.Fatalf("open audit log %s: %v", *auditLogPath, err)
+		}
+		defer fileLog.Close()
+		auditLog = fileLog
+	}
+	hooks := service.NewWebhookService(webhooks, service.WebhookOptions{
+		MaxAttempts: *webhookAttempts,
+		Backoff:     *webhookBackoff,
+		Timeout:     *webhookTimeout,
+		Retention:   *webhookRetention,
+		AllowedNets: webhookNets(*webhookAllowNets),
+	})
+	events := service.NewEventBus(*eventsReplay)
+	svc := service.NewNoteService(rp,
+		service.WithSearchIndex(search.NewMemIndex()),
+		service.WithRevisions(revs, repo.RevisionRetention{KeepLast: *revisionsKeep, MaxAge: *revisionsMaxAge}),
+		service.WithNotebooks(notebooks),
+		service.WithSharing(shares, users),
+		service.WithShareLinks(links),
+		service.WithAudit(auditLog),
+		service.WithWebhooks(hooks),
+		service.WithEvents(events),
+		service.WithQuota(service.Quota{MaxNotes: *quotaMaxNotes, MaxBytes: *quotaMaxBytes}),
+		service.WithNoteLimits(service.NoteLimits{
+			Title:   service.TextRule{MaxLength: *titleMaxLength, Forbidden: *titleForbidden},
+			Content: service.TextRule{MaxBytes: *contentMaxBytes, Multiline: true},
+			NFC:     *normalizeNFC,
+		}),
+	)
+	if err := svc.RebuildIndex(); err != nil {
+		log.Fatalf("build search index: %v", err)
+	}
+	auth := service.NewAuthService(users, sessions, *sessionTTL)
 	h := handlers.NewHandler(svc)
+	h.Auth = auth
+	h.Keys = service.NewAPIKeyService(apiKeys, users)
+	h.Webhooks = hooks
+	h.Events = events
+	h.Collab = service.NewCollabService(svc, *collabSave)
+	h.CollabOrigins = splitList(*collabOrigins)
+	h.EventsHeartbeat = *eventsHeartbeat
+	h.Audit = service.NewAuditService(auditLog, adminNames(*admins))
+	h.RequireIfMatch = *requireIfMatch
+
+	authn := &httpx.Authenticator{Sessions: auth, APIKeys: h.Keys}
+	if *jwtHMACKeyFile != "" || *jwtPublicKey != "" || *jwtJWKS != "" {
+		cfg := httpx.JWTConfig{
+			JWKSFile: *jwtJWKS,
+			Issuer:   *jwtIssuer,
+			Audience: *jwtAudience,
+			Leeway:   *jwtLeeway,
+		}
+		if *jwtHMACKeyFile != "" {
+			secret, err := os.ReadFile(*jwtHMACKeyFile)
+			if err != nil {
+				log.Fatalf("read jwt hmac key: %v", err)
+			}
+			cfg.HMACKey = bytes.TrimSpace(secret)
+		}
+		if *jwtPublicKey != "" {
+			key, err := httpx.LoadPublicKeyPEM(*jwtPublicKey)
+			if err != nil {
+				log.Fatalf("read jwt public key: %v", err)
+			}
+			cfg.PublicKeys = append(cfg.PublicKeys, key)
+		}
+		verifier, err := httpx.NewJWTVerifier(cfg)
+		if err != nil {
+			log.Fatalf("init jwt: %v", err)
+		}
+		authn.JWT = verifier
+	}
+
+	router := httpx.NewRouter(h, authn, limits)
+	if err := httpx.CheckRouteLimits(router, limits.Routes); err != nil {
+		log.Fatal(err)
+	}
 
-	router := httpx.NewRouter(h)
+	// По SIGINT/SIGTERM перестаём принимать запросы, дожидаемся текущих
+	// и фоновых задач, после чего отложенные Close закрывают хранилище.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var background sync.WaitGroup
+	if *purgeInterval > 0 {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			svc.RunTrashPurger(ctx, *purgeInterval, *trashRetention)
+		}()
+	}
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		auth.RunSessionCleaner(ctx, time.Hour)
+	}()
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		hooks.RunDispatcher(ctx)
+	}()
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		h.Collab.RunSaver(ctx) // при остановке сохраняет открытые документы
+	}()
 
 	addr := ":8080" // слушаем на всех интерфейсах
-	log.Println("Server started at", addr)
+	srv := &http.Server{Addr: addr, Handler: router}
+	// потоки /notes/events бесконечны: без этого Shutdown ждал бы их до
+	// таймаута
+	srv.RegisterOnShutdown(events.Close)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+	log.Println("Server started at", addr, "storage:", *storage)
 	log.Println("Swagger UI: http://localhost:8080/docs/")
-	log.Fatal(http.ListenAndServe(addr, router))
+
+	select {
+	case err := <-serveErr:
+		stop()
+		background.Wait()
+		log.Fatalf("server: %v", err) // Shutdown ещё не вызывался: это ошибка запуска
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+	background.Wait()
+}
+
+// splitList разбирает список через запятую, пропуская пустые элементы.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// webhookNets разбирает список сетей из флага -webhook-allow-nets;
+// отдельный IP-адрес означает сеть из одного адреса.
+func webhookNets(list string) []netip.Prefix {
+	var nets []netip.Prefix
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(item); err == nil {
+			nets = append(nets, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(item)
+		if err != nil {
+			log.Fatalf("invalid webhook network %q", item)
+		}
+		nets = append(nets, p.Masked())
+	}
+	return nets
+}
+
+// adminNames разбирает список администраторов из флага -admins; имена
+// нормализуются так же, как при регистрации.
+func adminNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		norm, err := service.NormalizeUsername(name)
+		if err != nil {
+			log.Fatalf("invalid admin name %q", name)
+		}
+		names = append(names, norm)
+	}
+	return names
 }
@@ -15,36 +15,108 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/notes": {
+        "/audit": {
             "get": {
-                "description": "Возвращает массив всех заметок",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает события создания, изменения, удаления и восстановления заметок всех пользователей по возрастанию ID:\nкто, когда, с какого адреса и в каком запросе изменил заметку и какие поля изменились.\nДоступен только администраторам (флаг -admins) с токеном сессии или JWT.\nЕсли есть следующая страница, её курсор передаётся в заголовке X-Next-Cursor.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "audit"
+                ],
+                "summary": "Журнал аудита",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "noteId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя пользователя, выполнившего изменение",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Не раньше (RFC 3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Раньше (RFC 3339)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Размер страницы (1–1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор из X-Next-Cursor предыдущей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Список заметок",
                 "responses": {
                     "200": {
-                        "description": "Список заметок",
+                        "description": "События",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/core.Note"
+                                "$ref": "#/definitions/core.AuditEvent"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Курсор следующей страницы"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет прав администратора",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/auth/login": {
             "post": {
-                "description": "Создаёт новую заметку с указанным заголовком и содержимым",
+                "description": "Проверяет имя и пароль и выдаёт токен сессии. Токен передаётся в заголовке Authorization: Bearer \u003ctoken\u003e.",
                 "consumes": [
                     "application/json"
                 ],
@@ -52,129 +124,231 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "auth"
                 ],
-                "summary": "Создать заметку",
+                "summary": "Вход",
                 "parameters": [
                     {
-                        "description": "Данные заметки",
+                        "description": "Имя пользователя и пароль",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateNoteRequest"
+                            "$ref": "#/definitions/handlers.CredentialsRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Созданная заметка",
+                    "200": {
+                        "description": "Токен сессии",
                         "schema": {
-                            "$ref": "#/definitions/core.Note"
+                            "$ref": "#/definitions/handlers.LoginResponse"
                         }
                     },
                     "400": {
-                        "description": "Ошибка валидации",
+                        "description": "Некорректный JSON",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверное имя или пароль",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток входа, см. Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
             }
         },
-        "/notes/{id}": {
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Делает токен из заголовка Authorization недействительным",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Выход",
+                "responses": {
+                    "204": {
+                        "description": "Сессия закрыта"
+                    },
+                    "401": {
+                        "description": "Нет действующего токена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/me": {
             "get": {
-                "description": "Возвращает заметку по её идентификатору",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "auth"
                 ],
-                "summary": "Получить заметку",
+                "summary": "Текущий пользователь",
+                "responses": {
+                    "200": {
+                        "description": "Пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/core.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет действующего токена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Создаёт учётную запись. Для работы с заметками затем нужно войти через /auth/login.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Регистрация",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID заметки",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Имя пользователя и пароль",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CredentialsRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Найденная заметка",
+                    "201": {
+                        "description": "Созданный пользователь",
                         "schema": {
-                            "$ref": "#/definitions/core.Note"
+                            "$ref": "#/definitions/core.User"
                         }
                     },
                     "400": {
-                        "description": "Некорректный ID",
+                        "description": "Некорректное имя или пароль",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Заметка не найдена",
+                    "409": {
+                        "description": "Имя уже занято",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много регистраций с этого адреса, см. Retry-After",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет заметку по ID. При успехе возвращает 204 No Content.",
-                "tags": [
-                    "notes"
-                ],
-                "summary": "Удалить заметку",
-                "parameters": [
+            }
+        },
+        "/keys": {
+            "get": {
+                "security": [
                     {
-                        "type": "integer",
-                        "description": "ID заметки",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "BearerAuth": []
                     }
                 ],
+                "description": "Возвращает ключи текущего пользователя без самих ключей: только начало ключа, области, срок и время последнего использования",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "keys"
+                ],
+                "summary": "Список API-ключей",
                 "responses": {
-                    "204": {
-                        "description": "Заметка удалена"
+                    "200": {
+                        "description": "Ключи",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.APIKey"
+                            }
+                        }
                     },
-                    "400": {
-                        "description": "Некорректный ID",
+                    "401": {
+                        "description": "Требуется аутентификация",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Заметка не найдена",
+                    "403": {
+                        "description": "Запрос подписан API-ключом",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
             },
-            "patch": {
-                "description": "Частично обновляет заметку (PATCH). Можно обновить только title, только content или оба поля.",
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт ключ для скриптов и CI. Ключ передаётся в заголовке X-API-Key и возвращается только в этом ответе — сервер хранит лишь его хеш. Выпускать ключи можно только с токеном сессии или JWT.",
                 "consumes": [
                     "application/json"
                 ],
@@ -182,112 +356,4701 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "notes"
+                    "keys"
                 ],
-                "summary": "Обновить заметку",
+                "summary": "Выпустить API-ключ",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID заметки",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Данные для обновления",
+                        "description": "Параметры ключа",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.UpdateNoteRequest"
+                            "$ref": "#/definitions/handlers.CreateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Обновлённая заметка",
+                    "201": {
+                        "description": "Выпущенный ключ",
                         "schema": {
-                            "$ref": "#/definitions/core.Note"
+                            "$ref": "#/definitions/handlers.CreateAPIKeyResponse"
                         }
                     },
                     "400": {
                         "description": "Некорректные данные",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Заметка не найдена",
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Запрос подписан API-ключом",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlers.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "core.Note": {
-            "description": "Заметка с заголовком и содержимым",
-            "type": "object",
-            "properties": {
-                "content": {
-                    "description": "Содержимое заметки",
-                    "type": "string",
-                    "example": "Текст заметки..."
-                },
-                "createdAt": {
-                    "description": "Дата и время создания",
-                    "type": "string",
-                    "example": "2024-12-08T12:00:00Z"
-                },
-                "id": {
-                    "description": "Уникальный идентификатор заметки",
-                    "type": "integer",
-                    "example": 1
-                },
-                "title": {
-                    "description": "Заголовок заметки",
-                    "type": "string",
-                    "example": "Моя заметка"
-                },
-                "updatedAt": {
-                    "description": "Дата и время последнего обновления",
-                    "type": "string",
-                    "example": "2024-12-08T13:00:00Z"
+        },
+        "/keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ключ перестаёт действовать сразу",
+                "tags": [
+                    "keys"
+                ],
+                "summary": "Отозвать API-ключ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID ключа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Ключ отозван"
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Запрос подписан API-ключом",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Ключ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
                 }
             }
         },
-        "handlers.CreateNoteRequest": {
-            "description": "Данные для создания новой заметки",
-            "type": "object",
-            "properties": {
-                "content": {
-                    "description": "Содержимое заметки",
-                    "type": "string",
+        "/limits": {
+            "get": {
+                "description": "Возвращает правила, по которым сервер проверяет заметки: длину заголовка в символах и размер текста в байтах,\nзапрещённые символы, форму нормализации Unicode, ограничения тегов и квоту хранилища пользователя.\nНулевое ограничение — без ограничения. Управляющие символы запрещены всегда, кроме переводов строк и\nтабуляции в полях с multiline; длина считается после нормализации. Доступен без аутентификации.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "limits"
+                ],
+                "summary": "Ограничения заметок",
+                "responses": {
+                    "200": {
+                        "description": "Ограничения",
+                        "schema": {
+                            "$ref": "#/definitions/service.Limits"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов, см. Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notebooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает все блокноты плоским списком по возрастанию ID; дерево строится по parentId",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notebooks"
+                ],
+                "summary": "Список блокнотов",
+                "responses": {
+                    "200": {
+                        "description": "Блокноты",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.Notebook"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создаёт блокнот на верхнем уровне или внутри другого блокнота",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notebooks"
+                ],
+                "summary": "Создать блокнот",
+                "parameters": [
+                    {
+                        "description": "Данные блокнота",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateNotebookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Созданный блокнот",
+                        "schema": {
+                            "$ref": "#/definitions/core.Notebook"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Родительский блокнот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notebooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notebooks"
+                ],
+                "summary": "Получить блокнот",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID блокнота",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Блокнот",
+                        "schema": {
+                            "$ref": "#/definitions/core.Notebook"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Блокнот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет блокнот. Политика policy определяет судьбу содержимого:\nreject — удалить, только если в блокноте нет заметок и вложенных блокнотов (иначе 409);\ncascade — удалить вложенные блокноты, а все их заметки переместить в корзину;\nroot — перенести заметки и вложенные блокноты на верхний уровень.\nЗаметки в корзине из удаляемых блокнотов переносятся на верхний уровень.",
+                "tags": [
+                    "notebooks"
+                ],
+                "summary": "Удалить блокнот",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID блокнота",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "reject",
+                            "cascade",
+                            "root"
+                        ],
+                        "type": "string",
+                        "default": "reject",
+                        "description": "Что делать с содержимым",
+                        "name": "policy",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Блокнот удалён"
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Блокнот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Блокнот не пуст",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notebooks"
+                ],
+                "summary": "Переименовать блокнот",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID блокнота",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое название",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RenameNotebookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Блокнот",
+                        "schema": {
+                            "$ref": "#/definitions/core.Notebook"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Блокнот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notebooks/{id}/move": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Делает блокнот дочерним для parentId или переносит его на верхний уровень (parentId: null).\nПеренос блокнота в самого себя или в свой вложенный блокнот отклоняется с 409.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notebooks"
+                ],
+                "summary": "Перенести блокнот",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID блокнота",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый родитель",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MoveNotebookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Блокнот",
+                        "schema": {
+                            "$ref": "#/definitions/core.Notebook"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Блокнот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Перенос создал бы цикл",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notebooks/{id}/notes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу заметок блокнота; с recursive=true — и всех вложенных блокнотов.\nОстальные параметры — как у списка заметок.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notebooks"
+                ],
+                "summary": "Заметки блокнота",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID блокнота",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Включать заметки вложенных блокнотов",
+                        "name": "recursive",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 500,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор из X-Next-Cursor предыдущей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "createdAt",
+                            "updatedAt",
+                            "title"
+                        ],
+                        "type": "string",
+                        "default": "id",
+                        "description": "Поле сортировки",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Направление сортировки",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Тег; параметр можно повторять",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "all",
+                            "any"
+                        ],
+                        "type": "string",
+                        "default": "all",
+                        "description": "all — нужны все теги, any — хотя бы один",
+                        "name": "tagMode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Заметки",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.Note"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Курсор следующей страницы"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Блокнот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу заметок с фильтрами и сортировкой.\nЕсли есть следующая страница, её курсор передаётся в заголовке X-Next-Cursor.\nФильтры по времени строгие; для updatedAt у неизменённой заметки используется createdAt.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Список заметок",
+                "parameters": [
+                    {
+                        "maximum": 500,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор из X-Next-Cursor предыдущей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "createdAt",
+                            "updatedAt",
+                            "title"
+                        ],
+                        "type": "string",
+                        "default": "id",
+                        "description": "Поле сортировки",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Направление сортировки",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Созданы после (RFC 3339)",
+                        "name": "createdAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Созданы до (RFC 3339)",
+                        "name": "createdBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Изменены после (RFC 3339)",
+                        "name": "updatedAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Изменены до (RFC 3339)",
+                        "name": "updatedBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Префикс заголовка (с учётом регистра)",
+                        "name": "titlePrefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Тег; параметр можно повторять",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "all",
+                            "any"
+                        ],
+                        "type": "string",
+                        "default": "all",
+                        "description": "all — нужны все теги, any — хотя бы один",
+                        "name": "tagMode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список заметок",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.Note"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Курсор следующей страницы"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создаёт новую заметку с указанным заголовком и содержимым. Заголовок и текст проверяются по правилам из GET /limits\nи приводятся к NFC; ответ 400 перечисляет все нарушения сразу.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Создать заметку",
+                "parameters": [
+                    {
+                        "description": "Данные заметки",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateNoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Созданная заметка",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия заметки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации (пустой или слишком длинный заголовок, запрещённые символы, некорректные теги или неизвестный блокнот)",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Тело запроса больше, чем допускают ограничения из GET /limits",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "507": {
+                        "description": "Исчерпана квота на число или объём заметок",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Server-Sent Events: событие note.created, note.updated или note.deleted (тело — service.NoteEvent)\nна каждое изменение своих заметок и заметок, которыми поделились с пользователем. Периодически\n(по умолчанию раз в 15 секунд) приходит комментарий-пинг. Переподключившись с заголовком Last-Event-ID, клиент получит пропущенные события;\nесли их уже нет в буфере сервера, первым придёт событие reset — заметки нужно перечитать целиком.\nКлиент, который не успевает читать поток, отключается.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Поток изменений заметок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID последнего полученного события",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "То же, что Last-Event-ID, для первого подключения EventSource",
+                        "name": "lastEventId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Поток событий",
+                        "schema": {
+                            "$ref": "#/definitions/service.NoteEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет области notes:read",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Сервер останавливается",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ищет заметки по заголовку и содержимому. Регистр и диакритика (ё/е, é/e) не учитываются,\nрусские и английские словоформы сводятся к общей основе. Все слова запроса должны\nвстречаться в заметке; слова в кавычках ищутся как фраза. Результаты упорядочены по релевантности.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Поиск заметок",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Поисковый запрос; фраза берётся в кавычки",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Максимум результатов (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результаты поиска",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.SearchResultResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Пустой или некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/shared": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Заметки других пользователей, к которым у текущего пользователя есть доступ, и его роль в каждой",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "Доступные мне заметки",
+                "responses": {
+                    "200": {
+                        "description": "Заметки и роли",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.SharedNote"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает страницу удалённых заметок (с полем deletedAt). Параметры — как у списка заметок.\nЗаметки удаляются из корзины насовсем по истечении срока хранения.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Корзина",
+                "parameters": [
+                    {
+                        "maximum": 500,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор из X-Next-Cursor предыдущей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "createdAt",
+                            "updatedAt",
+                            "title"
+                        ],
+                        "type": "string",
+                        "default": "id",
+                        "description": "Поле сортировки",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Направление сортировки",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Созданы после (RFC 3339)",
+                        "name": "createdAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Созданы до (RFC 3339)",
+                        "name": "createdBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Изменены после (RFC 3339)",
+                        "name": "updatedAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Изменены до (RFC 3339)",
+                        "name": "updatedBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Префикс заголовка (с учётом регистра)",
+                        "name": "titlePrefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Тег; параметр можно повторять",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "all",
+                            "any"
+                        ],
+                        "type": "string",
+                        "default": "all",
+                        "description": "all — нужны все теги, any — хотя бы один",
+                        "name": "tagMode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Заметки в корзине",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.Note"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Курсор следующей страницы"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/trash/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Безвозвратно удаляет заметку из корзины вместе с историей изменений.\nЗаметку вне корзины нужно сначала удалить через DELETE /notes/{id}.",
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Удалить заметку насовсем",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии заметки в корзине (обязателен в строгом режиме)",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Заметка удалена насовсем"
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Заметка не в корзине",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия заметки не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Не передан If-Match (строгий режим)",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает свою заметку или заметку, которой поделился другой пользователь. Ответ содержит ETag с версией заметки;\nесли она совпадает с If-None-Match, возвращается 304 без тела.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Получить заметку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag ранее полученной версии",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Найденная заметка",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия заметки"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Заметка не изменилась"
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Перемещает заметку в корзину: она пропадает из списка и поиска, но её можно восстановить\nчерез POST /notes/{id}/restore до истечения срока хранения. При успехе возвращает 204 No Content.\nС заголовком If-Match заметка удаляется, только если её версия совпадает с ETag.",
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Удалить заметку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag удаляемой версии (обязателен в строгом режиме)",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Заметка перемещена в корзину"
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Не владелец заметки или у API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия заметки не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Не передан If-Match (строгий режим)",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Частично обновляет заметку (PATCH). Формат тела выбирается по Content-Type:\napplication/json — поля UpdateNoteRequest, переданные поля заменяются;\napplication/merge-patch+json — JSON Merge Patch (RFC 7396) к документу NoteDocument {title, content, tags, notebookId},\nnull удаляет поле: {\"notebookId\": null} выносит заметку из блокнота;\napplication/json-patch+json — JSON Patch (RFC 6902) к тому же документу, например\n[{\"op\": \"test\", \"path\": \"/title\", \"value\": \"Черновик\"}, {\"op\": \"add\", \"path\": \"/tags/-\", \"value\": \"готово\"}].\nПатч применяется к текущей версии заметки целиком или не применяется вовсе; результат проверяется по правилам\nиз GET /limits. Не прошедшая операция test или отсутствующий путь — 409, другой Content-Type — 415.\nПеренести заметку в другой блокнот патчем может только владелец.\nС заголовком If-Match изменение применяется, только если версия заметки совпадает с ETag.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Обновить заметку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии, которую клиент изменяет (обязателен в строгом режиме)",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные для обновления, JSON Merge Patch или JSON Patch",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateNoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновлённая заметка",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия заметки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные или патч (правила — в GET /limits), блокнот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Роль viewer, перенос в блокнот не владельцем или у API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Операция test JSON Patch не прошла или пути нет в документе",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия заметки не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Тело запроса больше, чем допускают ограничения из GET /limits",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Неподдерживаемый Content-Type; допустимые — в заголовке Accept-Patch",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Не передан If-Match (строгий режим)",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "507": {
+                        "description": "Исчерпана квота на число или объём заметок",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/collab": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "WebSocket: участники правят текст заметки одновременно. Первое сообщение сервера — init с текстом (content),\nревизией (rev), своим clientId и списком участников. Участник отправляет {\"type\": \"op\", \"rev\": N, \"op\": [...], \"cursor\": {...}},\nгде rev — последняя известная ему ревизия, а op — операция над текстом: число \u003e 0 оставляет символы, \u003c 0 удаляет,\nстрока вставляется; позиции считаются в символах Unicode. Сервер преобразует операцию против принятых после rev,\nотвечает ack с новой ревизией и рассылает остальным op; одновременные вставки в одну позицию упорядочиваются по\nвремени приёма. Также приходят cursor, join и leave (присутствие участников), saved (заметка сохранена) и error,\nпосле которого сервер закрывает соединение — нужно подключиться заново. Участник с ролью viewer или API-ключ\nбез notes:write только наблюдают. Сервер сохраняет текст раз в несколько секунд и когда уходит последний участник;\nизменения заметки в обход сессии вливаются в документ операцией сервера. Браузер, который не может передать\nзаголовки, подключается с билетом из POST /notes/{id}/collab/ticket; страницы других сайтов допускаются,\nтолько если их Origin разрешён настройкой сервера.",
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Совместное редактирование заметки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Билет из POST /notes/{id}/collab/ticket вместо заголовков аутентификации",
+                        "name": "ticket",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Соединение WebSocket установлено"
+                    },
+                    "400": {
+                        "description": "Некорректный ID или не запрос WebSocket",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация, билет неверен или истёк",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет области notes:read или Origin не разрешён",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Сервер останавливается",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/collab/ticket": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Браузерный WebSocket не передаёт заголовки Authorization и X-API-Key. Страница получает билет этим запросом\nи подключается к /notes/{id}/collab?ticket=\u003cбилет\u003e. Билет одноразовый, действует 30 секунд и только для этой заметки;\nправа участника — те же, что у выдавшего запроса.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Билет для совместного редактирования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Билет",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CollabTicketResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет области notes:read",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/links": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ссылки без токенов: начало токена, срок действия, число открытий и время последнего открытия",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Публичные ссылки заметки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ссылки",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.ShareLink"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Не владелец заметки или у API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выпускает ссылку, по которой заметку может прочитать любой, даже без учётной записи.\nТокен ссылки возвращается только в этом ответе. Ссылки создаёт только владелец заметки.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "links"
+                ],
+                "summary": "Создать публичную ссылку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Срок действия и пароль",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateShareLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Созданная ссылка",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateShareLinkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный срок действия или пароль",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Не владелец заметки или у API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/links/{linkId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ссылка перестаёт открываться сразу",
+                "tags": [
+                    "links"
+                ],
+                "summary": "Отозвать публичную ссылку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID ссылки",
+                        "name": "linkId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Ссылка отозвана"
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Не владелец заметки или у API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка или ссылка не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/move": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Переносит заметку в блокнот notebookId или убирает её из блокнотов (notebookId: null).\nС заголовком If-Match перенос применяется, только если версия заметки совпадает с ETag.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notebooks"
+                ],
+                "summary": "Перенести заметку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии заметки (обязателен в строгом режиме)",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Целевой блокнот",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MoveNoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Заметка",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия заметки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные или блокнот не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия заметки не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Не передан If-Match (строгий режим)",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает удалённую заметку из корзины. Версия заметки увеличивается.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Восстановить заметку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag версии заметки в корзине (обязателен в строгом режиме)",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Восстановленная заметка",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия заметки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Заметка не в корзине",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия заметки не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Не передан If-Match (строгий режим)",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/revisions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает сохранённые ревизии заметки по возрастанию номера.\nСтарые ревизии удаляются согласно настройкам хранения; последняя сохраняется всегда.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "История заметки",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ревизии заметки",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.NoteRevision"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/revisions/diff": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Построчно сравнивает заголовок и содержимое двух ревизий заметки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Разница между ревизиями",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Исходная ревизия",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Конечная ревизия (по умолчанию — текущая версия)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Разница между ревизиями",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RevisionDiffResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка или ревизия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/revisions/{rev}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает заголовок и содержимое заметки в указанной ревизии",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Получить ревизию",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер ревизии",
+                        "name": "rev",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ревизия",
+                        "schema": {
+                            "$ref": "#/definitions/core.NoteRevision"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID или номер ревизии",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка или ревизия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/revisions/{rev}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает заметке заголовок и содержимое из ревизии. Это обычное изменение:\nверсия заметки растёт и в истории появляется новая ревизия. Поддерживает If-Match.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Восстановить ревизию",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер восстанавливаемой ревизии",
+                        "name": "rev",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag текущей версии заметки (обязателен в строгом режиме)",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Восстановленная заметка",
+                        "schema": {
+                            "$ref": "#/definitions/core.Note"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия заметки"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID, номер ревизии или данные",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка или ревизия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия заметки не совпадает с If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Не передан If-Match (строгий режим)",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "507": {
+                        "description": "Исчерпана квота на число или объём заметок",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/shares": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Список пользователей, которым владелец открыл заметку, с их ролями",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "Доступ к заметке",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Выданные доступы",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.Share"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Не владелец заметки или у API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}/shares/{username}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выдаёт пользователю доступ к заметке или меняет его роль. viewer может только читать заметку,\neditor — ещё и изменять её (PATCH), но не удалять и не делиться дальше. Делиться может только владелец.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "Поделиться заметкой",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя пользователя",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Роль",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ShareRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Выданный доступ",
+                        "schema": {
+                            "$ref": "#/definitions/core.Share"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректная роль или попытка поделиться с собой",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Не владелец заметки или у API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка или пользователь не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "Отозвать доступ",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID заметки",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя пользователя",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Доступ отозван"
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Не владелец заметки или у API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заметка, пользователь или доступ не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes:batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создаёт, изменяет и перемещает в корзину заметки за один запрос — например, при импорте или чистке.\nОперации выполняются по порядку и проверяются так же, как отдельные запросы POST /notes, PATCH /notes/{id}\nи DELETE /notes/{id}; итог каждой — в results с тем же статусом и телом, что у отдельного запроса.\nБез atomic операции независимы. С atomic: true пакет применяется целиком или не применяется вовсе:\nпервая неудачная операция получает свою ошибку, остальные — 424.\nОтвет 200 означает, что пакет принят; успех операций — в их статусах.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Пакет операций над заметками",
+                "parameters": [
+                    {
+                        "description": "Операции",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchNotesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Итоги операций",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchNotesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный JSON, пустой пакет, больше 100 операций, неизвестная операция или нет id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Тело запроса больше, чем допускают ограничения из GET /limits",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "У операции update или delete нет version (строгий режим)",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/public/notes/{token}": {
+            "get": {
+                "description": "Возвращает заметку по токену ссылки без аутентификации: браузеру — HTML-страницей, остальным — JSON\n(формат можно задать параметром format). Пароль защищённой ссылки передаётся в заголовке X-Share-Password\nили полем password формы (POST); HTML-страница сама показывает форму пароля. Каждое открытие учитывается.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "Открыть публичную ссылку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен ссылки",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Формат ответа",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Пароль ссылки",
+                        "name": "X-Share-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Заметка",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PublicNote"
+                        }
+                    },
+                    "403": {
+                        "description": "Нужен пароль или пароль неверен",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Ссылка не найдена, отозвана или истекла",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов с этого адреса, см. Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Возвращает заметку по токену ссылки без аутентификации: браузеру — HTML-страницей, остальным — JSON\n(формат можно задать параметром format). Пароль защищённой ссылки передаётся в заголовке X-Share-Password\nили полем password формы (POST); HTML-страница сама показывает форму пароля. Каждое открытие учитывается.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "Открыть публичную ссылку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Токен ссылки",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "Формат ответа",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Пароль ссылки",
+                        "name": "X-Share-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Заметка",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PublicNote"
+                        }
+                    },
+                    "403": {
+                        "description": "Нужен пароль или пароль неверен",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Ссылка не найдена, отозвана или истекла",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много запросов с этого адреса, см. Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает теги заметок (без учёта корзины) и число заметок с каждым, по алфавиту",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Список тегов",
+                "responses": {
+                    "200": {
+                        "description": "Теги",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repo.TagCount"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags/{tag}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Снимает тег со всех заметок, включая корзину. Сами заметки не удаляются.",
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Удалить тег",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тег",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Тег удалён"
+                    },
+                    "400": {
+                        "description": "Некорректный тег",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Тег не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Переименовывает тег во всех заметках, включая корзину. Версии изменённых заметок увеличиваются.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Переименовать тег",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Тег",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое имя",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RenameTagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Тег переименован",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TagChangeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный тег",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет нужной области доступа",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Тег не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает веб-хуки текущего пользователя без секретов",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Список веб-хуков",
+                "responses": {
+                    "200": {
+                        "description": "Веб-хуки",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.Webhook"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет области webhooks:manage",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Сервер будет отправлять на адрес POST-запросы с событиями заметок пользователя.\nЗапрос подписан: X-Webhook-Signature = \"t=\u003cunix-время\u003e,v1=\u003chex HMAC-SHA256 от \"\u003ct\u003e.\u003cтело\u003e\" с секретом веб-хука\u003e\".\nНеудачные доставки повторяются с растущими паузами; после последней попытки доставка попадает в dead letter.\nАдрес не может указывать на loopback, частные и link-local сети, кроме разрешённых настройкой сервера.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Зарегистрировать веб-хук",
+                "parameters": [
+                    {
+                        "description": "Адрес и события",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Зарегистрированный веб-хук",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateWebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный или внутренний адрес, неизвестные события",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет области webhooks:manage",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Слишком много веб-хуков",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет веб-хук вместе с журналом доставок; неотправленные события не будут доставлены",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Удалить веб-хук",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID веб-хука",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Веб-хук удалён"
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет области webhooks:manage",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Веб-хук не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает последние доставки веб-хука от новых к старым: событие, тело запроса, число попыток,\nвремя следующей попытки, ответ получателя и ошибку. status=dead — dead letter: доставки, для которых\nпопытки исчерпаны. Завершённые доставки хранятся ограниченное время (флаг -webhook-log-retention).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Журнал доставок",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID веб-хука",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "delivered",
+                            "dead"
+                        ],
+                        "type": "string",
+                        "description": "Состояние доставки",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 500,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Сколько доставок вернуть (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Доставки",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/core.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет области webhooks:manage",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Веб-хук не найден",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{deliveryId}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ставит доставку в очередь заново с полным набором попыток — например, чтобы отправить событие из dead letter\nпосле того, как получатель починен. Отправка происходит в фоне.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Повторить доставку",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID веб-хука",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID доставки",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Доставка в очереди",
+                        "schema": {
+                            "$ref": "#/definitions/core.WebhookDelivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется аутентификация",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "У API-ключа нет области webhooks:manage",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Веб-хук или доставка не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "core.APIKey": {
+            "description": "API-ключ (без секрета)",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "Дата и время создания",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "expiresAt": {
+                    "description": "Срок действия; без него ключ бессрочный",
+                    "type": "string",
+                    "example": "2025-12-08T12:00:00Z"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор ключа",
+                    "type": "integer",
+                    "example": 1
+                },
+                "lastUsedAt": {
+                    "description": "Когда ключ последний раз использовался (с точностью до минуты)",
+                    "type": "string",
+                    "example": "2024-12-09T08:30:00Z"
+                },
+                "name": {
+                    "description": "Название, чтобы отличать ключи друг от друга",
+                    "type": "string",
+                    "example": "ci"
+                },
+                "prefix": {
+                    "description": "Начало ключа для опознания в логах и настройках",
+                    "type": "string",
+                    "example": "nk_Xq3vB1a"
+                },
+                "scopes": {
+                    "description": "Области доступа",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "notes:read"
+                    ]
+                },
+                "userId": {
+                    "description": "Владелец ключа",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "core.AuditAction": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "delete",
+                "restore",
+                "purge"
+            ],
+            "x-enum-varnames": [
+                "AuditCreate",
+                "AuditUpdate",
+                "AuditDelete",
+                "AuditRestore",
+                "AuditPurge"
+            ]
+        },
+        "core.AuditEvent": {
+            "description": "Событие журнала аудита",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Вид изменения",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete",
+                        "restore",
+                        "purge"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/core.AuditAction"
+                        }
+                    ],
+                    "example": "update"
+                },
+                "actor": {
+                    "description": "Имя пользователя, выполнившего изменение",
+                    "type": "string",
+                    "example": "alice"
+                },
+                "actorId": {
+                    "description": "ID пользователя, выполнившего изменение; 0 — сам сервер (очистка корзины)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "changes": {
+                    "description": "Изменённые поля",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/core.FieldChange"
+                    }
+                },
+                "clientIp": {
+                    "description": "IP-адрес клиента",
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "id": {
+                    "description": "Порядковый номер события",
+                    "type": "integer",
+                    "example": 1
+                },
+                "noteId": {
+                    "description": "ID заметки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "ownerId": {
+                    "description": "ID владельца заметки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "requestId": {
+                    "description": "X-Request-Id запроса",
+                    "type": "string",
+                    "example": "host/abcdef-000001"
+                },
+                "subject": {
+                    "description": "Как вызывающий вошёл: claim sub для JWT, имя для сессии, начало API-ключа",
+                    "type": "string",
+                    "example": "alice"
+                },
+                "time": {
+                    "description": "Время изменения",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "version": {
+                    "description": "Версия заметки после изменения; у удалённой насовсем — последняя",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "core.DeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "delivered",
+                "dead"
+            ],
+            "x-enum-varnames": [
+                "DeliveryPending",
+                "DeliveryDelivered",
+                "DeliveryDead"
+            ]
+        },
+        "core.FieldChange": {
+            "description": "Изменение поля заметки",
+            "type": "object",
+            "properties": {
+                "after": {
+                    "description": "Значение после изменения",
+                    "type": "string",
+                    "example": "Отчёт за май"
+                },
+                "before": {
+                    "description": "Значение до изменения",
+                    "type": "string",
+                    "example": "Черновик"
+                },
+                "field": {
+                    "description": "Поле: title, content, tags, notebookId или deletedAt",
+                    "type": "string",
+                    "example": "title"
+                }
+            }
+        },
+        "core.Note": {
+            "description": "Заметка с заголовком и содержимым",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Содержимое заметки",
+                    "type": "string",
+                    "example": "Текст заметки..."
+                },
+                "createdAt": {
+                    "description": "Дата и время создания",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "deletedAt": {
+                    "description": "Дата и время перемещения в корзину (только у удалённых заметок)",
+                    "type": "string",
+                    "example": "2024-12-09T10:00:00Z"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор заметки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "notebookId": {
+                    "description": "ID блокнота; отсутствует у заметки вне блокнотов",
+                    "type": "integer",
+                    "example": 2
+                },
+                "ownerId": {
+                    "description": "ID владельца заметки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "tags": {
+                    "description": "Теги заметки: в нижнем регистре, без повторов, по алфавиту",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "работа",
+                        "отчёты"
+                    ]
+                },
+                "title": {
+                    "description": "Заголовок заметки",
+                    "type": "string",
+                    "example": "Моя заметка"
+                },
+                "updatedAt": {
+                    "description": "Дата и время последнего обновления",
+                    "type": "string",
+                    "example": "2024-12-08T13:00:00Z"
+                },
+                "version": {
+                    "description": "Версия заметки; увеличивается при каждом изменении, передаётся в ETag",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "core.NoteRevision": {
+            "description": "Ревизия (сохранённая версия) заметки",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Содержимое в этой ревизии",
+                    "type": "string",
+                    "example": "Текст заметки..."
+                },
+                "createdAt": {
+                    "description": "Время создания ревизии",
+                    "type": "string",
+                    "example": "2024-12-08T13:00:00Z"
+                },
+                "noteId": {
+                    "description": "ID заметки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "revision": {
+                    "description": "Номер ревизии (версия заметки)",
+                    "type": "integer",
+                    "example": 3
+                },
+                "title": {
+                    "description": "Заголовок в этой ревизии",
+                    "type": "string",
+                    "example": "Моя заметка"
+                }
+            }
+        },
+        "core.Notebook": {
+            "description": "Блокнот для группировки заметок",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "Дата и время создания",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор блокнота",
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "description": "Название блокнота",
+                    "type": "string",
+                    "example": "Работа"
+                },
+                "ownerId": {
+                    "description": "ID владельца блокнота",
+                    "type": "integer",
+                    "example": 1
+                },
+                "parentId": {
+                    "description": "ID родительского блокнота; отсутствует у блокнота верхнего уровня",
+                    "type": "integer",
+                    "example": 2
+                },
+                "updatedAt": {
+                    "description": "Дата и время последнего изменения",
+                    "type": "string",
+                    "example": "2024-12-08T13:00:00Z"
+                }
+            }
+        },
+        "core.Share": {
+            "description": "Доступ пользователя к заметке",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "Когда доступ выдан впервые",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "noteId": {
+                    "description": "ID заметки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "role": {
+                    "description": "Роль: viewer или editor",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/core.ShareRole"
+                        }
+                    ],
+                    "example": "viewer"
+                },
+                "userId": {
+                    "description": "ID пользователя, получившего доступ",
+                    "type": "integer",
+                    "example": 2
+                },
+                "username": {
+                    "description": "Имя пользователя, получившего доступ",
+                    "type": "string",
+                    "example": "bob"
+                }
+            }
+        },
+        "core.ShareLink": {
+            "description": "Публичная ссылка на заметку (без токена)",
+            "type": "object",
+            "properties": {
+                "accessCount": {
+                    "description": "Сколько раз ссылку открывали",
+                    "type": "integer",
+                    "example": 3
+                },
+                "createdAt": {
+                    "description": "Дата и время создания",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "expiresAt": {
+                    "description": "Срок действия; без него ссылка действует до отзыва",
+                    "type": "string",
+                    "example": "2024-12-15T12:00:00Z"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор ссылки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "lastAccessAt": {
+                    "description": "Когда ссылку открывали последний раз",
+                    "type": "string",
+                    "example": "2024-12-09T08:30:00Z"
+                },
+                "noteId": {
+                    "description": "ID заметки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "prefix": {
+                    "description": "Начало токена, чтобы отличать ссылки друг от друга",
+                    "type": "string",
+                    "example": "Zr8QpW1c"
+                },
+                "protected": {
+                    "description": "Защищена ли ссылка паролем",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "core.ShareRole": {
+            "type": "string",
+            "enum": [
+                "viewer",
+                "editor"
+            ],
+            "x-enum-varnames": [
+                "RoleViewer",
+                "RoleEditor"
+            ]
+        },
+        "core.SharedNote": {
+            "description": "Заметка, доступная пользователю по приглашению",
+            "type": "object",
+            "properties": {
+                "note": {
+                    "$ref": "#/definitions/core.Note"
+                },
+                "role": {
+                    "description": "Роль пользователя: viewer или editor",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/core.ShareRole"
+                        }
+                    ],
+                    "example": "editor"
+                }
+            }
+        },
+        "core.User": {
+            "description": "Пользователь",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "Дата и время регистрации",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор пользователя",
+                    "type": "integer",
+                    "example": 1
+                },
+                "username": {
+                    "description": "Имя пользователя (логин), в нижнем регистре",
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "core.Webhook": {
+            "description": "Веб-хук (без секрета)",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "Дата и время создания",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "events": {
+                    "description": "События, на которые подписан веб-хук",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "note.created",
+                        "note.updated"
+                    ]
+                },
+                "id": {
+                    "description": "Уникальный идентификатор веб-хука",
+                    "type": "integer",
+                    "example": 1
+                },
+                "url": {
+                    "description": "Адрес получателя (http или https)",
+                    "type": "string",
+                    "example": "https://example.com/hooks/notes"
+                },
+                "userId": {
+                    "description": "Владелец веб-хука; события приходят по его заметкам",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "core.WebhookDelivery": {
+            "description": "Доставка события веб-хуку",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Сколько попыток уже сделано",
+                    "type": "integer",
+                    "example": 1
+                },
+                "createdAt": {
+                    "description": "Дата и время события",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "event": {
+                    "description": "Событие",
+                    "type": "string",
+                    "example": "note.updated"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор доставки; передаётся в X-Webhook-Delivery",
+                    "type": "integer",
+                    "example": 1
+                },
+                "lastAttemptAt": {
+                    "description": "Когда была последняя попытка",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:30Z"
+                },
+                "lastError": {
+                    "description": "Ошибка последней попытки",
+                    "type": "string",
+                    "example": "unexpected status 503"
+                },
+                "nextAttemptAt": {
+                    "description": "Когда будет следующая попытка (только у ожидающих доставок)",
+                    "type": "string",
+                    "example": "2024-12-08T12:01:00Z"
+                },
+                "noteId": {
+                    "description": "Заметка, с которой произошло событие",
+                    "type": "integer",
+                    "example": 1
+                },
+                "payload": {
+                    "description": "Тело запроса, которое получает веб-хук",
+                    "type": "object"
+                },
+                "responseStatus": {
+                    "description": "HTTP-статус последнего ответа получателя",
+                    "type": "integer",
+                    "example": 503
+                },
+                "status": {
+                    "description": "Состояние доставки",
+                    "enum": [
+                        "pending",
+                        "delivered",
+                        "dead"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/core.DeliveryStatus"
+                        }
+                    ],
+                    "example": "pending"
+                },
+                "webhookId": {
+                    "description": "Веб-хук",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handlers.BatchNotesRequest": {
+            "description": "Операции над заметками, выполняемые за один запрос",
+            "type": "object",
+            "properties": {
+                "atomic": {
+                    "description": "true — применить все операции или ни одной; false — каждую независимо",
+                    "type": "boolean",
+                    "example": true
+                },
+                "operations": {
+                    "description": "Операции по порядку (не больше 100)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BatchOperation"
+                    }
+                }
+            }
+        },
+        "handlers.BatchNotesResponse": {
+            "description": "Итоги операций пакета в порядке запроса",
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BatchResult"
+                    }
+                }
+            }
+        },
+        "handlers.BatchOperation": {
+            "description": "Операция пакета: create — поля новой заметки; update — id, необязательная version и изменяемые поля; delete — id и необязательная version (заметка перемещается в корзину)",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Содержимое (create, update)",
+                    "type": "string",
+                    "example": "Обновлённый текст"
+                },
+                "id": {
+                    "description": "ID заметки (update, delete)",
+                    "type": "integer",
+                    "example": 1
+                },
+                "notebookId": {
+                    "description": "ID блокнота (только create)",
+                    "type": "integer",
+                    "example": 2
+                },
+                "op": {
+                    "description": "Вид операции",
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ],
+                    "example": "update"
+                },
+                "tags": {
+                    "description": "Теги (create, update); при update заменяют прежние",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "работа"
+                    ]
+                },
+                "title": {
+                    "description": "Заголовок (create — обязателен, update — опционально)",
+                    "type": "string",
+                    "example": "Обновлённый заголовок"
+                },
+                "version": {
+                    "description": "Версия заметки, которую клиент изменяет, — как If-Match (update, delete; обязательна в строгом режиме)",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "handlers.BatchResult": {
+            "description": "Итог операции: статус и тело, которые вернул бы отдельный запрос",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Ошибка операции",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.Problem"
+                        }
+                    ]
+                },
+                "note": {
+                    "description": "Созданная или изменённая заметка",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    ]
+                },
+                "status": {
+                    "description": "HTTP-статус операции: 201 (create), 200 (update), 204 (delete) или код ошибки;\n424 — операция атомарного пакета не применена из-за ошибки другой",
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "handlers.CollabTicketResponse": {
+            "description": "Одноразовый билет для подключения к совместному редактированию",
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "description": "Момент, после которого билет не принимается",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:30Z"
+                },
+                "ticket": {
+                    "description": "Билет; передаётся в ?ticket= адреса WebSocket",
+                    "type": "string",
+                    "example": "Zk3u0aQm..."
+                }
+            }
+        },
+        "handlers.CreateAPIKeyRequest": {
+            "description": "Название, области доступа и срок действия ключа",
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "description": "Срок действия (опционально); без него ключ бессрочный",
+                    "type": "string",
+                    "example": "2025-12-08T12:00:00Z"
+                },
+                "name": {
+                    "description": "Название ключа, до 100 символов",
+                    "type": "string",
+                    "example": "ci"
+                },
+                "scopes": {
+                    "description": "Области доступа: notes:read, notes:write, webhooks:manage",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "notes:read",
+                        "notes:write"
+                    ]
+                }
+            }
+        },
+        "handlers.CreateAPIKeyResponse": {
+            "description": "Ключ и его описание; ключ показывается только в этом ответе",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "Дата и время создания",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "expiresAt": {
+                    "description": "Срок действия; без него ключ бессрочный",
+                    "type": "string",
+                    "example": "2025-12-08T12:00:00Z"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор ключа",
+                    "type": "integer",
+                    "example": 1
+                },
+                "key": {
+                    "description": "Сам ключ для заголовка X-API-Key; сохраните его, повторно он не выдаётся",
+                    "type": "string",
+                    "example": "nk_Xq3vB1a..."
+                },
+                "lastUsedAt": {
+                    "description": "Когда ключ последний раз использовался (с точностью до минуты)",
+                    "type": "string",
+                    "example": "2024-12-09T08:30:00Z"
+                },
+                "name": {
+                    "description": "Название, чтобы отличать ключи друг от друга",
+                    "type": "string",
+                    "example": "ci"
+                },
+                "prefix": {
+                    "description": "Начало ключа для опознания в логах и настройках",
+                    "type": "string",
+                    "example": "nk_Xq3vB1a"
+                },
+                "scopes": {
+                    "description": "Области доступа",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "notes:read"
+                    ]
+                },
+                "userId": {
+                    "description": "Владелец ключа",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handlers.CreateNoteRequest": {
+            "description": "Данные для создания новой заметки",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Содержимое заметки",
+                    "type": "string",
+                    "example": "Текст заметки..."
+                },
+                "notebookId": {
+                    "description": "ID блокнота (опционально)",
+                    "type": "integer",
+                    "example": 2
+                },
+                "tags": {
+                    "description": "Теги (опционально); регистр и лишние пробелы не учитываются",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "работа",
+                        "отчёты"
+                    ]
+                },
+                "title": {
+                    "description": "Заголовок заметки (обязательное поле)",
+                    "type": "string",
+                    "example": "Моя первая заметка"
+                }
+            }
+        },
+        "handlers.CreateNotebookRequest": {
+            "description": "Данные для создания блокнота",
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "Название блокнота (обязательное поле)",
+                    "type": "string",
+                    "example": "Работа"
+                },
+                "parentId": {
+                    "description": "ID родительского блокнота; без него блокнот создаётся на верхнем уровне",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handlers.CreateShareLinkRequest": {
+            "description": "Срок действия и пароль ссылки (оба необязательны)",
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "description": "Срок действия; без него ссылка действует до отзыва",
+                    "type": "string",
+                    "example": "2024-12-15T12:00:00Z"
+                },
+                "password": {
+                    "description": "Пароль, который нужно ввести для открытия ссылки (до 72 байт)",
+                    "type": "string",
+                    "example": "letmein"
+                }
+            }
+        },
+        "handlers.CreateShareLinkResponse": {
+            "description": "Ссылка и её токен; токен показывается только в этом ответе",
+            "type": "object",
+            "properties": {
+                "accessCount": {
+                    "description": "Сколько раз ссылку открывали",
+                    "type": "integer",
+                    "example": 3
+                },
+                "createdAt": {
+                    "description": "Дата и время создания",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "expiresAt": {
+                    "description": "Срок действия; без него ссылка действует до отзыва",
+                    "type": "string",
+                    "example": "2024-12-15T12:00:00Z"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор ссылки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "lastAccessAt": {
+                    "description": "Когда ссылку открывали последний раз",
+                    "type": "string",
+                    "example": "2024-12-09T08:30:00Z"
+                },
+                "noteId": {
+                    "description": "ID заметки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "prefix": {
+                    "description": "Начало токена, чтобы отличать ссылки друг от друга",
+                    "type": "string",
+                    "example": "Zr8QpW1c"
+                },
+                "protected": {
+                    "description": "Защищена ли ссылка паролем",
+                    "type": "boolean",
+                    "example": false
+                },
+                "token": {
+                    "description": "Токен ссылки; сохраните его, повторно он не выдаётся",
+                    "type": "string",
+                    "example": "Zr8QpW1c..."
+                },
+                "url": {
+                    "description": "Путь публичной страницы заметки",
+                    "type": "string",
+                    "example": "/api/v1/public/notes/Zr8QpW1c..."
+                }
+            }
+        },
+        "handlers.CreateWebhookRequest": {
+            "description": "Адрес получателя и события, на которые он подписан",
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "События: note.created, note.updated, note.deleted",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "note.created",
+                        "note.updated",
+                        "note.deleted"
+                    ]
+                },
+                "url": {
+                    "description": "Адрес получателя (http или https)",
+                    "type": "string",
+                    "example": "https://example.com/hooks/notes"
+                }
+            }
+        },
+        "handlers.CreateWebhookResponse": {
+            "description": "Веб-хук и секрет подписи; секрет показывается только в этом ответе",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "Дата и время создания",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "events": {
+                    "description": "События, на которые подписан веб-хук",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "note.created",
+                        "note.updated"
+                    ]
+                },
+                "id": {
+                    "description": "Уникальный идентификатор веб-хука",
+                    "type": "integer",
+                    "example": 1
+                },
+                "secret": {
+                    "description": "Секрет для проверки X-Webhook-Signature; сохраните его, повторно он не выдаётся",
+                    "type": "string",
+                    "example": "whsec_Qm9vZ2xl..."
+                },
+                "url": {
+                    "description": "Адрес получателя (http или https)",
+                    "type": "string",
+                    "example": "https://example.com/hooks/notes"
+                },
+                "userId": {
+                    "description": "Владелец веб-хука; события приходят по его заметкам",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handlers.CredentialsRequest": {
+            "description": "Имя пользователя и пароль",
+            "type": "object",
+            "properties": {
+                "password": {
+                    "description": "Пароль: от 8 до 72 байт",
+                    "type": "string",
+                    "example": "correct horse"
+                },
+                "username": {
+                    "description": "Имя пользователя: 3–32 символа a-z, 0-9, '_', '.', '-' (регистр не учитывается)",
+                    "type": "string",
+                    "example": "alice"
+                }
+            }
+        },
+        "handlers.LoginResponse": {
+            "description": "Токен сессии для заголовка Authorization: Bearer",
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "description": "Момент истечения токена",
+                    "type": "string",
+                    "example": "2024-12-09T12:00:00Z"
+                },
+                "token": {
+                    "description": "Токен сессии",
+                    "type": "string",
+                    "example": "q3Vn1yJ0b9gZ..."
+                }
+            }
+        },
+        "handlers.MoveNoteRequest": {
+            "description": "Блокнот, в который переносится заметка",
+            "type": "object",
+            "properties": {
+                "notebookId": {
+                    "description": "ID блокнота; null — заметка вне блокнотов",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "handlers.MoveNotebookRequest": {
+            "description": "Новый родитель блокнота",
+            "type": "object",
+            "properties": {
+                "parentId": {
+                    "description": "ID нового родителя; null — перенос на верхний уровень",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handlers.Problem": {
+            "description": "Ошибка в формате RFC 7807 (application/problem+json)",
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "description": "Описание этого случая на языке из Accept-Language",
+                    "type": "string",
+                    "example": "title is required"
+                },
+                "errors": {
+                    "description": "Нарушенные правила (только для /problems/validation-error)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.Violation"
+                    }
+                },
+                "instance": {
+                    "description": "ID запроса (X-Request-Id); по нему ошибку можно найти в логах сервера",
+                    "type": "string",
+                    "example": "host/AbCdEf-000001"
+                },
+                "status": {
+                    "description": "HTTP-статус ответа",
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "description": "Краткое описание типа ошибки на языке из Accept-Language",
+                    "type": "string",
+                    "example": "Validation failed"
+                },
+                "type": {
+                    "description": "Тип ошибки: about:blank — смысл передаёт статус, /problems/validation-error — неверные входные данные",
+                    "type": "string",
+                    "example": "/problems/validation-error"
+                }
+            }
+        },
+        "handlers.PublicNote": {
+            "description": "Заметка без служебных полей (владелец, блокнот, версия)",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Содержимое заметки",
+                    "type": "string",
                     "example": "Текст заметки..."
                 },
+                "createdAt": {
+                    "description": "Дата и время создания",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "tags": {
+                    "description": "Теги заметки",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "работа"
+                    ]
+                },
                 "title": {
-                    "description": "Заголовок заметки (обязательное поле)",
+                    "description": "Заголовок заметки",
                     "type": "string",
-                    "example": "Моя первая заметка"
+                    "example": "Моя заметка"
+                },
+                "updatedAt": {
+                    "description": "Дата и время последнего обновления",
+                    "type": "string",
+                    "example": "2024-12-08T13:00:00Z"
                 }
             }
         },
-        "handlers.ErrorResponse": {
-            "description": "Ответ сервера при возникновении ошибки",
+        "handlers.RenameNotebookRequest": {
+            "description": "Новое название блокнота",
             "type": "object",
             "properties": {
-                "error": {
-                    "description": "Описание ошибки",
+                "name": {
+                    "description": "Новое название",
+                    "type": "string",
+                    "example": "Проекты"
+                }
+            }
+        },
+        "handlers.RenameTagRequest": {
+            "description": "Новое имя тега",
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "Новое имя тега; если у заметки уже есть такой тег, теги сливаются",
+                    "type": "string",
+                    "example": "проекты"
+                }
+            }
+        },
+        "handlers.RevisionDiffResponse": {
+            "description": "Разница между двумя ревизиями заметки",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Построчные изменения содержимого",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/textdiff.Line"
+                    }
+                },
+                "from": {
+                    "description": "Исходная ревизия",
+                    "type": "integer",
+                    "example": 1
+                },
+                "title": {
+                    "description": "Изменения заголовка",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/textdiff.Line"
+                    }
+                },
+                "to": {
+                    "description": "Конечная ревизия",
+                    "type": "integer",
+                    "example": 3
+                },
+                "unified": {
+                    "description": "Изменения содержимого в unified-виде (строки с префиксами « », «+», «-»)",
+                    "type": "string",
+                    "example": " первая строка\n-старая строка\n+новая строка\n"
+                }
+            }
+        },
+        "handlers.SearchResultResponse": {
+            "description": "Найденная заметка с оценкой релевантности и подсветкой совпадений",
+            "type": "object",
+            "properties": {
+                "note": {
+                    "description": "Найденная заметка",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    ]
+                },
+                "score": {
+                    "description": "Релевантность (больше — лучше)",
+                    "type": "number",
+                    "example": 1.83
+                },
+                "snippet": {
+                    "description": "Фрагмент содержимого с совпадениями в \u003cmark\u003e (HTML-экранирован)",
+                    "type": "string",
+                    "example": "…подготовить \u003cmark\u003eотчёт\u003c/mark\u003e к пятнице…"
+                },
+                "titleHighlight": {
+                    "description": "Заголовок с совпадениями в \u003cmark\u003e (HTML-экранирован)",
+                    "type": "string",
+                    "example": "\u003cmark\u003eОтчёт\u003c/mark\u003e за май"
+                }
+            }
+        },
+        "handlers.ShareRequest": {
+            "description": "Роль пользователя в заметке",
+            "type": "object",
+            "properties": {
+                "role": {
+                    "description": "Роль: viewer (только чтение) или editor (чтение и изменение)",
+                    "enum": [
+                        "viewer",
+                        "editor"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/core.ShareRole"
+                        }
+                    ],
+                    "example": "editor"
+                }
+            }
+        },
+        "handlers.TagChangeResponse": {
+            "description": "Итоговое имя тега и число изменённых заметок",
+            "type": "object",
+            "properties": {
+                "notes": {
+                    "description": "Число изменённых заметок (включая заметки в корзине)",
+                    "type": "integer",
+                    "example": 3
+                },
+                "tag": {
+                    "description": "Тег после изменения",
                     "type": "string",
-                    "example": "something went wrong"
+                    "example": "проекты"
                 }
             }
         },
@@ -300,12 +5063,239 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Обновлённый текст"
                 },
+                "tags": {
+                    "description": "Новый набор тегов (опционально); заменяет прежний, [] снимает все теги",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "работа"
+                    ]
+                },
                 "title": {
                     "description": "Новый заголовок (опционально)",
                     "type": "string",
                     "example": "Обновлённый заголовок"
                 }
             }
+        },
+        "repo.TagCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "tag": {
+                    "type": "string",
+                    "example": "работа"
+                }
+            }
+        },
+        "service.Limits": {
+            "description": "Ограничения заметок, чтобы клиент мог проверить данные до отправки",
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Правила для текста",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.TextRule"
+                        }
+                    ]
+                },
+                "normalization": {
+                    "description": "Форма нормализации Unicode, к которой приводятся заголовок и текст; пусто — не приводятся",
+                    "type": "string",
+                    "example": "NFC"
+                },
+                "quota": {
+                    "description": "Квота хранилища пользователя",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.Quota"
+                        }
+                    ]
+                },
+                "tags": {
+                    "description": "Ограничения тегов",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.TagLimits"
+                        }
+                    ]
+                },
+                "title": {
+                    "description": "Правила для заголовка; пробелы по краям отбрасываются",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.TextRule"
+                        }
+                    ]
+                }
+            }
+        },
+        "service.NoteEvent": {
+            "description": "Событие потока изменений заметок",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Уточнение: update и restore для note.updated, delete (в корзину) и\npurge (насовсем) для note.deleted",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/core.AuditAction"
+                        }
+                    ],
+                    "example": "update"
+                },
+                "id": {
+                    "description": "Идентификатор события; передаётся в Last-Event-ID при переподключении",
+                    "type": "string",
+                    "example": "lx3k9q-42"
+                },
+                "note": {
+                    "description": "Заметка после изменения (для удаления — до него)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/core.Note"
+                        }
+                    ]
+                },
+                "occurredAt": {
+                    "description": "Время изменения",
+                    "type": "string",
+                    "example": "2024-12-08T12:00:00Z"
+                },
+                "type": {
+                    "description": "Событие: note.created, note.updated или note.deleted",
+                    "type": "string",
+                    "example": "note.updated"
+                }
+            }
+        },
+        "service.Quota": {
+            "description": "Квота хранилища пользователя; 0 — без ограничения",
+            "type": "object",
+            "properties": {
+                "maxBytes": {
+                    "description": "MaxBytes — максимальный суммарный размер заголовков и содержимого.",
+                    "type": "integer",
+                    "example": 10485760
+                },
+                "maxNotes": {
+                    "description": "MaxNotes — максимальное число заметок.",
+                    "type": "integer",
+                    "example": 1000
+                }
+            }
+        },
+        "service.TagLimits": {
+            "description": "Ограничения тегов заметки",
+            "type": "object",
+            "properties": {
+                "maxCount": {
+                    "description": "Максимальное число тегов у заметки",
+                    "type": "integer",
+                    "example": 20
+                },
+                "maxLength": {
+                    "description": "Максимальная длина тега в символах",
+                    "type": "integer",
+                    "example": 32
+                }
+            }
+        },
+        "service.TextRule": {
+            "description": "Правила для текстового поля заметки",
+            "type": "object",
+            "properties": {
+                "forbidden": {
+                    "description": "Символы, запрещённые помимо управляющих",
+                    "type": "string",
+                    "example": "\u003c\u003e"
+                },
+                "maxBytes": {
+                    "description": "Максимальный размер в байтах UTF-8 (0 — без ограничения)",
+                    "type": "integer",
+                    "example": 0
+                },
+                "maxLength": {
+                    "description": "Максимальная длина в символах Unicode (0 — без ограничения)",
+                    "type": "integer",
+                    "example": 200
+                },
+                "multiline": {
+                    "description": "Разрешены ли переводы строк и табуляция",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "service.Violation": {
+            "description": "Нарушенное правило проверки входных данных",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Код правила: required, too_short, too_long, too_many, invalid, unsupported, in_past",
+                    "type": "string",
+                    "example": "required"
+                },
+                "field": {
+                    "description": "Поле запроса; элемент массива — с индексом: tags[2]",
+                    "type": "string",
+                    "example": "title"
+                },
+                "message": {
+                    "description": "Описание для человека на языке из Accept-Language",
+                    "type": "string",
+                    "example": "title is required"
+                }
+            }
+        },
+        "textdiff.Line": {
+            "type": "object",
+            "properties": {
+                "op": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/textdiff.Op"
+                        }
+                    ],
+                    "example": "insert"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "новая строка"
+                }
+            }
+        },
+        "textdiff.Op": {
+            "type": "string",
+            "enum": [
+                "equal",
+                "insert",
+                "delete"
+            ],
+            "x-enum-varnames": [
+                "Equal",
+                "Insert",
+                "Delete"
+            ]
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "API-ключ из POST /keys; доступ ограничен областями ключа",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "Токен сессии из POST /auth/login или JWT доверенного сервиса в виде \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -317,7 +5307,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/api/v1",
 	Schemes:          []string{"http"},
 	Title:            "Notes API",
-	Description:      "Учебный REST API для заметок (CRUD) для практического занятия №12.\nДемонстрация code-first подхода с генерацией Swagger документации через swag.",
+	Description:      "Учебный REST API для заметок (CRUD) для практического занятия №12.\nДемонстрация code-first подхода с генерацией Swagger документации через swag.\nОшибки возвращаются в формате RFC 7807 (application/problem+json, схема handlers.Problem):\ntype, title, status, detail, instance (ID запроса) и для неверных данных — список нарушенных правил errors.\nСообщения об ошибках — на русском или английском по заголовку Accept-Language (по умолчанию английский);\nязык ответа — в заголовке Content-Language.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
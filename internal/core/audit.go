@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+// AuditAction — вид изменения заметки в журнале аудита.
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	// AuditDelete — перемещение в корзину.
+	AuditDelete AuditAction = "delete"
+	// AuditRestore — возврат из корзины.
+	AuditRestore AuditAction = "restore"
+	// AuditPurge — удаление насовсем.
+	AuditPurge AuditAction = "purge"
+)
+
+// FieldChange — изменение одного поля заметки. У созданной заметки нет
+// Before, у удалённой насовсем — After.
+// @Description Изменение поля заметки
+type FieldChange struct {
+	// Поле: title, content, tags, notebookId или deletedAt
+	Field string `json:"field" example:"title"`
+	// Значение до изменения
+	Before any `json:"before,omitempty" swaggertype:"string" example:"Черновик"`
+	// Значение после изменения
+	After any `json:"after,omitempty" swaggertype:"string" example:"Отчёт за май"`
+}
+
+// AuditEvent — запись журнала аудита: кто, когда и как изменил заметку.
+// @Description Событие журнала аудита
+type AuditEvent struct {
+	// Порядковый номер события
+	ID int64 `json:"id" example:"1"`
+	// Время изменения
+	Time time.Time `json:"time" example:"2024-12-08T12:00:00Z"`
+	// Вид изменения
+	Action AuditAction `json:"action" example:"update" enums:"create,update,delete,restore,purge"`
+	// ID заметки
+	NoteID int64 `json:"noteId" example:"1"`
+	// ID владельца заметки
+	OwnerID int64 `json:"ownerId" example:"1"`
+	// Версия заметки после изменения; у удалённой насовсем — последняя
+	Version int64 `json:"version" example:"2"`
+	// ID пользователя, выполнившего изменение; 0 — сам сервер (очистка корзины)
+	ActorID int64 `json:"actorId" example:"1"`
+	// Имя пользователя, выполнившего изменение
+	Actor string `json:"actor,omitempty" example:"alice"`
+	// Как вызывающий вошёл: claim sub для JWT, имя для сессии, начало API-ключа
+	Subject string `json:"subject,omitempty" example:"alice"`
+	// X-Request-Id запроса
+	RequestID string `json:"requestId,omitempty" example:"host/abcdef-000001"`
+	// IP-адрес клиента
+	ClientIP string `json:"clientIp,omitempty" example:"203.0.113.7"`
+	// Изменённые поля
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// RequestMeta — сведения о HTTP-запросе, нужные журналу аудита.
+type RequestMeta struct {
+	RequestID string
+	ClientIP  string
+}
+
+type requestMetaKey struct{}
+
+// WithRequestMeta возвращает контекст со сведениями о запросе m.
+func WithRequestMeta(ctx context.Context, m RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, m)
+}
+
+// RequestMetaFrom извлекает сведения о запросе из контекста.
+func RequestMetaFrom(ctx context.Context) (RequestMeta, bool) {
+	m, ok := ctx.Value(requestMetaKey{}).(RequestMeta)
+	return m, ok
+}
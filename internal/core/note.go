@@ -11,8 +11,18 @@ type Note struct {
 	Title string `json:"title" example:"Моя заметка"`
 	// Содержимое заметки
 	Content string `json:"content" example:"Текст заметки..."`
+	// ID владельца заметки
+	OwnerID int64 `json:"ownerId" example:"1"`
+	// ID блокнота; отсутствует у заметки вне блокнотов
+	NotebookID *int64 `json:"notebookId,omitempty" example:"2"`
+	// Теги заметки: в нижнем регистре, без повторов, по алфавиту
+	Tags []string `json:"tags,omitempty" example:"работа,отчёты"`
+	// Версия заметки; увеличивается при каждом изменении, передаётся в ETag
+	Version int64 `json:"version" example:"1"`
 	// Дата и время создания
 	CreatedAt time.Time `json:"createdAt" example:"2024-12-08T12:00:00Z"`
 	// Дата и время последнего обновления
 	UpdatedAt *time.Time `json:"updatedAt,omitempty" example:"2024-12-08T13:00:00Z"`
+	// Дата и время перемещения в корзину (только у удалённых заметок)
+	DeletedAt *time.Time `json:"deletedAt,omitempty" example:"2024-12-09T10:00:00Z"`
 }
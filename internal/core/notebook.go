@@ -0,0 +1,21 @@
+package core
+
+import "time"
+
+// Notebook — блокнот (папка) для заметок. Блокноты вкладываются друг
+// в друга через ParentID; у блокнота верхнего уровня ParentID == nil.
+// @Description Блокнот для группировки заметок
+type Notebook struct {
+	// Уникальный идентификатор блокнота
+	ID int64 `json:"id" example:"1"`
+	// Название блокнота
+	Name string `json:"name" example:"Работа"`
+	// ID владельца блокнота
+	OwnerID int64 `json:"ownerId" example:"1"`
+	// ID родительского блокнота; отсутствует у блокнота верхнего уровня
+	ParentID *int64 `json:"parentId,omitempty" example:"2"`
+	// Дата и время создания
+	CreatedAt time.Time `json:"createdAt" example:"2024-12-08T12:00:00Z"`
+	// Дата и время последнего изменения
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" example:"2024-12-08T13:00:00Z"`
+}
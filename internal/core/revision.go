@@ -0,0 +1,19 @@
+package core
+
+import "time"
+
+// NoteRevision — неизменяемый снимок заметки после одного изменения.
+// Номер ревизии совпадает с версией заметки, которую она фиксирует.
+// @Description Ревизия (сохранённая версия) заметки
+type NoteRevision struct {
+	// ID заметки
+	NoteID int64 `json:"noteId" example:"1"`
+	// Номер ревизии (версия заметки)
+	Revision int64 `json:"revision" example:"3"`
+	// Заголовок в этой ревизии
+	Title string `json:"title" example:"Моя заметка"`
+	// Содержимое в этой ревизии
+	Content string `json:"content" example:"Текст заметки..."`
+	// Время создания ревизии
+	CreatedAt time.Time `json:"createdAt" example:"2024-12-08T13:00:00Z"`
+}
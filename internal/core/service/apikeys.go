@@ -0,0 +1,187 @@
+package service
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "log"
+    "slices"
+    "strings"
+    "time"
+    "unicode/utf8"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+const (
+    // APIKeyPrefix начинает каждый ключ: по нему ключ легко найти в
+    // конфигурации и логах и отличить от токена сессии.
+    APIKeyPrefix = "nk_"
+    // MaxAPIKeyNameLength — максимальная длина названия ключа в символах.
+    MaxAPIKeyNameLength = 100
+    // apiKeyShownChars — сколько символов после APIKeyPrefix хранится
+    // открыто в core.APIKey.Prefix.
+    apiKeyShownChars = 8
+    // lastUsedResolution — LastUsedAt обновляется не чаще раза в минуту,
+    // чтобы каждый запрос скрипта не превращался в запись в хранилище.
+    lastUsedResolution = time.Minute
+)
+
+// APIKeyScopes — области, которые можно выдать ключу.
+var APIKeyScopes = []string{core.ScopeNotesRead, core.ScopeNotesWrite, core.ScopeWebhooksManage}
+
+// APIKeyService выпускает и проверяет API-ключи.
+type APIKeyService struct {
+    keys  repo.APIKeyRepository
+    users repo.UserRepository
+}
+
+func NewAPIKeyService(keys repo.APIKeyRepository, users repo.UserRepository) *APIKeyService {
+    return &APIKeyService{keys: keys, users: users}
+}
+
+// normalizeScopes проверяет области и возвращает их без повторов в
+// порядке APIKeyScopes.
+func normalizeScopes(scopes []string) ([]string, error) {
+    return pickKnown("scopes", scopes, APIKeyScopes)
+}
+
+// pickKnown проверяет, что values из поля field — непустое подмножество
+// known, и возвращает его без повторов в порядке known.
+func pickKnown(field string, values, known []string) ([]string, error) {
+    var v violations
+    for i, value := range values {
+        if !slices.Contains(known, value) {
+            v.add(fmt.Sprintf("%s[%d]", field, i), CodeUnsupported,
+                "%q is not one of %s", value, strings.Join(known, ", "))
+        }
+    }
+    if len(values) == 0 {
+        v.add(field, CodeRequired, "%s must not be empty", field)
+    }
+    if err := v.err(); err != nil {
+        return nil, err
+    }
+    out := make([]string, 0, len(known))
+    for _, k := range known {
+        if slices.Contains(values, k) {
+            out = append(out, k)
+        }
+    }
+    return out, nil
+}
+
+// Create выпускает ключ текущему пользователю. Сам ключ возвращается
+// только здесь: в хранилище попадает его SHA-256, восстановить ключ
+// потом нельзя. expiresAt == nil — ключ бессрочный.
+func (s *APIKeyService) Create(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*core.APIKey, string, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, "", err
+    }
+    var v violations
+    name = strings.TrimSpace(name)
+    switch {
+    case name == "":
+        v.add("name", CodeRequired, "%s is required", "name")
+    case utf8.RuneCountInString(name) > MaxAPIKeyNameLength:
+        v.add("name", CodeTooLong, "%s must be at most %d characters", "name", MaxAPIKeyNameLength)
+    }
+    scopes, err = normalizeScopes(scopes)
+    if err := v.collect(err); err != nil {
+        return nil, "", err
+    }
+    now := time.Now().UTC()
+    if expiresAt != nil {
+        if !expiresAt.After(now) {
+            v.add("expiresAt", CodeInPast, "%s must be in the future", "expiresAt")
+        }
+        t := expiresAt.UTC()
+        expiresAt = &t
+    }
+    if err := v.err(); err != nil {
+        return nil, "", err
+    }
+
+    raw := make([]byte, tokenBytes)
+    if _, err := rand.Read(raw); err != nil {
+        return nil, "", err
+    }
+    secret := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+    k := core.APIKey{
+        UserID:    owner,
+        Name:      name,
+        Prefix:    secret[:len(APIKeyPrefix)+apiKeyShownChars],
+        KeyHash:   hashToken(secret),
+        Scopes:    scopes,
+        CreatedAt: now,
+        ExpiresAt: expiresAt,
+    }
+    id, err := s.keys.Create(k)
+    if err != nil {
+        return nil, "", err
+    }
+    k.ID = id
+    return &k, secret, nil
+}
+
+// List возвращает ключи текущего пользователя.
+func (s *APIKeyService) List(ctx context.Context) ([]core.APIKey, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return s.keys.List(owner)
+}
+
+// Revoke отзывает ключ текущего пользователя; чужой или несуществующий
+// ключ — repo.ErrAPIKeyNotFound.
+func (s *APIKeyService) Revoke(ctx context.Context, id int64) error {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return err
+    }
+    return s.keys.Delete(owner, id)
+}
+
+// Authenticate находит владельца ключа. Неизвестный, отозванный или
+// истёкший ключ — ErrUnauthenticated. Вызывающему достаются только
+// области ключа.
+func (s *APIKeyService) Authenticate(key string) (core.Principal, error) {
+    if !strings.HasPrefix(key, APIKeyPrefix) {
+        return core.Principal{}, ErrUnauthenticated
+    }
+    k, err := s.keys.GetByHash(hashToken(key))
+    if errors.Is(err, repo.ErrAPIKeyNotFound) {
+        return core.Principal{}, ErrUnauthenticated
+    }
+    if err != nil {
+        return core.Principal{}, err
+    }
+    now := time.Now()
+    if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+        return core.Principal{}, ErrUnauthenticated
+    }
+    u, err := s.users.GetByID(k.UserID)
+    if errors.Is(err, repo.ErrUserNotFound) {
+        return core.Principal{}, ErrUnauthenticated
+    }
+    if err != nil {
+        return core.Principal{}, err
+    }
+
+    if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= lastUsedResolution {
+        if err := s.keys.Touch(k.ID, now); err != nil {
+            log.Printf("apikeys: touch key %d: %v", k.ID, err)
+        }
+    }
+    scopes := k.Scopes
+    if scopes == nil {
+        scopes = []string{} // nil означало бы все области
+    }
+    return core.Principal{UserID: u.ID, Username: u.Username, Subject: k.Prefix, Scopes: scopes}, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+    "context"
+    "log"
+    "slices"
+    "time"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+const (
+    // DefaultAuditPageSize — размер страницы журнала аудита по умолчанию.
+    DefaultAuditPageSize = 100
+    // MaxAuditPageSize — максимальный размер страницы журнала аудита.
+    MaxAuditPageSize = 1000
+)
+
+// WithAudit подключает журнал аудита: каждое создание, изменение,
+// удаление и восстановление заметки записывается в него с автором,
+// сведениями о запросе и изменёнными полями.
+func WithAudit(l repo.AuditLog) Option {
+    return func(s *NoteService) {
+        s.auditLog = l
+    }
+}
+
+// snapshotNote копирует заметку до изменения: функция обновления
+// репозитория меняет её на месте.
+func snapshotNote(n *core.Note) *core.Note {
+    c := *n
+    c.Tags = slices.Clone(n.Tags)
+    return &c
+}
+
+// audit записывает изменение заметки before → after; before == nil у
+// созданной заметки, after == nil у удалённой насовсем. Заметка уже
+// записана, поэтому ошибки журнала только логируются.
+func (s *NoteService) audit(ctx context.Context, action core.AuditAction, before, after *core.Note) {
+    if s.auditLog == nil {
+        return
+    }
+    n := after
+    if n == nil {
+        n = before
+    }
+    e := core.AuditEvent{
+        Time:    time.Now().UTC(),
+        Action:  action,
+        NoteID:  n.ID,
+        OwnerID: n.OwnerID,
+        Version: n.Version,
+        Changes: noteChanges(before, after),
+    }
+    if p, ok := core.PrincipalFrom(ctx); ok {
+        e.ActorID, e.Actor, e.Subject = p.UserID, p.Username, p.Subject
+    }
+    if m, ok := core.RequestMetaFrom(ctx); ok {
+        e.RequestID, e.ClientIP = m.RequestID, m.ClientIP
+    }
+    if _, err := s.auditLog.Append(e); err != nil {
+        log.Printf("audit: %s note %d: %v", action, n.ID, err)
+    }
+}
+
+// noteChanges перечисляет поля, которые различаются у before и after;
+// отсутствующая заметка считается пустой.
+func noteChanges(before, after *core.Note) []core.FieldChange {
+    var a, b core.Note
+    if before != nil {
+        a = *before
+    }
+    if after != nil {
+        b = *after
+    }
+    var changes []core.FieldChange
+    add := func(field string, differ bool, before, after any) {
+        if differ {
+            changes = append(changes, core.FieldChange{Field: field, Before: before, After: after})
+        }
+    }
+    add("title", a.Title != b.Title, optional(a.Title != "", a.Title), optional(b.Title != "", b.Title))
+    add("content", a.Content != b.Content, optional(a.Content != "", a.Content), optional(b.Content != "", b.Content))
+    add("tags", !slices.Equal(a.Tags, b.Tags), optional(len(a.Tags) > 0, a.Tags), optional(len(b.Tags) > 0, b.Tags))
+    add("notebookId", !equalPtr(a.NotebookID, b.NotebookID), deref(a.NotebookID), deref(b.NotebookID))
+    add("deletedAt", !equalPtr(a.DeletedAt, b.DeletedAt), deref(a.DeletedAt), deref(b.DeletedAt))
+    return changes
+}
+
+// optional возвращает v, если set, иначе nil — пустое значение не
+// попадает в JSON события.
+func optional[T any](set bool, v T) any {
+    if !set {
+        return nil
+    }
+    return v
+}
+
+func deref[T any](p *T) any {
+    if p == nil {
+        return nil
+    }
+    return *p
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+    if a == nil || b == nil {
+        return a == b
+    }
+    return *a == *b
+}
+
+// AuditService отдаёт журнал аудита администраторам.
+type AuditService struct {
+    log    repo.AuditLog
+    admins []string
+}
+
+// NewAuditService создаёт сервис; admins — имена пользователей,
+// которым доступен журнал.
+func NewAuditService(l repo.AuditLog, admins []string) *AuditService {
+    return &AuditService{log: l, admins: admins}
+}
+
+// IsAdmin сообщает, администратор ли вызывающий. API-ключи
+// администраторских прав не дают, даже если выданы администратору.
+func (s *AuditService) IsAdmin(ctx context.Context) bool {
+    p, ok := core.PrincipalFrom(ctx)
+    return ok && p.Scopes == nil && slices.Contains(s.admins, p.Username)
+}
+
+// Query возвращает страницу журнала аудита. Limit приводится к диапазону
+// [1, MaxAuditPageSize]; не администратору — ErrForbidden.
+func (s *AuditService) Query(ctx context.Context, q repo.AuditQuery) (repo.AuditPage, error) {
+    if _, err := ownerFrom(ctx); err != nil {
+        return repo.AuditPage{}, err
+    }
+    if !s.IsAdmin(ctx) {
+        return repo.AuditPage{}, ErrForbidden
+    }
+    if q.Since != nil && q.Until != nil && !q.Since.Before(*q.Until) {
+        return repo.AuditPage{}, invalid("until", CodeInvalid, "%s must be after %s", "until", "since")
+    }
+    if q.Limit <= 0 {
+        q.Limit = DefaultAuditPageSize
+    }
+    if q.Limit > MaxAuditPageSize {
+        q.Limit = MaxAuditPageSize
+    }
+    return s.log.Query(q)
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "log"
+    "strings"
+    "time"
+
+    "golang.org/x/crypto/bcrypt"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+var (
+    ErrInvalidCredentials = errors.New("invalid username or password")
+    ErrUsernameTaken      = errors.New("username is already taken")
+)
+
+const (
+    // MinUsernameLength и MaxUsernameLength — допустимая длина имени
+    // пользователя.
+    MinUsernameLength = 3
+    MaxUsernameLength = 32
+    // MinPasswordLength — минимальная длина пароля в байтах.
+    MinPasswordLength = 8
+    // MaxPasswordLength — bcrypt учитывает только первые 72 байта пароля,
+    // поэтому более длинные пароли отклоняются, а не обрезаются молча.
+    MaxPasswordLength = 72
+    // DefaultSessionTTL — время жизни сессии по умолчанию.
+    DefaultSessionTTL = 24 * time.Hour
+)
+
+// tokenBytes — длина случайной части токена сессии.
+const tokenBytes = 32
+
+// AuthService регистрирует пользователей и ведёт их сессии.
+type AuthService struct {
+    users    repo.UserRepository
+    sessions repo.SessionRepository
+    ttl      time.Duration
+
+    // dummyHash сравнивается с паролем при входе под несуществующим
+    // именем, чтобы время ответа не выдавало, есть ли такой пользователь.
+    dummyHash []byte
+}
+
+// NewAuthService создаёт сервис; ttl <= 0 — DefaultSessionTTL.
+func NewAuthService(users repo.UserRepository, sessions repo.SessionRepository, ttl time.Duration) *AuthService {
+    if ttl <= 0 {
+        ttl = DefaultSessionTTL
+    }
+    dummy, err := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+    if err != nil {
+        panic(err) // возможно только при недопустимом cost
+    }
+    return &AuthService{users: users, sessions: sessions, ttl: ttl, dummyHash: dummy}
+}
+
+// NormalizeUsername приводит имя к нижнему регистру и проверяет, что оно
+// из MinUsernameLength–MaxUsernameLength символов a-z, 0-9, '_', '.', '-'.
+func NormalizeUsername(username string) (string, error) {
+    username = strings.ToLower(strings.TrimSpace(username))
+    switch {
+    case username == "":
+        return "", invalid("username", CodeRequired, "%s is required", "username")
+    case len(username) < MinUsernameLength:
+        return "", invalid("username", CodeTooShort, "%s must be at least %d characters", "username", MinUsernameLength)
+    case len(username) > MaxUsernameLength:
+        return "", invalid("username", CodeTooLong, "%s must be at most %d characters", "username", MaxUsernameLength)
+    }
+    for _, r := range username {
+        if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '.' || r == '-') {
+            return "", invalid("username", CodeInvalid, "%s may contain only letters a-z, digits, '_', '.' and '-'", "username")
+        }
+    }
+    return username, nil
+}
+
+// Register создаёт пользователя; занятое имя — ErrUsernameTaken.
+func (s *AuthService) Register(username, password string) (*core.User, error) {
+    var v violations
+    username, err := NormalizeUsername(username)
+    if err := v.collect(err); err != nil {
+        return nil, err
+    }
+    switch {
+    case len(password) < MinPasswordLength:
+        v.add("password", CodeTooShort, "%s must be at least %d bytes", "password", MinPasswordLength)
+    case len(password) > MaxPasswordLength:
+        v.add("password", CodeTooLong, "%s must be at most %d bytes", "password", MaxPasswordLength)
+    }
+    if err := v.err(); err != nil {
+        return nil, err
+    }
+    hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    if err != nil {
+        return nil, err
+    }
+    id, err := s.users.Create(core.User{Username: username, PasswordHash: hash})
+    if errors.Is(err, repo.ErrUserExists) {
+        return nil, ErrUsernameTaken
+    }
+    if err != nil {
+        return nil, err
+    }
+    return s.users.GetByID(id)
+}
+
+// Login проверяет пароль и открывает сессию. Возвращает токен, который
+// клиент передаёт в Authorization: Bearer, и время его истечения.
+// Неверное имя и неверный пароль неотличимы: ErrInvalidCredentials.
+func (s *AuthService) Login(username, password string) (string, time.Time, error) {
+    hash := s.dummyHash
+    username, err := NormalizeUsername(username)
+    var u *core.User
+    if err == nil {
+        u, err = s.users.GetByUsername(username)
+        if err != nil && !errors.Is(err, repo.ErrUserNotFound) {
+            return "", time.Time{}, err
+        }
+        if u != nil {
+            hash = u.PasswordHash
+        }
+    }
+    if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || u == nil {
+        return "", time.Time{}, ErrInvalidCredentials
+    }
+
+    raw := make([]byte, tokenBytes)
+    if _, err := rand.Read(raw); err != nil {
+        return "", time.Time{}, err
+    }
+    token := base64.RawURLEncoding.EncodeToString(raw)
+    now := time.Now().UTC()
+    session := core.Session{
+        TokenHash: hashToken(token),
+        UserID:    u.ID,
+        CreatedAt: now,
+        ExpiresAt: now.Add(s.ttl),
+    }
+    if err := s.sessions.Create(session); err != nil {
+        return "", time.Time{}, err
+    }
+    return token, session.ExpiresAt, nil
+}
+
+// Authenticate находит пользователя по токену сессии. Неизвестный или
+// истёкший токен — ErrUnauthenticated.
+func (s *AuthService) Authenticate(token string) (core.Principal, error) {
+    session, err := s.sessions.Get(hashToken(token))
+    if errors.Is(err, repo.ErrSessionNotFound) {
+        return core.Principal{}, ErrUnauthenticated
+    }
+    if err != nil {
+        return core.Principal{}, err
+    }
+    if !time.Now().Before(session.ExpiresAt) {
+        return core.Principal{}, ErrUnauthenticated
+    }
+    u, err := s.users.GetByID(session.UserID)
+    if errors.Is(err, repo.ErrUserNotFound) {
+        return core.Principal{}, ErrUnauthenticated
+    }
+    if err != nil {
+        return core.Principal{}, err
+    }
+    return core.Principal{UserID: u.ID, Username: u.Username, Subject: u.Username}, nil
+}
+
+// externalPrefix отличает пользователей, заведённых по внешнему токену,
+// от зарегистрированных: в именах последних двоеточие недопустимо, так что
+// совпасть они не могут, и войти по паролю под таким именем нельзя.
+const externalPrefix = "jwt:"
+
+// ResolveSubject находит (а при первом обращении заводит) пользователя
+// для субъекта из токена, выданного другим сервисом. Так заметки
+// внешних вызывающих изолированы так же, как заметки зарегистрированных.
+func (s *AuthService) ResolveSubject(subject string) (core.Principal, error) {
+    if subject == "" {
+        return core.Principal{}, ErrUnauthenticated
+    }
+    username := externalPrefix + subject
+    u, err := s.users.GetByUsername(username)
+    if errors.Is(err, repo.ErrUserNotFound) {
+        // пароля нет: войти можно только по токену
+        _, err = s.users.Create(core.User{Username: username, PasswordHash: []byte{}})
+        if err != nil && !errors.Is(err, repo.ErrUserExists) {
+            return core.Principal{}, err
+        }
+        // при гонке пользователя мог создать параллельный запрос
+        u, err = s.users.GetByUsername(username)
+    }
+    if err != nil {
+        return core.Principal{}, err
+    }
+    return core.Principal{UserID: u.ID, Username: u.Username, Subject: subject}, nil
+}
+
+// Logout закрывает сессию; повторный выход — не ошибка.
+func (s *AuthService) Logout(token string) error {
+    err := s.sessions.Delete(hashToken(token))
+    if errors.Is(err, repo.ErrSessionNotFound) {
+        return nil
+    }
+    return err
+}
+
+// GetUser возвращает пользователя по ID.
+func (s *AuthService) GetUser(id int64) (*core.User, error) {
+    return s.users.GetByID(id)
+}
+
+// DeleteExpiredSessions удаляет истёкшие сессии и возвращает их число.
+func (s *AuthService) DeleteExpiredSessions() (int, error) {
+    return s.sessions.DeleteExpired(time.Now())
+}
+
+// hashToken — в хранилище попадает только SHA-256 токена.
+func hashToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
+
+// RunSessionCleaner раз в interval удаляет истёкшие сессии. Блокируется
+// до отмены ctx; ошибки только логируются.
+func (s *AuthService) RunSessionCleaner(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        if _, err := s.DeleteExpiredSessions(); err != nil {
+            log.Printf("auth: delete expired sessions: %v", err)
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
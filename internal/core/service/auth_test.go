@@ -0,0 +1,176 @@
+package service_test
+
+import (
+    "errors"
+    "sync"
+    "testing"
+    "time"
+
+    "example.com/notes-api/internal/core/service"
+    "example.com/notes-api/internal/repo"
+)
+
+func newAuthService(ttl time.Duration) *service.AuthService {
+    return service.NewAuthService(repo.NewUserRepoMem(), repo.NewSessionRepoMem(), ttl)
+}
+
+func TestRegister(t *testing.T) {
+    auth := newAuthService(0)
+    u, err := auth.Register(" Alice ", "password1")
+    if err != nil || u.Username != "alice" {
+        t.Fatalf("Register = %+v, %v", u, err)
+    }
+    // имена сравниваются после нормализации
+    for _, name := range []string{"alice", "ALICE", "  alice"} {
+        if _, err := auth.Register(name, "password2"); !errors.Is(err, service.ErrUsernameTaken) {
+            t.Errorf("Register(%q): err = %v, want ErrUsernameTaken", name, err)
+        }
+    }
+
+    var verr *service.ValidationError
+    if _, err := auth.Register("a!", "short"); !errors.As(err, &verr) || len(verr.Violations) != 2 {
+        t.Errorf("Register: err = %v, want violations of username and password", err)
+    }
+    if _, err := auth.Register("bob", string(make([]byte, service.MaxPasswordLength+1))); !errors.Is(err, service.ErrValidation) {
+        t.Errorf("long password: err = %v, want ErrValidation", err)
+    }
+}
+
+func TestLogin(t *testing.T) {
+    auth := newAuthService(0)
+    u, err := auth.Register("alice", "password1")
+    if err != nil {
+        t.Fatalf("Register: %v", err)
+    }
+
+    // неверный пароль, неизвестное и недопустимое имя неотличимы
+    for _, tc := range []struct{ username, password string }{
+        {"alice", "password2"},
+        {"alice", ""},
+        {"bob", "password1"},
+        {"a!", "password1"},
+    } {
+        if _, _, err := auth.Login(tc.username, tc.password); !errors.Is(err, service.ErrInvalidCredentials) {
+            t.Errorf("Login(%q, %q): err = %v, want ErrInvalidCredentials", tc.username, tc.password, err)
+        }
+    }
+
+    token, expires, err := auth.Login("ALICE", "password1")
+    if err != nil || token == "" || time.Until(expires) < service.DefaultSessionTTL-time.Minute {
+        t.Fatalf("Login = %q, %v, %v", token, expires, err)
+    }
+    p, err := auth.Authenticate(token)
+    if err != nil || p.UserID != u.ID || p.Username != "alice" {
+        t.Errorf("Authenticate = %+v, %v", p, err)
+    }
+    if _, err := auth.Authenticate(token + "x"); !errors.Is(err, service.ErrUnauthenticated) {
+        t.Errorf("unknown token: err = %v, want ErrUnauthenticated", err)
+    }
+}
+
+func TestSessionRevoked(t *testing.T) {
+    auth := newAuthService(0)
+    if _, err := auth.Register("alice", "password1"); err != nil {
+        t.Fatalf("Register: %v", err)
+    }
+    first, _, err := auth.Login("alice", "password1")
+    if err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+    second, _, err := auth.Login("alice", "password1")
+    if err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+
+    if err := auth.Logout(first); err != nil {
+        t.Fatalf("Logout: %v", err)
+    }
+    if _, err := auth.Authenticate(first); !errors.Is(err, service.ErrUnauthenticated) {
+        t.Errorf("revoked session: err = %v, want ErrUnauthenticated", err)
+    }
+    // другие сессии пользователя остаются
+    if _, err := auth.Authenticate(second); err != nil {
+        t.Errorf("other session: %v", err)
+    }
+    if err := auth.Logout(first); err != nil {
+        t.Errorf("second Logout: %v", err)
+    }
+}
+
+func TestSessionExpired(t *testing.T) {
+    auth := newAuthService(time.Nanosecond)
+    if _, err := auth.Register("alice", "password1"); err != nil {
+        t.Fatalf("Register: %v", err)
+    }
+    token, _, err := auth.Login("alice", "password1")
+    if err != nil {
+        t.Fatalf("Login: %v", err)
+    }
+    time.Sleep(time.Millisecond)
+
+    if _, err := auth.Authenticate(token); !errors.Is(err, service.ErrUnauthenticated) {
+        t.Errorf("expired session: err = %v, want ErrUnauthenticated", err)
+    }
+    if n, err := auth.DeleteExpiredSessions(); err != nil || n != 1 {
+        t.Errorf("DeleteExpiredSessions = %d, %v; want 1", n, err)
+    }
+    if _, err := auth.Authenticate(token); !errors.Is(err, service.ErrUnauthenticated) {
+        t.Errorf("deleted session: err = %v, want ErrUnauthenticated", err)
+    }
+}
+
+func TestResolveSubject(t *testing.T) {
+    auth := newAuthService(0)
+    local, err := auth.Register("alice", "password1")
+    if err != nil {
+        t.Fatalf("Register: %v", err)
+    }
+
+    // внешний субъект с тем же именем — другой пользователь
+    p, err := auth.ResolveSubject("alice")
+    if err != nil || p.UserID == local.ID || p.Username != "jwt:alice" || p.Subject != "alice" {
+        t.Fatalf("ResolveSubject = %+v, %v", p, err)
+    }
+    if again, err := auth.ResolveSubject("alice"); err != nil || again.UserID != p.UserID {
+        t.Errorf("ResolveSubject again = %+v, %v; want user %d", again, err, p.UserID)
+    }
+    if _, err := auth.ResolveSubject(""); !errors.Is(err, service.ErrUnauthenticated) {
+        t.Errorf("empty subject: err = %v, want ErrUnauthenticated", err)
+    }
+
+    // под внешним именем нельзя ни зарегистрироваться, ни войти по паролю
+    if _, err := auth.Register("jwt:bob", "password1"); !errors.Is(err, service.ErrValidation) {
+        t.Errorf("Register jwt:bob: err = %v, want ErrValidation", err)
+    }
+    for _, password := range []string{"", "password1"} {
+        if _, _, err := auth.Login("jwt:alice", password); !errors.Is(err, service.ErrInvalidCredentials) {
+            t.Errorf("Login jwt:alice %q: err = %v, want ErrInvalidCredentials", password, err)
+        }
+    }
+    if u, err := auth.GetUser(local.ID); err != nil || u.Username != "alice" {
+        t.Errorf("local user = %+v, %v", u, err)
+    }
+}
+
+func TestResolveSubjectConcurrent(t *testing.T) {
+    auth := newAuthService(0)
+    ids := make([]int64, 10)
+    var wg sync.WaitGroup
+    for i := range ids {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            p, err := auth.ResolveSubject("service-a")
+            if err != nil {
+                t.Errorf("ResolveSubject: %v", err)
+            }
+            ids[i] = p.UserID
+        }()
+    }
+    wg.Wait()
+    for _, id := range ids {
+        if id != ids[0] {
+            t.Fatalf("ResolveSubject created several users: %v", ids)
+        }
+    }
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+// MaxBatchSize — максимальное число операций в одном пакете.
+const MaxBatchSize = 100
+
+// ErrBatchAborted — операция атомарного пакета не применена, потому что
+// не удалась другая операция того же пакета.
+var ErrBatchAborted = errors.New("batch aborted")
+
+// NoteBatchAction — вид операции пакета.
+type NoteBatchAction string
+
+const (
+    // BatchCreate создаёт заметку, как CreateNote.
+    BatchCreate NoteBatchAction = "create"
+    // BatchUpdate изменяет заметку, как UpdateNote.
+    BatchUpdate NoteBatchAction = "update"
+    // BatchDelete перемещает заметку в корзину, как DeleteNote.
+    BatchDelete NoteBatchAction = "delete"
+)
+
+// NoteBatchOp — операция пакета BatchNotes.
+type NoteBatchOp struct {
+    Action NoteBatchAction
+    // ID и Version — заметка для update и delete; Version != 0 — как в
+    // UpdateNote.
+    ID      int64
+    Version int64
+    // Create — поля новой заметки для create.
+    Create NoteCreateInput
+    // Update — изменения для update.
+    Update NoteUpdateInput
+}
+
+// NoteBatchResult — итог операции пакета.
+type NoteBatchResult struct {
+    // Note — созданная или изменённая заметка; для delete — nil.
+    Note *core.Note
+    // Err — ошибка операции, та же, что вернул бы CreateNote, UpdateNote
+    // или DeleteNote; ErrBatchAborted — операция не применена из-за
+    // ошибки другой.
+    Err error
+}
+
+// BatchNotes выполняет операции по порядку и возвращает итог каждой.
+// Без atomic операции независимы: неудачная не мешает остальным. С
+// atomic пакет применяется целиком в одной транзакции репозитория или не
+// применяется вовсе: первая неудачная операция получает свою ошибку, а
+// остальные — ErrBatchAborted. Ошибка BatchNotes относится к пакету
+// целиком (неверный состав, сбой хранилища), итоги тогда не возвращаются.
+func (s *NoteService) BatchNotes(ctx context.Context, ops []NoteBatchOp, atomic bool) ([]NoteBatchResult, error) {
+    caller, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if err := checkBatch(ops); err != nil {
+        return nil, err
+    }
+    results := make([]NoteBatchResult, len(ops))
+    if atomic {
+        return results, s.batchAtomic(ctx, caller, ops, results)
+    }
+    for i, op := range ops {
+        r := &results[i]
+        switch op.Action {
+        case BatchCreate:
+            r.Note, r.Err = s.CreateNote(ctx, op.Create)
+        case BatchUpdate:
+            r.Note, r.Err = s.UpdateNote(ctx, op.ID, op.Version, op.Update)
+        case BatchDelete:
+            r.Err = s.DeleteNote(ctx, op.ID, op.Version)
+        }
+    }
+    return results, nil
+}
+
+// checkBatch проверяет состав пакета: число операций, их виды и ID.
+func checkBatch(ops []NoteBatchOp) error {
+    if len(ops) == 0 {
+        return invalid("operations", CodeRequired, "%s must not be empty", "operations")
+    }
+    if len(ops) > MaxBatchSize {
+        return invalid("operations", CodeTooMany, "at most %d operations are allowed", MaxBatchSize)
+    }
+    var v violations
+    for i, op := range ops {
+        switch op.Action {
+        case BatchCreate:
+        case BatchUpdate, BatchDelete:
+            if op.ID <= 0 {
+                field := fmt.Sprintf("operations[%d].id", i)
+                v.add(field, CodeRequired, "%s is required", field)
+            }
+        default:
+            field := fmt.Sprintf("operations[%d].op", i)
+            v.add(field, CodeUnsupported, "%s must be one of %s", field, "create, update, delete")
+        }
+    }
+    return v.err()
+}
+
+// batchStep — операция атомарного пакета, подготовленная до транзакции.
+type batchStep struct {
+    owner  int64
+    note   core.Note // create
+    edit   noteEdit  // update
+    before *core.Note
+    after  *core.Note
+}
+
+// batchAtomic выполняет пакет в одной транзакции. Всё, что требует
+// других хранилищ (права, блокноты, квота), проверяется до неё: внутри
+// Batch к ним обращаться нельзя.
+func (s *NoteService) batchAtomic(ctx context.Context, caller int64, ops []NoteBatchOp, results []NoteBatchResult) error {
+    steps := make([]batchStep, len(ops))
+    owners := make([]int64, 0, len(ops))
+    var notebooks bool
+    for i, op := range ops {
+        st := &steps[i]
+        var err error
+        switch op.Action {
+        case BatchCreate:
+            st.owner = caller
+            st.note, err = s.checkCreate(caller, op.Create)
+            notebooks = notebooks || op.Create.NotebookID != nil
+        case BatchUpdate:
+            if st.owner, err = s.authorize(ctx, op.ID, accessWrite); err == nil {
+                st.edit, err = s.checkUpdate(op.Update)
+            }
+        case BatchDelete:
+            st.owner, err = s.authorize(ctx, op.ID, accessOwner)
+        }
+        if err != nil {
+            abortBatch(results, i, err)
+            return nil
+        }
+        owners = append(owners, st.owner)
+    }
+
+    usage, unlock, err := s.lockQuotas(owners)
+    if err != nil {
+        return err
+    }
+    defer unlock()
+    if notebooks {
+        // пока удерживается мьютекс блокнотов, проверенные блокноты никто
+        // не удалит
+        defer s.notebookLocks.lock(caller)()
+        for i, st := range steps {
+            if err := s.checkNotebook(caller, st.note.NotebookID); err != nil {
+                abortBatch(results, i, err)
+                return nil
+            }
+        }
+    }
+
+    failed := -1
+    err = s.repo.Batch(func(tx repo.NoteRepository) error {
+        for i, op := range ops {
+            if err := s.batchStep(tx, op, &steps[i], usage); err != nil {
+                failed = i
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        if failed < 0 {
+            return err // транзакция не сохранилась
+        }
+        abortBatch(results, failed, err)
+        return nil
+    }
+
+    for i, op := range ops {
+        st := &steps[i]
+        switch op.Action {
+        case BatchCreate:
+            s.reindex(st.after)
+            s.recordRevision(st.after)
+            s.noteChanged(ctx, core.AuditCreate, nil, st.after)
+            results[i].Note = st.after
+        case BatchUpdate:
+            s.reindex(st.after)
+            s.recordRevision(st.after)
+            s.noteChanged(ctx, core.AuditUpdate, st.before, st.after)
+            results[i].Note = st.after
+        case BatchDelete:
+            s.unindex(op.ID)
+            s.noteChanged(ctx, core.AuditDelete, st.before, st.after)
+        }
+    }
+    return nil
+}
+
+// batchStep выполняет операцию атомарного пакета в транзакции tx и
+// учитывает её в объёме заметок usage.
+func (s *NoteService) batchStep(tx repo.NoteRepository, op NoteBatchOp, st *batchStep, usage map[int64]repo.NoteUsage) error {
+    u := usage[st.owner]
+    var err error
+    switch op.Action {
+    case BatchCreate:
+        size := repo.NoteSize(st.note.Title, st.note.Content)
+        if !s.quota.allows(u, 1, size) {
+            return ErrQuotaExceeded
+        }
+        id, err := tx.Create(st.note)
+        if err != nil {
+            return err
+        }
+        if st.after, err = tx.GetByID(st.owner, id); err != nil {
+            return err
+        }
+        u.Notes++
+        u.Bytes += size
+    case BatchUpdate:
+        if st.after, err = tx.Update(st.owner, op.ID, op.Version, s.editFn(st.edit, u, &st.before)); err != nil {
+            return err
+        }
+        u.Bytes += repo.NoteSize(st.after.Title, st.after.Content) - repo.NoteSize(st.before.Title, st.before.Content)
+    case BatchDelete:
+        if st.after, err = tx.Update(st.owner, op.ID, op.Version, trashFn(&st.before)); err != nil {
+            return err
+        }
+    }
+    usage[st.owner] = u
+    return nil
+}
+
+// abortBatch записывает ошибку err операции failed, а остальным
+// операциям — ErrBatchAborted.
+func abortBatch(results []NoteBatchResult, failed int, err error) {
+    for i := range results {
+        results[i] = NoteBatchResult{Err: ErrBatchAborted}
+    }
+    results[failed].Err = err
+}
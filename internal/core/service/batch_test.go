@@ -0,0 +1,208 @@
+package service_test
+
+import (
+    "errors"
+    "testing"
+
+    "example.com/notes-api/internal/core/service"
+    "example.com/notes-api/internal/repo"
+)
+
+// wantBatchErrs проверяет ошибки операций пакета; nil — успех.
+func wantBatchErrs(t *testing.T, results []service.NoteBatchResult, want ...error) {
+    t.Helper()
+    if len(results) != len(want) {
+        t.Fatalf("got %d results, want %d", len(results), len(want))
+    }
+    for i, r := range results {
+        if !errors.Is(r.Err, want[i]) || (want[i] == nil) != (r.Err == nil) {
+            t.Errorf("operation %d: err = %v, want %v", i, r.Err, want[i])
+        }
+    }
+}
+
+// wantUnchanged проверяет, что атомарный пакет ничего не применил:
+// у alice одна исходная заметка с исходным заголовком.
+func wantUnchanged(t *testing.T, f sharedFixture) {
+    t.Helper()
+    n, err := f.svc.GetNote(f.alice, f.noteID)
+    if err != nil || n.Title != "План" || n.Version != 1 || n.DeletedAt != nil {
+        t.Errorf("GetNote = %+v, %v; want the note unchanged", n, err)
+    }
+    if u, err := f.notes.Usage(f.userID(f.alice)); err != nil || u.Notes != 1 {
+        t.Errorf("Usage = %+v, %v; want 1 note", u, err)
+    }
+}
+
+func TestBatchAtomicRollsBackOnValidation(t *testing.T) {
+    f := newSharedFixture(t)
+    title := "Правка"
+    results, err := f.svc.BatchNotes(f.alice, []service.NoteBatchOp{
+        {Action: service.BatchCreate, Create: service.NoteCreateInput{Title: "Новая"}},
+        {Action: service.BatchUpdate, ID: f.noteID, Update: service.NoteUpdateInput{Title: &title}},
+        {Action: service.BatchCreate, Create: service.NoteCreateInput{Title: "   "}},
+    }, true)
+    if err != nil {
+        t.Fatalf("BatchNotes: %v", err)
+    }
+    wantBatchErrs(t, results, service.ErrBatchAborted, service.ErrBatchAborted, service.ErrValidation)
+    wantUnchanged(t, f)
+}
+
+func TestBatchAtomicRollsBackOnAuthorization(t *testing.T) {
+    f := newSharedFixture(t)
+    title := "Правка"
+    // carol может править заметку, но не удалять её
+    results, err := f.svc.BatchNotes(f.carol, []service.NoteBatchOp{
+        {Action: service.BatchUpdate, ID: f.noteID, Update: service.NoteUpdateInput{Title: &title}},
+        {Action: service.BatchDelete, ID: f.noteID},
+    }, true)
+    if err != nil {
+        t.Fatalf("BatchNotes: %v", err)
+    }
+    wantBatchErrs(t, results, service.ErrBatchAborted, service.ErrForbidden)
+
+    // чужая заметка для dave не существует
+    results, err = f.svc.BatchNotes(f.dave, []service.NoteBatchOp{
+        {Action: service.BatchCreate, Create: service.NoteCreateInput{Title: "Своя"}},
+        {Action: service.BatchUpdate, ID: f.noteID, Update: service.NoteUpdateInput{Title: &title}},
+    }, true)
+    if err != nil {
+        t.Fatalf("BatchNotes: %v", err)
+    }
+    wantBatchErrs(t, results, service.ErrBatchAborted, repo.ErrNoteNotFound)
+    if u, err := f.notes.Usage(f.userID(f.dave)); err != nil || u.Notes != 0 {
+        t.Errorf("dave's usage = %+v, %v; want no notes", u, err)
+    }
+    wantUnchanged(t, f)
+}
+
+func TestBatchAtomicRollsBackInTransaction(t *testing.T) {
+    f := newSharedFixture(t)
+    title := "Правка"
+    // конфликт версий обнаруживается только в транзакции, когда create и
+    // update уже записаны
+    results, err := f.svc.BatchNotes(f.alice, []service.NoteBatchOp{
+        {Action: service.BatchCreate, Create: service.NoteCreateInput{Title: "Новая"}},
+        {Action: service.BatchUpdate, ID: f.noteID, Version: 1, Update: service.NoteUpdateInput{Title: &title}},
+        {Action: service.BatchDelete, ID: f.noteID, Version: 1},
+    }, true)
+    if err != nil {
+        t.Fatalf("BatchNotes: %v", err)
+    }
+    wantBatchErrs(t, results, service.ErrBatchAborted, service.ErrBatchAborted, repo.ErrVersionConflict)
+    wantUnchanged(t, f)
+}
+
+func TestBatchAtomicQuotaCountsWholeBatch(t *testing.T) {
+    f := newSharedFixture(t, service.WithQuota(service.Quota{MaxNotes: 3}))
+    create := service.NoteBatchOp{Action: service.BatchCreate, Create: service.NoteCreateInput{Title: "Новая"}}
+
+    // каждая операция в отдельности в квоту помещается, все три — нет
+    results, err := f.svc.BatchNotes(f.alice, []service.NoteBatchOp{create, create, create}, true)
+    if err != nil {
+        t.Fatalf("BatchNotes: %v", err)
+    }
+    wantBatchErrs(t, results, service.ErrBatchAborted, service.ErrBatchAborted, service.ErrQuotaExceeded)
+    wantUnchanged(t, f)
+
+    results, err = f.svc.BatchNotes(f.alice, []service.NoteBatchOp{create, create}, true)
+    if err != nil {
+        t.Fatalf("BatchNotes: %v", err)
+    }
+    wantBatchErrs(t, results, nil, nil)
+}
+
+func TestBatchAtomicQuotaBytes(t *testing.T) {
+    // «План» + «текст» — 18 байт
+    f := newSharedFixture(t, service.WithQuota(service.Quota{MaxBytes: 40}))
+    content := "0123456789"
+    results, err := f.svc.BatchNotes(f.alice, []service.NoteBatchOp{
+        {Action: service.BatchUpdate, ID: f.noteID, Update: service.NoteUpdateInput{Content: &content}},
+        {Action: service.BatchCreate, Create: service.NoteCreateInput{Title: "Ещё", Content: content}},
+    }, true)
+    if err != nil {
+        t.Fatalf("BatchNotes: %v", err)
+    }
+    // update добавляет 0 байт, create — 16: вместе 34 из 40
+    wantBatchErrs(t, results, nil, nil)
+
+    results, err = f.svc.BatchNotes(f.alice, []service.NoteBatchOp{
+        {Action: service.BatchCreate, Create: service.NoteCreateInput{Title: "1"}},
+        {Action: service.BatchCreate, Create: service.NoteCreateInput{Title: "123456"}},
+    }, true)
+    if err != nil {
+        t.Fatalf("BatchNotes: %v", err)
+    }
+    wantBatchErrs(t, results, service.ErrBatchAborted, service.ErrQuotaExceeded)
+}
+
+func TestBatchAtomicSameNote(t *testing.T) {
+    f := newSharedFixture(t)
+    first, second := "Первая правка", "Вторая правка"
+    results, err := f.svc.BatchNotes(f.alice, []service.NoteBatchOp{
+        {Action: service.BatchUpdate, ID: f.noteID, Version: 1, Update: service.NoteUpdateInput{Title: &first}},
+        {Action: service.BatchCreate, Create: service.NoteCreateInput{Title: "Новая"}},
+        {Action: service.BatchUpdate, ID: f.noteID, Version: 2, Update: service.NoteUpdateInput{Title: &second}},
+        {Action: service.BatchDelete, ID: f.noteID, Version: 3},
+    }, true)
+    if err != nil {
+        t.Fatalf("BatchNotes: %v", err)
+    }
+    wantBatchErrs(t, results, nil, nil, nil, nil)
+    if n := results[2].Note; n == nil || n.Title != second || n.Version != 3 {
+        t.Errorf("second update = %+v", n)
+    }
+
+    if _, err := f.svc.GetNote(f.alice, f.noteID); !errors.Is(err, repo.ErrNoteNotFound) {
+        t.Errorf("GetNote: err = %v, want ErrNoteNotFound", err)
+    }
+    trashed, err := f.svc.GetTrashedNote(f.alice, f.noteID)
+    if err != nil || trashed.Title != second || trashed.Version != 4 {
+        t.Errorf("GetTrashedNote = %+v, %v", trashed, err)
+    }
+    if u, err := f.notes.Usage(f.userID(f.alice)); err != nil || u.Notes != 2 {
+        t.Errorf("Usage = %+v, %v; want 2 notes", u, err)
+    }
+}
+
+func TestBatchIndependent(t *testing.T) {
+    f := newSharedFixture(t, service.WithQuota(service.Quota{MaxNotes: 2}))
+    title := "Правка"
+    results, err := f.svc.BatchNotes(f.carol, []service.NoteBatchOp{
+        {Action: service.BatchUpdate, ID: f.noteID, Update: service.NoteUpdateInput{Title: &title}},
+        {Action: service.BatchDelete, ID: f.noteID},
+        {Action: service.BatchCreate, Create: service.NoteCreateInput{Title: "   "}},
+        {Action: service.BatchCreate, Create: service.NoteCreateInput{Title: "Своя"}},
+    }, false)
+    if err != nil {
+        t.Fatalf("BatchNotes: %v", err)
+    }
+    wantBatchErrs(t, results, nil, service.ErrForbidden, service.ErrValidation, nil)
+    if n, err := f.svc.GetNote(f.alice, f.noteID); err != nil || n.Title != title {
+        t.Errorf("GetNote = %+v, %v; want the update applied", n, err)
+    }
+
+    // квота проверяется для каждой операции по очереди
+    create := service.NoteBatchOp{Action: service.BatchCreate, Create: service.NoteCreateInput{Title: "Новая"}}
+    results, err = f.svc.BatchNotes(f.alice, []service.NoteBatchOp{create, create}, false)
+    if err != nil {
+        t.Fatalf("BatchNotes: %v", err)
+    }
+    wantBatchErrs(t, results, nil, service.ErrQuotaExceeded)
+}
+
+func TestBatchRejectsMalformed(t *testing.T) {
+    f := newSharedFixture(t)
+    for name, ops := range map[string][]service.NoteBatchOp{
+        "empty":      nil,
+        "too many":   make([]service.NoteBatchOp, service.MaxBatchSize+1),
+        "no id":      {{Action: service.BatchUpdate}},
+        "unknown op": {{Action: "move", ID: f.noteID}},
+    } {
+        if _, err := f.svc.BatchNotes(f.alice, ops, true); !errors.Is(err, service.ErrValidation) {
+            t.Errorf("%s: err = %v, want ErrValidation", name, err)
+        }
+    }
+    wantUnchanged(t, f)
+}
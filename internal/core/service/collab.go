@@ -0,0 +1,621 @@
+package service
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/base64"
+    "errors"
+    "log"
+    "slices"
+    "strconv"
+    "sync"
+    "time"
+    "unicode/utf8"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/ot"
+    "example.com/notes-api/internal/repo"
+)
+
+var (
+    ErrCollabClosed  = errors.New("collaborative editing is shut down")
+    ErrStaleRevision = errors.New("revision is too old")
+)
+
+const (
+    // DefaultCollabSaveInterval — как часто сессия совместного
+    // редактирования сохраняет заметку.
+    DefaultCollabSaveInterval = 5 * time.Second
+    // collabHistory — сколько последних операций хранит сессия: клиент,
+    // отставший сильнее, должен переподключиться.
+    collabHistory = 1000
+    // collabClientBuffer — сколько сообщений может ждать участника.
+    // Кто отстал сильнее, того сессия отключает, а не ждёт.
+    collabClientBuffer = 256
+    // collabSaveAttempts — сколько раз сохранение вливает чужие
+    // изменения заметки и пробует снова.
+    collabSaveAttempts = 3
+    // CollabTicketTTL — сколько действует билет на подключение.
+    CollabTicketTTL = 30 * time.Second
+)
+
+// Сообщения сессии совместного редактирования участнику.
+const (
+    CollabInit   = "init"   // состояние документа и участники при подключении
+    CollabAck    = "ack"    // операция участника принята
+    CollabOp     = "op"     // операция другого участника или сервера
+    CollabCursor = "cursor" // другой участник переместил курсор
+    CollabJoin   = "join"   // подключился участник
+    CollabLeave  = "leave"  // участник отключился
+    CollabSaved  = "saved"  // заметка сохранена
+    CollabError  = "error"  // сессия отключает участника
+)
+
+// CollabSelection — курсор или выделение участника, в символах.
+type CollabSelection struct {
+    Position int `json:"position"`
+    Anchor   int `json:"anchor"`
+}
+
+// CollabParticipant — участник сессии.
+type CollabParticipant struct {
+    ClientID string           `json:"clientId"`
+    UserID   int64            `json:"userId"`
+    Username string           `json:"username"`
+    ReadOnly bool             `json:"readOnly"`
+    Cursor   *CollabSelection `json:"cursor,omitempty"`
+}
+
+// CollabMessage — сообщение участнику; какие поля заполнены, зависит
+// от Type.
+type CollabMessage struct {
+    Type string `json:"type"`
+    // Rev — ревизия документа после операции (init, ack, op) или
+    // сохранённая ревизия (saved).
+    Rev          int64               `json:"rev"`
+    ClientID     string              `json:"clientId,omitempty"`
+    Op           ot.Operation        `json:"op,omitempty"`
+    Content      *string             `json:"content,omitempty"`
+    Version      int64               `json:"version,omitempty"`
+    ReadOnly     bool                `json:"readOnly,omitempty"`
+    Participants []CollabParticipant `json:"participants,omitempty"`
+    Participant  *CollabParticipant  `json:"participant,omitempty"`
+    Cursor       *CollabSelection    `json:"cursor,omitempty"`
+    Error        string              `json:"error,omitempty"`
+}
+
+// CollabService ведёт сессии совместного редактирования текста заметок.
+// Участники присылают операции (см. пакет ot) над известной им
+// ревизией; сессия преобразует их против операций, принятых с тех пор,
+// применяет в едином порядке и рассылает остальным — поэтому копии
+// сходятся, а одновременные правки не теряются. Заметка сохраняется
+// через NoteService раз в saveInterval и когда уходит последний
+// участник.
+type CollabService struct {
+    notes        *NoteService
+    saveInterval time.Duration
+
+    mu         sync.Mutex
+    sessions   map[int64]*collabSession
+    nextClient int64
+    closed     bool
+    // tickets — выданные и ещё не использованные билеты по хешу.
+    tickets map[string]collabTicket
+}
+
+// collabTicket — одноразовый билет на подключение к сессии заметки.
+type collabTicket struct {
+    principal core.Principal
+    noteID    int64
+    expiresAt time.Time
+}
+
+// NewCollabService создаёт сервис совместного редактирования
+// (saveInterval <= 0 — DefaultCollabSaveInterval).
+func NewCollabService(notes *NoteService, saveInterval time.Duration) *CollabService {
+    if saveInterval <= 0 {
+        saveInterval = DefaultCollabSaveInterval
+    }
+    return &CollabService{
+        notes:        notes,
+        saveInterval: saveInterval,
+        sessions:     make(map[int64]*collabSession),
+        tickets:      make(map[string]collabTicket),
+    }
+}
+
+// IssueTicket выпускает одноразовый билет, по которому текущий
+// пользователь в течение CollabTicketTTL может подключиться к сессии
+// заметки id. Браузерный WebSocket не передаёт заголовок Authorization,
+// поэтому страница получает билет обычным запросом с токеном и
+// передаёт его в адресе подключения.
+func (c *CollabService) IssueTicket(ctx context.Context, id int64) (string, time.Time, error) {
+    p, ok := core.PrincipalFrom(ctx)
+    if !ok || p.UserID <= 0 {
+        return "", time.Time{}, ErrUnauthenticated
+    }
+    if _, err := c.notes.GetNote(ctx, id); err != nil {
+        return "", time.Time{}, err
+    }
+    raw := make([]byte, tokenBytes)
+    if _, err := rand.Read(raw); err != nil {
+        return "", time.Time{}, err
+    }
+    ticket := base64.RawURLEncoding.EncodeToString(raw)
+    now := time.Now().UTC()
+    expiresAt := now.Add(CollabTicketTTL)
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    for hash, t := range c.tickets {
+        if !now.Before(t.expiresAt) {
+            delete(c.tickets, hash)
+        }
+    }
+    c.tickets[hashToken(ticket)] = collabTicket{principal: p, noteID: id, expiresAt: expiresAt}
+    return ticket, expiresAt, nil
+}
+
+// RedeemTicket возвращает пользователя, которому выдан билет ticket на
+// заметку id, и гасит билет. Неизвестный, просроченный или выданный на
+// другую заметку билет — ErrUnauthenticated.
+func (c *CollabService) RedeemTicket(ticket string, id int64) (core.Principal, error) {
+    hash := hashToken(ticket)
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    t, ok := c.tickets[hash]
+    if !ok || t.noteID != id {
+        return core.Principal{}, ErrUnauthenticated
+    }
+    delete(c.tickets, hash)
+    if !time.Now().Before(t.expiresAt) {
+        return core.Principal{}, ErrUnauthenticated
+    }
+    return t.principal, nil
+}
+
+// collabSession — документ одной заметки и его участники. Блокировки
+// берутся в порядке saveMu, CollabService.mu, mu.
+type collabSession struct {
+    noteID int64
+    // saveMu сериализует сохранения заметки.
+    saveMu sync.Mutex
+
+    mu      sync.Mutex
+    content string
+    rev     int64
+    // history — последние операции: history[i] переводит документ из
+    // ревизии rev-len(history)+i в следующую.
+    history []ot.Operation
+    // stored — текст заметки в хранилище в версии version; pending —
+    // операции, применённые после него: content == stored∘pending.
+    stored  string
+    version int64
+    pending []ot.Operation
+    // editor — последний, кто правил документ: от его имени сессия
+    // сохраняет заметку.
+    editor  core.Principal
+    clients map[string]*CollabClient
+    ended   bool
+}
+
+// CollabClient — подключение участника к сессии.
+type CollabClient struct {
+    // Out получает сообщения участнику, первым — CollabInit. Канал
+    // закрывается, когда участника отключили.
+    Out <-chan CollabMessage
+
+    out       chan CollabMessage
+    info      CollabParticipant
+    principal core.Principal
+    session   *collabSession
+    svc       *CollabService
+}
+
+// Join подключает текущего пользователя к сессии заметки id, открывая
+// её при необходимости. Читатель заметки (роль viewer или API-ключ без
+// notes:write) только наблюдает за правками.
+func (c *CollabService) Join(ctx context.Context, id int64) (*CollabClient, error) {
+    p, ok := core.PrincipalFrom(ctx)
+    if !ok || p.UserID <= 0 {
+        return nil, ErrUnauthenticated
+    }
+    n, err := c.notes.GetNote(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+    readOnly := !p.HasScope(core.ScopeNotesWrite)
+    if !readOnly {
+        _, err := c.notes.authorize(ctx, id, accessWrite)
+        if errors.Is(err, ErrForbidden) {
+            readOnly = true
+        } else if err != nil {
+            return nil, err
+        }
+    }
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if c.closed {
+        return nil, ErrCollabClosed
+    }
+    s := c.sessions[id]
+    if s == nil {
+        s = &collabSession{
+            noteID:  id,
+            content: n.Content,
+            stored:  n.Content,
+            version: n.Version,
+            clients: make(map[string]*CollabClient),
+        }
+        c.sessions[id] = s
+    }
+    c.nextClient++
+    out := make(chan CollabMessage, collabClientBuffer)
+    cl := &CollabClient{
+        Out: out,
+        out: out,
+        info: CollabParticipant{
+            ClientID: "c" + strconv.FormatInt(c.nextClient, 10),
+            UserID:   p.UserID,
+            Username: p.Username,
+            ReadOnly: readOnly,
+        },
+        principal: p,
+        session:   s,
+        svc:       c,
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    content := s.content
+    cl.out <- CollabMessage{
+        Type:         CollabInit,
+        Rev:          s.rev,
+        ClientID:     cl.info.ClientID,
+        Content:      &content,
+        Version:      s.version,
+        ReadOnly:     readOnly,
+        Participants: s.participants(),
+    }
+    info := cl.info
+    s.broadcast(CollabMessage{Type: CollabJoin, Participant: &info}, nil)
+    s.clients[cl.info.ClientID] = cl
+    return cl, nil
+}
+
+// Submit применяет операцию участника над ревизией rev; cursor —
+// курсор участника после неё (nil — не изменился). Ошибка означает,
+// что копия участника разошлась с сессией: его нужно отключить
+// (Disconnect), чтобы он переподключился и получил документ заново.
+// Операция, после которой текст нарушает правила NoteLimits.Content,
+// отклоняется с ValidationError.
+func (cl *CollabClient) Submit(rev int64, op ot.Operation, cursor *CollabSelection) error {
+    if cl.info.ReadOnly {
+        return ErrForbidden
+    }
+    s := cl.session
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.clients[cl.info.ClientID] != cl {
+        return ErrCollabClosed
+    }
+    first := s.rev - int64(len(s.history))
+    if rev < first || rev > s.rev {
+        return ErrStaleRevision
+    }
+    for _, h := range s.history[rev-first:] {
+        var err error
+        if op, _, err = ot.Transform(op, h); err != nil {
+            return ErrValidation
+        }
+    }
+    content, err := op.Apply(s.content)
+    if err != nil {
+        return ErrValidation
+    }
+    var v violations
+    cl.svc.notes.limits.Content.check(&v, "content", content, false)
+    if err := v.err(); err != nil {
+        return err
+    }
+    s.commit(op, content, cl)
+    s.pending = append(s.pending, op)
+    s.editor = cl.principal
+    if cursor != nil {
+        cl.info.Cursor = s.clamp(*cursor)
+        s.broadcast(CollabMessage{Type: CollabCursor, ClientID: cl.info.ClientID, Cursor: cl.info.Cursor}, cl)
+    }
+    return nil
+}
+
+// MoveCursor сообщает остальным участникам курсор участника.
+func (cl *CollabClient) MoveCursor(cursor CollabSelection) {
+    s := cl.session
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.clients[cl.info.ClientID] != cl {
+        return
+    }
+    cl.info.Cursor = s.clamp(cursor)
+    s.broadcast(CollabMessage{Type: CollabCursor, ClientID: cl.info.ClientID, Cursor: cl.info.Cursor}, cl)
+}
+
+// Leave отключает участника. Повторный вызов ничего не делает.
+func (cl *CollabClient) Leave() {
+    cl.disconnect("")
+}
+
+// Disconnect отключает участника, отправив ему сообщение CollabError с
+// причиной reason.
+func (cl *CollabClient) Disconnect(reason string) {
+    cl.disconnect(reason)
+}
+
+func (cl *CollabClient) disconnect(reason string) {
+    s := cl.session
+    s.mu.Lock()
+    if s.clients[cl.info.ClientID] != cl {
+        s.mu.Unlock()
+        return
+    }
+    if reason != "" {
+        select {
+        case cl.out <- CollabMessage{Type: CollabError, Error: reason}:
+        default:
+        }
+    }
+    s.remove(cl)
+    empty := len(s.clients) == 0
+    s.mu.Unlock()
+    if empty {
+        cl.svc.flush(s)
+    }
+}
+
+// apply применяет операцию к документу сессии и рассылает её; author
+// получает подтверждение, остальные — саму операцию (author == nil —
+// операция сервера).
+func (s *collabSession) apply(op ot.Operation, author *CollabClient) error {
+    content, err := op.Apply(s.content)
+    if err != nil {
+        return err
+    }
+    s.commit(op, content, author)
+    return nil
+}
+
+// commit делает content, полученный операцией op, текущим документом
+// сессии; author — как в apply.
+func (s *collabSession) commit(op ot.Operation, content string, author *CollabClient) {
+    s.content = content
+    s.rev++
+    s.history = append(s.history, op)
+    if len(s.history) > 2*collabHistory {
+        s.history = slices.Clone(s.history[len(s.history)-collabHistory:])
+    }
+    for _, cl := range s.clients {
+        if cl != author && cl.info.Cursor != nil {
+            cl.info.Cursor = &CollabSelection{
+                Position: op.TransformIndex(cl.info.Cursor.Position),
+                Anchor:   op.TransformIndex(cl.info.Cursor.Anchor),
+            }
+        }
+    }
+
+    msg := CollabMessage{Type: CollabOp, Rev: s.rev, Op: op}
+    if author != nil {
+        msg.ClientID = author.info.ClientID
+        s.send(author, CollabMessage{Type: CollabAck, Rev: s.rev})
+    }
+    s.broadcast(msg, author)
+}
+
+// clamp ограничивает курсор длиной документа.
+func (s *collabSession) clamp(c CollabSelection) *CollabSelection {
+    n := utf8.RuneCountInString(s.content)
+    return &CollabSelection{Position: min(max(c.Position, 0), n), Anchor: min(max(c.Anchor, 0), n)}
+}
+
+// participants возвращает описания участников в порядке подключения.
+func (s *collabSession) participants() []CollabParticipant {
+    out := make([]CollabParticipant, 0, len(s.clients))
+    for _, cl := range s.clients {
+        out = append(out, cl.info)
+    }
+    slices.SortFunc(out, func(a, b CollabParticipant) int {
+        return compareClientIDs(a.ClientID, b.ClientID)
+    })
+    return out
+}
+
+// compareClientIDs сравнивает идентификаторы вида c<номер> по номеру.
+func compareClientIDs(a, b string) int {
+    if len(a) != len(b) {
+        return len(a) - len(b)
+    }
+    switch {
+    case a < b:
+        return -1
+    case a > b:
+        return 1
+    }
+    return 0
+}
+
+// send отправляет сообщение участнику; отставшего отключает.
+func (s *collabSession) send(cl *CollabClient, msg CollabMessage) {
+    select {
+    case cl.out <- msg:
+    default:
+        log.Printf("collab: note %d: drop slow client %s", s.noteID, cl.info.ClientID)
+        s.remove(cl)
+        if len(s.clients) == 0 {
+            go cl.svc.flush(s)
+        }
+    }
+}
+
+// broadcast отправляет сообщение всем участникам, кроме except.
+func (s *collabSession) broadcast(msg CollabMessage, except *CollabClient) {
+    for _, cl := range s.clients {
+        if cl != except {
+            s.send(cl, msg)
+        }
+    }
+}
+
+// remove убирает участника из сессии и сообщает об этом остальным.
+func (s *collabSession) remove(cl *CollabClient) {
+    delete(s.clients, cl.info.ClientID)
+    close(cl.out)
+    info := cl.info
+    s.broadcast(CollabMessage{Type: CollabLeave, Participant: &info}, nil)
+}
+
+// end отключает всех участников с сообщением reason и закрывает сессию.
+func (c *CollabService) end(s *collabSession, reason string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    for _, cl := range s.clients {
+        select {
+        case cl.out <- CollabMessage{Type: CollabError, Error: reason}:
+        default:
+        }
+        delete(s.clients, cl.info.ClientID)
+        close(cl.out)
+    }
+    s.ended = true
+    if c.sessions[s.noteID] == s {
+        delete(c.sessions, s.noteID)
+    }
+}
+
+// flush сохраняет сессию и закрывает её, если участников не осталось.
+// Если сохранить не удалось, сессия остаётся: RunSaver попробует снова.
+func (c *CollabService) flush(s *collabSession) {
+    if err := c.save(s); err != nil {
+        log.Printf("collab: save note %d: %v", s.noteID, err)
+        return
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if len(s.clients) == 0 && c.sessions[s.noteID] == s {
+        delete(c.sessions, s.noteID)
+    }
+}
+
+// save записывает документ сессии в заметку. Если заметку изменили в
+// обход сессии (PATCH, восстановление версии), её текст вливается в
+// документ операцией сервера, и запись повторяется. Заметка, которая
+// пропала или стала недоступна последнему редактору, закрывает сессию.
+func (c *CollabService) save(s *collabSession) error {
+    s.saveMu.Lock()
+    defer s.saveMu.Unlock()
+
+    for attempt := 1; ; attempt++ {
+        s.mu.Lock()
+        if len(s.pending) == 0 || s.ended {
+            s.mu.Unlock()
+            return nil
+        }
+        content, version, rev, saved, editor := s.content, s.version, s.rev, len(s.pending), s.editor
+        s.mu.Unlock()
+
+        ctx := core.WithPrincipal(context.Background(), editor)
+        n, err := c.notes.UpdateNote(ctx, s.noteID, version, NoteUpdateInput{Content: &content})
+        if err == nil {
+            s.mu.Lock()
+            s.stored, s.version = content, n.Version
+            s.pending = s.pending[saved:]
+            s.broadcast(CollabMessage{Type: CollabSaved, Rev: rev, Version: n.Version}, nil)
+            if n.Content != content {
+                // сервис нормализовал текст (NFC): правка вливается как
+                // изменение в обход сессии
+                err = s.merge(n)
+            }
+            s.mu.Unlock()
+            return err
+        }
+        if errors.Is(err, repo.ErrVersionConflict) && attempt < collabSaveAttempts {
+            n, err = c.notes.GetNote(ctx, s.noteID)
+            if err == nil {
+                s.mu.Lock()
+                err = s.merge(n)
+                s.mu.Unlock()
+                if err == nil {
+                    continue
+                }
+            }
+        }
+        if errors.Is(err, repo.ErrNoteNotFound) || errors.Is(err, ErrForbidden) {
+            c.end(s, "note is no longer available")
+            return nil
+        }
+        return err
+    }
+}
+
+// merge вливает в документ текст заметки n, изменённый в обход сессии.
+func (s *collabSession) merge(n *core.Note) error {
+    ext := ot.Diff(s.stored, n.Content)
+    pending := make([]ot.Operation, len(s.pending))
+    for i, op := range s.pending {
+        var err error
+        if ext, pending[i], err = ot.Transform(ext, op); err != nil {
+            return err
+        }
+    }
+    s.stored, s.version, s.pending = n.Content, n.Version, pending
+    if ext.IsNoop() {
+        return nil
+    }
+    return s.apply(ext, nil)
+}
+
+// RunSaver сохраняет изменённые документы раз в saveInterval, пока не
+// отменён ctx. При остановке сохраняет всё и отключает участников.
+func (c *CollabService) RunSaver(ctx context.Context) {
+    ticker := time.NewTicker(c.saveInterval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            c.close()
+            return
+        case <-ticker.C:
+            for _, s := range c.snapshot() {
+                c.flush(s)
+            }
+        }
+    }
+}
+
+// snapshot возвращает открытые сессии.
+func (c *CollabService) snapshot() []*collabSession {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    out := make([]*collabSession, 0, len(c.sessions))
+    for _, s := range c.sessions {
+        out = append(out, s)
+    }
+    return out
+}
+
+// close сохраняет все сессии и закрывает их; новые подключения не
+// принимаются.
+func (c *CollabService) close() {
+    c.mu.Lock()
+    c.closed = true
+    c.mu.Unlock()
+    for _, s := range c.snapshot() {
+        if err := c.save(s); err != nil {
+            log.Printf("collab: save note %d: %v", s.noteID, err)
+        }
+        c.end(s, "server is shutting down")
+    }
+}
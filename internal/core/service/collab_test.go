@@ -0,0 +1,34 @@
+package service_test
+
+import (
+    "errors"
+    "testing"
+
+    "example.com/notes-api/internal/core/service"
+    "example.com/notes-api/internal/repo"
+)
+
+func TestCollabTicket(t *testing.T) {
+    f := newSharedFixture(t)
+    collab := service.NewCollabService(f.svc, 0)
+
+    ticket, expiresAt, err := collab.IssueTicket(f.bob, f.noteID)
+    if err != nil || ticket == "" || expiresAt.IsZero() {
+        t.Fatalf("IssueTicket = %q, %v, %v", ticket, expiresAt, err)
+    }
+    if _, err := collab.RedeemTicket(ticket, f.noteID+1); !errors.Is(err, service.ErrUnauthenticated) {
+        t.Errorf("RedeemTicket(other note): err = %v, want ErrUnauthenticated", err)
+    }
+    p, err := collab.RedeemTicket(ticket, f.noteID)
+    if err != nil || p.UserID != f.userID(f.bob) {
+        t.Fatalf("RedeemTicket = %+v, %v; want bob", p, err)
+    }
+    if _, err := collab.RedeemTicket(ticket, f.noteID); !errors.Is(err, service.ErrUnauthenticated) {
+        t.Errorf("second RedeemTicket: err = %v, want ErrUnauthenticated", err)
+    }
+
+    // билет выдаётся только тому, кто видит заметку
+    if _, _, err := collab.IssueTicket(f.dave, f.noteID); !errors.Is(err, repo.ErrNoteNotFound) {
+        t.Errorf("IssueTicket without access: err = %v, want ErrNoteNotFound", err)
+    }
+}
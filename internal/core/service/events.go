@@ -0,0 +1,231 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "log"
+    "slices"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "example.com/notes-api/internal/core"
+)
+
+var ErrEventBusClosed = errors.New("event bus is closed")
+
+const (
+    // DefaultEventReplay — сколько последних событий шина хранит для
+    // переподключений с Last-Event-ID.
+    DefaultEventReplay = 1000
+    // eventSubscriberBuffer — сколько событий может ждать подписчика.
+    // Кто отстал сильнее, того шина отключает, а не ждёт.
+    eventSubscriberBuffer = 64
+)
+
+// NoteEvent — изменение заметки в потоке событий.
+// @Description Событие потока изменений заметок
+type NoteEvent struct {
+    // Идентификатор события; передаётся в Last-Event-ID при переподключении
+    ID string `json:"id" example:"lx3k9q-42"`
+    // Событие: note.created, note.updated или note.deleted
+    Type string `json:"type" example:"note.updated"`
+    // Уточнение: update и restore для note.updated, delete (в корзину) и
+    // purge (насовсем) для note.deleted
+    Action core.AuditAction `json:"action" example:"update"`
+    // Время изменения
+    OccurredAt time.Time `json:"occurredAt" example:"2024-12-08T12:00:00Z"`
+    // Заметка после изменения (для удаления — до него)
+    Note *core.Note `json:"note"`
+
+    seq int64
+    // audience — кому событие видно: владелец и те, с кем поделились
+    // заметкой.
+    audience []int64
+}
+
+// EventBus раздаёт изменения заметок подписчикам (GET /notes/events)
+// внутри процесса и хранит последние события, чтобы переподключившийся
+// клиент получил пропущенное. Публикация никогда не ждёт подписчика:
+// отставший отключается и догоняет по Last-Event-ID.
+type EventBus struct {
+    // epoch отличает события этого запуска: после перезапуска номера
+    // начинаются заново, и старый Last-Event-ID не должен совпасть с
+    // новым событием.
+    epoch string
+
+    mu     sync.Mutex
+    seq    int64
+    ring   []NoteEvent // последние события, ring[head] — самое старое
+    head   int
+    size   int
+    subs   map[*Subscription]struct{}
+    closed bool
+}
+
+// NewEventBus создаёт шину, хранящую replay последних событий
+// (replay <= 0 — DefaultEventReplay).
+func NewEventBus(replay int) *EventBus {
+    if replay <= 0 {
+        replay = DefaultEventReplay
+    }
+    return &EventBus{
+        epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+        ring:  make([]NoteEvent, replay),
+        subs:  make(map[*Subscription]struct{}),
+    }
+}
+
+// WithEvents публикует изменения заметок в шину b.
+func WithEvents(b *EventBus) Option {
+    return func(s *NoteService) {
+        s.events = b
+    }
+}
+
+// Subscription — подписка одного клиента.
+type Subscription struct {
+    // C получает события по мере публикации. Канал закрывается, если
+    // подписчик отстал или шина закрыта.
+    C <-chan NoteEvent
+
+    ch     chan NoteEvent
+    userID int64
+    bus    *EventBus
+}
+
+// Close отменяет подписку. Повторный вызов ничего не делает.
+func (s *Subscription) Close() {
+    b := s.bus
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if _, ok := b.subs[s]; ok {
+        delete(b.subs, s)
+        close(s.ch)
+    }
+}
+
+// Subscribe подписывает текущего пользователя на изменения его заметок и
+// заметок, которыми с ним поделились. lastEventID — ID последнего
+// полученного события ("" — только новые события); события после него
+// возвращаются в replay. complete == false, если часть событий после
+// lastEventID уже вытеснена из буфера или ID из другого запуска: клиенту
+// стоит перечитать заметки целиком.
+func (b *EventBus) Subscribe(ctx context.Context, lastEventID string) (sub *Subscription, replay []NoteEvent, complete bool, err error) {
+    user, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, nil, false, err
+    }
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.closed {
+        return nil, nil, false, ErrEventBusClosed
+    }
+
+    complete = true
+    if lastEventID != "" {
+        after, ok := b.parseID(lastEventID)
+        if !ok {
+            // чужой или испорченный ID: всё, что было до подписки,
+            // неизвестно клиенту
+            after, complete = b.seq, false
+        } else if b.size > 0 && after < b.ring[b.head].seq-1 {
+            complete = false
+        }
+        for i := 0; i < b.size; i++ {
+            e := b.ring[(b.head+i)%len(b.ring)]
+            if e.seq > after && slices.Contains(e.audience, user) {
+                replay = append(replay, e)
+            }
+        }
+    }
+
+    ch := make(chan NoteEvent, eventSubscriberBuffer)
+    sub = &Subscription{C: ch, ch: ch, userID: user, bus: b}
+    b.subs[sub] = struct{}{}
+    return sub, replay, complete, nil
+}
+
+// parseID разбирает ID события этого запуска.
+func (b *EventBus) parseID(id string) (int64, bool) {
+    epoch, seq, ok := strings.Cut(id, "-")
+    if !ok || epoch != b.epoch {
+        return 0, false
+    }
+    n, err := strconv.ParseInt(seq, 10, 64)
+    if err != nil || n < 0 || n > b.seq {
+        return 0, false
+    }
+    return n, true
+}
+
+// Publish рассылает изменение заметки n пользователям audience.
+func (b *EventBus) Publish(action core.AuditAction, n *core.Note, audience []int64) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.closed {
+        return
+    }
+
+    b.seq++
+    e := NoteEvent{
+        ID:         b.epoch + "-" + strconv.FormatInt(b.seq, 10),
+        Type:       webhookEvent(action),
+        Action:     action,
+        OccurredAt: time.Now().UTC(),
+        Note:       n,
+        seq:        b.seq,
+        audience:   audience,
+    }
+    if b.size < len(b.ring) {
+        b.ring[(b.head+b.size)%len(b.ring)] = e
+        b.size++
+    } else {
+        b.ring[b.head] = e
+        b.head = (b.head + 1) % len(b.ring)
+    }
+
+    for sub := range b.subs {
+        if !slices.Contains(audience, sub.userID) {
+            continue
+        }
+        select {
+        case sub.ch <- e:
+        default:
+            log.Printf("events: drop slow subscriber of user %d", sub.userID)
+            delete(b.subs, sub)
+            close(sub.ch)
+        }
+    }
+}
+
+// Close отключает всех подписчиков; новые подписки не принимаются.
+// Вызывается при остановке сервера, чтобы открытые потоки не держали
+// Shutdown.
+func (b *EventBus) Close() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.closed = true
+    for sub := range b.subs {
+        delete(b.subs, sub)
+        close(sub.ch)
+    }
+}
+
+// publishEvent отправляет изменение заметки n в шину: владельцу и всем,
+// с кем заметкой поделились.
+func (s *NoteService) publishEvent(action core.AuditAction, n *core.Note) {
+    audience := []int64{n.OwnerID}
+    if s.shares != nil {
+        shares, err := s.shares.ListByNote(n.ID)
+        if err != nil {
+            log.Printf("events: list shares of note %d: %v", n.ID, err)
+        }
+        for _, sh := range shares {
+            audience = append(audience, sh.UserID)
+        }
+    }
+    s.events.Publish(action, n, audience)
+}
@@ -0,0 +1,178 @@
+package service_test
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/core/service"
+)
+
+func userCtx(id int64) context.Context {
+    return core.WithPrincipal(context.Background(), core.Principal{UserID: id})
+}
+
+func subscribe(t *testing.T, b *service.EventBus, user int64, lastEventID string) (*service.Subscription, []service.NoteEvent, bool) {
+    t.Helper()
+    sub, replay, complete, err := b.Subscribe(userCtx(user), lastEventID)
+    if err != nil {
+        t.Fatalf("Subscribe: %v", err)
+    }
+    t.Cleanup(sub.Close)
+    return sub, replay, complete
+}
+
+func noteIDs(events []service.NoteEvent) []int64 {
+    ids := make([]int64, len(events))
+    for i, e := range events {
+        ids[i] = e.Note.ID
+    }
+    return ids
+}
+
+func TestEventBusReplay(t *testing.T) {
+    b := service.NewEventBus(10)
+    sub, _, _ := subscribe(t, b, 1, "")
+    for id := int64(1); id <= 3; id++ {
+        b.Publish(core.AuditUpdate, &core.Note{ID: id, OwnerID: 1}, []int64{1})
+    }
+    first := <-sub.C
+
+    _, replay, complete := subscribe(t, b, 1, first.ID)
+    if !complete {
+        t.Error("complete = false, want true")
+    }
+    if got := noteIDs(replay); len(got) != 2 || got[0] != 2 || got[1] != 3 {
+        t.Errorf("replay after %s = %v, want [2 3]", first.ID, got)
+    }
+
+    if _, replay, complete := subscribe(t, b, 1, ""); len(replay) != 0 || !complete {
+        t.Errorf("without Last-Event-ID: replay = %v, complete = %v", noteIDs(replay), complete)
+    }
+}
+
+func TestEventBusReplayEvicted(t *testing.T) {
+    b := service.NewEventBus(2)
+    sub, _, _ := subscribe(t, b, 1, "")
+    for id := int64(1); id <= 4; id++ {
+        b.Publish(core.AuditUpdate, &core.Note{ID: id, OwnerID: 1}, []int64{1})
+    }
+    first := <-sub.C
+
+    // событие 2 уже вытеснено из буфера
+    _, replay, complete := subscribe(t, b, 1, first.ID)
+    if complete {
+        t.Error("complete = true after eviction, want false")
+    }
+    if got := noteIDs(replay); len(got) != 2 || got[0] != 3 || got[1] != 4 {
+        t.Errorf("replay = %v, want [3 4]", got)
+    }
+
+    for _, id := range []string{"other-run-1", "garbage"} {
+        if _, replay, complete := subscribe(t, b, 1, id); complete || len(replay) != 0 {
+            t.Errorf("Last-Event-ID %q: replay = %v, complete = %v", id, noteIDs(replay), complete)
+        }
+    }
+}
+
+func TestEventBusAudience(t *testing.T) {
+    b := service.NewEventBus(10)
+    owner, _, _ := subscribe(t, b, 1, "")
+    shared, _, _ := subscribe(t, b, 2, "")
+    stranger, _, _ := subscribe(t, b, 3, "")
+
+    b.Publish(core.AuditCreate, &core.Note{ID: 1, OwnerID: 1}, []int64{1, 2})
+    b.Publish(core.AuditCreate, &core.Note{ID: 2, OwnerID: 1}, []int64{1})
+
+    e1 := <-owner.C
+    if e1.Note.ID != 1 || e1.Type != core.EventNoteCreated {
+        t.Errorf("owner got %+v", e1)
+    }
+    if e := <-owner.C; e.Note.ID != 2 {
+        t.Errorf("owner got note %d, want 2", e.Note.ID)
+    }
+    if e := <-shared.C; e.Note.ID != 1 {
+        t.Errorf("shared user got note %d, want 1", e.Note.ID)
+    }
+    select {
+    case e := <-shared.C:
+        t.Errorf("shared user got note %d outside the audience", e.Note.ID)
+    default:
+    }
+    select {
+    case e := <-stranger.C:
+        t.Errorf("stranger got note %d", e.Note.ID)
+    default:
+    }
+
+    // replay фильтруется так же
+    if _, replay, _ := subscribe(t, b, 1, e1.ID); len(replay) != 1 || replay[0].Note.ID != 2 {
+        t.Errorf("owner replay = %v, want [2]", noteIDs(replay))
+    }
+    if _, replay, _ := subscribe(t, b, 2, e1.ID); len(replay) != 0 {
+        t.Errorf("shared user replay = %v, want none", noteIDs(replay))
+    }
+}
+
+func TestEventBusDropsSlowSubscriber(t *testing.T) {
+    b := service.NewEventBus(1000)
+    slow, _, _ := subscribe(t, b, 1, "")
+    fast, _, _ := subscribe(t, b, 1, "")
+
+    var last service.NoteEvent
+    for id := int64(1); id <= 100; id++ {
+        b.Publish(core.AuditUpdate, &core.Note{ID: id, OwnerID: 1}, []int64{1})
+        last = <-fast.C
+    }
+    if last.Note.ID != 100 {
+        t.Errorf("fast subscriber got note %d last, want 100", last.Note.ID)
+    }
+
+    // медленный подписчик получает то, что успело попасть в буфер, а
+    // потом канал закрывается; догнать можно по Last-Event-ID
+    var got []service.NoteEvent
+    for e := range slow.C {
+        got = append(got, e)
+    }
+    if len(got) == 0 || len(got) >= 100 {
+        t.Fatalf("slow subscriber got %d events before being dropped", len(got))
+    }
+    _, replay, complete := subscribe(t, b, 1, got[len(got)-1].ID)
+    if !complete || len(got)+len(replay) != 100 {
+        t.Errorf("catch-up replay: %d events, complete = %v; want %d", len(replay), complete, 100-len(got))
+    }
+}
+
+func TestPurgeNotifiesSharedUsers(t *testing.T) {
+    for _, tc := range []struct {
+        name  string
+        purge func(f sharedFixture) error
+    }{
+        {"PurgeNote", func(f sharedFixture) error { return f.svc.PurgeNote(f.alice, f.noteID, 0) }},
+        {"PurgeTrash", func(f sharedFixture) error {
+            _, err := f.svc.PurgeTrash(time.Now().Add(time.Minute))
+            return err
+        }},
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            b := service.NewEventBus(10)
+            f := newSharedFixture(t, service.WithEvents(b))
+            if err := f.svc.DeleteNote(f.alice, f.noteID, 0); err != nil {
+                t.Fatalf("DeleteNote: %v", err)
+            }
+            sub, _, _ := subscribe(t, b, f.userID(f.bob), "")
+            if err := tc.purge(f); err != nil {
+                t.Fatalf("purge: %v", err)
+            }
+            select {
+            case e := <-sub.C:
+                if e.Action != core.AuditPurge || e.Note.ID != f.noteID {
+                    t.Errorf("viewer got %+v, want purge of note %d", e, f.noteID)
+                }
+            default:
+                t.Error("viewer got no purge event")
+            }
+        })
+    }
+}
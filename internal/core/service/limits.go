@@ -0,0 +1,151 @@
+package service
+
+import (
+    "strings"
+    "unicode"
+    "unicode/utf8"
+
+    "golang.org/x/text/unicode/norm"
+)
+
+const (
+    // DefaultMaxTitleLength — максимальная длина заголовка по умолчанию
+    // в символах.
+    DefaultMaxTitleLength = 200
+    // DefaultMaxContentBytes — максимальный размер текста заметки по
+    // умолчанию.
+    DefaultMaxContentBytes = 1 << 20
+)
+
+// TextRule — правила проверки текстового поля заметки. Текст должен
+// быть в UTF-8, управляющие символы запрещены всегда, кроме переводов
+// строк и табуляции в многострочном поле.
+// @Description Правила для текстового поля заметки
+type TextRule struct {
+    // Максимальная длина в символах Unicode (0 — без ограничения)
+    MaxLength int `json:"maxLength" example:"200"`
+    // Максимальный размер в байтах UTF-8 (0 — без ограничения)
+    MaxBytes int `json:"maxBytes" example:"0"`
+    // Разрешены ли переводы строк и табуляция
+    Multiline bool `json:"multiline" example:"false"`
+    // Символы, запрещённые помимо управляющих
+    Forbidden string `json:"forbidden,omitempty" example:"<>"`
+}
+
+// check проверяет значение поля field и возвращает его, приведённое к
+// NFC, если nfc. Нарушения добавляются в v; длина проверяется после
+// нормализации.
+func (r TextRule) check(v *violations, field, value string, nfc bool) string {
+    if !utf8.ValidString(value) {
+        v.add(field, CodeInvalid, "%s must be valid UTF-8", field)
+        return value
+    }
+    if nfc {
+        value = norm.NFC.String(value)
+    }
+    if strings.ContainsFunc(value, r.control) {
+        v.add(field, CodeInvalid, "%s must not contain control characters", field)
+    }
+    if i := strings.IndexAny(value, r.Forbidden); r.Forbidden != "" && i >= 0 {
+        c, _ := utf8.DecodeRuneInString(value[i:])
+        v.add(field, CodeInvalid, "%s must not contain %q", field, c)
+    }
+    if r.MaxLength > 0 && utf8.RuneCountInString(value) > r.MaxLength {
+        v.add(field, CodeTooLong, "%s must be at most %d characters", field, r.MaxLength)
+    }
+    if r.MaxBytes > 0 && len(value) > r.MaxBytes {
+        v.add(field, CodeTooLong, "%s must be at most %d bytes", field, r.MaxBytes)
+    }
+    return value
+}
+
+// control сообщает, запрещён ли управляющий символ c.
+func (r TextRule) control(c rune) bool {
+    if r.Multiline && (c == '\n' || c == '\r' || c == '\t') {
+        return false
+    }
+    return unicode.IsControl(c)
+}
+
+// NoteLimits — правила проверки заголовка и текста заметки. Их
+// применяют CreateNote, UpdateNote и совместное редактирование; все
+// нарушения сообщаются сразу.
+type NoteLimits struct {
+    Title   TextRule
+    Content TextRule
+    // NFC приводит заголовок и текст к форме нормализации Unicode NFC:
+    // «й», набранная как «и» и комбинируемый знак, хранится одним
+    // символом.
+    NFC bool
+}
+
+// DefaultNoteLimits возвращает правила, которые действуют, если
+// WithNoteLimits не задан.
+func DefaultNoteLimits() NoteLimits {
+    return NoteLimits{
+        Title:   TextRule{MaxLength: DefaultMaxTitleLength},
+        Content: TextRule{MaxBytes: DefaultMaxContentBytes, Multiline: true},
+        NFC:     true,
+    }
+}
+
+// WithNoteLimits заменяет правила проверки заголовка и текста заметок.
+func WithNoteLimits(l NoteLimits) Option {
+    return func(s *NoteService) {
+        s.limits = l
+    }
+}
+
+// checkTitle проверяет заголовок без пробелов по краям и возвращает его
+// нормализованным.
+func (l NoteLimits) checkTitle(v *violations, title string) string {
+    title = strings.TrimSpace(title)
+    if title == "" {
+        v.add("title", CodeRequired, "%s is required", "title")
+        return title
+    }
+    return l.Title.check(v, "title", title, l.NFC)
+}
+
+// checkContent проверяет текст заметки и возвращает его нормализованным.
+func (l NoteLimits) checkContent(v *violations, content string) string {
+    return l.Content.check(v, "content", content, l.NFC)
+}
+
+// Limits — ограничения входных данных заметок, которые проверяет сервер.
+// @Description Ограничения заметок, чтобы клиент мог проверить данные до отправки
+type Limits struct {
+    // Правила для заголовка; пробелы по краям отбрасываются
+    Title TextRule `json:"title"`
+    // Правила для текста
+    Content TextRule `json:"content"`
+    // Форма нормализации Unicode, к которой приводятся заголовок и текст; пусто — не приводятся
+    Normalization string `json:"normalization,omitempty" example:"NFC"`
+    // Ограничения тегов
+    Tags TagLimits `json:"tags"`
+    // Квота хранилища пользователя
+    Quota Quota `json:"quota"`
+}
+
+// TagLimits — ограничения тегов заметки.
+// @Description Ограничения тегов заметки
+type TagLimits struct {
+    // Максимальная длина тега в символах
+    MaxLength int `json:"maxLength" example:"32"`
+    // Максимальное число тегов у заметки
+    MaxCount int `json:"maxCount" example:"20"`
+}
+
+// Limits возвращает действующие ограничения заметок.
+func (s *NoteService) Limits() Limits {
+    l := Limits{
+        Title:   s.limits.Title,
+        Content: s.limits.Content,
+        Tags:    TagLimits{MaxLength: MaxTagLength, MaxCount: MaxTagsPerNote},
+        Quota:   s.quota,
+    }
+    if s.limits.NFC {
+        l.Normalization = "NFC"
+    }
+    return l
+}
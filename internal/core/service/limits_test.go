@@ -0,0 +1,127 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "testing"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+// checked — результат TextRule.check: значение и нарушения как
+// "field:code: message".
+func checked(r TextRule, value string, nfc bool) (string, []string) {
+    var v violations
+    out := r.check(&v, "f", value, nfc)
+    got := make([]string, len(v))
+    for i, x := range v {
+        got[i] = x.Field + ":" + x.Code + ": " + x.Message
+    }
+    return out, got
+}
+
+func TestTextRuleCheck(t *testing.T) {
+    tests := []struct {
+        name  string
+        rule  TextRule
+        value string
+        nfc   bool
+        out   string
+        want  []string
+    }{
+        {name: "ok", rule: TextRule{MaxLength: 5}, value: "абвгд", out: "абвгд"},
+        {
+            // «й» из «и» и комбинируемого знака: 2 руны до NFC, 1 после
+            name: "nfc", rule: TextRule{MaxLength: 1}, value: "и\u0306", nfc: true, out: "й",
+        },
+        {
+            name: "without nfc", rule: TextRule{MaxLength: 1}, value: "и\u0306", out: "и\u0306",
+            want: []string{"f:too_long: f must be at most 1 characters"},
+        },
+        {
+            name: "runes, not bytes", rule: TextRule{MaxLength: 3}, value: "ёжик", out: "ёжик",
+            want: []string{"f:too_long: f must be at most 3 characters"},
+        },
+        {
+            name: "bytes, not runes", rule: TextRule{MaxBytes: 4}, value: "ёжи", out: "ёжи",
+            want: []string{"f:too_long: f must be at most 4 bytes"},
+        },
+        {name: "byte limit fits", rule: TextRule{MaxBytes: 6}, value: "ёжи", out: "ёжи"},
+        {
+            name: "control", rule: TextRule{}, value: "a\x00b", out: "a\x00b",
+            want: []string{"f:invalid: f must not contain control characters"},
+        },
+        {
+            name: "newline in single line", rule: TextRule{}, value: "a\nb", out: "a\nb",
+            want: []string{"f:invalid: f must not contain control characters"},
+        },
+        {name: "multiline", rule: TextRule{Multiline: true}, value: "a\r\n\tb", out: "a\r\n\tb"},
+        {
+            name: "control in multiline", rule: TextRule{Multiline: true}, value: "a\n\x1bb", out: "a\n\x1bb",
+            want: []string{"f:invalid: f must not contain control characters"},
+        },
+        {
+            name: "forbidden", rule: TextRule{Forbidden: "<>"}, value: "a>b<c", out: "a>b<c",
+            want: []string{`f:invalid: f must not contain '>'`},
+        },
+        {
+            name: "invalid utf-8", rule: TextRule{MaxLength: 1, Forbidden: "a"}, value: "aa\xff", nfc: true, out: "aa\xff",
+            want: []string{"f:invalid: f must be valid UTF-8"},
+        },
+        {
+            name: "all violations at once", rule: TextRule{MaxLength: 3, MaxBytes: 4, Forbidden: "#"}, value: "#\x07ёжик",
+            out:  "#\x07ёжик",
+            want: []string{
+                "f:invalid: f must not contain control characters",
+                "f:invalid: f must not contain '#'",
+                "f:too_long: f must be at most 3 characters",
+                "f:too_long: f must be at most 4 bytes",
+            },
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            out, got := checked(tt.rule, tt.value, tt.nfc)
+            if out != tt.out {
+                t.Errorf("value = %q, want %q", out, tt.out)
+            }
+            if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+                t.Errorf("violations:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+            }
+        })
+    }
+}
+
+func TestNoteLimitsReportAllFields(t *testing.T) {
+    svc := NewNoteService(repo.NewNoteRepoMem(), WithNoteLimits(NoteLimits{
+        Title:   TextRule{MaxLength: 5, Forbidden: "<>"},
+        Content: TextRule{MaxBytes: 8, Multiline: true},
+        NFC:     true,
+    }))
+    ctx := core.WithPrincipal(context.Background(), core.Principal{UserID: 1})
+
+    _, err := svc.CreateNote(ctx, NoteCreateInput{Title: "<длинный>", Content: "текст\x00"})
+    var verr *ValidationError
+    if !errors.As(err, &verr) {
+        t.Fatalf("CreateNote: err = %v, want ValidationError", err)
+    }
+    var got []string
+    for _, v := range verr.Violations {
+        got = append(got, v.Field+":"+v.Code)
+    }
+    want := "title:invalid title:too_long content:invalid content:too_long"
+    if strings.Join(got, " ") != want {
+        t.Errorf("violations = %v, want %s", got, want)
+    }
+
+    // пробелы по краям заголовка не считаются, текст приводится к NFC
+    n, err := svc.CreateNote(ctx, NoteCreateInput{Title: "  ёжик  ", Content: "и\u0306"})
+    if err != nil || n.Title != "ёжик" || n.Content != "й" {
+        t.Errorf("CreateNote = %+v, %v", n, err)
+    }
+    if _, err := svc.CreateNote(ctx, NoteCreateInput{Title: "   "}); !errors.Is(err, ErrValidation) {
+        t.Errorf("blank title: err = %v, want ErrValidation", err)
+    }
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/base64"
+    "errors"
+    "log"
+    "time"
+
+    "golang.org/x/crypto/bcrypt"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+var (
+    ErrShareLinksUnavailable = errors.New("share links are not configured")
+    // ErrLinkPassword — ссылка защищена паролем, а он не передан или
+    // неверен.
+    ErrLinkPassword = errors.New("share link password is missing or wrong")
+)
+
+// linkPrefixChars — сколько первых символов токена ссылки хранится
+// открыто в core.ShareLink.Prefix.
+const linkPrefixChars = 8
+
+// WithShareLinks включает публичные ссылки на заметки.
+func WithShareLinks(r repo.ShareLinkRepository) Option {
+    return func(s *NoteService) {
+        s.links = r
+    }
+}
+
+// CreateShareLink выпускает публичную ссылку на заметку только для
+// чтения. Токен возвращается только здесь: в хранилище попадает его
+// SHA-256. expiresAt == nil — ссылка действует до отзыва; пустой
+// password — ссылка без пароля. Выпускать ссылки может только владелец.
+func (s *NoteService) CreateShareLink(ctx context.Context, noteID int64, expiresAt *time.Time, password string) (*core.ShareLink, string, error) {
+    if s.links == nil {
+        return nil, "", ErrShareLinksUnavailable
+    }
+    n, err := s.ownedNote(ctx, noteID)
+    if err != nil {
+        return nil, "", err
+    }
+    var v violations
+    now := time.Now().UTC()
+    if expiresAt != nil {
+        if !expiresAt.After(now) {
+            v.add("expiresAt", CodeInPast, "%s must be in the future", "expiresAt")
+        }
+        t := expiresAt.UTC()
+        expiresAt = &t
+    }
+    if len(password) > MaxPasswordLength {
+        v.add("password", CodeTooLong, "%s must be at most %d bytes", "password", MaxPasswordLength)
+    }
+    if err := v.err(); err != nil {
+        return nil, "", err
+    }
+    var passwordHash []byte
+    if password != "" {
+        if passwordHash, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost); err != nil {
+            return nil, "", err
+        }
+    }
+
+    raw := make([]byte, tokenBytes)
+    if _, err := rand.Read(raw); err != nil {
+        return nil, "", err
+    }
+    token := base64.RawURLEncoding.EncodeToString(raw)
+
+    l := core.ShareLink{
+        NoteID:       n.ID,
+        OwnerID:      n.OwnerID,
+        Prefix:       token[:linkPrefixChars],
+        TokenHash:    hashToken(token),
+        PasswordHash: passwordHash,
+        Protected:    passwordHash != nil,
+        CreatedAt:    now,
+        ExpiresAt:    expiresAt,
+    }
+    id, err := s.links.Create(l)
+    if err != nil {
+        return nil, "", err
+    }
+    l.ID = id
+    return &l, token, nil
+}
+
+// ListShareLinks возвращает ссылки на заметку вместе со статистикой
+// открытий.
+func (s *NoteService) ListShareLinks(ctx context.Context, noteID int64) ([]core.ShareLink, error) {
+    if s.links == nil {
+        return nil, ErrShareLinksUnavailable
+    }
+    owner, err := s.authorize(ctx, noteID, accessOwner)
+    if err != nil {
+        return nil, err
+    }
+    return s.links.ListByNote(owner, noteID)
+}
+
+// RevokeShareLink отзывает ссылку; ссылка перестаёт открываться сразу.
+func (s *NoteService) RevokeShareLink(ctx context.Context, noteID, id int64) error {
+    if s.links == nil {
+        return ErrShareLinksUnavailable
+    }
+    owner, err := s.authorize(ctx, noteID, accessOwner)
+    if err != nil {
+        return err
+    }
+    return s.links.Delete(owner, noteID, id)
+}
+
+// OpenShareLink возвращает заметку по токену публичной ссылки; вызывается
+// без аутентификации. Неизвестная, отозванная или истёкшая ссылка, как и
+// ссылка на заметку в корзине, — repo.ErrShareLinkNotFound; неверный
+// или непереданный пароль — ErrLinkPassword. Каждое успешное открытие
+// учитывается в статистике ссылки.
+func (s *NoteService) OpenShareLink(token, password string) (*core.Note, error) {
+    if s.links == nil {
+        return nil, ErrShareLinksUnavailable
+    }
+    l, err := s.links.GetByHash(hashToken(token))
+    if err != nil {
+        return nil, err
+    }
+    now := time.Now()
+    if l.ExpiresAt != nil && !now.Before(*l.ExpiresAt) {
+        return nil, repo.ErrShareLinkNotFound
+    }
+    if l.Protected {
+        if password == "" || bcrypt.CompareHashAndPassword(l.PasswordHash, []byte(password)) != nil {
+            return nil, ErrLinkPassword
+        }
+    }
+    n, err := s.repo.GetByID(l.OwnerID, l.NoteID)
+    if errors.Is(err, repo.ErrNoteNotFound) {
+        return nil, repo.ErrShareLinkNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    if n.DeletedAt != nil {
+        return nil, repo.ErrShareLinkNotFound
+    }
+
+    if err := s.links.RecordAccess(l.ID, now); err != nil {
+        log.Printf("links: record access to link %d: %v", l.ID, err)
+    }
+    return n, nil
+}
+
+// forgetShareLinks удаляет ссылки на заметку, удалённую насовсем.
+func (s *NoteService) forgetShareLinks(id int64) {
+    if s.links == nil {
+        return
+    }
+    if err := s.links.DeleteByNote(id); err != nil {
+        log.Printf("links: delete note %d: %v", id, err)
+    }
+}
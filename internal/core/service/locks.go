@@ -0,0 +1,45 @@
+package service
+
+import "sync"
+
+// ownerLock — мьютекс одного владельца; refs — сколько вызовов держат
+// его или ждут.
+type ownerLock struct {
+    mu   sync.Mutex
+    refs int
+}
+
+// ownerLocks — мьютексы по владельцам: запросы разных владельцев друг
+// друга не ждут. Нулевое значение готово к работе.
+type ownerLocks struct {
+    mu    sync.Mutex
+    locks map[int64]*ownerLock
+}
+
+// lock захватывает мьютекс владельца owner и возвращает функцию,
+// освобождающую его; мьютекс удаляется из карты, когда он больше никому
+// не нужен.
+func (o *ownerLocks) lock(owner int64) func() {
+    o.mu.Lock()
+    if o.locks == nil {
+        o.locks = make(map[int64]*ownerLock)
+    }
+    l := o.locks[owner]
+    if l == nil {
+        l = &ownerLock{}
+        o.locks[owner] = l
+    }
+    l.refs++
+    o.mu.Unlock()
+
+    l.mu.Lock()
+    return func() {
+        l.mu.Unlock()
+        o.mu.Lock()
+        l.refs--
+        if l.refs == 0 {
+            delete(o.locks, owner)
+        }
+        o.mu.Unlock()
+    }
+}
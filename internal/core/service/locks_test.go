@@ -0,0 +1,55 @@
+package service
+
+import (
+    "context"
+    "strconv"
+    "testing"
+    "time"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+// Патч, не трогающий блокнот, не ждёт мьютекса блокнотов владельца;
+// перенос в блокнот ждёт.
+func TestPatchNoteTakesNotebookLockOnlyToMove(t *testing.T) {
+    s := NewNoteService(repo.NewNoteRepoMem(), WithNotebooks(repo.NewNotebookRepoMem()))
+    ctx := core.WithPrincipal(context.Background(), core.Principal{UserID: 1})
+    n, err := s.CreateNote(ctx, NoteCreateInput{Title: "t"})
+    if err != nil {
+        t.Fatalf("CreateNote: %v", err)
+    }
+    nb, err := s.CreateNotebook(ctx, "nb", nil)
+    if err != nil {
+        t.Fatalf("CreateNotebook: %v", err)
+    }
+    patch := func(body string) <-chan error {
+        done := make(chan error, 1)
+        go func() {
+            _, err := s.PatchNote(ctx, n.ID, 0, func(doc []byte) ([]byte, error) { return []byte(body), nil })
+            done <- err
+        }()
+        return done
+    }
+
+    unlock := s.notebookLocks.lock(1)
+    select {
+    case err := <-patch(`{"title": "edited", "content": "", "tags": [], "notebookId": null}`):
+        if err != nil {
+            t.Fatalf("title patch: %v", err)
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("title patch waits for the notebook lock")
+    }
+
+    move := patch(`{"title": "edited", "content": "", "tags": [], "notebookId": ` + strconv.FormatInt(nb.ID, 10) + `}`)
+    select {
+    case err := <-move:
+        t.Fatalf("move finished while the notebook lock was held: %v", err)
+    case <-time.After(50 * time.Millisecond):
+    }
+    unlock()
+    if err := <-move; err != nil {
+        t.Fatalf("move: %v", err)
+    }
+}
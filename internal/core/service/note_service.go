@@ -1,71 +1,336 @@
 package service
 
 import (
+    "context"
     "errors"
-    "strings"
+    "time"
 
     "example.com/notes-api/internal/core"
     "example.com/notes-api/internal/repo"
+    "example.com/notes-api/internal/search"
 )
 
 var (
-    ErrValidation = errors.New("validation error")
+    ErrValidation        = errors.New("validation error")
+    ErrSearchUnavailable = errors.New("search is not configured")
+    ErrNotInTrash        = errors.New("note is not in trash")
+    ErrUnauthenticated   = errors.New("unauthenticated")
 )
 
+// ownerFrom возвращает ID пользователя, от имени которого выполняется
+// запрос. Пользовательские операции видят только его заметки и те,
+// которыми с ним поделились (см. authorize).
+func ownerFrom(ctx context.Context) (int64, error) {
+    p, ok := core.PrincipalFrom(ctx)
+    if !ok || p.UserID <= 0 {
+        return 0, ErrUnauthenticated
+    }
+    return p.UserID, nil
+}
+
 type NoteService struct {
-    repo repo.NoteRepository
+    repo      repo.NoteRepository
+    index     search.Index
+    revisions repo.RevisionRepository
+    retention repo.RevisionRetention
+    notebooks repo.NotebookRepository
+    shares    repo.ShareRepository
+    users     repo.UserRepository
+    links     repo.ShareLinkRepository
+    quota     Quota
+    limits    NoteLimits
+    auditLog  repo.AuditLog
+    webhooks  *WebhookService
+    events    *EventBus
+
+    // notebookLocks сериализуют изменения дерева блокнотов владельца и
+    // ссылок на его блокноты, чтобы проверки «родитель существует» и
+    // «нет цикла» не устаревали до записи.
+    notebookLocks ownerLocks
+    // quotaLocks сериализуют проверку квоты и запись для каждого
+    // владельца (см. lockQuota). Если нужны оба, quotaLocks
+    // захватываются первыми.
+    quotaLocks ownerLocks
+}
+
+// Option — необязательная зависимость NoteService.
+type Option func(*NoteService)
+
+// WithSearchIndex подключает полнотекстовый индекс; сервис обновляет его
+// при каждом создании, изменении и удалении заметки.
+func WithSearchIndex(idx search.Index) Option {
+    return func(s *NoteService) {
+        s.index = idx
+    }
+}
+
+func NewNoteService(r repo.NoteRepository, opts ...Option) *NoteService {
+    s := &NoteService{repo: r, limits: DefaultNoteLimits()}
+    for _, opt := range opts {
+        opt(s)
+    }
+    return s
 }
 
-func NewNoteService(r repo.NoteRepository) *NoteService {
-    return &NoteService{repo: r}
+type NoteCreateInput struct {
+    Title      string   `json:"title"`
+    Content    string   `json:"content"`
+    Tags       []string `json:"tags"`
+    NotebookID *int64   `json:"notebookId"`
 }
 
-func (s *NoteService) CreateNote(title, content string) (*core.Note, error) {
-    title = strings.TrimSpace(title)
-    if title == "" {
-        return nil, ErrValidation
+func (s *NoteService) CreateNote(ctx context.Context, input NoteCreateInput) (*core.Note, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    n, err := s.checkCreate(owner, input)
+    if err != nil {
+        return nil, err
+    }
+    usage, unlock, err := s.lockQuota(owner)
+    if err != nil {
+        return nil, err
+    }
+    defer unlock()
+    if !s.quota.allows(usage, 1, repo.NoteSize(n.Title, n.Content)) {
+        return nil, ErrQuotaExceeded
     }
 
-    n := core.Note{
-        Title:   title,
-        Content: content,
+    id, err := s.createNote(n)
+    if err != nil {
+        return nil, err
     }
-    id, err := s.repo.Create(n)
+    created, err := s.repo.GetByID(owner, id)
     if err != nil {
         return nil, err
     }
-    return s.repo.GetByID(id)
+    s.reindex(created)
+    s.recordRevision(created)
+    s.noteChanged(ctx, core.AuditCreate, nil, created)
+    return created, nil
 }
 
-func (s *NoteService) ListNotes() ([]core.Note, error) {
-    return s.repo.GetAll()
+// checkCreate проверяет поля новой заметки и возвращает её, ещё не
+// сохранённую.
+func (s *NoteService) checkCreate(owner int64, input NoteCreateInput) (core.Note, error) {
+    var v violations
+    title := s.limits.checkTitle(&v, input.Title)
+    content := s.limits.checkContent(&v, input.Content)
+    tags, err := NormalizeTags(input.Tags)
+    if err := v.collect(err); err != nil {
+        return core.Note{}, err
+    }
+    if err := v.err(); err != nil {
+        return core.Note{}, err
+    }
+    return core.Note{
+        OwnerID:    owner,
+        Title:      title,
+        Content:    content,
+        Tags:       tags,
+        NotebookID: input.NotebookID,
+    }, nil
+}
+
+// createNote сохраняет заметку, проверив, что её блокнот принадлежит
+// тому же владельцу.
+func (s *NoteService) createNote(n core.Note) (int64, error) {
+    if n.NotebookID == nil {
+        return s.repo.Create(n)
+    }
+    defer s.notebookLocks.lock(n.OwnerID)()
+
+    if err := s.checkNotebook(n.OwnerID, n.NotebookID); err != nil {
+        return 0, err
+    }
+    return s.repo.Create(n)
+}
+
+const (
+    // DefaultPageSize — размер страницы списка, если клиент его не указал.
+    DefaultPageSize = 50
+    // MaxPageSize — максимальный размер страницы списка.
+    MaxPageSize = 500
+)
+
+// ListNotes возвращает страницу заметок. Limit приводится к диапазону
+// [1, MaxPageSize]; q.Trashed выбирает заметки в корзине, теги фильтра
+// нормализуются так же, как теги заметок. Владелец берётся из ctx,
+// q.OwnerID игнорируется.
+func (s *NoteService) ListNotes(ctx context.Context, q repo.NoteQuery) (repo.NotePage, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return repo.NotePage{}, err
+    }
+    q.OwnerID = owner
+    if len(q.Tags) > 0 {
+        tags, err := normalizeTags("tag", q.Tags) // ?tag=...&tag=...
+        if err != nil {
+            return repo.NotePage{}, err
+        }
+        q.Tags = tags
+    }
+    if q.Limit <= 0 {
+        q.Limit = DefaultPageSize
+    }
+    if q.Limit > MaxPageSize {
+        q.Limit = MaxPageSize
+    }
+    return s.repo.Find(q)
 }
 
-func (s *NoteService) GetNote(id int64) (*core.Note, error) {
-    return s.repo.GetByID(id)
+// GetNote возвращает заметку — свою или открытую вызывающему; заметка
+// в корзине считается ненайденной.
+func (s *NoteService) GetNote(ctx context.Context, id int64) (*core.Note, error) {
+    owner, err := s.authorize(ctx, id, accessRead)
+    if err != nil {
+        return nil, err
+    }
+    n, err := s.repo.GetByID(owner, id)
+    if err != nil {
+        return nil, err
+    }
+    if n.DeletedAt != nil {
+        return nil, repo.ErrNoteNotFound
+    }
+    return n, nil
 }
 
 type NoteUpdateInput struct {
-    Title   *string `json:"title"`
-    Content *string `json:"content"`
-}
-
-func (s *NoteService) UpdateNote(id int64, input NoteUpdateInput) (*core.Note, error) {
-    return s.repo.Update(id, func(n *core.Note) error {
-        if input.Title != nil {
-            title := strings.TrimSpace(*input.Title)
-            if title == "" {
-                return ErrValidation
-            }
-            n.Title = title
+    Title   *string   `json:"title"`
+    Content *string   `json:"content"`
+    Tags    *[]string `json:"tags"` // заменяет весь набор тегов
+}
+
+// UpdateNote частично обновляет заметку. Если version != 0, изменение
+// применяется только к этой версии заметки (иначе repo.ErrVersionConflict).
+// Чужую заметку может изменить пользователь с ролью editor; квота
+// считается по владельцу заметки.
+func (s *NoteService) UpdateNote(ctx context.Context, id int64, version int64, input NoteUpdateInput) (*core.Note, error) {
+    owner, err := s.authorize(ctx, id, accessWrite)
+    if err != nil {
+        return nil, err
+    }
+    edit, err := s.checkUpdate(input)
+    if err != nil {
+        return nil, err
+    }
+    usage, unlock, err := s.lockQuota(owner)
+    if err != nil {
+        return nil, err
+    }
+    defer unlock()
+    var before *core.Note
+    updated, err := s.repo.Update(owner, id, version, s.editFn(edit, usage, &before))
+    if err != nil {
+        return nil, err
+    }
+    s.reindex(updated)
+    s.recordRevision(updated)
+    s.noteChanged(ctx, core.AuditUpdate, before, updated)
+    return updated, nil
+}
+
+// noteEdit — проверенные поля NoteUpdateInput; nil — поле не меняется.
+type noteEdit struct {
+    title, content *string
+    tags           *[]string
+}
+
+// checkUpdate проверяет поля, которые меняет input.
+func (s *NoteService) checkUpdate(input NoteUpdateInput) (noteEdit, error) {
+    var v violations
+    var e noteEdit
+    if input.Title != nil {
+        title := s.limits.checkTitle(&v, *input.Title)
+        e.title = &title
+    }
+    if input.Content != nil {
+        content := s.limits.checkContent(&v, *input.Content)
+        e.content = &content
+    }
+    if input.Tags != nil {
+        tags, err := NormalizeTags(*input.Tags)
+        if err := v.collect(err); err != nil {
+            return noteEdit{}, err
         }
-        if input.Content != nil {
-            n.Content = *input.Content
+        e.tags = &tags
+    }
+    return e, v.err()
+}
+
+// editFn возвращает updateFn для repo.Update: он применяет edit к
+// заметке вне корзины, если изменение размера помещается в квоту при
+// объёме usage, и сохраняет в *before заметку до изменения.
+func (s *NoteService) editFn(edit noteEdit, usage repo.NoteUsage, before **core.Note) func(*core.Note) error {
+    return func(n *core.Note) error {
+        if n.DeletedAt != nil {
+            return repo.ErrNoteNotFound
+        }
+        *before = snapshotNote(n)
+        size := repo.NoteSize(n.Title, n.Content)
+        if edit.title != nil {
+            n.Title = *edit.title
+        }
+        if edit.content != nil {
+            n.Content = *edit.content
+        }
+        if edit.tags != nil {
+            n.Tags = *edit.tags
+        }
+        if !s.quota.allows(usage, 0, repo.NoteSize(n.Title, n.Content)-size) {
+            return ErrQuotaExceeded
+        }
+        return nil
+    }
+}
+
+// DeleteNote перемещает заметку в корзину; version != 0 — как в
+// UpdateNote. Заметка пропадает из списков и поиска, но её можно
+// восстановить через RestoreNote, пока её не удалили насовсем. Удалить
+// заметку может только владелец.
+func (s *NoteService) DeleteNote(ctx context.Context, id int64, version int64) error {
+    owner, err := s.authorize(ctx, id, accessOwner)
+    if err != nil {
+        return err
+    }
+    var before *core.Note
+    deleted, err := s.repo.Update(owner, id, version, trashFn(&before))
+    if err != nil {
+        return err
+    }
+    s.unindex(id)
+    s.noteChanged(ctx, core.AuditDelete, before, deleted)
+    return nil
+}
+
+// trashFn возвращает updateFn для repo.Update, перемещающий заметку в
+// корзину; в *before сохраняется заметка до изменения.
+func trashFn(before **core.Note) func(*core.Note) error {
+    return func(n *core.Note) error {
+        if n.DeletedAt != nil {
+            return repo.ErrNoteNotFound
         }
+        *before = snapshotNote(n)
+        now := time.Now().UTC()
+        n.DeletedAt = &now
         return nil
-    })
+    }
 }
 
-func (s *NoteService) DeleteNote(id int64) error {
-    return s.repo.Delete(id)
+// noteChanged сообщает об изменении заметки before → after журналу
+// аудита, веб-хукам владельца и в поток событий (см. audit).
+func (s *NoteService) noteChanged(ctx context.Context, action core.AuditAction, before, after *core.Note) {
+    s.audit(ctx, action, before, after)
+    n := after
+    if n == nil {
+        n = before
+    }
+    if s.webhooks != nil {
+        s.webhooks.Publish(action, n)
+    }
+    if s.events != nil {
+        s.publishEvent(action, n)
+    }
 }
@@ -0,0 +1,383 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "slices"
+    "strings"
+    "time"
+    "unicode/utf8"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+var (
+    ErrNotebooksUnavailable = errors.New("notebooks are not configured")
+    ErrNotebookCycle        = errors.New("notebook cannot be moved into itself or its descendant")
+    ErrNotebookNotEmpty     = errors.New("notebook is not empty")
+)
+
+// MaxNotebookNameLength — максимальная длина названия блокнота в символах.
+const MaxNotebookNameLength = 100
+
+// NotebookDeletePolicy — что делать с содержимым удаляемого блокнота.
+type NotebookDeletePolicy string
+
+const (
+    // DeleteIfEmpty удаляет только блокнот без вложенных блокнотов и
+    // заметок (заметки в корзине не мешают); иначе ErrNotebookNotEmpty.
+    DeleteIfEmpty NotebookDeletePolicy = "reject"
+    // DeleteCascade удаляет все вложенные блокноты, а их заметки
+    // перемещает в корзину.
+    DeleteCascade NotebookDeletePolicy = "cascade"
+    // DeleteMoveToRoot переносит заметки и вложенные блокноты в корень.
+    DeleteMoveToRoot NotebookDeletePolicy = "root"
+)
+
+// Valid сообщает, поддерживается ли политика.
+func (p NotebookDeletePolicy) Valid() bool {
+    switch p {
+    case DeleteIfEmpty, DeleteCascade, DeleteMoveToRoot:
+        return true
+    }
+    return false
+}
+
+// WithNotebooks включает блокноты.
+func WithNotebooks(r repo.NotebookRepository) Option {
+    return func(s *NoteService) {
+        s.notebooks = r
+    }
+}
+
+func normalizeNotebookName(name string) (string, error) {
+    name = strings.TrimSpace(name)
+    switch {
+    case name == "":
+        return "", invalid("name", CodeRequired, "%s is required", "name")
+    case utf8.RuneCountInString(name) > MaxNotebookNameLength:
+        return "", invalid("name", CodeTooLong, "%s must be at most %d characters", "name", MaxNotebookNameLength)
+    }
+    return name, nil
+}
+
+// checkNotebook проверяет, что блокнот владельца существует; nil — корень.
+// Вызывается под notebookLocks владельца.
+func (s *NoteService) checkNotebook(owner int64, id *int64) error {
+    if id == nil {
+        return nil
+    }
+    if s.notebooks == nil {
+        return ErrNotebooksUnavailable
+    }
+    _, err := s.notebooks.GetByID(owner, *id)
+    return err
+}
+
+func (s *NoteService) CreateNotebook(ctx context.Context, name string, parentID *int64) (*core.Notebook, error) {
+    if s.notebooks == nil {
+        return nil, ErrNotebooksUnavailable
+    }
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    name, err = normalizeNotebookName(name)
+    if err != nil {
+        return nil, err
+    }
+
+    defer s.notebookLocks.lock(owner)()
+
+    if err := s.checkNotebook(owner, parentID); err != nil {
+        return nil, err
+    }
+    id, err := s.notebooks.Create(core.Notebook{OwnerID: owner, Name: name, ParentID: parentID})
+    if err != nil {
+        return nil, err
+    }
+    return s.notebooks.GetByID(owner, id)
+}
+
+func (s *NoteService) GetNotebook(ctx context.Context, id int64) (*core.Notebook, error) {
+    if s.notebooks == nil {
+        return nil, ErrNotebooksUnavailable
+    }
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return s.notebooks.GetByID(owner, id)
+}
+
+// ListNotebooks возвращает все блокноты вызывающего плоским списком по
+// возрастанию ID; дерево восстанавливается по ParentID.
+func (s *NoteService) ListNotebooks(ctx context.Context) ([]core.Notebook, error) {
+    if s.notebooks == nil {
+        return nil, ErrNotebooksUnavailable
+    }
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return s.notebooks.List(owner)
+}
+
+func (s *NoteService) RenameNotebook(ctx context.Context, id int64, name string) (*core.Notebook, error) {
+    if s.notebooks == nil {
+        return nil, ErrNotebooksUnavailable
+    }
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    name, err = normalizeNotebookName(name)
+    if err != nil {
+        return nil, err
+    }
+    return s.notebooks.Update(owner, id, func(nb *core.Notebook) error {
+        nb.Name = name
+        return nil
+    })
+}
+
+// MoveNotebook делает parentID (nil — корень) родителем блокнота id.
+// Перенос в самого себя или в своего потомка — ErrNotebookCycle.
+func (s *NoteService) MoveNotebook(ctx context.Context, id int64, parentID *int64) (*core.Notebook, error) {
+    if s.notebooks == nil {
+        return nil, ErrNotebooksUnavailable
+    }
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    defer s.notebookLocks.lock(owner)()
+
+    if _, err := s.notebooks.GetByID(owner, id); err != nil {
+        return nil, err
+    }
+    if parentID != nil {
+        all, err := s.notebooks.List(owner)
+        if err != nil {
+            return nil, err
+        }
+        parents := make(map[int64]*int64, len(all))
+        for _, nb := range all {
+            parents[nb.ID] = nb.ParentID
+        }
+        if _, ok := parents[*parentID]; !ok {
+            return nil, repo.ErrNotebookNotFound
+        }
+        // поднимаемся от нового родителя к корню; дерево без циклов,
+        // поэтому путь конечен
+        for cur := parentID; cur != nil; cur = parents[*cur] {
+            if *cur == id {
+                return nil, ErrNotebookCycle
+            }
+        }
+    }
+    return s.notebooks.Update(owner, id, func(nb *core.Notebook) error {
+        nb.ParentID = parentID
+        return nil
+    })
+}
+
+// subtree возвращает id и ID всех вложенных в него блокнотов; потомки
+// идут после предков.
+func (s *NoteService) subtree(owner, id int64) ([]int64, error) {
+    all, err := s.notebooks.List(owner)
+    if err != nil {
+        return nil, err
+    }
+    children := make(map[int64][]int64)
+    for _, nb := range all {
+        if nb.ParentID != nil {
+            children[*nb.ParentID] = append(children[*nb.ParentID], nb.ID)
+        }
+    }
+    ids := []int64{id}
+    for i := 0; i < len(ids); i++ {
+        ids = append(ids, children[ids[i]]...)
+    }
+    return ids, nil
+}
+
+// ListNotebookNotes возвращает страницу заметок блокнота, а при recursive —
+// и всех вложенных блокнотов. Остальные параметры q — как в ListNotes.
+func (s *NoteService) ListNotebookNotes(ctx context.Context, id int64, recursive bool, q repo.NoteQuery) (repo.NotePage, error) {
+    if s.notebooks == nil {
+        return repo.NotePage{}, ErrNotebooksUnavailable
+    }
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return repo.NotePage{}, err
+    }
+    if _, err := s.notebooks.GetByID(owner, id); err != nil {
+        return repo.NotePage{}, err
+    }
+    q.NotebookIDs = []int64{id}
+    if recursive {
+        ids, err := s.subtree(owner, id)
+        if err != nil {
+            return repo.NotePage{}, err
+        }
+        q.NotebookIDs = ids
+    }
+    return s.ListNotes(ctx, q)
+}
+
+// MoveNote переносит заметку в блокнот notebookID (nil — в корень);
+// version != 0 — как в UpdateNote.
+func (s *NoteService) MoveNote(ctx context.Context, id int64, version int64, notebookID *int64) (*core.Note, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    defer s.notebookLocks.lock(owner)()
+
+    if err := s.checkNotebook(owner, notebookID); err != nil {
+        return nil, err
+    }
+    var before *core.Note
+    moved, err := s.repo.Update(owner, id, version, func(n *core.Note) error {
+        if n.DeletedAt != nil {
+            return repo.ErrNoteNotFound
+        }
+        before = snapshotNote(n)
+        n.NotebookID = notebookID
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    s.noteChanged(ctx, core.AuditUpdate, before, moved)
+    return moved, nil
+}
+
+// DeleteNotebook удаляет блокнот по политике policy. Заметки в корзине из
+// удаляемых блокнотов при любой политике переносятся в корень, чтобы
+// после восстановления не ссылаться на несуществующий блокнот.
+func (s *NoteService) DeleteNotebook(ctx context.Context, id int64, policy NotebookDeletePolicy) error {
+    if s.notebooks == nil {
+        return ErrNotebooksUnavailable
+    }
+    if !policy.Valid() {
+        return invalid("policy", CodeUnsupported, "%s must be one of %s", "policy", "reject, cascade, root")
+    }
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return err
+    }
+
+    defer s.notebookLocks.lock(owner)()
+
+    if _, err := s.notebooks.GetByID(owner, id); err != nil {
+        return err
+    }
+    ids, err := s.subtree(owner, id)
+    if err != nil {
+        return err
+    }
+
+    switch policy {
+    case DeleteIfEmpty:
+        if len(ids) > 1 {
+            return ErrNotebookNotEmpty
+        }
+        page, err := s.repo.Find(repo.NoteQuery{OwnerID: owner, Limit: 1, NotebookIDs: ids})
+        if err != nil {
+            return err
+        }
+        if len(page.Notes) > 0 {
+            return ErrNotebookNotEmpty
+        }
+    case DeleteCascade:
+        now := time.Now().UTC()
+        err := s.updateNotebookNotes(ctx, owner, ids, false, func(n *core.Note) {
+            n.NotebookID = nil
+            n.DeletedAt = &now
+        })
+        if err != nil {
+            return err
+        }
+    case DeleteMoveToRoot:
+        ids = ids[:1]
+        err := s.updateNotebookNotes(ctx, owner, ids, false, func(n *core.Note) { n.NotebookID = nil })
+        if err != nil {
+            return err
+        }
+        all, err := s.notebooks.List(owner)
+        if err != nil {
+            return err
+        }
+        for _, nb := range all {
+            if nb.ParentID == nil || *nb.ParentID != id {
+                continue
+            }
+            if _, err := s.notebooks.Update(owner, nb.ID, func(nb *core.Notebook) error {
+                nb.ParentID = nil
+                return nil
+            }); err != nil {
+                return err
+            }
+        }
+    }
+
+    if err := s.updateNotebookNotes(ctx, owner, ids, true, func(n *core.Note) { n.NotebookID = nil }); err != nil {
+        return err
+    }
+    // потомки удаляются раньше предков
+    for i := len(ids) - 1; i >= 0; i-- {
+        if err := s.notebooks.Delete(owner, ids[i]); err != nil && !errors.Is(err, repo.ErrNotebookNotFound) {
+            return err
+        }
+    }
+    return nil
+}
+
+// errNoteMoved — заметка покинула блокнот между выборкой и изменением.
+var errNoteMoved = errors.New("note moved")
+
+// updateNotebookNotes применяет change ко всем заметкам из блокнотов ids
+// (в корзине или вне её). Заметки, ушедшие в корзину, убираются из
+// поискового индекса.
+func (s *NoteService) updateNotebookNotes(ctx context.Context, owner int64, ids []int64, trashed bool, change func(n *core.Note)) error {
+    q := repo.NoteQuery{OwnerID: owner, Limit: MaxPageSize, NotebookIDs: ids, Trashed: trashed}
+    for {
+        page, err := s.repo.Find(q)
+        if err != nil {
+            return err
+        }
+        for _, n := range page.Notes {
+            var before *core.Note
+            updated, err := s.repo.Update(owner, n.ID, 0, func(n *core.Note) error {
+                // заметку могли перенести или удалить после выборки
+                if n.NotebookID == nil || !slices.Contains(ids, *n.NotebookID) || (n.DeletedAt != nil) != trashed {
+                    return errNoteMoved
+                }
+                before = snapshotNote(n)
+                change(n)
+                return nil
+            })
+            if errors.Is(err, errNoteMoved) || errors.Is(err, repo.ErrNoteNotFound) {
+                continue
+            }
+            if err != nil {
+                return err
+            }
+            action := core.AuditUpdate
+            if !trashed && updated.DeletedAt != nil {
+                s.unindex(updated.ID)
+                action = core.AuditDelete
+            }
+            s.noteChanged(ctx, action, before, updated)
+        }
+        if page.Next == nil {
+            return nil
+        }
+        q.After = page.Next
+    }
+}
@@ -0,0 +1,205 @@
+package service_test
+
+import (
+    "errors"
+    "testing"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/core/service"
+    "example.com/notes-api/internal/repo"
+)
+
+// notebookTree — блокноты alice «Работа» → «Проекты» → «Q1» и отдельный
+// «Дом» с заметками; одна заметка из «Работы» лежит в корзине.
+type notebookTree struct {
+    sharedFixture
+    work, projects, q1, home                int64
+    inWork, trashedInWork, inProjects, inQ1 int64
+}
+
+func newNotebookTree(t *testing.T) notebookTree {
+    t.Helper()
+    f, work := newNotebookFixture(t)
+    tr := notebookTree{sharedFixture: f, work: work}
+    notebook := func(name string, parent *int64) int64 {
+        nb, err := f.svc.CreateNotebook(f.alice, name, parent)
+        if err != nil {
+            t.Fatalf("CreateNotebook %s: %v", name, err)
+        }
+        return nb.ID
+    }
+    note := func(nb int64) int64 {
+        n, err := f.svc.CreateNote(f.alice, service.NoteCreateInput{Title: "Заметка", NotebookID: &nb})
+        if err != nil {
+            t.Fatalf("CreateNote: %v", err)
+        }
+        return n.ID
+    }
+    tr.projects = notebook("Проекты", &tr.work)
+    tr.q1 = notebook("Q1", &tr.projects)
+    tr.home = notebook("Дом", nil)
+    tr.inWork, tr.trashedInWork, tr.inProjects, tr.inQ1 = note(tr.work), note(tr.work), note(tr.projects), note(tr.q1)
+    if err := f.svc.DeleteNote(f.alice, tr.trashedInWork, 0); err != nil {
+        t.Fatalf("DeleteNote: %v", err)
+    }
+    return tr
+}
+
+// wantParent проверяет родителя блокнота; nil — корень.
+func (tr notebookTree) wantParent(t *testing.T, id int64, parent *int64) {
+    t.Helper()
+    nb, err := tr.svc.GetNotebook(tr.alice, id)
+    if err != nil || (nb.ParentID == nil) != (parent == nil) || (parent != nil && *nb.ParentID != *parent) {
+        t.Errorf("notebook %d: %+v, %v; want parent %v", id, nb, err, parent)
+    }
+}
+
+// wantNote проверяет блокнот заметки (nil — корень) и лежит ли она
+// в корзине.
+func (tr notebookTree) wantNote(t *testing.T, id int64, notebook *int64, trashed bool) {
+    t.Helper()
+    n, err := tr.notes.GetByID(tr.userID(tr.alice), id)
+    if err != nil {
+        t.Fatalf("note %d: %v", id, err)
+    }
+    if (n.NotebookID == nil) != (notebook == nil) || (notebook != nil && *n.NotebookID != *notebook) || (n.DeletedAt != nil) != trashed {
+        t.Errorf("note %d: %+v; want notebook %v, trashed %v", id, n, notebook, trashed)
+    }
+}
+
+// wantGone проверяет, что блокнотов больше нет.
+func (tr notebookTree) wantGone(t *testing.T, ids ...int64) {
+    t.Helper()
+    for _, id := range ids {
+        if _, err := tr.svc.GetNotebook(tr.alice, id); !errors.Is(err, repo.ErrNotebookNotFound) {
+            t.Errorf("notebook %d: err = %v, want ErrNotebookNotFound", id, err)
+        }
+    }
+}
+
+func TestMoveNotebookCycle(t *testing.T) {
+    tr := newNotebookTree(t)
+
+    for name, parent := range map[string]int64{"itself": tr.work, "child": tr.projects, "grandchild": tr.q1} {
+        if _, err := tr.svc.MoveNotebook(tr.alice, tr.work, &parent); !errors.Is(err, service.ErrNotebookCycle) {
+            t.Errorf("into %s: err = %v, want ErrNotebookCycle", name, err)
+        }
+    }
+    unknown := int64(999)
+    if _, err := tr.svc.MoveNotebook(tr.alice, tr.work, &unknown); !errors.Is(err, repo.ErrNotebookNotFound) {
+        t.Errorf("into unknown: err = %v, want ErrNotebookNotFound", err)
+    }
+    // чужие блокноты не видны ни как источник, ни как родитель
+    other, err := tr.svc.CreateNotebook(tr.dave, "Чужой", nil)
+    if err != nil {
+        t.Fatalf("CreateNotebook: %v", err)
+    }
+    if _, err := tr.svc.MoveNotebook(tr.alice, tr.work, &other.ID); !errors.Is(err, repo.ErrNotebookNotFound) {
+        t.Errorf("into someone else's: err = %v, want ErrNotebookNotFound", err)
+    }
+    if _, err := tr.svc.MoveNotebook(tr.dave, tr.q1, &other.ID); !errors.Is(err, repo.ErrNotebookNotFound) {
+        t.Errorf("someone else moves: err = %v, want ErrNotebookNotFound", err)
+    }
+    tr.wantParent(t, tr.work, nil)
+    tr.wantParent(t, tr.projects, &tr.work)
+    tr.wantParent(t, tr.q1, &tr.projects)
+
+    // вынесенный в корень потомок перестаёт быть потомком
+    if _, err := tr.svc.MoveNotebook(tr.alice, tr.q1, nil); err != nil {
+        t.Fatalf("MoveNotebook to root: %v", err)
+    }
+    if _, err := tr.svc.MoveNotebook(tr.alice, tr.work, &tr.q1); err != nil {
+        t.Fatalf("MoveNotebook: %v", err)
+    }
+    tr.wantParent(t, tr.work, &tr.q1)
+    tr.wantParent(t, tr.q1, nil)
+    if _, err := tr.svc.MoveNotebook(tr.alice, tr.q1, &tr.projects); !errors.Is(err, service.ErrNotebookCycle) {
+        t.Errorf("into new descendant: err = %v, want ErrNotebookCycle", err)
+    }
+}
+
+func TestDeleteNotebookReject(t *testing.T) {
+    tr := newNotebookTree(t)
+
+    for name, id := range map[string]int64{"with children": tr.work, "with notes": tr.q1} {
+        if err := tr.svc.DeleteNotebook(tr.alice, id, service.DeleteIfEmpty); !errors.Is(err, service.ErrNotebookNotEmpty) {
+            t.Errorf("%s: err = %v, want ErrNotebookNotEmpty", name, err)
+        }
+    }
+    tr.wantParent(t, tr.q1, &tr.projects)
+    tr.wantNote(t, tr.inQ1, &tr.q1, false)
+
+    if _, err := tr.svc.MoveNote(tr.alice, tr.inQ1, 0, nil); err != nil {
+        t.Fatalf("MoveNote: %v", err)
+    }
+    if err := tr.svc.DeleteNotebook(tr.alice, tr.q1, service.DeleteIfEmpty); err != nil {
+        t.Fatalf("DeleteNotebook q1: %v", err)
+    }
+    tr.wantGone(t, tr.q1)
+
+    // заметки в корзине не мешают удалению и уходят в корень
+    if err := tr.svc.DeleteNote(tr.alice, tr.inProjects, 0); err != nil {
+        t.Fatalf("DeleteNote: %v", err)
+    }
+    if err := tr.svc.DeleteNotebook(tr.alice, tr.projects, service.DeleteIfEmpty); err != nil {
+        t.Fatalf("DeleteNotebook projects: %v", err)
+    }
+    tr.wantNote(t, tr.inProjects, nil, true)
+    if n, err := tr.svc.RestoreNote(tr.alice, tr.inProjects, 0); err != nil || n.NotebookID != nil {
+        t.Errorf("RestoreNote = %+v, %v", n, err)
+    }
+    tr.wantParent(t, tr.work, nil)
+}
+
+func TestDeleteNotebookCascade(t *testing.T) {
+    tr := newNotebookTree(t)
+    if err := tr.svc.DeleteNotebook(tr.alice, tr.work, service.DeleteCascade); err != nil {
+        t.Fatalf("DeleteNotebook: %v", err)
+    }
+    tr.wantGone(t, tr.work, tr.projects, tr.q1)
+    tr.wantParent(t, tr.home, nil)
+
+    // заметки всего поддерева уходят в корзину без блокнота
+    for _, id := range []int64{tr.inWork, tr.trashedInWork, tr.inProjects, tr.inQ1} {
+        tr.wantNote(t, id, nil, true)
+    }
+    tr.wantNote(t, tr.noteID, nil, false)
+    if page, err := tr.svc.ListNotes(tr.alice, repo.NoteQuery{}); err != nil || len(page.Notes) != 1 {
+        t.Errorf("ListNotes = %+v, %v; want the note outside notebooks only", page, err)
+    }
+    if n, err := tr.svc.RestoreNote(tr.alice, tr.inQ1, 0); err != nil || n.NotebookID != nil {
+        t.Errorf("RestoreNote = %+v, %v", n, err)
+    }
+}
+
+func TestDeleteNotebookMoveToRoot(t *testing.T) {
+    tr := newNotebookTree(t)
+    if err := tr.svc.DeleteNotebook(tr.alice, tr.work, service.DeleteMoveToRoot); err != nil {
+        t.Fatalf("DeleteNotebook: %v", err)
+    }
+    tr.wantGone(t, tr.work)
+
+    // дочерний блокнот переходит в корень вместе со своим поддеревом
+    tr.wantParent(t, tr.projects, nil)
+    tr.wantParent(t, tr.q1, &tr.projects)
+    tr.wantNote(t, tr.inWork, nil, false)
+    tr.wantNote(t, tr.trashedInWork, nil, true)
+    tr.wantNote(t, tr.inProjects, &tr.projects, false)
+    tr.wantNote(t, tr.inQ1, &tr.q1, false)
+}
+
+func TestDeleteNotebookChecks(t *testing.T) {
+    tr := newNotebookTree(t)
+    if err := tr.svc.DeleteNotebook(tr.alice, tr.work, "all"); !errors.Is(err, service.ErrValidation) {
+        t.Errorf("unknown policy: err = %v, want ErrValidation", err)
+    }
+    // доступ к заметке не даёт доступа к блокноту владельца
+    if _, err := tr.svc.ShareNote(tr.alice, tr.inWork, "dave", core.RoleEditor); err != nil {
+        t.Fatalf("ShareNote: %v", err)
+    }
+    if err := tr.svc.DeleteNotebook(tr.dave, tr.work, service.DeleteCascade); !errors.Is(err, repo.ErrNotebookNotFound) {
+        t.Errorf("someone else deletes: err = %v, want ErrNotebookNotFound", err)
+    }
+    tr.wantParent(t, tr.work, nil)
+    tr.wantNote(t, tr.inWork, &tr.work, false)
+}
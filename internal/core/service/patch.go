@@ -0,0 +1,209 @@
+package service
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "slices"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+// ErrNotOwner — изменение, доступное только владельцу заметки, пришло
+// от пользователя, с которым ею поделились; errors.Is(err, ErrForbidden)
+// для него истинно.
+var ErrNotOwner = fmt.Errorf("%w: only the owner can move the note", ErrForbidden)
+
+// NoteDocument — изменяемые поля заметки: документ, к которому
+// применяются JSON Merge Patch и JSON Patch.
+// @Description Изменяемые поля заметки — документ, к которому применяются патчи
+type NoteDocument struct {
+    // Заголовок
+    Title string `json:"title" example:"Моя заметка"`
+    // Содержимое
+    Content string `json:"content" example:"Текст заметки..."`
+    // Теги; [] — без тегов
+    Tags []string `json:"tags" example:"работа,отчёты"`
+    // ID блокнота; null — вне блокнотов
+    NotebookID *int64 `json:"notebookId" example:"2"`
+}
+
+// noteDocumentFields — поля NoteDocument в порядке проверки.
+var noteDocumentFields = []string{"title", "content", "tags", "notebookId"}
+
+// NotePatch превращает документ NoteDocument в формате JSON в новый.
+// Ошибка NotePatch возвращается из PatchNote как есть.
+type NotePatch func(doc []byte) ([]byte, error)
+
+// PatchNote изменяет заметку патчем. Патч применяется внутри
+// repo.Update к текущей версии заметки, так что его проверки (test в
+// JSON Patch) и изменения относятся к одной версии; version != 0 — как
+// в UpdateNote. Результат проверяется так же, как в UpdateNote, и
+// сохраняется целиком или не сохраняется вовсе. Перенести заметку в
+// другой блокнот может только владелец.
+func (s *NoteService) PatchNote(ctx context.Context, id int64, version int64, patch NotePatch) (*core.Note, error) {
+    owner, err := s.authorize(ctx, id, accessWrite)
+    if err != nil {
+        return nil, err
+    }
+    caller, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    usage, unlock, err := s.lockQuota(owner)
+    if err != nil {
+        return nil, err
+    }
+    defer unlock()
+    // блокнот из патча известен только внутри Update, а обращаться к
+    // хранилищу из Update нельзя (у SQLite одно соединение). Поэтому
+    // патч сначала применяется без блокнотов; если он переносит заметку
+    // в блокнот, блокноты владельца читаются под его мьютексом (пока он
+    // удерживается, их никто не удалит) и патч применяется заново
+    patched, before, err := s.patchNote(owner, caller, id, version, patch, usage, nil)
+    if errors.Is(err, errNeedNotebooks) {
+        defer s.notebookLocks.lock(owner)()
+        var all []core.Notebook
+        if all, err = s.notebooks.List(owner); err != nil {
+            return nil, err
+        }
+        notebooks := make(map[int64]bool, len(all))
+        for _, nb := range all {
+            notebooks[nb.ID] = true
+        }
+        patched, before, err = s.patchNote(owner, caller, id, version, patch, usage, notebooks)
+    }
+    if err != nil {
+        return nil, err
+    }
+    s.reindex(patched)
+    s.recordRevision(patched)
+    s.noteChanged(ctx, core.AuditUpdate, before, patched)
+    return patched, nil
+}
+
+// errNeedNotebooks — патч переносит заметку в блокнот, а блокноты
+// владельца не прочитаны.
+var errNeedNotebooks = errors.New("patch needs notebooks")
+
+// patchNote применяет патч к заметке в repo.Update и возвращает её после
+// и до изменения. notebooks — блокноты владельца; nil — не прочитаны,
+// тогда перенос в блокнот возвращает errNeedNotebooks, ничего не изменив.
+func (s *NoteService) patchNote(owner, caller, id, version int64, patch NotePatch, usage repo.NoteUsage, notebooks map[int64]bool) (*core.Note, *core.Note, error) {
+    var before *core.Note
+    patched, err := s.repo.Update(owner, id, version, func(n *core.Note) error {
+        if n.DeletedAt != nil {
+            return repo.ErrNoteNotFound
+        }
+        doc, err := json.Marshal(documentOf(n))
+        if err != nil {
+            return err
+        }
+        if doc, err = patch(doc); err != nil {
+            return err
+        }
+        d, err := decodeNoteDocument(doc)
+        if err != nil {
+            return err
+        }
+
+        var v violations
+        title := s.limits.checkTitle(&v, d.Title)
+        content := s.limits.checkContent(&v, d.Content)
+        tags, err := NormalizeTags(d.Tags)
+        if err := v.collect(err); err != nil {
+            return err
+        }
+        if err := v.err(); err != nil {
+            return err
+        }
+        if !sameNotebook(n.NotebookID, d.NotebookID) {
+            switch {
+            case caller != owner:
+                return ErrNotOwner
+            case d.NotebookID == nil:
+            case s.notebooks == nil:
+                return ErrNotebooksUnavailable
+            case notebooks == nil:
+                return errNeedNotebooks
+            case !notebooks[*d.NotebookID]:
+                return repo.ErrNotebookNotFound
+            }
+        }
+
+        before = snapshotNote(n)
+        size := repo.NoteSize(n.Title, n.Content)
+        n.Title, n.Content, n.Tags, n.NotebookID = title, content, tags, d.NotebookID
+        if !s.quota.allows(usage, 0, repo.NoteSize(n.Title, n.Content)-size) {
+            return ErrQuotaExceeded
+        }
+        return nil
+    })
+    return patched, before, err
+}
+
+// documentOf возвращает изменяемые поля заметки n.
+func documentOf(n *core.Note) NoteDocument {
+    d := NoteDocument{Title: n.Title, Content: n.Content, Tags: slices.Clone(n.Tags), NotebookID: n.NotebookID}
+    if d.Tags == nil {
+        d.Tags = []string{} // чтобы JSON Patch мог добавить /tags/-
+    }
+    return d
+}
+
+// decodeNoteDocument разбирает документ после патча. Неизвестное поле
+// или значение не того типа — ошибка валидации; отсутствующее поле
+// получает нулевое значение.
+func decodeNoteDocument(data []byte) (NoteDocument, error) {
+    var d NoteDocument
+    var fields map[string]json.RawMessage
+    if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+        return d, invalid("", CodeInvalid, "patched note must be a JSON object")
+    }
+    var v violations
+    for _, name := range noteDocumentFields {
+        raw, ok := fields[name]
+        if !ok {
+            continue
+        }
+        switch name {
+        case "title":
+            if json.Unmarshal(raw, &d.Title) != nil {
+                v.add(name, CodeInvalid, "%s must be a string", name)
+            }
+        case "content":
+            if json.Unmarshal(raw, &d.Content) != nil {
+                v.add(name, CodeInvalid, "%s must be a string", name)
+            }
+        case "tags":
+            if json.Unmarshal(raw, &d.Tags) != nil {
+                v.add(name, CodeInvalid, "%s must be an array of strings", name)
+            }
+        case "notebookId":
+            if json.Unmarshal(raw, &d.NotebookID) != nil {
+                v.add(name, CodeInvalid, "%s must be an integer or null", name)
+            }
+        }
+    }
+    unknown := make([]string, 0, len(fields))
+    for name := range fields {
+        if !slices.Contains(noteDocumentFields, name) {
+            unknown = append(unknown, name)
+        }
+    }
+    slices.Sort(unknown)
+    for _, name := range unknown {
+        v.add(name, CodeUnsupported, "%s cannot be changed", name)
+    }
+    return d, v.err()
+}
+
+// sameNotebook сообщает, указывают ли a и b на один блокнот.
+func sameNotebook(a, b *int64) bool {
+    if a == nil || b == nil {
+        return a == b
+    }
+    return *a == *b
+}
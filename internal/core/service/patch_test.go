@@ -0,0 +1,172 @@
+package service_test
+
+import (
+    "errors"
+    "fmt"
+    "slices"
+    "testing"
+
+    "example.com/notes-api/internal/core/service"
+    "example.com/notes-api/internal/jsonpatch"
+    "example.com/notes-api/internal/repo"
+)
+
+func mergePatch(body string) service.NotePatch {
+    return func(doc []byte) ([]byte, error) {
+        return jsonpatch.MergePatch(doc, []byte(body))
+    }
+}
+
+func jsonPatch(t *testing.T, body string) service.NotePatch {
+    t.Helper()
+    ops, err := jsonpatch.Parse([]byte(body))
+    if err != nil {
+        t.Fatalf("Parse(%s): %v", body, err)
+    }
+    return ops.Apply
+}
+
+// newNotebookFixture — sharedFixture с блокнотами и блокнотом alice.
+func newNotebookFixture(t *testing.T) (sharedFixture, int64) {
+    t.Helper()
+    f := newSharedFixture(t, service.WithNotebooks(repo.NewNotebookRepoMem()))
+    nb, err := f.svc.CreateNotebook(f.alice, "Работа", nil)
+    if err != nil {
+        t.Fatalf("CreateNotebook: %v", err)
+    }
+    return f, nb.ID
+}
+
+func TestPatchNoteMerge(t *testing.T) {
+    f, nb := newNotebookFixture(t)
+
+    n, err := f.svc.PatchNote(f.alice, f.noteID, 1, mergePatch(fmt.Sprintf(`{"title": " Итоги ", "tags": ["Работа", "q1"], "notebookId": %d}`, nb)))
+    if err != nil {
+        t.Fatalf("PatchNote: %v", err)
+    }
+    if n.Title != "Итоги" || n.Content != "текст" || !slices.Equal(n.Tags, []string{"q1", "работа"}) || n.NotebookID == nil || *n.NotebookID != nb || n.Version != 2 {
+        t.Errorf("after patch: %+v", n)
+    }
+
+    // null убирает необязательные поля: текст, теги, блокнот
+    n, err = f.svc.PatchNote(f.alice, f.noteID, 2, mergePatch(`{"content": null, "tags": null, "notebookId": null}`))
+    if err != nil {
+        t.Fatalf("PatchNote: %v", err)
+    }
+    if n.Title != "Итоги" || n.Content != "" || len(n.Tags) != 0 || n.NotebookID != nil {
+        t.Errorf("after null patch: %+v", n)
+    }
+
+    // заголовок обязателен, неизвестные поля не меняются
+    for _, body := range []string{`{"title": null}`, `{"id": 5}`, `{"title": 5}`} {
+        if _, err := f.svc.PatchNote(f.alice, f.noteID, 0, mergePatch(body)); !errors.Is(err, service.ErrValidation) {
+            t.Errorf("PatchNote(%s): err = %v, want ErrValidation", body, err)
+        }
+    }
+    if _, err := f.svc.PatchNote(f.alice, f.noteID, 2, mergePatch(`{"title": "x"}`)); !errors.Is(err, repo.ErrVersionConflict) {
+        t.Errorf("stale version: err = %v, want ErrVersionConflict", err)
+    }
+}
+
+func TestPatchNoteJSONPatchIsAtomic(t *testing.T) {
+    f := newSharedFixture(t)
+    for _, tc := range []struct {
+        name string
+        body string
+        want error
+    }{
+        {
+            "failed test",
+            `[{"op": "replace", "path": "/title", "value": "Новый"}, {"op": "test", "path": "/content", "value": "другой"}]`,
+            jsonpatch.ErrTestFailed,
+        },
+        {
+            "missing path",
+            `[{"op": "add", "path": "/tags/-", "value": "a"}, {"op": "remove", "path": "/tags/5"}]`,
+            jsonpatch.ErrPath,
+        },
+        {
+            "invalid result",
+            `[{"op": "replace", "path": "/title", "value": "Новый"}, {"op": "replace", "path": "/content", "value": "a\u0000b"}]`,
+            service.ErrValidation,
+        },
+    } {
+        t.Run(tc.name, func(t *testing.T) {
+            if _, err := f.svc.PatchNote(f.alice, f.noteID, 0, jsonPatch(t, tc.body)); !errors.Is(err, tc.want) {
+                t.Fatalf("PatchNote: err = %v, want %v", err, tc.want)
+            }
+            wantUnchanged(t, f)
+        })
+    }
+
+    n, err := f.svc.PatchNote(f.alice, f.noteID, 0, jsonPatch(t, `[
+        {"op": "test", "path": "/content", "value": "текст"},
+        {"op": "add", "path": "/tags/-", "value": "план"},
+        {"op": "move", "from": "/content", "path": "/title"}
+    ]`))
+    if err != nil || n.Title != "текст" || n.Content != "" || !slices.Equal(n.Tags, []string{"план"}) {
+        t.Errorf("PatchNote = %+v, %v", n, err)
+    }
+}
+
+func TestPatchNoteNotebooks(t *testing.T) {
+    f, nb := newNotebookFixture(t)
+    move := mergePatch(fmt.Sprintf(`{"notebookId": %d}`, nb))
+
+    // редактор может править заметку, но не переносить её
+    if _, err := f.svc.PatchNote(f.carol, f.noteID, 0, move); !errors.Is(err, service.ErrNotOwner) || !errors.Is(err, service.ErrForbidden) {
+        t.Errorf("editor moves: err = %v, want ErrNotOwner", err)
+    }
+    if n, err := f.svc.PatchNote(f.carol, f.noteID, 0, mergePatch(`{"title": "Правка"}`)); err != nil || n.NotebookID != nil {
+        t.Errorf("editor edits: %+v, %v", n, err)
+    }
+    if _, err := f.svc.PatchNote(f.bob, f.noteID, 0, mergePatch(`{"title": "Правка"}`)); !errors.Is(err, service.ErrForbidden) {
+        t.Errorf("viewer edits: err = %v, want ErrForbidden", err)
+    }
+
+    if _, err := f.svc.PatchNote(f.alice, f.noteID, 0, mergePatch(`{"notebookId": 999}`)); !errors.Is(err, repo.ErrNotebookNotFound) {
+        t.Errorf("unknown notebook: err = %v, want ErrNotebookNotFound", err)
+    }
+    // чужой блокнот для alice не существует
+    other, err := f.svc.CreateNotebook(f.dave, "Чужой", nil)
+    if err != nil {
+        t.Fatalf("CreateNotebook: %v", err)
+    }
+    if _, err := f.svc.PatchNote(f.alice, f.noteID, 0, mergePatch(fmt.Sprintf(`{"notebookId": %d}`, other.ID))); !errors.Is(err, repo.ErrNotebookNotFound) {
+        t.Errorf("someone else's notebook: err = %v, want ErrNotebookNotFound", err)
+    }
+
+    n, err := f.svc.PatchNote(f.alice, f.noteID, 2, move)
+    if err != nil || n.NotebookID == nil || *n.NotebookID != nb || n.Title != "Правка" || n.Version != 3 {
+        t.Fatalf("owner moves: %+v, %v", n, err)
+    }
+    // редактор может менять заметку в блокноте, не трогая блокнот
+    if n, err := f.svc.PatchNote(f.carol, f.noteID, 0, jsonPatch(t, `[{"op": "replace", "path": "/content", "value": "ещё"}]`)); err != nil || n.NotebookID == nil {
+        t.Errorf("editor edits note in notebook: %+v, %v", n, err)
+    }
+}
+
+func TestPatchNoteWithoutNotebooks(t *testing.T) {
+    f := newSharedFixture(t)
+    if _, err := f.svc.PatchNote(f.alice, f.noteID, 0, mergePatch(`{"notebookId": 1}`)); !errors.Is(err, service.ErrNotebooksUnavailable) {
+        t.Errorf("err = %v, want ErrNotebooksUnavailable", err)
+    }
+    if _, err := f.svc.PatchNote(f.alice, f.noteID, 0, mergePatch(`{"notebookId": null}`)); err != nil {
+        t.Errorf("notebookId null: %v", err)
+    }
+}
+
+func TestPatchNoteQuota(t *testing.T) {
+    // «План» + «текст» — 18 байт
+    f := newSharedFixture(t, service.WithQuota(service.Quota{MaxBytes: 20}))
+    if _, err := f.svc.PatchNote(f.alice, f.noteID, 0, mergePatch(`{"content": "текст длиннее"}`)); !errors.Is(err, service.ErrQuotaExceeded) {
+        t.Errorf("err = %v, want ErrQuotaExceeded", err)
+    }
+    wantUnchanged(t, f)
+    if _, err := f.svc.PatchNote(f.alice, f.noteID, 0, mergePatch(`{"content": "т"}`)); err != nil {
+        t.Errorf("shrinking patch: %v", err)
+    }
+    if u, err := f.notes.Usage(f.userID(f.alice)); err != nil || u.Bytes != int64(len("План")+len("т")) {
+        t.Errorf("Usage = %+v, %v", u, err)
+    }
+}
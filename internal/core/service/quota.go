@@ -0,0 +1,92 @@
+package service
+
+import (
+    "errors"
+    "slices"
+
+    "example.com/notes-api/internal/repo"
+)
+
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// Quota — ограничения хранилища на одного пользователя. Заметки в
+// корзине тоже занимают место, пока их не удалят насовсем. Нулевое поле —
+// без ограничения.
+// @Description Квота хранилища пользователя; 0 — без ограничения
+type Quota struct {
+    // MaxNotes — максимальное число заметок.
+    MaxNotes int `json:"maxNotes" example:"1000"`
+    // MaxBytes — максимальный суммарный размер заголовков и содержимого.
+    MaxBytes int64 `json:"maxBytes" example:"10485760"`
+}
+
+func (q Quota) enabled() bool {
+    return q.MaxNotes > 0 || q.MaxBytes > 0
+}
+
+// allows сообщает, помещается ли в квоту объём usage, к которому
+// добавится notes заметок и bytes байт. Уменьшение объёма разрешено
+// всегда, даже если квота уже превышена (например, после её снижения).
+func (q Quota) allows(usage repo.NoteUsage, notes int, bytes int64) bool {
+    if notes > 0 && q.MaxNotes > 0 && usage.Notes+notes > q.MaxNotes {
+        return false
+    }
+    if bytes > 0 && q.MaxBytes > 0 && usage.Bytes+bytes > q.MaxBytes {
+        return false
+    }
+    return true
+}
+
+// WithQuota включает квоты на хранилище пользователя; их проверяют
+// CreateNote, UpdateNote, PatchNote и BatchNotes.
+func WithQuota(q Quota) Option {
+    return func(s *NoteService) {
+        s.quota = q
+    }
+}
+
+// lockQuota сериализует проверку квоты с записью, чтобы параллельные
+// запросы одного пользователя не превысили её вместе, и возвращает
+// текущий объём его заметок. Без квоты ничего не делает.
+func (s *NoteService) lockQuota(owner int64) (repo.NoteUsage, func(), error) {
+    if !s.quota.enabled() {
+        return repo.NoteUsage{}, func() {}, nil
+    }
+    unlock := s.quotaLocks.lock(owner)
+    usage, err := s.repo.Usage(owner)
+    if err != nil {
+        unlock()
+        return repo.NoteUsage{}, nil, err
+    }
+    return usage, unlock, nil
+}
+
+// lockQuotas — lockQuota для нескольких владельцев сразу (атомарный
+// пакет может менять заметки разных владельцев). Мьютексы захватываются
+// по возрастанию ID владельца, чтобы два пакета не ждали друг друга.
+func (s *NoteService) lockQuotas(owners []int64) (map[int64]repo.NoteUsage, func(), error) {
+    usage := make(map[int64]repo.NoteUsage, len(owners))
+    if !s.quota.enabled() {
+        return usage, func() {}, nil
+    }
+    owners = slices.Clone(owners)
+    slices.Sort(owners)
+    owners = slices.Compact(owners)
+
+    unlocks := make([]func(), 0, len(owners))
+    unlock := func() {
+        for i := len(unlocks) - 1; i >= 0; i-- {
+            unlocks[i]()
+        }
+    }
+    for _, owner := range owners {
+        unlocks = append(unlocks, s.quotaLocks.lock(owner))
+        u, err := s.repo.Usage(owner)
+        if err != nil {
+            unlock()
+            return nil, nil, err
+        }
+        usage[owner] = u
+    }
+    return usage, unlock, nil
+}
@@ -0,0 +1,40 @@
+package service_test
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/core/service"
+    "example.com/notes-api/internal/repo"
+)
+
+func TestQuotaConcurrentCreate(t *testing.T) {
+    const maxNotes = 5
+    r := repo.NewNoteRepoMem()
+    svc := service.NewNoteService(r, service.WithQuota(service.Quota{MaxNotes: maxNotes}))
+
+    var wg sync.WaitGroup
+    for owner := int64(1); owner <= 3; owner++ {
+        ctx := core.WithPrincipal(context.Background(), core.Principal{UserID: owner})
+        for i := 0; i < 4*maxNotes; i++ {
+            wg.Add(1)
+            go func() {
+                defer wg.Done()
+                _, err := svc.CreateNote(ctx, service.NoteCreateInput{Title: "заметка"})
+                if err != nil && !errors.Is(err, service.ErrQuotaExceeded) {
+                    t.Errorf("CreateNote: %v", err)
+                }
+            }()
+        }
+    }
+    wg.Wait()
+
+    for owner := int64(1); owner <= 3; owner++ {
+        if u, err := r.Usage(owner); err != nil || u.Notes != maxNotes {
+            t.Errorf("Usage(%d) = %+v, %v; want %d notes", owner, u, err, maxNotes)
+        }
+    }
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+    "context"
+    "log"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+    "example.com/notes-api/internal/textdiff"
+)
+
+// WithRevisions включает историю изменений: каждое создание и изменение
+// заметки сохраняет ревизию, старые ревизии удаляются по retention.
+func WithRevisions(r repo.RevisionRepository, retention repo.RevisionRetention) Option {
+    return func(s *NoteService) {
+        s.revisions = r
+        s.retention = retention
+    }
+}
+
+// RevisionDiff — построчная разница между двумя ревизиями заметки.
+type RevisionDiff struct {
+    From    int64
+    To      int64
+    Title   []textdiff.Line
+    Content []textdiff.Line
+}
+
+// ListRevisions возвращает сохранённые ревизии заметки по возрастанию номера.
+func (s *NoteService) ListRevisions(ctx context.Context, noteID int64) ([]core.NoteRevision, error) {
+    if _, err := s.GetNote(ctx, noteID); err != nil {
+        return nil, err
+    }
+    if s.revisions == nil {
+        return []core.NoteRevision{}, nil
+    }
+    return s.revisions.List(noteID)
+}
+
+func (s *NoteService) GetRevision(ctx context.Context, noteID, revision int64) (*core.NoteRevision, error) {
+    if _, err := s.GetNote(ctx, noteID); err != nil {
+        return nil, err
+    }
+    if s.revisions == nil {
+        return nil, repo.ErrRevisionNotFound
+    }
+    return s.revisions.Get(noteID, revision)
+}
+
+// DiffRevisions сравнивает ревизии from и to; to == 0 — последняя ревизия.
+// Номер последней ревизии берётся из истории, а не из версии заметки:
+// перемещение в корзину и восстановление меняют версию без новой ревизии.
+func (s *NoteService) DiffRevisions(ctx context.Context, noteID, from, to int64) (*RevisionDiff, error) {
+    if to == 0 {
+        revs, err := s.ListRevisions(ctx, noteID)
+        if err != nil {
+            return nil, err
+        }
+        if len(revs) == 0 {
+            return nil, repo.ErrRevisionNotFound
+        }
+        to = revs[len(revs)-1].Revision
+    }
+    a, err := s.GetRevision(ctx, noteID, from)
+    if err != nil {
+        return nil, err
+    }
+    b, err := s.GetRevision(ctx, noteID, to)
+    if err != nil {
+        return nil, err
+    }
+    return &RevisionDiff{
+        From:    from,
+        To:      to,
+        Title:   textdiff.Lines(a.Title, b.Title),
+        Content: textdiff.Lines(a.Content, b.Content),
+    }, nil
+}
+
+// RestoreRevision возвращает заметке заголовок и содержимое ревизии.
+// Это обычное изменение: версия растёт и появляется новая ревизия.
+func (s *NoteService) RestoreRevision(ctx context.Context, noteID, revision, version int64) (*core.Note, error) {
+    rev, err := s.GetRevision(ctx, noteID, revision)
+    if err != nil {
+        return nil, err
+    }
+    return s.UpdateNote(ctx, noteID, version, NoteUpdateInput{
+        Title:   &rev.Title,
+        Content: &rev.Content,
+    })
+}
+
+// recordRevision сохраняет состояние заметки как ревизию. Заметка уже
+// записана, поэтому ошибки истории только логируются.
+func (s *NoteService) recordRevision(n *core.Note) {
+    if s.revisions == nil {
+        return
+    }
+    createdAt := n.CreatedAt
+    if n.UpdatedAt != nil {
+        createdAt = *n.UpdatedAt
+    }
+    rev := core.NoteRevision{
+        NoteID:    n.ID,
+        Revision:  n.Version,
+        Title:     n.Title,
+        Content:   n.Content,
+        CreatedAt: createdAt,
+    }
+    if err := s.revisions.Append(rev); err != nil {
+        log.Printf("revisions: append note %d rev %d: %v", n.ID, n.Version, err)
+        return
+    }
+    if err := s.revisions.Prune(n.ID, s.retention); err != nil {
+        log.Printf("revisions: prune note %d: %v", n.ID, err)
+    }
+}
+
+// forgetRevisions удаляет историю заметки, удалённой насовсем.
+func (s *NoteService) forgetRevisions(id int64) {
+    if s.revisions == nil {
+        return
+    }
+    if err := s.revisions.DeleteAll(id); err != nil {
+        log.Printf("revisions: delete note %d: %v", id, err)
+    }
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "log"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+    "example.com/notes-api/internal/search"
+)
+
+const (
+    // DefaultSearchLimit — число результатов поиска по умолчанию.
+    DefaultSearchLimit = 20
+    // MaxSearchLimit — максимальное число результатов поиска.
+    MaxSearchLimit = 100
+)
+
+// SearchResult — найденная заметка с оценкой релевантности и подсветкой.
+type SearchResult struct {
+    Note    core.Note
+    Score   float64
+    Title   string // HTML-экранированный заголовок с <mark>
+    Snippet string // HTML-экранированный фрагмент текста с <mark>
+}
+
+// SearchNotes ищет заметки вызывающего по заголовку и содержимому.
+// Запрос без единого слова — ошибка валидации.
+func (s *NoteService) SearchNotes(ctx context.Context, text string, limit int) ([]SearchResult, error) {
+    if s.index == nil {
+        return nil, ErrSearchUnavailable
+    }
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    q := search.ParseQuery(text)
+    if q.Empty() {
+        return nil, invalid("q", CodeRequired, "search query is empty")
+    }
+    q.OwnerID = owner
+    if limit <= 0 {
+        limit = DefaultSearchLimit
+    }
+    if limit > MaxSearchLimit {
+        limit = MaxSearchLimit
+    }
+
+    hits, err := s.index.Search(q, limit)
+    if err != nil {
+        return nil, err
+    }
+
+    results := make([]SearchResult, 0, len(hits))
+    for _, h := range hits {
+        n, err := s.GetNote(ctx, h.NoteID)
+        if errors.Is(err, repo.ErrNoteNotFound) {
+            continue // индекс отстал от хранилища
+        }
+        if err != nil {
+            return nil, err
+        }
+        results = append(results, SearchResult{Note: *n, Score: h.Score, Title: h.Title, Snippet: h.Snippet})
+    }
+    return results, nil
+}
+
+// RebuildIndex заново индексирует все заметки хранилища, кроме заметок
+// в корзине; вызывается при старте, если индекс не хранится вместе
+// с заметками.
+func (s *NoteService) RebuildIndex() error {
+    if s.index == nil {
+        return nil
+    }
+    notes, err := s.repo.GetAll()
+    if err != nil {
+        return err
+    }
+    for _, n := range notes {
+        if n.DeletedAt != nil {
+            continue
+        }
+        if err := s.index.Index(n); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// reindex обновляет заметку в индексе. Запись в хранилище уже прошла,
+// поэтому ошибка индекса только логируется.
+func (s *NoteService) reindex(n *core.Note) {
+    if s.index == nil {
+        return
+    }
+    if err := s.index.Index(*n); err != nil {
+        log.Printf("search: index note %d: %v", n.ID, err)
+    }
+}
+
+// unindex убирает заметку из индекса; ошибка, как и в reindex, только
+// логируется.
+func (s *NoteService) unindex(id int64) {
+    if s.index == nil {
+        return
+    }
+    if err := s.index.Remove(id); err != nil {
+        log.Printf("search: remove note %d: %v", id, err)
+    }
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "log"
+    "strings"
+    "time"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+var (
+    ErrSharingUnavailable = errors.New("sharing is not configured")
+    // ErrForbidden — заметка вызывающему видна, но его роль не разрешает
+    // действие.
+    ErrForbidden = errors.New("forbidden")
+)
+
+// WithSharing включает совместный доступ к заметкам; users нужен, чтобы
+// находить получателя по имени.
+func WithSharing(shares repo.ShareRepository, users repo.UserRepository) Option {
+    return func(s *NoteService) {
+        s.shares = shares
+        s.users = users
+    }
+}
+
+// access — действие с заметкой, которое проверяет authorize.
+type access int
+
+const (
+    accessRead  access = iota // чтение: viewer, editor, владелец
+    accessWrite               // изменение: editor, владелец
+    accessOwner               // удаление и управление доступом: только владелец
+)
+
+// authorize возвращает владельца заметки id, если вызывающему разрешено
+// действие need. Чужая заметка без выданного доступа (или в корзине) —
+// repo.ErrNoteNotFound, чтобы не раскрывать её существование; доступ
+// есть, но роль не позволяет — ErrForbidden. Без WithSharing каждый
+// видит только свои заметки.
+func (s *NoteService) authorize(ctx context.Context, id int64, need access) (int64, error) {
+    caller, err := ownerFrom(ctx)
+    if err != nil {
+        return 0, err
+    }
+    if s.shares == nil {
+        return caller, nil
+    }
+    n, err := s.repo.GetByID(repo.AllOwners, id)
+    if err != nil {
+        return 0, err
+    }
+    if n.OwnerID == caller {
+        return caller, nil
+    }
+    share, err := s.shares.Get(id, caller)
+    if errors.Is(err, repo.ErrShareNotFound) {
+        return 0, repo.ErrNoteNotFound
+    }
+    if err != nil {
+        return 0, err
+    }
+    if n.DeletedAt != nil {
+        return 0, repo.ErrNoteNotFound
+    }
+    switch {
+    case need == accessRead:
+    case need == accessWrite && share.Role == core.RoleEditor:
+    default:
+        return 0, ErrForbidden
+    }
+    return n.OwnerID, nil
+}
+
+// ownedNote возвращает заметку вне корзины, если её владелец — вызывающий.
+func (s *NoteService) ownedNote(ctx context.Context, id int64) (*core.Note, error) {
+    owner, err := s.authorize(ctx, id, accessOwner)
+    if err != nil {
+        return nil, err
+    }
+    n, err := s.repo.GetByID(owner, id)
+    if err != nil {
+        return nil, err
+    }
+    if n.DeletedAt != nil {
+        return nil, repo.ErrNoteNotFound
+    }
+    return n, nil
+}
+
+// ownShared проверяет, что вызывающий — владелец заметки id вне корзины,
+// и находит пользователя username.
+func (s *NoteService) ownShared(ctx context.Context, id int64, username string) (int64, *core.User, error) {
+    if s.shares == nil {
+        return 0, nil, ErrSharingUnavailable
+    }
+    n, err := s.ownedNote(ctx, id)
+    if err != nil {
+        return 0, nil, err
+    }
+    u, err := s.users.GetByUsername(strings.ToLower(strings.TrimSpace(username)))
+    if err != nil {
+        return 0, nil, err
+    }
+    return n.OwnerID, u, nil
+}
+
+// ShareNote выдаёт пользователю username доступ к заметке с ролью role
+// или меняет роль уже выданного доступа. Делиться может только
+// владелец; неизвестный пользователь — repo.ErrUserNotFound.
+func (s *NoteService) ShareNote(ctx context.Context, id int64, username string, role core.ShareRole) (*core.Share, error) {
+    if !role.Valid() {
+        return nil, invalid("role", CodeUnsupported, "%s must be one of %s", "role", "viewer, editor")
+    }
+    owner, u, err := s.ownShared(ctx, id, username)
+    if err != nil {
+        return nil, err
+    }
+    if u.ID == owner {
+        return nil, invalid("username", CodeInvalid, "the owner already has full access")
+    }
+    err = s.shares.Put(core.Share{NoteID: id, UserID: u.ID, Role: role, CreatedAt: time.Now().UTC()})
+    if err != nil {
+        return nil, err
+    }
+    share, err := s.shares.Get(id, u.ID)
+    if err != nil {
+        return nil, err
+    }
+    share.Username = u.Username
+    return share, nil
+}
+
+// UnshareNote отзывает доступ пользователя username к заметке; если
+// доступа не было — repo.ErrShareNotFound.
+func (s *NoteService) UnshareNote(ctx context.Context, id int64, username string) error {
+    _, u, err := s.ownShared(ctx, id, username)
+    if err != nil {
+        return err
+    }
+    return s.shares.Delete(id, u.ID)
+}
+
+// ListShares возвращает, кому владелец открыл заметку.
+func (s *NoteService) ListShares(ctx context.Context, id int64) ([]core.Share, error) {
+    if s.shares == nil {
+        return nil, ErrSharingUnavailable
+    }
+    if _, err := s.authorize(ctx, id, accessOwner); err != nil {
+        return nil, err
+    }
+    shares, err := s.shares.ListByNote(id)
+    if err != nil {
+        return nil, err
+    }
+    out := shares[:0]
+    for _, sh := range shares {
+        u, err := s.users.GetByID(sh.UserID)
+        if errors.Is(err, repo.ErrUserNotFound) {
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+        sh.Username = u.Username
+        out = append(out, sh)
+    }
+    return out, nil
+}
+
+// ListSharedWithMe возвращает заметки других пользователей, к которым у
+// вызывающего есть доступ, вместе с его ролью. Заметки в корзине
+// пропускаются.
+func (s *NoteService) ListSharedWithMe(ctx context.Context) ([]core.SharedNote, error) {
+    caller, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if s.shares == nil {
+        return nil, ErrSharingUnavailable
+    }
+    shares, err := s.shares.ListByUser(caller)
+    if err != nil {
+        return nil, err
+    }
+    out := make([]core.SharedNote, 0, len(shares))
+    for _, sh := range shares {
+        n, err := s.repo.GetByID(repo.AllOwners, sh.NoteID)
+        if errors.Is(err, repo.ErrNoteNotFound) {
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+        if n.DeletedAt != nil {
+            continue
+        }
+        out = append(out, core.SharedNote{Note: *n, Role: sh.Role})
+    }
+    return out, nil
+}
+
+// forgetShares удаляет доступы к заметке, удалённой насовсем.
+func (s *NoteService) forgetShares(id int64) {
+    if s.shares == nil {
+        return
+    }
+    if err := s.shares.DeleteByNote(id); err != nil {
+        log.Printf("shares: delete note %d: %v", id, err)
+    }
+}
@@ -0,0 +1,128 @@
+package service_test
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/core/service"
+    "example.com/notes-api/internal/repo"
+)
+
+// sharedFixture — заметка владельца alice, открытая bob (viewer)
+// и carol (editor); dave доступа не получил.
+type sharedFixture struct {
+    svc                     *service.NoteService
+    notes                   *repo.NoteRepoMem
+    shares                  *repo.ShareRepoMem
+    links                   *repo.ShareLinkRepoMem
+    noteID                  int64
+    alice, bob, carol, dave context.Context
+}
+
+func newSharedFixture(t *testing.T, opts ...service.Option) sharedFixture {
+    t.Helper()
+    users, shares, links := repo.NewUserRepoMem(), repo.NewShareRepoMem(), repo.NewShareLinkRepoMem()
+    opts = append([]service.Option{
+        service.WithSharing(shares, users),
+        service.WithShareLinks(links),
+    }, opts...)
+    notes := repo.NewNoteRepoMem()
+    svc := service.NewNoteService(notes, opts...)
+    as := func(name string) context.Context {
+        id, err := users.Create(core.User{Username: name, PasswordHash: []byte("x")})
+        if err != nil {
+            t.Fatalf("Create user %s: %v", name, err)
+        }
+        return core.WithPrincipal(context.Background(), core.Principal{UserID: id, Username: name})
+    }
+    f := sharedFixture{svc: svc, notes: notes, shares: shares, links: links, alice: as("alice"), bob: as("bob"), carol: as("carol"), dave: as("dave")}
+
+    n, err := svc.CreateNote(f.alice, service.NoteCreateInput{Title: "План", Content: "текст"})
+    if err != nil {
+        t.Fatalf("CreateNote: %v", err)
+    }
+    f.noteID = n.ID
+    if _, err := svc.ShareNote(f.alice, n.ID, "bob", core.RoleViewer); err != nil {
+        t.Fatalf("ShareNote bob: %v", err)
+    }
+    if _, err := svc.ShareNote(f.alice, n.ID, "carol", core.RoleEditor); err != nil {
+        t.Fatalf("ShareNote carol: %v", err)
+    }
+    return f
+}
+
+// userID возвращает ID пользователя из контекста фикстуры.
+func (f sharedFixture) userID(ctx context.Context) int64 {
+    p, _ := core.PrincipalFrom(ctx)
+    return p.UserID
+}
+
+func TestAuthorizeViewer(t *testing.T) {
+    f := newSharedFixture(t)
+    title := "Чужой"
+
+    if n, err := f.svc.GetNote(f.bob, f.noteID); err != nil || n.Title != "План" {
+        t.Errorf("GetNote = %+v, %v", n, err)
+    }
+    if _, err := f.svc.UpdateNote(f.bob, f.noteID, 0, service.NoteUpdateInput{Title: &title}); !errors.Is(err, service.ErrForbidden) {
+        t.Errorf("UpdateNote: err = %v, want ErrForbidden", err)
+    }
+    if err := f.svc.DeleteNote(f.bob, f.noteID, 0); !errors.Is(err, service.ErrForbidden) {
+        t.Errorf("DeleteNote: err = %v, want ErrForbidden", err)
+    }
+}
+
+func TestAuthorizeEditor(t *testing.T) {
+    f := newSharedFixture(t)
+    title := "Правка"
+
+    n, err := f.svc.UpdateNote(f.carol, f.noteID, 0, service.NoteUpdateInput{Title: &title})
+    if err != nil || n.Title != title {
+        t.Fatalf("UpdateNote = %+v, %v", n, err)
+    }
+    if err := f.svc.DeleteNote(f.carol, f.noteID, 0); !errors.Is(err, service.ErrForbidden) {
+        t.Errorf("DeleteNote: err = %v, want ErrForbidden", err)
+    }
+    if _, err := f.svc.ShareNote(f.carol, f.noteID, "dave", core.RoleViewer); !errors.Is(err, service.ErrForbidden) {
+        t.Errorf("ShareNote: err = %v, want ErrForbidden", err)
+    }
+    if _, _, err := f.svc.CreateShareLink(f.carol, f.noteID, nil, ""); !errors.Is(err, service.ErrForbidden) {
+        t.Errorf("CreateShareLink: err = %v, want ErrForbidden", err)
+    }
+}
+
+func TestAuthorizeNoShare(t *testing.T) {
+    f := newSharedFixture(t)
+    title := "Чужой"
+
+    if _, err := f.svc.GetNote(f.dave, f.noteID); !errors.Is(err, repo.ErrNoteNotFound) {
+        t.Errorf("GetNote: err = %v, want ErrNoteNotFound", err)
+    }
+    if _, err := f.svc.UpdateNote(f.dave, f.noteID, 0, service.NoteUpdateInput{Title: &title}); !errors.Is(err, repo.ErrNoteNotFound) {
+        t.Errorf("UpdateNote: err = %v, want ErrNoteNotFound", err)
+    }
+    if err := f.svc.DeleteNote(f.dave, f.noteID, 0); !errors.Is(err, repo.ErrNoteNotFound) {
+        t.Errorf("DeleteNote: err = %v, want ErrNoteNotFound", err)
+    }
+}
+
+func TestAuthorizeTrashedShared(t *testing.T) {
+    f := newSharedFixture(t)
+    if err := f.svc.DeleteNote(f.alice, f.noteID, 0); err != nil {
+        t.Fatalf("DeleteNote: %v", err)
+    }
+    title := "Правка"
+
+    if _, err := f.svc.GetNote(f.bob, f.noteID); !errors.Is(err, repo.ErrNoteNotFound) {
+        t.Errorf("GetNote: err = %v, want ErrNoteNotFound", err)
+    }
+    if _, err := f.svc.UpdateNote(f.carol, f.noteID, 0, service.NoteUpdateInput{Title: &title}); !errors.Is(err, repo.ErrNoteNotFound) {
+        t.Errorf("UpdateNote: err = %v, want ErrNoteNotFound", err)
+    }
+    shared, err := f.svc.ListSharedWithMe(f.bob)
+    if err != nil || len(shared) != 0 {
+        t.Errorf("ListSharedWithMe = %+v, %v; want none", shared, err)
+    }
+}
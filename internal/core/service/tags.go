@@ -0,0 +1,198 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "slices"
+    "strings"
+    "unicode/utf8"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+var ErrTagNotFound = errors.New("tag not found")
+
+const (
+    // MaxTagLength — максимальная длина тега в символах.
+    MaxTagLength = 32
+    // MaxTagsPerNote — максимальное число тегов у одной заметки.
+    MaxTagsPerNote = 20
+)
+
+// NormalizeTag приводит тег к каноническому виду: нижний регистр, без
+// пробелов по краям, пробелы внутри схлопываются в один. Пустой тег,
+// тег длиннее MaxTagLength или с запятой — ошибка валидации.
+func NormalizeTag(tag string) (string, error) {
+    return normalizeTag("tag", tag)
+}
+
+// normalizeTag — NormalizeTag для тега из поля field запроса.
+func normalizeTag(field, tag string) (string, error) {
+    tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+    switch {
+    case tag == "":
+        return "", invalid(field, CodeRequired, "%s must not be empty", field)
+    case utf8.RuneCountInString(tag) > MaxTagLength:
+        return "", invalid(field, CodeTooLong, "%s must be at most %d characters", field, MaxTagLength)
+    case strings.Contains(tag, ","):
+        return "", invalid(field, CodeInvalid, "%s must not contain commas", field)
+    }
+    return tag, nil
+}
+
+// NormalizeTags нормализует каждый тег, убирает повторы и сортирует.
+// Ошибка перечисляет все неверные теги.
+func NormalizeTags(tags []string) ([]string, error) {
+    return normalizeTags("tags", tags)
+}
+
+// normalizeTags — NormalizeTags для тегов из поля field запроса.
+func normalizeTags(field string, tags []string) ([]string, error) {
+    out := make([]string, 0, len(tags))
+    var v violations
+    for i, tag := range tags {
+        norm, err := normalizeTag(fmt.Sprintf("%s[%d]", field, i), tag)
+        if err := v.collect(err); err != nil {
+            return nil, err
+        }
+        out = append(out, norm)
+    }
+    slices.Sort(out)
+    out = slices.Compact(out)
+    if len(out) > MaxTagsPerNote {
+        v.add(field, CodeTooMany, "at most %d tags are allowed", MaxTagsPerNote)
+    }
+    if err := v.err(); err != nil {
+        return nil, err
+    }
+    if len(out) == 0 {
+        return nil, nil
+    }
+    return out, nil
+}
+
+// ListTags возвращает теги заметок вызывающего вне корзины с числом заметок.
+func (s *NoteService) ListTags(ctx context.Context) ([]repo.TagCount, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return s.repo.TagCounts(owner)
+}
+
+// RenameTag переименовывает тег во всех заметках, включая корзину, и
+// возвращает число изменённых заметок. Если у заметки уже есть тег с
+// новым именем, теги сливаются.
+func (s *NoteService) RenameTag(ctx context.Context, from, to string) (int, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return 0, err
+    }
+    var v violations
+    from, err = NormalizeTag(from)
+    if err := v.collect(err); err != nil {
+        return 0, err
+    }
+    to, err = normalizeTag("name", to)
+    if err := v.collect(err); err != nil {
+        return 0, err
+    }
+    if err := v.err(); err != nil {
+        return 0, err
+    }
+    if from == to {
+        // переименование в себя ничего не меняет, но тег должен существовать
+        return 0, s.tagExists(owner, from)
+    }
+    return s.retag(ctx, owner, from, func(tags []string) []string {
+        tags = slices.DeleteFunc(tags, func(t string) bool { return t == from })
+        tags = append(tags, to)
+        slices.Sort(tags)
+        return slices.Compact(tags)
+    })
+}
+
+// DeleteTag снимает тег со всех заметок, включая корзину, и возвращает
+// число изменённых заметок.
+func (s *NoteService) DeleteTag(ctx context.Context, tag string) (int, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return 0, err
+    }
+    tag, err = NormalizeTag(tag)
+    if err != nil {
+        return 0, err
+    }
+    return s.retag(ctx, owner, tag, func(tags []string) []string {
+        return slices.DeleteFunc(tags, func(t string) bool { return t == tag })
+    })
+}
+
+// tagExists возвращает ErrTagNotFound, если тега tag нет ни у одной
+// заметки владельца, включая корзину.
+func (s *NoteService) tagExists(owner int64, tag string) error {
+    for _, trashed := range []bool{false, true} {
+        page, err := s.repo.Find(repo.NoteQuery{OwnerID: owner, Limit: 1, Tags: []string{tag}, Trashed: trashed})
+        if err != nil {
+            return err
+        }
+        if len(page.Notes) > 0 {
+            return nil
+        }
+    }
+    return ErrTagNotFound
+}
+
+// errTagGone — заметка потеряла тег между выборкой и изменением.
+var errTagGone = errors.New("tag is gone")
+
+// retag применяет change к тегам каждой заметки владельца с тегом tag. Заметки
+// выбираются страницами по ID, поэтому изменения не сбивают курсор.
+// Каждое изменение — новая версия заметки, поэтому для него, как и для
+// UpdateNote, пишется ревизия. Если тега нет ни у одной заметки —
+// ErrTagNotFound.
+func (s *NoteService) retag(ctx context.Context, owner int64, tag string, change func(tags []string) []string) (int, error) {
+    changed := 0
+    for _, trashed := range []bool{false, true} {
+        q := repo.NoteQuery{OwnerID: owner, Limit: MaxPageSize, Tags: []string{tag}, Trashed: trashed}
+        for {
+            page, err := s.repo.Find(q)
+            if err != nil {
+                return changed, err
+            }
+            for _, n := range page.Notes {
+                var before *core.Note
+                updated, err := s.repo.Update(owner, n.ID, 0, func(n *core.Note) error {
+                    if !slices.Contains(n.Tags, tag) {
+                        return errTagGone
+                    }
+                    before = snapshotNote(n)
+                    n.Tags = change(n.Tags)
+                    return nil
+                })
+                if errors.Is(err, errTagGone) || errors.Is(err, repo.ErrNoteNotFound) {
+                    continue
+                }
+                if err != nil {
+                    return changed, err
+                }
+                if !trashed {
+                    s.reindex(updated)
+                }
+                s.recordRevision(updated)
+                s.noteChanged(ctx, core.AuditUpdate, before, updated)
+                changed++
+            }
+            if page.Next == nil {
+                break
+            }
+            q.After = page.Next
+        }
+    }
+    if changed == 0 {
+        return 0, ErrTagNotFound
+    }
+    return changed, nil
+}
@@ -0,0 +1,170 @@
+package service_test
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "slices"
+    "strings"
+    "testing"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/core/service"
+    "example.com/notes-api/internal/repo"
+)
+
+// tagged создаёт заметку alice с тегами tags.
+func tagged(t *testing.T, f sharedFixture, tags ...string) int64 {
+    t.Helper()
+    n, err := f.svc.CreateNote(f.alice, service.NoteCreateInput{Title: "Заметка", Tags: tags})
+    if err != nil {
+        t.Fatalf("CreateNote: %v", err)
+    }
+    return n.ID
+}
+
+// wantTags проверяет теги заметки alice, в том числе в корзине.
+func wantTags(t *testing.T, f sharedFixture, id int64, want ...string) {
+    t.Helper()
+    n, err := f.notes.GetByID(f.userID(f.alice), id)
+    if err != nil || !slices.Equal(n.Tags, want) {
+        t.Errorf("note %d: %+v, %v; want tags %q", id, n, err, want)
+    }
+}
+
+func TestRenameTagMerges(t *testing.T) {
+    f := newSharedFixture(t)
+    both := tagged(t, f, "работа", "срочно")
+    only := tagged(t, f, "Работа")
+    trashed := tagged(t, f, "работа", "срочно", "архив")
+    if err := f.svc.DeleteNote(f.alice, trashed, 0); err != nil {
+        t.Fatalf("DeleteNote: %v", err)
+    }
+
+    // у двух заметок новый тег уже есть: теги сливаются, а не повторяются
+    changed, err := f.svc.RenameTag(f.alice, " РАБОТА ", "Срочно")
+    if err != nil || changed != 3 {
+        t.Fatalf("RenameTag = %d, %v; want 3", changed, err)
+    }
+    wantTags(t, f, both, "срочно")
+    wantTags(t, f, only, "срочно")
+    wantTags(t, f, trashed, "архив", "срочно")
+
+    tags, err := f.svc.ListTags(f.alice)
+    if err != nil || !slices.Equal(tags, []repo.TagCount{{Tag: "срочно", Count: 2}}) {
+        t.Errorf("ListTags = %+v, %v", tags, err)
+    }
+    // у заметки, которой тег не касается, версия не меняется
+    if n, err := f.svc.GetNote(f.alice, f.noteID); err != nil || n.Version != 1 {
+        t.Errorf("GetNote = %+v, %v", n, err)
+    }
+
+    if _, err := f.svc.RenameTag(f.alice, "работа", "дом"); !errors.Is(err, service.ErrTagNotFound) {
+        t.Errorf("renamed tag again: err = %v, want ErrTagNotFound", err)
+    }
+    if changed, err := f.svc.RenameTag(f.alice, "Срочно", "срочно"); err != nil || changed != 0 {
+        t.Errorf("rename into itself = %d, %v", changed, err)
+    }
+    if _, err := f.svc.RenameTag(f.alice, "дом", "дом"); !errors.Is(err, service.ErrTagNotFound) {
+        t.Errorf("unknown tag into itself: err = %v, want ErrTagNotFound", err)
+    }
+    // теги чужих заметок не видны
+    if _, err := f.svc.RenameTag(f.dave, "срочно", "дом"); !errors.Is(err, service.ErrTagNotFound) {
+        t.Errorf("someone else's tag: err = %v, want ErrTagNotFound", err)
+    }
+}
+
+func TestRenameTagLimits(t *testing.T) {
+    f := newSharedFixture(t)
+    tags := make([]string, service.MaxTagsPerNote)
+    for i := range tags {
+        tags[i] = fmt.Sprintf("тег %02d", i)
+    }
+    full := tagged(t, f, tags...)
+
+    var verr *service.ValidationError
+    for _, tc := range []struct{ from, to, field string }{
+        {"тег 00", "", "name"},
+        {"тег 00", "а,б", "name"},
+        {"тег 00", strings.Repeat("т", service.MaxTagLength+1), "name"},
+        {"", "новый", "tag"},
+    } {
+        _, err := f.svc.RenameTag(f.alice, tc.from, tc.to)
+        if !errors.As(err, &verr) || len(verr.Violations) != 1 || verr.Violations[0].Field != tc.field {
+            t.Errorf("RenameTag(%q, %q): err = %v, want a violation of %s", tc.from, tc.to, err, tc.field)
+        }
+    }
+    // обе ошибки сообщаются сразу
+    if _, err := f.svc.RenameTag(f.alice, "", ""); !errors.As(err, &verr) || len(verr.Violations) != 2 {
+        t.Errorf("RenameTag: err = %v, want two violations", err)
+    }
+    wantTags(t, f, full, tags...)
+
+    // переименование не добавляет тегов, поэтому заметке с предельным
+    // числом тегов оно разрешено
+    if changed, err := f.svc.RenameTag(f.alice, "тег 00", "тег 99"); err != nil || changed != 1 {
+        t.Fatalf("RenameTag = %d, %v", changed, err)
+    }
+    wantTags(t, f, full, append(slices.Clone(tags[1:]), "тег 99")...)
+    if changed, err := f.svc.RenameTag(f.alice, "тег 99", "тег 01"); err != nil || changed != 1 {
+        t.Fatalf("RenameTag = %d, %v", changed, err)
+    }
+    wantTags(t, f, full, tags[1:]...)
+}
+
+func TestRetagSkipsChangedNotes(t *testing.T) {
+    notes := &changingRepo{NoteRepoMem: repo.NewNoteRepoMem(), onFind: func(int) {}}
+    svc := service.NewNoteService(notes)
+    ctx := core.WithPrincipal(context.Background(), core.Principal{UserID: 1, Username: "alice"})
+
+    // больше одной страницы retag
+    const total = service.MaxPageSize + 100
+    ids := make([]int64, total)
+    for i := range ids {
+        n, err := svc.CreateNote(ctx, service.NoteCreateInput{Title: "Заметка", Tags: []string{"старый"}})
+        if err != nil {
+            t.Fatalf("CreateNote: %v", err)
+        }
+        ids[i] = n.ID
+    }
+
+    // после первой страницы с одной её заметки тег снимают, другую
+    // удаляют насовсем, а ещё одна заметка с тегом появляется в конце
+    untagged, purged := ids[10], ids[20]
+    var added int64
+    notes.onFind = func(finds int) {
+        if finds != 1 {
+            return
+        }
+        if _, err := notes.Update(1, untagged, 0, func(n *core.Note) error {
+            n.Tags = nil
+            return nil
+        }); err != nil {
+            t.Errorf("Update: %v", err)
+        }
+        if err := notes.Delete(1, purged, 0); err != nil {
+            t.Errorf("Delete: %v", err)
+        }
+        n, err := svc.CreateNote(ctx, service.NoteCreateInput{Title: "Новая", Tags: []string{"старый"}})
+        if err != nil {
+            t.Fatalf("CreateNote: %v", err)
+        }
+        added = n.ID
+    }
+
+    changed, err := svc.RenameTag(ctx, "старый", "новый")
+    if err != nil || changed != total-1 {
+        t.Fatalf("RenameTag = %d, %v; want %d", changed, err, total-1)
+    }
+    if n, err := svc.GetNote(ctx, untagged); err != nil || len(n.Tags) != 0 || n.Version != 2 {
+        t.Errorf("untagged note: %+v, %v", n, err)
+    }
+    for _, id := range []int64{ids[0], ids[total-1], added} {
+        if n, err := svc.GetNote(ctx, id); err != nil || !slices.Equal(n.Tags, []string{"новый"}) {
+            t.Errorf("note %d: %+v, %v", id, n, err)
+        }
+    }
+    if tags, err := svc.ListTags(ctx); err != nil || !slices.Equal(tags, []repo.TagCount{{Tag: "новый", Count: total - 1}}) {
+        t.Errorf("ListTags = %+v, %v", tags, err)
+    }
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "log"
+    "time"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+// purgeBatch — сколько заметок PurgeTrash выбирает из хранилища за раз.
+const purgeBatch = 100
+
+// GetTrashedNote возвращает заметку из корзины; заметка вне корзины
+// считается ненайденной.
+func (s *NoteService) GetTrashedNote(ctx context.Context, id int64) (*core.Note, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    n, err := s.repo.GetByID(owner, id)
+    if err != nil {
+        return nil, err
+    }
+    if n.DeletedAt == nil {
+        return nil, repo.ErrNoteNotFound
+    }
+    return n, nil
+}
+
+// RestoreNote возвращает заметку из корзины; version != 0 — как в
+// UpdateNote. Для заметки вне корзины возвращает ErrNotInTrash.
+func (s *NoteService) RestoreNote(ctx context.Context, id int64, version int64) (*core.Note, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    var before *core.Note
+    restored, err := s.repo.Update(owner, id, version, func(n *core.Note) error {
+        if n.DeletedAt == nil {
+            return ErrNotInTrash
+        }
+        before = snapshotNote(n)
+        n.DeletedAt = nil
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    s.reindex(restored)
+    s.noteChanged(ctx, core.AuditRestore, before, restored)
+    return restored, nil
+}
+
+// PurgeNote удаляет заметку из корзины насовсем вместе с историей,
+// выданными доступами и публичными ссылками; version != 0 — как в
+// UpdateNote. Заметку вне корзины сначала нужно удалить через DeleteNote.
+func (s *NoteService) PurgeNote(ctx context.Context, id int64, version int64) error {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return err
+    }
+    // проверка «в корзине» и удаление — одна транзакция: заметку,
+    // которую восстановили между ними, удалить нельзя
+    var n *core.Note
+    err = s.repo.Batch(func(tx repo.NoteRepository) error {
+        var err error
+        if n, err = tx.GetByID(owner, id); err != nil {
+            return err
+        }
+        if n.DeletedAt == nil {
+            return ErrNotInTrash
+        }
+        return tx.Delete(owner, id, version)
+    })
+    if err != nil {
+        return err
+    }
+    // событие — до удаления доступов: его получают и те, с кем
+    // заметкой поделились
+    s.noteChanged(ctx, core.AuditPurge, n, nil)
+    s.forgetRevisions(id)
+    s.forgetShares(id)
+    s.forgetShareLinks(id)
+    return nil
+}
+
+// PurgeTrash удаляет насовсем заметки всех пользователей, попавшие
+// в корзину раньше olderThan, и возвращает их число; в журнале аудита
+// автором удаления значится сам сервер. Заметку, которую успели
+// восстановить или изменить между выборкой и удалением, защищает
+// проверка версии.
+func (s *NoteService) PurgeTrash(olderThan time.Time) (int, error) {
+    q := repo.NoteQuery{OwnerID: repo.AllOwners, Limit: purgeBatch, Trashed: true, DeletedBefore: &olderThan}
+    purged := 0
+    for {
+        page, err := s.repo.Find(q)
+        if err != nil {
+            return purged, err
+        }
+        for _, n := range page.Notes {
+            err := s.repo.Delete(n.OwnerID, n.ID, n.Version)
+            if errors.Is(err, repo.ErrNoteNotFound) || errors.Is(err, repo.ErrVersionConflict) {
+                continue
+            }
+            if err != nil {
+                return purged, err
+            }
+            s.noteChanged(context.Background(), core.AuditPurge, &n, nil)
+            s.forgetRevisions(n.ID)
+            s.forgetShares(n.ID)
+            s.forgetShareLinks(n.ID)
+            purged++
+        }
+        if page.Next == nil {
+            return purged, nil
+        }
+        q.After = page.Next
+    }
+}
+
+// RunTrashPurger раз в interval удаляет из корзины заметки старше
+// retention. Блокируется до отмены ctx; ошибки только логируются.
+func (s *NoteService) RunTrashPurger(ctx context.Context, interval, retention time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        n, err := s.PurgeTrash(time.Now().Add(-retention))
+        if err != nil {
+            log.Printf("trash: purge: %v", err)
+        } else if n > 0 {
+            log.Printf("trash: purged %d notes", n)
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
@@ -0,0 +1,208 @@
+package service_test
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/core/service"
+    "example.com/notes-api/internal/repo"
+)
+
+// trashedFixture — sharedFixture, заметка которой с публичной ссылкой
+// лежит в корзине.
+func trashedFixture(t *testing.T, opts ...service.Option) sharedFixture {
+    t.Helper()
+    f := newSharedFixture(t, opts...)
+    if _, _, err := f.svc.CreateShareLink(f.alice, f.noteID, nil, ""); err != nil {
+        t.Fatalf("CreateShareLink: %v", err)
+    }
+    if err := f.svc.DeleteNote(f.alice, f.noteID, 0); err != nil {
+        t.Fatalf("DeleteNote: %v", err)
+    }
+    return f
+}
+
+// wantShared проверяет, остались ли у заметки доступы и ссылки.
+func wantShared(t *testing.T, f sharedFixture, want bool) {
+    t.Helper()
+    shares, err := f.shares.ListByNote(f.noteID)
+    if err != nil || (len(shares) == 2) != want {
+        t.Errorf("shares = %+v, %v; want kept: %v", shares, err, want)
+    }
+    links, err := f.links.ListByNote(f.userID(f.alice), f.noteID)
+    if err != nil || (len(links) == 1) != want {
+        t.Errorf("links = %+v, %v; want kept: %v", links, err, want)
+    }
+}
+
+func TestTrashOnlyOwner(t *testing.T) {
+    f := trashedFixture(t)
+
+    // доступ к заметке не даёт права восстановить или удалить её
+    for name, ctx := range map[string]context.Context{"viewer": f.bob, "editor": f.carol, "stranger": f.dave} {
+        if _, err := f.svc.GetTrashedNote(ctx, f.noteID); !errors.Is(err, repo.ErrNoteNotFound) {
+            t.Errorf("%s: GetTrashedNote: err = %v, want ErrNoteNotFound", name, err)
+        }
+        if _, err := f.svc.RestoreNote(ctx, f.noteID, 0); !errors.Is(err, repo.ErrNoteNotFound) {
+            t.Errorf("%s: RestoreNote: err = %v, want ErrNoteNotFound", name, err)
+        }
+        if err := f.svc.PurgeNote(ctx, f.noteID, 0); !errors.Is(err, repo.ErrNoteNotFound) {
+            t.Errorf("%s: PurgeNote: err = %v, want ErrNoteNotFound", name, err)
+        }
+    }
+    if _, err := f.svc.RestoreNote(context.Background(), f.noteID, 0); !errors.Is(err, service.ErrUnauthenticated) {
+        t.Errorf("anonymous: RestoreNote: err = %v, want ErrUnauthenticated", err)
+    }
+    if n, err := f.svc.GetTrashedNote(f.alice, f.noteID); err != nil || n.DeletedAt == nil {
+        t.Fatalf("GetTrashedNote = %+v, %v; want the note in trash", n, err)
+    }
+    wantShared(t, f, true)
+
+    n, err := f.svc.RestoreNote(f.alice, f.noteID, 2)
+    if err != nil || n.DeletedAt != nil || n.Version != 3 {
+        t.Fatalf("RestoreNote = %+v, %v", n, err)
+    }
+    if _, err := f.svc.RestoreNote(f.alice, f.noteID, 0); !errors.Is(err, service.ErrNotInTrash) {
+        t.Errorf("RestoreNote again: err = %v, want ErrNotInTrash", err)
+    }
+    if n, err := f.svc.GetNote(f.bob, f.noteID); err != nil || n.Title != "План" {
+        t.Errorf("viewer after restore: %+v, %v", n, err)
+    }
+}
+
+func TestPurgeNote(t *testing.T) {
+    f := trashedFixture(t)
+
+    if err := f.svc.PurgeNote(f.alice, f.noteID, 1); !errors.Is(err, repo.ErrVersionConflict) {
+        t.Errorf("stale version: err = %v, want ErrVersionConflict", err)
+    }
+    wantShared(t, f, true)
+
+    if err := f.svc.PurgeNote(f.alice, f.noteID, 2); err != nil {
+        t.Fatalf("PurgeNote: %v", err)
+    }
+    if _, err := f.svc.GetTrashedNote(f.alice, f.noteID); !errors.Is(err, repo.ErrNoteNotFound) {
+        t.Errorf("GetTrashedNote: err = %v, want ErrNoteNotFound", err)
+    }
+    wantShared(t, f, false)
+    if err := f.svc.PurgeNote(f.alice, f.noteID, 0); !errors.Is(err, repo.ErrNoteNotFound) {
+        t.Errorf("PurgeNote again: err = %v, want ErrNoteNotFound", err)
+    }
+}
+
+func TestPurgeNoteOutsideTrash(t *testing.T) {
+    f := newSharedFixture(t)
+    if _, _, err := f.svc.CreateShareLink(f.alice, f.noteID, nil, ""); err != nil {
+        t.Fatalf("CreateShareLink: %v", err)
+    }
+
+    // заметка вне корзины не удаляется, и доступы к ней остаются
+    if err := f.svc.PurgeNote(f.alice, f.noteID, 0); !errors.Is(err, service.ErrNotInTrash) {
+        t.Errorf("err = %v, want ErrNotInTrash", err)
+    }
+    wantUnchanged(t, f)
+    wantShared(t, f, true)
+}
+
+func TestPurgeFreesQuota(t *testing.T) {
+    f := trashedFixture(t, service.WithQuota(service.Quota{MaxNotes: 2}))
+    if _, err := f.svc.CreateNote(f.alice, service.NoteCreateInput{Title: "Вторая"}); err != nil {
+        t.Fatalf("CreateNote: %v", err)
+    }
+
+    // заметка в корзине занимает место, пока её не удалят насовсем
+    if _, err := f.svc.CreateNote(f.alice, service.NoteCreateInput{Title: "Третья"}); !errors.Is(err, service.ErrQuotaExceeded) {
+        t.Fatalf("CreateNote: err = %v, want ErrQuotaExceeded", err)
+    }
+    if err := f.svc.PurgeNote(f.alice, f.noteID, 0); err != nil {
+        t.Fatalf("PurgeNote: %v", err)
+    }
+    if u, err := f.notes.Usage(f.userID(f.alice)); err != nil || u.Notes != 1 || u.Bytes != int64(len("Вторая")) {
+        t.Errorf("Usage = %+v, %v", u, err)
+    }
+    if _, err := f.svc.CreateNote(f.alice, service.NoteCreateInput{Title: "Третья"}); err != nil {
+        t.Errorf("CreateNote after purge: %v", err)
+    }
+}
+
+// changingRepo вызывает onFind после каждой выборки страницы, чтобы
+// заметки менялись между страницами PurgeTrash.
+type changingRepo struct {
+    *repo.NoteRepoMem
+    finds  int
+    onFind func(finds int)
+}
+
+func (r *changingRepo) Find(q repo.NoteQuery) (repo.NotePage, error) {
+    page, err := r.NoteRepoMem.Find(q)
+    r.finds++
+    r.onFind(r.finds)
+    return page, err
+}
+
+func TestPurgeTrashSkipsChangedNotes(t *testing.T) {
+    notes := &changingRepo{NoteRepoMem: repo.NewNoteRepoMem(), onFind: func(int) {}}
+    svc := service.NewNoteService(notes)
+    ctx := core.WithPrincipal(context.Background(), core.Principal{UserID: 1, Username: "alice"})
+
+    // больше одной страницы PurgeTrash; последняя заметка остаётся вне корзины
+    const total = 250
+    ids := make([]int64, total)
+    for i := range ids {
+        n, err := svc.CreateNote(ctx, service.NoteCreateInput{Title: "Заметка"})
+        if err != nil {
+            t.Fatalf("CreateNote: %v", err)
+        }
+        ids[i] = n.ID
+        if i < total-1 {
+            if err := svc.DeleteNote(ctx, n.ID, 0); err != nil {
+                t.Fatalf("DeleteNote: %v", err)
+            }
+        }
+    }
+
+    // после первой страницы одну её заметку правят в корзине (её версия
+    // устаревает), а заметку со второй страницы восстанавливают
+    edited, restored := ids[10], ids[150]
+    notes.onFind = func(finds int) {
+        if finds != 1 {
+            return
+        }
+        if _, err := notes.Update(1, edited, 0, func(n *core.Note) error {
+            n.Title = "Правка"
+            return nil
+        }); err != nil {
+            t.Errorf("Update: %v", err)
+        }
+        if _, err := svc.RestoreNote(ctx, restored, 0); err != nil {
+            t.Errorf("RestoreNote: %v", err)
+        }
+    }
+
+    purged, err := svc.PurgeTrash(time.Now().Add(time.Hour))
+    if err != nil || purged != total-3 {
+        t.Fatalf("PurgeTrash = %d, %v; want %d", purged, err, total-3)
+    }
+    if notes.finds != 3 {
+        t.Errorf("%d pages, want 3", notes.finds)
+    }
+    if n, err := svc.GetTrashedNote(ctx, edited); err != nil || n.Title != "Правка" {
+        t.Errorf("edited note: %+v, %v; want it kept in trash", n, err)
+    }
+    for _, id := range []int64{restored, ids[total-1]} {
+        if _, err := svc.GetNote(ctx, id); err != nil {
+            t.Errorf("GetNote(%d): %v", id, err)
+        }
+    }
+
+    // при следующем запуске удаляется и изменённая заметка
+    if purged, err := svc.PurgeTrash(time.Now().Add(time.Hour)); err != nil || purged != 1 {
+        t.Errorf("second PurgeTrash = %d, %v; want 1", purged, err)
+    }
+    if u, err := notes.Usage(1); err != nil || u.Notes != 2 {
+        t.Errorf("Usage = %+v, %v; want 2 notes", u, err)
+    }
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "golang.org/x/text/language"
+
+    "example.com/notes-api/internal/i18n"
+)
+
+// Коды нарушенных правил в Violation.Code.
+const (
+    CodeRequired    = "required"    // значение не задано или пустое
+    CodeTooShort    = "too_short"   // короче допустимого
+    CodeTooLong     = "too_long"    // длиннее допустимого
+    CodeTooMany     = "too_many"    // элементов больше допустимого
+    CodeInvalid     = "invalid"     // неверный формат или недопустимое сочетание
+    CodeUnsupported = "unsupported" // значение не из списка допустимых
+    CodeInPast      = "in_past"     // момент времени уже прошёл
+)
+
+// Violation — нарушенное правило проверки входных данных.
+// @Description Нарушенное правило проверки входных данных
+type Violation struct {
+    // Поле запроса; элемент массива — с индексом: tags[2]
+    Field string `json:"field" example:"title"`
+    // Код правила: required, too_short, too_long, too_many, invalid, unsupported, in_past
+    Code string `json:"code" example:"required"`
+    // Описание для человека на языке из Accept-Language
+    Message string `json:"message" example:"title is required"`
+
+    // format и args — из чего построено Message (см. Localize)
+    format string
+    args   []any
+}
+
+// newViolation возвращает нарушение с сообщением fmt.Sprintf(format, args...).
+func newViolation(field, code, format string, args ...any) Violation {
+    return Violation{Field: field, Code: code, Message: fmt.Sprintf(format, args...), format: format, args: args}
+}
+
+// Localize возвращает нарушение с сообщением на языке lang.
+func (v Violation) Localize(lang language.Tag) Violation {
+    if v.format != "" {
+        v.Message = i18n.Sprintf(lang, v.format, v.args...)
+    }
+    return v
+}
+
+// ValidationError — входные данные нарушают одно или несколько правил;
+// Violations перечисляет все найденные нарушения. errors.Is(err,
+// ErrValidation) для него истинно.
+type ValidationError struct {
+    Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+    msgs := make([]string, len(e.Violations))
+    for i, v := range e.Violations {
+        msgs[i] = v.Message
+    }
+    return "validation error: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+    return target == ErrValidation
+}
+
+// invalid возвращает ValidationError с одним нарушением; сообщение —
+// fmt.Sprintf(format, args...), format — ключ каталога i18n.
+func invalid(field, code, format string, args ...any) error {
+    return &ValidationError{Violations: []Violation{newViolation(field, code, format, args...)}}
+}
+
+// violations собирает нарушения, чтобы сообщить обо всех сразу.
+type violations []Violation
+
+func (v *violations) add(field, code, format string, args ...any) {
+    *v = append(*v, newViolation(field, code, format, args...))
+}
+
+// collect забирает нарушения из ValidationError; другую ошибку
+// возвращает как есть.
+func (v *violations) collect(err error) error {
+    var verr *ValidationError
+    if errors.As(err, &verr) {
+        *v = append(*v, verr.Violations...)
+        return nil
+    }
+    return err
+}
+
+// err возвращает ValidationError, если нарушения есть, иначе nil.
+func (v violations) err() error {
+    if len(v) == 0 {
+        return nil
+    }
+    return &ValidationError{Violations: v}
+}
@@ -0,0 +1,572 @@
+package service
+
+import (
+    "bytes"
+    "context"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "net"
+    "net/http"
+    "net/netip"
+    "net/url"
+    "slices"
+    "strconv"
+    "strings"
+    "sync"
+    "syscall"
+    "time"
+    "unicode/utf8"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+var (
+    ErrTooManyWebhooks = errors.New("too many webhooks")
+    // ErrWebhookAddressBlocked — адрес получателя внутренний (loopback,
+    // частная или link-local сеть) и не входит в WebhookOptions.AllowedNets.
+    ErrWebhookAddressBlocked = errors.New("webhook address is not allowed")
+)
+
+const (
+    // WebhookSecretPrefix начинает секрет подписи веб-хука.
+    WebhookSecretPrefix = "whsec_"
+    // MaxWebhooksPerUser — сколько веб-хуков может завести пользователь.
+    MaxWebhooksPerUser = 20
+    // MaxWebhookURLLength — максимальная длина адреса веб-хука.
+    MaxWebhookURLLength = 2048
+    // MaxDeliveryPageSize — сколько доставок отдаёт журнал за раз.
+    MaxDeliveryPageSize = 500
+
+    // Заголовки запроса к получателю.
+    WebhookEventHeader     = "X-Webhook-Event"
+    WebhookDeliveryHeader  = "X-Webhook-Delivery"
+    WebhookSignatureHeader = "X-Webhook-Signature"
+
+    // webhookPollInterval — как часто диспетчер проверяет, не подошло ли
+    // время повторных попыток.
+    webhookPollInterval = time.Second
+    // webhookMaxError — сколько символов ошибки попытки сохраняется.
+    webhookMaxError = 500
+)
+
+// WebhookEvents — события, на которые можно подписаться.
+var WebhookEvents = []string{core.EventNoteCreated, core.EventNoteUpdated, core.EventNoteDeleted}
+
+// WebhookOptions — настройки доставки. Нулевые поля заменяются
+// значениями по умолчанию.
+type WebhookOptions struct {
+    // MaxAttempts — сколько раз пытаться доставить событие, прежде чем
+    // отправить его в dead letter (по умолчанию 8).
+    MaxAttempts int
+    // Backoff — пауза перед второй попыткой; дальше она удваивается
+    // (по умолчанию 30s).
+    Backoff time.Duration
+    // MaxBackoff — предел паузы между попытками (по умолчанию 1h).
+    MaxBackoff time.Duration
+    // Timeout — сколько ждать ответа получателя (по умолчанию 10s).
+    Timeout time.Duration
+    // Workers — сколько доставок выполняется одновременно (по умолчанию 4).
+    Workers int
+    // Retention — сколько хранить завершённые доставки (по умолчанию 7 дней).
+    Retention time.Duration
+    // AllowedNets — внутренние сети, куда всё же можно доставлять события.
+    // Остальные loopback, частные и link-local адреса запрещены, чтобы
+    // веб-хук не стал доступом к внутренним сервисам (SSRF).
+    AllowedNets []netip.Prefix
+}
+
+// allows сообщает, можно ли доставлять события на адрес addr.
+func (o WebhookOptions) allows(addr netip.Addr) bool {
+    addr = addr.Unmap()
+    internal := addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+        addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+        addr.IsInterfaceLocalMulticast() || addr.IsMulticast()
+    if !internal {
+        return true
+    }
+    for _, p := range o.AllowedNets {
+        if p.Contains(addr) {
+            return true
+        }
+    }
+    return false
+}
+
+// dialControl проверяет адрес перед каждым соединением с получателем —
+// уже после разрешения имени, так что запрет не обойти DNS-записью,
+// указывающей на внутренний адрес.
+func (o WebhookOptions) dialControl(_, address string, _ syscall.RawConn) error {
+    ap, err := netip.ParseAddrPort(address)
+    if err != nil {
+        return err
+    }
+    if !o.allows(ap.Addr()) {
+        return fmt.Errorf("%w: %s", ErrWebhookAddressBlocked, ap.Addr())
+    }
+    return nil
+}
+
+func (o *WebhookOptions) setDefaults() {
+    if o.MaxAttempts <= 0 {
+        o.MaxAttempts = 8
+    }
+    if o.Backoff <= 0 {
+        o.Backoff = 30 * time.Second
+    }
+    if o.MaxBackoff <= 0 {
+        o.MaxBackoff = time.Hour
+    }
+    if o.Timeout <= 0 {
+        o.Timeout = 10 * time.Second
+    }
+    if o.Workers <= 0 {
+        o.Workers = 4
+    }
+    if o.Retention <= 0 {
+        o.Retention = 7 * 24 * time.Hour
+    }
+}
+
+// backoff — пауза после attempts неудачных попыток.
+func (o WebhookOptions) backoff(attempts int) time.Duration {
+    d := o.Backoff
+    for i := 1; i < attempts && d < o.MaxBackoff; i++ {
+        d *= 2
+    }
+    return min(d, o.MaxBackoff)
+}
+
+// WebhookService управляет веб-хуками и доставляет им события заметок.
+// Событие сначала сохраняется как доставка, а отправляет его RunDispatcher
+// в фоне, так что запрос, изменивший заметку, получателя не ждёт.
+type WebhookService struct {
+    hooks  repo.WebhookRepository
+    opts   WebhookOptions
+    client *http.Client
+
+    wake chan struct{}
+    // inFlight — доставки, которые отправляются прямо сейчас: пока
+    // попытка не записана, хранилище ещё считает их ожидающими.
+    mu       sync.Mutex
+    inFlight map[int64]bool
+}
+
+func NewWebhookService(hooks repo.WebhookRepository, opts WebhookOptions) *WebhookService {
+    opts.setDefaults()
+    dialer := &net.Dialer{Timeout: opts.Timeout, Control: opts.dialControl}
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    transport.Proxy = nil // через прокси проверка адреса получателя не сработала бы
+    transport.DialContext = dialer.DialContext
+    return &WebhookService{
+        hooks: hooks,
+        opts:  opts,
+        client: &http.Client{
+            Transport: transport,
+            Timeout:   opts.Timeout,
+            // перенаправление — неудачная попытка: подписанное событие
+            // уходит только на зарегистрированный адрес
+            CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+        },
+        wake:     make(chan struct{}, 1),
+        inFlight: make(map[int64]bool),
+    }
+}
+
+// WithWebhooks подключает веб-хуки: сервис сообщает им о создании,
+// изменении и удалении заметок.
+func WithWebhooks(w *WebhookService) Option {
+    return func(s *NoteService) {
+        s.webhooks = w
+    }
+}
+
+// normalizeWebhookURL проверяет, что адрес абсолютный http(s) и не
+// указывает на запрещённый внутренний адрес. Имена хостов проверяются
+// при каждом соединении (см. dialControl), здесь — только IP-адреса
+// и localhost, чтобы такой веб-хук не регистрировался вовсе.
+func (s *WebhookService) normalizeWebhookURL(raw string) (string, error) {
+    if raw == "" {
+        return "", invalid("url", CodeRequired, "%s is required", "url")
+    }
+    if len(raw) > MaxWebhookURLLength {
+        return "", invalid("url", CodeTooLong, "%s must be at most %d bytes", "url", MaxWebhookURLLength)
+    }
+    u, err := url.Parse(raw)
+    if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+        return "", invalid("url", CodeInvalid, "%s must be an absolute http or https URL", "url")
+    }
+    if u.User != nil {
+        return "", invalid("url", CodeInvalid, "%s must not contain credentials", "url")
+    }
+    var addrs []netip.Addr
+    host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+    if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+        addrs = []netip.Addr{netip.AddrFrom4([4]byte{127, 0, 0, 1}), netip.IPv6Loopback()}
+    } else if addr, err := netip.ParseAddr(host); err == nil {
+        addrs = []netip.Addr{addr}
+    }
+    if len(addrs) > 0 && !slices.ContainsFunc(addrs, s.opts.allows) {
+        return "", invalid("url", CodeInvalid, "%s must not point to a private address", "url")
+    }
+    u.Fragment = ""
+    return u.String(), nil
+}
+
+// Create регистрирует веб-хук текущего пользователя. Секрет подписи
+// возвращается только здесь.
+func (s *WebhookService) Create(ctx context.Context, rawURL string, events []string) (*core.Webhook, string, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, "", err
+    }
+    var v violations
+    target, err := s.normalizeWebhookURL(rawURL)
+    if err := v.collect(err); err != nil {
+        return nil, "", err
+    }
+    events, err = pickKnown("events", events, WebhookEvents)
+    if err := v.collect(err); err != nil {
+        return nil, "", err
+    }
+    if err := v.err(); err != nil {
+        return nil, "", err
+    }
+    existing, err := s.hooks.List(owner)
+    if err != nil {
+        return nil, "", err
+    }
+    if len(existing) >= MaxWebhooksPerUser {
+        return nil, "", ErrTooManyWebhooks
+    }
+
+    raw := make([]byte, tokenBytes)
+    if _, err := rand.Read(raw); err != nil {
+        return nil, "", err
+    }
+    secret := WebhookSecretPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+    h := core.Webhook{
+        UserID:    owner,
+        URL:       target,
+        Events:    events,
+        Secret:    secret,
+        CreatedAt: time.Now().UTC(),
+    }
+    id, err := s.hooks.Create(h)
+    if err != nil {
+        return nil, "", err
+    }
+    h.ID = id
+    return &h, secret, nil
+}
+
+// List возвращает веб-хуки текущего пользователя.
+func (s *WebhookService) List(ctx context.Context) ([]core.Webhook, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return s.hooks.List(owner)
+}
+
+// Delete удаляет веб-хук вместе с журналом доставок; неотправленные
+// события пропадают.
+func (s *WebhookService) Delete(ctx context.Context, id int64) error {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return err
+    }
+    return s.hooks.Delete(owner, id)
+}
+
+// ListDeliveries возвращает последние доставки веб-хука, от новых к
+// старым; status == core.DeliveryDead — dead letter. Limit приводится к
+// диапазону [1, MaxDeliveryPageSize].
+func (s *WebhookService) ListDeliveries(ctx context.Context, webhookID int64, status core.DeliveryStatus, limit int) ([]core.WebhookDelivery, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if status != "" && !status.Valid() {
+        return nil, invalid("status", CodeUnsupported, "%s must be one of %s", "status", "pending, delivered, dead")
+    }
+    if _, err := s.hooks.Get(owner, webhookID); err != nil {
+        return nil, err
+    }
+    if limit <= 0 {
+        limit = DefaultPageSize
+    }
+    return s.hooks.ListDeliveries(repo.DeliveryQuery{
+        UserID:    owner,
+        WebhookID: webhookID,
+        Status:    status,
+        Limit:     min(limit, MaxDeliveryPageSize),
+    })
+}
+
+// RetryDelivery ставит доставку в очередь заново с полным набором
+// попыток — обычно чтобы достать событие из dead letter после того, как
+// получатель починен.
+func (s *WebhookService) RetryDelivery(ctx context.Context, webhookID, id int64) (*core.WebhookDelivery, error) {
+    owner, err := ownerFrom(ctx)
+    if err != nil {
+        return nil, err
+    }
+    d, err := s.hooks.GetDelivery(owner, webhookID, id)
+    if err != nil {
+        return nil, err
+    }
+    now := time.Now().UTC()
+    d.Status = core.DeliveryPending
+    d.Attempts = 0
+    d.NextAttemptAt = &now
+    if err := s.hooks.UpdateDelivery(*d); err != nil {
+        return nil, err
+    }
+    s.signal()
+    return d, nil
+}
+
+// WebhookPayload — тело запроса к веб-хуку.
+type WebhookPayload struct {
+    // Type — событие: note.created, note.updated или note.deleted.
+    Type string `json:"type"`
+    // Action уточняет событие: update и restore для note.updated,
+    // delete (в корзину) и purge (насовсем) для note.deleted.
+    Action     core.AuditAction `json:"action"`
+    OccurredAt time.Time        `json:"occurredAt"`
+    Note       *core.Note       `json:"note"`
+}
+
+// webhookEvent — событие веб-хука для изменения заметки action.
+func webhookEvent(action core.AuditAction) string {
+    switch action {
+    case core.AuditCreate:
+        return core.EventNoteCreated
+    case core.AuditDelete, core.AuditPurge:
+        return core.EventNoteDeleted
+    }
+    return core.EventNoteUpdated
+}
+
+// Publish ставит событие об изменении заметки n в очередь каждого
+// подписанного веб-хука её владельца. Заметка уже записана, поэтому
+// ошибки только логируются.
+func (s *WebhookService) Publish(action core.AuditAction, n *core.Note) {
+    event := webhookEvent(action)
+    hooks, err := s.hooks.List(n.OwnerID)
+    if err != nil {
+        log.Printf("webhooks: list hooks of user %d: %v", n.OwnerID, err)
+        return
+    }
+    now := time.Now().UTC()
+    var payload []byte
+    queued := false
+    for _, h := range hooks {
+        if !slices.Contains(h.Events, event) {
+            continue
+        }
+        if payload == nil {
+            if payload, err = json.Marshal(WebhookPayload{Type: event, Action: action, OccurredAt: now, Note: n}); err != nil {
+                log.Printf("webhooks: encode %s for note %d: %v", event, n.ID, err)
+                return
+            }
+        }
+        _, err := s.hooks.AddDelivery(core.WebhookDelivery{
+            WebhookID:     h.ID,
+            UserID:        h.UserID,
+            Event:         event,
+            NoteID:        n.ID,
+            Payload:       payload,
+            Status:        core.DeliveryPending,
+            CreatedAt:     now,
+            NextAttemptAt: &now,
+        })
+        if err != nil && !errors.Is(err, repo.ErrWebhookNotFound) {
+            log.Printf("webhooks: queue %s for hook %d: %v", event, h.ID, err)
+            continue
+        }
+        queued = queued || err == nil
+    }
+    if queued {
+        s.signal()
+    }
+}
+
+// signal будит диспетчер, не дожидаясь очередного опроса.
+func (s *WebhookService) signal() {
+    select {
+    case s.wake <- struct{}{}:
+    default:
+    }
+}
+
+// RunDispatcher отправляет ожидающие доставки и раз в час удаляет из
+// журнала старые завершённые. Блокируется до отмены ctx и дожидается
+// начатых попыток.
+func (s *WebhookService) RunDispatcher(ctx context.Context) {
+    var workers sync.WaitGroup
+    defer workers.Wait()
+    sem := make(chan struct{}, s.opts.Workers)
+
+    ticker := time.NewTicker(webhookPollInterval)
+    defer ticker.Stop()
+    var lastPrune time.Time
+
+    for {
+        if time.Since(lastPrune) >= time.Hour {
+            lastPrune = time.Now()
+            if n, err := s.hooks.PruneDeliveries(lastPrune.Add(-s.opts.Retention)); err != nil {
+                log.Printf("webhooks: prune deliveries: %v", err)
+            } else if n > 0 {
+                log.Printf("webhooks: pruned %d deliveries", n)
+            }
+        }
+
+        due, err := s.hooks.DueDeliveries(time.Now(), s.opts.Workers*4)
+        if err != nil {
+            log.Printf("webhooks: list due deliveries: %v", err)
+        }
+        for _, d := range due {
+            if !s.claim(d.ID) {
+                continue
+            }
+            select {
+            case sem <- struct{}{}:
+            case <-ctx.Done():
+                s.release(d.ID)
+                return
+            }
+            workers.Add(1)
+            go func() {
+                defer workers.Done()
+                defer func() { <-sem }()
+                defer s.release(d.ID)
+                s.attempt(ctx, d)
+            }()
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-s.wake:
+        case <-ticker.C:
+        }
+    }
+}
+
+func (s *WebhookService) claim(id int64) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.inFlight[id] {
+        return false
+    }
+    s.inFlight[id] = true
+    return true
+}
+
+func (s *WebhookService) release(id int64) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.inFlight, id)
+}
+
+// attempt отправляет доставку и записывает результат: 2xx — доставлено,
+// иначе следующая попытка через backoff или, если попытки исчерпаны,
+// dead letter.
+func (s *WebhookService) attempt(ctx context.Context, d core.WebhookDelivery) {
+    h, err := s.hooks.Get(d.UserID, d.WebhookID)
+    if errors.Is(err, repo.ErrWebhookNotFound) {
+        return // веб-хук удалён вместе с доставками
+    }
+    if err != nil {
+        log.Printf("webhooks: get hook %d: %v", d.WebhookID, err)
+        return
+    }
+
+    status, sendErr := s.send(ctx, h, d)
+    if sendErr != nil && ctx.Err() != nil {
+        return // остановка сервера: попытка не засчитывается
+    }
+
+    now := time.Now().UTC()
+    d.Attempts++
+    d.LastAttemptAt = &now
+    d.ResponseStatus = status
+    d.LastError = ""
+    switch {
+    case sendErr == nil:
+        d.Status = core.DeliveryDelivered
+        d.NextAttemptAt = nil
+    case d.Attempts >= s.opts.MaxAttempts:
+        d.Status = core.DeliveryDead
+        d.NextAttemptAt = nil
+    default:
+        next := now.Add(s.opts.backoff(d.Attempts))
+        d.NextAttemptAt = &next
+    }
+    if sendErr != nil {
+        d.LastError = truncate(sendErr.Error(), webhookMaxError)
+    }
+    if err := s.hooks.UpdateDelivery(d); err != nil && !errors.Is(err, repo.ErrDeliveryNotFound) {
+        log.Printf("webhooks: update delivery %d: %v", d.ID, err)
+    }
+}
+
+// send выполняет одну попытку и возвращает HTTP-статус ответа (0, если
+// ответа нет) и ошибку, если получатель не ответил 2xx.
+func (s *WebhookService) send(ctx context.Context, h *core.Webhook, d core.WebhookDelivery) (int, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(d.Payload))
+    if err != nil {
+        return 0, err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("User-Agent", "notes-api-webhooks/1.0")
+    req.Header.Set(WebhookEventHeader, d.Event)
+    req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(d.ID, 10))
+    req.Header.Set(WebhookSignatureHeader, SignWebhook(h.Secret, time.Now(), d.Payload))
+
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return 0, err
+    }
+    defer resp.Body.Close()
+    _, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+    }
+    return resp.StatusCode, nil
+}
+
+// SignWebhook возвращает значение X-Webhook-Signature:
+// "t=<unix-время>,v1=<hex HMAC-SHA256 от "<t>.<тело>">". Получатель
+// пересчитывает подпись своим секретом и отбрасывает запросы со старым t,
+// чтобы перехваченное событие нельзя было повторить.
+func SignWebhook(secret string, at time.Time, body []byte) string {
+    ts := strconv.FormatInt(at.Unix(), 10)
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte(ts))
+    mac.Write([]byte{'.'})
+    mac.Write(body)
+    return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// truncate обрезает s до n байт, не разрывая символ UTF-8.
+func truncate(s string, n int) string {
+    if len(s) <= n {
+        return s
+    }
+    for n > 0 && !utf8.RuneStart(s[n]) {
+        n--
+    }
+    return s[:n]
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "net/netip"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "example.com/notes-api/internal/core"
+    "example.com/notes-api/internal/repo"
+)
+
+// loopbackNets разрешает доставку на httptest.Server.
+var loopbackNets = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+func newTestWebhooks(opts WebhookOptions) (*WebhookService, repo.WebhookRepository, context.Context) {
+    hooks := repo.NewWebhookRepoMem()
+    ctx := core.WithPrincipal(context.Background(), core.Principal{UserID: 1})
+    return NewWebhookService(hooks, opts), hooks, ctx
+}
+
+// deliverDue выполняет по одной попытке для каждой ожидающей доставки,
+// не дожидаясь пауз между попытками.
+func deliverDue(t *testing.T, s *WebhookService) {
+    t.Helper()
+    due, err := s.hooks.DueDeliveries(time.Now().Add(24*time.Hour), 100)
+    if err != nil {
+        t.Fatalf("DueDeliveries: %v", err)
+    }
+    for _, d := range due {
+        s.attempt(context.Background(), d)
+    }
+}
+
+func TestWebhookURLBlocksPrivateAddresses(t *testing.T) {
+    s, _, ctx := newTestWebhooks(WebhookOptions{})
+    for _, raw := range []string{
+        "http://127.0.0.1:8080/hook",
+        "http://localhost/hook",
+        "http://api.localhost./hook",
+        "http://10.1.2.3/hook",
+        "http://192.168.0.10/hook",
+        "http://169.254.169.254/latest/meta-data",
+        "http://[::1]/hook",
+        "http://[::ffff:127.0.0.1]/hook",
+        "http://0.0.0.0/hook",
+    } {
+        if _, _, err := s.Create(ctx, raw, []string{core.EventNoteCreated}); !errors.Is(err, ErrValidation) {
+            t.Errorf("Create(%s): err = %v, want ErrValidation", raw, err)
+        }
+    }
+    if _, _, err := s.Create(ctx, "https://93.184.216.34/hook", []string{core.EventNoteCreated}); err != nil {
+        t.Errorf("Create(public address): %v", err)
+    }
+
+    allowed, _, ctx := newTestWebhooks(WebhookOptions{AllowedNets: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}})
+    if _, _, err := allowed.Create(ctx, "http://10.1.2.3/hook", []string{core.EventNoteCreated}); err != nil {
+        t.Errorf("Create(allowed network): %v", err)
+    }
+}
+
+func TestWebhookDialBlocksPrivateAddresses(t *testing.T) {
+    var calls atomic.Int32
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        calls.Add(1)
+    }))
+    defer srv.Close()
+
+    // веб-хук с именем хоста, которое разрешилось во внутренний адрес:
+    // при регистрации его не поймать, остановить должен dialer
+    s, hooks, _ := newTestWebhooks(WebhookOptions{})
+    id, err := hooks.Create(core.Webhook{UserID: 1, URL: srv.URL, Events: WebhookEvents, Secret: "whsec_test"})
+    if err != nil {
+        t.Fatalf("Create: %v", err)
+    }
+    s.Publish(core.AuditCreate, &core.Note{ID: 7, OwnerID: 1, Title: "t"})
+    deliverDue(t, s)
+
+    if n := calls.Load(); n != 0 {
+        t.Errorf("receiver got %d requests, want 0", n)
+    }
+    ds, err := hooks.ListDeliveries(repo.DeliveryQuery{UserID: 1, WebhookID: id, Limit: 10})
+    if err != nil || len(ds) != 1 {
+        t.Fatalf("ListDeliveries = %+v, %v", ds, err)
+    }
+    if !strings.Contains(ds[0].LastError, ErrWebhookAddressBlocked.Error()) {
+        t.Errorf("LastError = %q, want %q", ds[0].LastError, ErrWebhookAddressBlocked)
+    }
+}
+
+func TestSignWebhook(t *testing.T) {
+    at := time.Unix(1733659200, 0)
+    got := SignWebhook("whsec_test", at, []byte(`{"type":"note.created"}`))
+    // HMAC-SHA256("whsec_test", "1733659200.{"type":"note.created"}")
+    want := "t=1733659200,v1=" + hmacHex(t, "whsec_test", `1733659200.{"type":"note.created"}`)
+    if got != want {
+        t.Errorf("SignWebhook = %q, want %q", got, want)
+    }
+    if other := SignWebhook("whsec_other", at, []byte(`{"type":"note.created"}`)); other == got {
+        t.Error("signature does not depend on the secret")
+    }
+}
+
+func TestWebhookBackoff(t *testing.T) {
+    o := WebhookOptions{Backoff: time.Second, MaxBackoff: 10 * time.Second}
+    for attempts, want := range map[int]time.Duration{
+        1:  time.Second,
+        2:  2 * time.Second,
+        3:  4 * time.Second,
+        4:  8 * time.Second,
+        5:  10 * time.Second,
+        50: 10 * time.Second,
+    } {
+        if got := o.backoff(attempts); got != want {
+            t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+        }
+    }
+}
+
+// flakyReceiver — получатель, который отвечает 503 первые fails раз,
+// а потом 204, и проверяет подпись каждого запроса так, как это сделал
+// бы настоящий получатель.
+type flakyReceiver struct {
+    t      *testing.T
+    secret string
+    fails  int32
+    calls  atomic.Int32
+}
+
+func (f *flakyReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    body, err := io.ReadAll(r.Body)
+    if err != nil {
+        f.t.Errorf("read body: %v", err)
+    }
+    sig := r.Header.Get(WebhookSignatureHeader)
+    ts, _, _ := strings.Cut(strings.TrimPrefix(sig, "t="), ",")
+    if want := "t=" + ts + ",v1=" + hmacHex(f.t, f.secret, ts+"."+string(body)); sig != want {
+        f.t.Errorf("%s = %q, want %q", WebhookSignatureHeader, sig, want)
+    }
+    if r.Header.Get(WebhookEventHeader) != core.EventNoteCreated {
+        f.t.Errorf("%s = %q", WebhookEventHeader, r.Header.Get(WebhookEventHeader))
+    }
+    if f.calls.Add(1) <= f.fails {
+        w.WriteHeader(http.StatusServiceUnavailable)
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func hmacHex(t *testing.T, secret, msg string) string {
+    t.Helper()
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte(msg))
+    return hex.EncodeToString(mac.Sum(nil))
+}
+
+// startDelivery регистрирует веб-хук на адрес receiver и публикует одно
+// событие note.created.
+func startDelivery(t *testing.T, opts WebhookOptions, receiver *flakyReceiver) (*WebhookService, context.Context, *core.Webhook) {
+    t.Helper()
+    srv := httptest.NewServer(receiver)
+    t.Cleanup(srv.Close)
+
+    opts.AllowedNets = loopbackNets
+    s, _, ctx := newTestWebhooks(opts)
+    h, secret, err := s.Create(ctx, srv.URL+"/hook", []string{core.EventNoteCreated})
+    if err != nil {
+        t.Fatalf("Create: %v", err)
+    }
+    receiver.t, receiver.secret = t, secret
+    s.Publish(core.AuditCreate, &core.Note{ID: 7, OwnerID: 1, Title: "t"})
+    return s, ctx, h
+}
+
+func onlyDelivery(t *testing.T, s *WebhookService, ctx context.Context, hookID int64) core.WebhookDelivery {
+    t.Helper()
+    ds, err := s.ListDeliveries(ctx, hookID, "", 10)
+    if err != nil || len(ds) != 1 {
+        t.Fatalf("ListDeliveries = %+v, %v; want one delivery", ds, err)
+    }
+    return ds[0]
+}
+
+func TestWebhookRetriesUntilDelivered(t *testing.T) {
+    receiver := &flakyReceiver{fails: 2}
+    opts := WebhookOptions{MaxAttempts: 5, Backoff: time.Minute, MaxBackoff: time.Hour}
+    s, ctx, h := startDelivery(t, opts, receiver)
+
+    for attempt := 1; attempt <= 2; attempt++ {
+        before := time.Now()
+        deliverDue(t, s)
+        d := onlyDelivery(t, s, ctx, h.ID)
+        if d.Status != core.DeliveryPending || d.Attempts != attempt || d.ResponseStatus != http.StatusServiceUnavailable {
+            t.Fatalf("after attempt %d: %+v", attempt, d)
+        }
+        wait := opts.backoff(attempt)
+        if d.NextAttemptAt == nil || d.NextAttemptAt.Before(before.Add(wait)) || d.NextAttemptAt.After(time.Now().Add(wait)) {
+            t.Errorf("after attempt %d: NextAttemptAt = %v, want now + %v", attempt, d.NextAttemptAt, wait)
+        }
+    }
+    deliverDue(t, s)
+    d := onlyDelivery(t, s, ctx, h.ID)
+    if d.Status != core.DeliveryDelivered || d.Attempts != 3 || d.NextAttemptAt != nil || d.LastError != "" {
+        t.Errorf("final delivery = %+v", d)
+    }
+    if n := receiver.calls.Load(); n != 3 {
+        t.Errorf("receiver got %d requests, want 3", n)
+    }
+}
+
+func TestWebhookDeadLetterAndRetry(t *testing.T) {
+    receiver := &flakyReceiver{fails: 3}
+    s, ctx, h := startDelivery(t, WebhookOptions{MaxAttempts: 3, Backoff: time.Minute}, receiver)
+
+    for i := 0; i < 3; i++ {
+        deliverDue(t, s)
+    }
+    d := onlyDelivery(t, s, ctx, h.ID)
+    if d.Status != core.DeliveryDead || d.Attempts != 3 || d.NextAttemptAt != nil || d.LastError == "" {
+        t.Fatalf("after MaxAttempts: %+v", d)
+    }
+    deliverDue(t, s) // dead letter больше не отправляется
+    if n := receiver.calls.Load(); n != 3 {
+        t.Fatalf("receiver got %d requests, want 3", n)
+    }
+
+    retried, err := s.RetryDelivery(ctx, h.ID, d.ID)
+    if err != nil || retried.Status != core.DeliveryPending || retried.Attempts != 0 {
+        t.Fatalf("RetryDelivery = %+v, %v", retried, err)
+    }
+    deliverDue(t, s)
+    d = onlyDelivery(t, s, ctx, h.ID)
+    if d.Status != core.DeliveryDelivered || d.Attempts != 1 {
+        t.Errorf("after retry: %+v", d)
+    }
+
+    other := core.WithPrincipal(context.Background(), core.Principal{UserID: 2})
+    if _, err := s.RetryDelivery(other, h.ID, d.ID); !errors.Is(err, repo.ErrDeliveryNotFound) {
+        t.Errorf("RetryDelivery by another user: err = %v, want ErrDeliveryNotFound", err)
+    }
+}
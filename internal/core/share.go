@@ -0,0 +1,70 @@
+package core
+
+import "time"
+
+// ShareRole — права пользователя на чужую заметку.
+type ShareRole string
+
+const (
+	// RoleViewer может только читать заметку.
+	RoleViewer ShareRole = "viewer"
+	// RoleEditor может читать и изменять заметку, но не удалять её и не
+	// делиться ею дальше.
+	RoleEditor ShareRole = "editor"
+)
+
+// Valid сообщает, поддерживается ли роль.
+func (r ShareRole) Valid() bool {
+	return r == RoleViewer || r == RoleEditor
+}
+
+// Share — доступ пользователя к заметке другого владельца.
+// @Description Доступ пользователя к заметке
+type Share struct {
+	// ID заметки
+	NoteID int64 `json:"noteId" example:"1"`
+	// ID пользователя, получившего доступ
+	UserID int64 `json:"userId" example:"2"`
+	// Имя пользователя, получившего доступ
+	Username string `json:"username" example:"bob"`
+	// Роль: viewer или editor
+	Role ShareRole `json:"role" example:"viewer"`
+	// Когда доступ выдан впервые
+	CreatedAt time.Time `json:"createdAt" example:"2024-12-08T12:00:00Z"`
+}
+
+// SharedNote — заметка, которой поделились с пользователем, и его роль.
+// @Description Заметка, доступная пользователю по приглашению
+type SharedNote struct {
+	Note Note `json:"note"`
+	// Роль пользователя: viewer или editor
+	Role ShareRole `json:"role" example:"editor"`
+}
+
+// ShareLink — публичная ссылка на заметку только для чтения. Токен
+// ссылки показывается один раз при создании; хранится только его хеш.
+// @Description Публичная ссылка на заметку (без токена)
+type ShareLink struct {
+	// Уникальный идентификатор ссылки
+	ID int64 `json:"id" example:"1"`
+	// ID заметки
+	NoteID int64 `json:"noteId" example:"1"`
+	// Владелец заметки; наружу не отдаётся
+	OwnerID int64 `json:"-"`
+	// Начало токена, чтобы отличать ссылки друг от друга
+	Prefix string `json:"prefix" example:"Zr8QpW1c"`
+	// SHA-256 токена; наружу не отдаётся
+	TokenHash string `json:"-"`
+	// bcrypt-хеш пароля; пустой — ссылка без пароля
+	PasswordHash []byte `json:"-"`
+	// Защищена ли ссылка паролем
+	Protected bool `json:"protected" example:"false"`
+	// Дата и время создания
+	CreatedAt time.Time `json:"createdAt" example:"2024-12-08T12:00:00Z"`
+	// Срок действия; без него ссылка действует до отзыва
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" example:"2024-12-15T12:00:00Z"`
+	// Сколько раз ссылку открывали
+	AccessCount int64 `json:"accessCount" example:"3"`
+	// Когда ссылку открывали последний раз
+	LastAccessAt *time.Time `json:"lastAccessAt,omitempty" example:"2024-12-09T08:30:00Z"`
+}
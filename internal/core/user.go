@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// User — учётная запись пользователя.
+// @Description Пользователь
+type User struct {
+	// Уникальный идентификатор пользователя
+	ID int64 `json:"id" example:"1"`
+	// Имя пользователя (логин), в нижнем регистре
+	Username string `json:"username" example:"alice"`
+	// bcrypt-хеш пароля; наружу не отдаётся
+	PasswordHash []byte `json:"-"`
+	// Дата и время регистрации
+	CreatedAt time.Time `json:"createdAt" example:"2024-12-08T12:00:00Z"`
+}
+
+// Session — сессия, открытая при входе. Хранится только хеш токена:
+// утечка хранилища не даёт войти под чужой сессией.
+type Session struct {
+	TokenHash string
+	UserID    int64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Principal — аутентифицированный вызывающий, от имени которого
+// выполняется запрос.
+type Principal struct {
+	UserID   int64
+	Username string
+	// Subject — идентификатор вызывающего у того, кто его аутентифицировал:
+	// claim sub для JWT, имя пользователя для сессии, начало ключа для
+	// API-ключа.
+	Subject string
+	// Scopes — области доступа API-ключа; nil — все области (сессия, JWT).
+	Scopes []string
+}
+
+// HasScope сообщает, разрешена ли вызывающему область scope.
+func (p Principal) HasScope(scope string) bool {
+	return p.Scopes == nil || slices.Contains(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст с вызывающим p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom извлекает вызывающего из контекста.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Области доступа API-ключей. Сессии и JWT имеют все области.
+const (
+	ScopeNotesRead  = "notes:read"
+	ScopeNotesWrite = "notes:write"
+	// ScopeWebhooksManage — управление веб-хуками, которые отправляют
+	// заметки на внешние адреса.
+	ScopeWebhooksManage = "webhooks:manage"
+	// ScopeKeysManage — управление API-ключами; ключу эту область выдать
+	// нельзя, так что ключ не может выпустить другой ключ.
+	ScopeKeysManage = "keys:manage"
+)
+
+// APIKey — ключ доступа для скриптов и CI. Сам ключ показывается один раз
+// при создании; хранится только его хеш.
+// @Description API-ключ (без секрета)
+type APIKey struct {
+	// Уникальный идентификатор ключа
+	ID int64 `json:"id" example:"1"`
+	// Владелец ключа
+	UserID int64 `json:"userId" example:"1"`
+	// Название, чтобы отличать ключи друг от друга
+	Name string `json:"name" example:"ci"`
+	// Начало ключа для опознания в логах и настройках
+	Prefix string `json:"prefix" example:"nk_Xq3vB1a"`
+	// SHA-256 ключа; наружу не отдаётся
+	KeyHash string `json:"-"`
+	// Области доступа
+	Scopes []string `json:"scopes" example:"notes:read"`
+	// Дата и время создания
+	CreatedAt time.Time `json:"createdAt" example:"2024-12-08T12:00:00Z"`
+	// Срок действия; без него ключ бессрочный
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" example:"2025-12-08T12:00:00Z"`
+	// Когда ключ последний раз использовался (с точностью до минуты)
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" example:"2024-12-09T08:30:00Z"`
+}
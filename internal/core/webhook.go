@@ -0,0 +1,85 @@
+package core
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// События заметок, на которые подписываются веб-хуки и поток событий.
+const (
+	EventNoteCreated = "note.created"
+	EventNoteUpdated = "note.updated"
+	// EventNoteDeleted — заметка перемещена в корзину или удалена насовсем.
+	EventNoteDeleted = "note.deleted"
+)
+
+// Webhook — адрес, на который сервер отправляет события заметок
+// пользователя.
+// @Description Веб-хук (без секрета)
+type Webhook struct {
+	// Уникальный идентификатор веб-хука
+	ID int64 `json:"id" example:"1"`
+	// Владелец веб-хука; события приходят по его заметкам
+	UserID int64 `json:"userId" example:"1"`
+	// Адрес получателя (http или https)
+	URL string `json:"url" example:"https://example.com/hooks/notes"`
+	// События, на которые подписан веб-хук
+	Events []string `json:"events" example:"note.created,note.updated"`
+	// Секрет подписи HMAC-SHA256; показывается только при создании
+	Secret string `json:"-"`
+	// Дата и время создания
+	CreatedAt time.Time `json:"createdAt" example:"2024-12-08T12:00:00Z"`
+}
+
+// DeliveryStatus — состояние доставки события веб-хуку.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending — доставка ждёт очередной попытки.
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered — получатель ответил 2xx.
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead — попытки исчерпаны; доставку можно повторить вручную.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// Valid сообщает, известно ли состояние.
+func (s DeliveryStatus) Valid() bool {
+	switch s {
+	case DeliveryPending, DeliveryDelivered, DeliveryDead:
+		return true
+	}
+	return false
+}
+
+// WebhookDelivery — одно событие для одного веб-хука и история попыток
+// его доставить.
+// @Description Доставка события веб-хуку
+type WebhookDelivery struct {
+	// Уникальный идентификатор доставки; передаётся в X-Webhook-Delivery
+	ID int64 `json:"id" example:"1"`
+	// Веб-хук
+	WebhookID int64 `json:"webhookId" example:"1"`
+	// Владелец веб-хука
+	UserID int64 `json:"-"`
+	// Событие
+	Event string `json:"event" example:"note.updated"`
+	// Заметка, с которой произошло событие
+	NoteID int64 `json:"noteId" example:"1"`
+	// Тело запроса, которое получает веб-хук
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+	// Состояние доставки
+	Status DeliveryStatus `json:"status" example:"pending" enums:"pending,delivered,dead"`
+	// Сколько попыток уже сделано
+	Attempts int `json:"attempts" example:"1"`
+	// Дата и время события
+	CreatedAt time.Time `json:"createdAt" example:"2024-12-08T12:00:00Z"`
+	// Когда будет следующая попытка (только у ожидающих доставок)
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty" example:"2024-12-08T12:01:00Z"`
+	// Когда была последняя попытка
+	LastAttemptAt *time.Time `json:"lastAttemptAt,omitempty" example:"2024-12-08T12:00:30Z"`
+	// HTTP-статус последнего ответа получателя
+	ResponseStatus int `json:"responseStatus,omitempty" example:"503"`
+	// Ошибка последней попытки
+	LastError string `json:"lastError,omitempty" example:"unexpected status 503"`
+}
@@ -0,0 +1,226 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/http/handlers"
+	"example.com/notes-api/internal/i18n"
+)
+
+// authRealm — realm в заголовке WWW-Authenticate.
+const authRealm = "notes-api"
+
+// APIKeyHeader — заголовок, в котором скрипты передают API-ключ.
+const APIKeyHeader = "X-API-Key"
+
+// Authenticator — middleware, пропускающий только запросы с действующим
+// токеном в Authorization: Bearer или ключом в APIKeyHeader. Принимаются
+// токены сессий из /auth/login, если задан JWT — JWT других сервисов, а
+// если задан APIKeys — API-ключи. Вызывающий кладётся в контекст запроса
+// (core.PrincipalFrom).
+type Authenticator struct {
+	Sessions *service.AuthService
+	// JWT — проверка JWT; nil — JWT не принимаются.
+	JWT *JWTVerifier
+	// APIKeys — проверка API-ключей; nil — ключи не принимаются.
+	APIKeys *service.APIKeyService
+}
+
+// bearerError — ошибка аутентификации в терминах RFC 6750, раздел 3.
+type bearerError struct {
+	status      int
+	code        string // invalid_request, invalid_token, insufficient_scope
+	description string
+	scope       string // область, которой не хватило
+}
+
+// write отвечает ошибкой с заголовком WWW-Authenticate.
+func (e bearerError) write(w http.ResponseWriter, r *http.Request) {
+	challenge := fmt.Sprintf("Bearer realm=%q", authRealm)
+	msg := "authentication required"
+	if e.description != "" {
+		msg = e.description
+	}
+	if e.code != "" {
+		challenge += fmt.Sprintf(", error=%q, error_description=%q", e.code, e.description)
+	}
+	if e.scope != "" {
+		challenge += fmt.Sprintf(", scope=%q", e.scope)
+		msg = i18n.Sprintf(i18n.FromRequest(r), "credentials lack scope %s", e.scope)
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	handlers.WriteProblem(w, r, handlers.Problem{Status: e.status, Detail: msg})
+}
+
+func invalidToken(description string) *bearerError {
+	return &bearerError{status: http.StatusUnauthorized, code: "invalid_token", description: description}
+}
+
+// Middleware оборачивает next проверкой токена.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, berr, err := a.authenticate(r)
+		if err != nil {
+			handlers.WriteProblem(w, r, handlers.Problem{Status: http.StatusInternalServerError, Detail: "internal error"})
+			return
+		}
+		if berr != nil {
+			berr.write(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(core.WithPrincipal(r.Context(), p)))
+	})
+}
+
+// CollabTicket — Middleware для подключения к совместному
+// редактированию: браузерный WebSocket не умеет передавать заголовки,
+// поэтому вызывающего определяет одноразовый билет из ?ticket=
+// (CollabService.IssueTicket), выданный на заметку {id}. Запрос без
+// билета проверяется как обычно.
+func (a *Authenticator) CollabTicket(collab *service.CollabService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		auth := a.Middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ticket := r.URL.Query().Get("ticket")
+			if ticket == "" {
+				auth.ServeHTTP(w, r)
+				return
+			}
+			id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+			if err != nil {
+				handlers.WriteProblem(w, r, handlers.Problem{Status: http.StatusBadRequest, Detail: "invalid id"})
+				return
+			}
+			p, err := collab.RedeemTicket(ticket, id)
+			if errors.Is(err, service.ErrUnauthenticated) {
+				(&bearerError{status: http.StatusUnauthorized, description: "ticket is invalid or expired"}).write(w, r)
+				return
+			}
+			if err != nil {
+				handlers.WriteProblem(w, r, handlers.Problem{Status: http.StatusInternalServerError, Detail: "internal error"})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(core.WithPrincipal(r.Context(), p)))
+		})
+	}
+}
+
+// authenticate определяет вызывающего. Ошибка клиента возвращается как
+// bearerError, внутренняя — как error.
+func (a *Authenticator) authenticate(r *http.Request) (core.Principal, *bearerError, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return a.authenticateKey(key)
+	}
+
+	header := r.Header.Get("Authorization")
+	scheme, token, _ := strings.Cut(header, " ")
+	if header == "" || !strings.EqualFold(scheme, "Bearer") {
+		// RFC 6750: если клиент не пытался аутентифицироваться, код
+		// ошибки не указывается
+		return core.Principal{}, &bearerError{status: http.StatusUnauthorized}, nil
+	}
+	token = strings.TrimSpace(token)
+	if token == "" || strings.ContainsAny(token, " \t") {
+		return core.Principal{}, &bearerError{
+			status: http.StatusBadRequest, code: "invalid_request", description: "malformed Authorization header",
+		}, nil
+	}
+
+	// токен сессии — base64url без точек, у JWT их две
+	if strings.Count(token, ".") == 2 {
+		if a.JWT == nil {
+			return core.Principal{}, invalidToken("JWT is not accepted"), nil
+		}
+		claims, err := a.JWT.Verify(token)
+		if err != nil {
+			return core.Principal{}, invalidToken(jwtErrorDescription(err)), nil
+		}
+		if claims.Subject == "" {
+			return core.Principal{}, invalidToken("token has no subject"), nil
+		}
+		p, err := a.Sessions.ResolveSubject(claims.Subject)
+		if err != nil {
+			return core.Principal{}, nil, err
+		}
+		return p, nil, nil
+	}
+
+	p, err := a.Sessions.Authenticate(token)
+	if errors.Is(err, service.ErrUnauthenticated) {
+		return core.Principal{}, invalidToken("token is invalid or expired"), nil
+	}
+	if err != nil {
+		return core.Principal{}, nil, err
+	}
+	return p, nil, nil
+}
+
+// authenticateKey проверяет API-ключ. Схема Bearer к ключу не
+// относится, поэтому код ошибки в WWW-Authenticate не указывается.
+func (a *Authenticator) authenticateKey(key string) (core.Principal, *bearerError, error) {
+	if a.APIKeys == nil {
+		return core.Principal{}, &bearerError{status: http.StatusUnauthorized, description: "API keys are not accepted"}, nil
+	}
+	p, err := a.APIKeys.Authenticate(key)
+	if errors.Is(err, service.ErrUnauthenticated) {
+		return core.Principal{}, &bearerError{status: http.StatusUnauthorized, description: "API key is invalid or expired"}, nil
+	}
+	if err != nil {
+		return core.Principal{}, nil, err
+	}
+	return p, nil, nil
+}
+
+// RequireScope пропускает только вызывающих с областью scope. Ставится
+// после Middleware; сессиям и JWT доступны все области, API-ключам —
+// только выданные при создании.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := core.PrincipalFrom(r.Context())
+			if !ok {
+				(&bearerError{status: http.StatusUnauthorized}).write(w, r)
+				return
+			}
+			if !p.HasScope(scope) {
+				(&bearerError{
+					status:      http.StatusForbidden,
+					code:        "insufficient_scope",
+					description: "credentials lack scope " + scope,
+					scope:       scope,
+				}).write(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// jwtErrorDescription — короткое описание ошибки проверки JWT для
+// error_description; подробности подписи и ключей наружу не отдаются.
+func jwtErrorDescription(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "token is expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return "token is not valid yet"
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return "token has invalid audience"
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return "token has invalid issuer"
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		return "token is missing required claim"
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return "token is malformed"
+	}
+	return "token signature is invalid"
+}
@@ -0,0 +1,74 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/repo"
+)
+
+func TestAuthenticatorAPIKey(t *testing.T) {
+	users := repo.NewUserRepoMem()
+	keys := service.NewAPIKeyService(repo.NewAPIKeyRepoMem(), users)
+	a := &Authenticator{
+		Sessions: service.NewAuthService(users, repo.NewSessionRepoMem(), time.Hour),
+		APIKeys:  keys,
+	}
+
+	id, err := users.Create(core.User{Username: "alice", PasswordHash: []byte("x")})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	ctx := core.WithPrincipal(context.Background(), core.Principal{UserID: id})
+	_, readKey, err := keys.Create(ctx, "reader", []string{core.ScopeNotesRead}, nil)
+	if err != nil {
+		t.Fatalf("Create key: %v", err)
+	}
+	revoked, revokedKey, err := keys.Create(ctx, "old", []string{core.ScopeNotesRead}, nil)
+	if err != nil {
+		t.Fatalf("Create key: %v", err)
+	}
+	if err := keys.Revoke(ctx, revoked.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	write := a.Middleware(RequireScope(core.ScopeNotesWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	read := a.Middleware(RequireScope(core.ScopeNotesRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	tests := []struct {
+		name      string
+		handler   http.Handler
+		key       string
+		status    int
+		challenge string
+	}{
+		{"read", read, readKey, http.StatusOK, ""},
+		{"missingScope", write, readKey, http.StatusForbidden, `error="insufficient_scope", error_description="credentials lack scope notes:write", scope="notes:write"`},
+		{"revoked", read, revokedKey, http.StatusUnauthorized, `Bearer realm="notes-api"`},
+		{"unknown", read, "nk_unknown", http.StatusUnauthorized, `Bearer realm="notes-api"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notes", nil)
+		req.Header.Set(APIKeyHeader, tt.key)
+		rec := httptest.NewRecorder()
+		tt.handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		if c := rec.Header().Get("WWW-Authenticate"); !strings.Contains(c, tt.challenge) || (tt.challenge == "") != (c == "") {
+			t.Errorf("%s: WWW-Authenticate = %q, want %q", tt.name, c, tt.challenge)
+		}
+	}
+
+	list, err := keys.List(ctx)
+	if err != nil || len(list) != 1 || list[0].LastUsedAt == nil {
+		t.Errorf("List = %+v, %v; want one key with LastUsedAt", list, err)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/repo"
+)
+
+// CreateAPIKeyRequest модель запроса на выпуск API-ключа.
+// @Description Название, области доступа и срок действия ключа
+type CreateAPIKeyRequest struct {
+	// Название ключа, до 100 символов
+	Name string `json:"name" example:"ci"`
+	// Области доступа: notes:read, notes:write, webhooks:manage
+	Scopes []string `json:"scopes" example:"notes:read,notes:write"`
+	// Срок действия (опционально); без него ключ бессрочный
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" example:"2025-12-08T12:00:00Z"`
+}
+
+// CreateAPIKeyResponse модель ответа на выпуск API-ключа.
+// @Description Ключ и его описание; ключ показывается только в этом ответе
+type CreateAPIKeyResponse struct {
+	core.APIKey
+	// Сам ключ для заголовка X-API-Key; сохраните его, повторно он не выдаётся
+	Key string `json:"key" example:"nk_Xq3vB1a..."`
+}
+
+// CreateAPIKey выпускает API-ключ.
+// @Summary Выпустить API-ключ
+// @Description Создаёт ключ для скриптов и CI. Ключ передаётся в заголовке X-API-Key и возвращается только в этом ответе — сервер хранит лишь его хеш. Выпускать ключи можно только с токеном сессии или JWT.
+// @Tags keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body CreateAPIKeyRequest true "Параметры ключа"
+// @Success 201 {object} CreateAPIKeyResponse "Выпущенный ключ"
+// @Failure 400 {object} Problem "Некорректные данные"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "Запрос подписан API-ключом"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /keys [post]
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var input CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	key, secret, err := h.Keys.Create(r.Context(), input.Name, input.Scopes, input.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			writeValidationError(w, r, err)
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(CreateAPIKeyResponse{APIKey: *key, Key: secret})
+}
+
+// ListAPIKeys возвращает API-ключи пользователя.
+// @Summary Список API-ключей
+// @Description Возвращает ключи текущего пользователя без самих ключей: только начало ключа, области, срок и время последнего использования
+// @Tags keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} core.APIKey "Ключи"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "Запрос подписан API-ключом"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /keys [get]
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.Keys.List(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(keys)
+}
+
+// RevokeAPIKey отзывает API-ключ.
+// @Summary Отозвать API-ключ
+// @Description Ключ перестаёт действовать сразу
+// @Tags keys
+// @Security BearerAuth
+// @Param id path int true "ID ключа"
+// @Success 204 "Ключ отозван"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "Запрос подписан API-ключом"
+// @Failure 404 {object} Problem "Ключ не найден"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	if err := h.Keys.Revoke(r.Context(), id); err != nil {
+		if errors.Is(err, repo.ErrAPIKeyNotFound) {
+			writeError(w, r, http.StatusNotFound, "api key not found")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/repo"
+)
+
+// ListAuditEvents возвращает журнал аудита.
+// @Summary Журнал аудита
+// @Description Возвращает события создания, изменения, удаления и восстановления заметок всех пользователей по возрастанию ID:
+// @Description кто, когда, с какого адреса и в каком запросе изменил заметку и какие поля изменились.
+// @Description Доступен только администраторам (флаг -admins) с токеном сессии или JWT.
+// @Description Если есть следующая страница, её курсор передаётся в заголовке X-Next-Cursor.
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Param noteId query int false "ID заметки"
+// @Param actor query string false "Имя пользователя, выполнившего изменение"
+// @Param since query string false "Не раньше (RFC 3339)" format(date-time)
+// @Param until query string false "Раньше (RFC 3339)" format(date-time)
+// @Param limit query int false "Размер страницы (1–1000)" default(100)
+// @Param cursor query string false "Курсор из X-Next-Cursor предыдущей страницы"
+// @Success 200 {array} core.AuditEvent "События"
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
+// @Failure 400 {object} Problem "Некорректные параметры запроса"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "Нет прав администратора"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /audit [get]
+func (h *Handler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	q, err := parseAuditQuery(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.Audit.Query(r.Context(), q)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrForbidden):
+			writeError(w, r, http.StatusForbidden, "admin access required")
+		case errors.Is(err, service.ErrValidation):
+			writeValidationError(w, r, err)
+		default:
+			writeError(w, r, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+
+	events := page.Events
+	if events == nil {
+		events = []core.AuditEvent{}
+	}
+	if page.Next != 0 {
+		w.Header().Set("X-Next-Cursor", strconv.FormatInt(page.Next, 10))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(events)
+}
+
+// parseAuditQuery разбирает фильтры журнала аудита из query string.
+func parseAuditQuery(r *http.Request) (repo.AuditQuery, error) {
+	params := r.URL.Query()
+	var q repo.AuditQuery
+
+	ints := []struct {
+		name string
+		dst  *int64
+	}{
+		{"noteId", &q.NoteID},
+		{"cursor", &q.After},
+	}
+	for _, p := range ints {
+		v := params.Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 {
+			return q, errors.New("invalid " + p.name)
+		}
+		*p.dst = n
+	}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return q, errors.New("invalid limit")
+		}
+		q.Limit = limit
+	}
+
+	times := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"since", &q.Since},
+		{"until", &q.Until},
+	}
+	for _, p := range times {
+		v := params.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return q, errors.New("invalid " + p.name)
+		}
+		*p.dst = &t
+	}
+
+	q.Actor = params.Get("actor")
+	return q, nil
+}
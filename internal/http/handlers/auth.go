@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+)
+
+// CredentialsRequest модель запроса на регистрацию и вход.
+// @Description Имя пользователя и пароль
+type CredentialsRequest struct {
+	// Имя пользователя: 3–32 символа a-z, 0-9, '_', '.', '-' (регистр не учитывается)
+	Username string `json:"username" example:"alice"`
+	// Пароль: от 8 до 72 байт
+	Password string `json:"password" example:"correct horse"`
+}
+
+// LoginResponse модель ответа на вход.
+// @Description Токен сессии для заголовка Authorization: Bearer
+type LoginResponse struct {
+	// Токен сессии
+	Token string `json:"token" example:"q3Vn1yJ0b9gZ..."`
+	// Момент истечения токена
+	ExpiresAt time.Time `json:"expiresAt" example:"2024-12-09T12:00:00Z"`
+}
+
+// bearerToken извлекает токен из заголовка Authorization: Bearer.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// Register регистрирует пользователя.
+// @Summary Регистрация
+// @Description Создаёт учётную запись. Для работы с заметками затем нужно войти через /auth/login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body CredentialsRequest true "Имя пользователя и пароль"
+// @Success 201 {object} core.User "Созданный пользователь"
+// @Failure 400 {object} Problem "Некорректное имя или пароль"
+// @Failure 409 {object} Problem "Имя уже занято"
+// @Failure 429 {object} Problem "Слишком много регистраций с этого адреса, см. Retry-After"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /auth/register [post]
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	var input CredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	user, err := h.Auth.Register(input.Username, input.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrValidation):
+			writeValidationError(w, r, err)
+		case errors.Is(err, service.ErrUsernameTaken):
+			writeError(w, r, http.StatusConflict, "username is already taken")
+		default:
+			writeError(w, r, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(user)
+}
+
+// Login открывает сессию.
+// @Summary Вход
+// @Description Проверяет имя и пароль и выдаёт токен сессии. Токен передаётся в заголовке Authorization: Bearer <token>.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body CredentialsRequest true "Имя пользователя и пароль"
+// @Success 200 {object} LoginResponse "Токен сессии"
+// @Failure 400 {object} Problem "Некорректный JSON"
+// @Failure 401 {object} Problem "Неверное имя или пароль"
+// @Failure 429 {object} Problem "Слишком много попыток входа, см. Retry-After"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /auth/login [post]
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var input CredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	token, expiresAt, err := h.Auth.Login(input.Username, input.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			writeError(w, r, http.StatusUnauthorized, "invalid username or password")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(LoginResponse{Token: token, ExpiresAt: expiresAt})
+}
+
+// Logout закрывает текущую сессию.
+// @Summary Выход
+// @Description Делает токен из заголовка Authorization недействительным
+// @Tags auth
+// @Security BearerAuth
+// @Success 204 "Сессия закрыта"
+// @Failure 401 {object} Problem "Нет действующего токена"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /auth/logout [post]
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	token, _ := bearerToken(r) // токен уже проверен middleware
+	if err := h.Auth.Logout(token); err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Me возвращает текущего пользователя.
+// @Summary Текущий пользователь
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} core.User "Пользователь"
+// @Failure 401 {object} Problem "Нет действующего токена"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /auth/me [get]
+func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	p, _ := core.PrincipalFrom(r.Context())
+	user, err := h.Auth.GetUser(p.UserID)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(user)
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/i18n"
+	"example.com/notes-api/internal/repo"
+)
+
+// BatchNotesRequest модель пакета операций над заметками.
+// @Description Операции над заметками, выполняемые за один запрос
+type BatchNotesRequest struct {
+	// true — применить все операции или ни одной; false — каждую независимо
+	Atomic bool `json:"atomic" example:"true"`
+	// Операции по порядку (не больше 100)
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation модель операции пакета.
+// @Description Операция пакета: create — поля новой заметки; update — id, необязательная version и изменяемые поля;
+// @Description delete — id и необязательная version (заметка перемещается в корзину)
+type BatchOperation struct {
+	// Вид операции
+	Op string `json:"op" enums:"create,update,delete" example:"update"`
+	// ID заметки (update, delete)
+	ID int64 `json:"id,omitempty" example:"1"`
+	// Версия заметки, которую клиент изменяет, — как If-Match (update, delete; обязательна в строгом режиме)
+	Version int64 `json:"version,omitempty" example:"3"`
+	// Заголовок (create — обязателен, update — опционально)
+	Title *string `json:"title,omitempty" example:"Обновлённый заголовок"`
+	// Содержимое (create, update)
+	Content *string `json:"content,omitempty" example:"Обновлённый текст"`
+	// Теги (create, update); при update заменяют прежние
+	Tags *[]string `json:"tags,omitempty" example:"работа"`
+	// ID блокнота (только create)
+	NotebookID *int64 `json:"notebookId,omitempty" example:"2"`
+}
+
+// BatchNotesResponse модель итогов пакета.
+// @Description Итоги операций пакета в порядке запроса
+type BatchNotesResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult модель итога одной операции пакета.
+// @Description Итог операции: статус и тело, которые вернул бы отдельный запрос
+type BatchResult struct {
+	// HTTP-статус операции: 201 (create), 200 (update), 204 (delete) или код ошибки;
+	// 424 — операция атомарного пакета не применена из-за ошибки другой
+	Status int `json:"status" example:"200"`
+	// Созданная или изменённая заметка
+	Note *core.Note `json:"note,omitempty"`
+	// Ошибка операции
+	Error *Problem `json:"error,omitempty"`
+}
+
+// BatchNotes выполняет пакет операций над заметками.
+// @Summary Пакет операций над заметками
+// @Description Создаёт, изменяет и перемещает в корзину заметки за один запрос — например, при импорте или чистке.
+// @Description Операции выполняются по порядку и проверяются так же, как отдельные запросы POST /notes, PATCH /notes/{id}
+// @Description и DELETE /notes/{id}; итог каждой — в results с тем же статусом и телом, что у отдельного запроса.
+// @Description Без atomic операции независимы. С atomic: true пакет применяется целиком или не применяется вовсе:
+// @Description первая неудачная операция получает свою ошибку, остальные — 424.
+// @Description Ответ 200 означает, что пакет принят; успех операций — в их статусах.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param input body BatchNotesRequest true "Операции"
+// @Success 200 {object} BatchNotesResponse "Итоги операций"
+// @Failure 400 {object} Problem "Неверный JSON, пустой пакет, больше 100 операций, неизвестная операция или нет id"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 413 {object} Problem "Тело запроса больше, чем допускают ограничения из GET /limits"
+// @Failure 428 {object} Problem "У операции update или delete нет version (строгий режим)"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes:batch [post]
+func (h *Handler) BatchNotes(w http.ResponseWriter, r *http.Request) {
+	// каждая операция может нести заметку наибольшего размера
+	if n := h.noteBodyLimit(); n > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, service.MaxBatchSize*(n+noteBodyHeadroom)+noteBodyHeadroom)
+	}
+	var input BatchNotesRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	ops := make([]service.NoteBatchOp, len(input.Operations))
+	for i, in := range input.Operations {
+		op := service.NoteBatchOp{Action: service.NoteBatchAction(in.Op), ID: in.ID, Version: in.Version}
+		switch op.Action {
+		case service.BatchCreate:
+			op.Create = service.NoteCreateInput{NotebookID: in.NotebookID}
+			if in.Title != nil {
+				op.Create.Title = *in.Title
+			}
+			if in.Content != nil {
+				op.Create.Content = *in.Content
+			}
+			if in.Tags != nil {
+				op.Create.Tags = *in.Tags
+			}
+		case service.BatchUpdate, service.BatchDelete:
+			if in.NotebookID != nil {
+				writeErrorf(w, r, http.StatusBadRequest, "%s cannot be changed", fmt.Sprintf("operations[%d].notebookId", i))
+				return
+			}
+			if h.RequireIfMatch && in.Version == 0 {
+				writeErrorf(w, r, http.StatusPreconditionRequired, "%s is required", fmt.Sprintf("operations[%d].version", i))
+				return
+			}
+			if op.Action == service.BatchUpdate {
+				op.Update = service.NoteUpdateInput{Title: in.Title, Content: in.Content, Tags: in.Tags}
+			}
+		}
+		ops[i] = op
+	}
+
+	results, err := h.Service.BatchNotes(r.Context(), ops, input.Atomic)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			writeValidationError(w, r, err)
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	// номер операции, из-за которой не применён атомарный пакет
+	failed := -1
+	for i, res := range results {
+		if res.Err != nil && !errors.Is(res.Err, service.ErrBatchAborted) {
+			failed = i
+			break
+		}
+	}
+	resp := BatchNotesResponse{Results: make([]BatchResult, len(results))}
+	for i, res := range results {
+		out := &resp.Results[i]
+		if res.Err != nil {
+			p := completeProblem(r, batchProblem(r, ops[i].Action, res.Err, failed))
+			out.Status, out.Error = p.Status, &p
+			continue
+		}
+		out.Note = res.Note
+		switch ops[i].Action {
+		case service.BatchCreate:
+			out.Status = http.StatusCreated
+		case service.BatchUpdate:
+			out.Status = http.StatusOK
+		case service.BatchDelete:
+			out.Status = http.StatusNoContent
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// batchProblem возвращает ошибку операции пакета — ту же, что вернул бы
+// отдельный запрос с этой операцией; failed — номер операции, из-за
+// которой не применён атомарный пакет.
+func batchProblem(r *http.Request, action service.NoteBatchAction, err error, failed int) Problem {
+	switch {
+	case errors.Is(err, service.ErrBatchAborted):
+		return Problem{
+			Status: http.StatusFailedDependency,
+			Detail: i18n.Sprintf(i18n.FromRequest(r), "operation %d failed, nothing was applied", failed),
+		}
+	case errors.Is(err, service.ErrValidation):
+		return validationProblem(r, err)
+	case errors.Is(err, repo.ErrNoteNotFound):
+		return Problem{Status: http.StatusNotFound, Detail: "note not found"}
+	case errors.Is(err, repo.ErrVersionConflict):
+		return Problem{Status: http.StatusPreconditionFailed, Detail: "version mismatch"}
+	case errors.Is(err, service.ErrForbidden) && action == service.BatchDelete:
+		return Problem{Status: http.StatusForbidden, Detail: "only the owner can delete the note"}
+	case errors.Is(err, service.ErrForbidden):
+		return Problem{Status: http.StatusForbidden, Detail: "viewer cannot edit the note"}
+	case errors.Is(err, repo.ErrNotebookNotFound):
+		return Problem{Status: http.StatusBadRequest, Detail: "notebook not found"}
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return Problem{Status: http.StatusInsufficientStorage, Detail: "storage quota exceeded"}
+	}
+	return Problem{Status: http.StatusInternalServerError, Detail: "internal error"}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example.com/notes-api/internal/core/service"
+)
+
+func TestBatchBodyLimit(t *testing.T) {
+	h, ctx := newLimitedHandler(t)
+	limit := service.MaxBatchSize*(6*(40+100)+noteBodyHeadroom) + noteBodyHeadroom
+
+	body := `{"operations": [{"op": "create", "title": "t", "content": "` + strings.Repeat("a", limit) + `"}]}`
+	r := httptest.NewRequest(http.MethodPost, "/notes:batch", strings.NewReader(body)).WithContext(ctx)
+	w := httptest.NewRecorder()
+	h.BatchNotes(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413; body %s", w.Code, w.Body)
+	}
+
+	// тело в пределах лимита, текст сверх правил — ошибка операции
+	body = `{"operations": [{"op": "create", "title": "t", "content": "` + strings.Repeat("a", 101) + `"}]}`
+	r = httptest.NewRequest(http.MethodPost, "/notes:batch", strings.NewReader(body)).WithContext(ctx)
+	w = httptest.NewRecorder()
+	h.BatchNotes(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":400`) {
+		t.Errorf("status = %d, body %s; want 200 with a 400 result", w.Code, w.Body)
+	}
+}
+
+// batchStatuses выполняет пакет из запроса r и возвращает статусы его операций.
+func batchStatuses(t *testing.T, h *Handler, r *http.Request) []int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.BatchNotes(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body %s", w.Code, w.Body)
+	}
+	var resp BatchNotesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	statuses := make([]int, len(resp.Results))
+	for i, res := range resp.Results {
+		statuses[i] = res.Status
+		if (res.Error == nil) != (res.Status < 300) {
+			t.Errorf("result %d: status %d with error %+v", i, res.Status, res.Error)
+		}
+	}
+	return statuses
+}
+
+func TestBatchStatuses(t *testing.T) {
+	h, ctx := newLimitedHandler(t)
+	tests := []struct {
+		name string
+		body string
+		want []int
+	}{
+		{
+			"independent",
+			`{"operations": [
+				{"op": "create", "title": "новая"},
+				{"op": "update", "id": 1, "version": 1, "title": "правка"},
+				{"op": "update", "id": 1, "version": 1, "title": "устарела"},
+				{"op": "update", "id": 42, "title": "нет такой"},
+				{"op": "create", "title": ""},
+				{"op": "delete", "id": 1}
+			]}`,
+			[]int{201, 200, 412, 404, 400, 204},
+		},
+		{
+			"atomic",
+			`{"atomic": true, "operations": [
+				{"op": "create", "title": "новая"},
+				{"op": "update", "id": 99, "title": "нет такой"},
+				{"op": "create", "title": "ещё"}
+			]}`,
+			[]int{424, 404, 424},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/notes:batch", strings.NewReader(tt.body)).WithContext(ctx)
+			got := batchStatuses(t, h, r)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("statuses = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
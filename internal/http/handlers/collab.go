@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"golang.org/x/text/language"
+
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/i18n"
+	"example.com/notes-api/internal/ot"
+	"example.com/notes-api/internal/repo"
+)
+
+const (
+	// collabMaxMessage — максимальный размер сообщения участника.
+	collabMaxMessage = 1 << 20
+	// collabPongWait — сколько ждать ответа на ping, прежде чем считать
+	// соединение потерянным; ping отправляется в два раза чаще.
+	collabPongWait = 60 * time.Second
+	// collabWriteWait — сколько ждать записи сообщения в соединение.
+	collabWriteWait = 10 * time.Second
+)
+
+// CollabTicketResponse модель билета на подключение.
+// @Description Одноразовый билет для подключения к совместному редактированию
+type CollabTicketResponse struct {
+	// Билет; передаётся в ?ticket= адреса WebSocket
+	Ticket string `json:"ticket" example:"Zk3u0aQm..."`
+	// Момент, после которого билет не принимается
+	ExpiresAt time.Time `json:"expiresAt" example:"2024-12-08T12:00:30Z"`
+}
+
+// collabOriginAllowed разрешает подключение клиентам без Origin (не
+// браузерам), страницам того же хоста и страницам из h.CollabOrigins.
+// Остальные страницы не должны подключаться от имени пользователя,
+// даже получив билет.
+func (h *Handler) collabOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range h.CollabOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateCollabTicket выдаёт билет на подключение к совместному редактированию.
+// @Summary Билет для совместного редактирования
+// @Description Браузерный WebSocket не передаёт заголовки Authorization и X-API-Key. Страница получает билет этим запросом
+// @Description и подключается к /notes/{id}/collab?ticket=<билет>. Билет одноразовый, действует 30 секунд и только для этой заметки;
+// @Description права участника — те же, что у выдавшего запроса.
+// @Tags notes
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Success 200 {object} CollabTicketResponse "Билет"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет области notes:read"
+// @Failure 404 {object} Problem "Заметка не найдена"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/{id}/collab/ticket [post]
+func (h *Handler) CreateCollabTicket(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	ticket, expiresAt, err := h.Collab.IssueTicket(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNoteNotFound) {
+			writeError(w, r, http.StatusNotFound, "note not found")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(CollabTicketResponse{Ticket: ticket, ExpiresAt: expiresAt})
+}
+
+// CollabRequest — сообщение участника совместного редактирования.
+type CollabRequest struct {
+	// Type — op (операция) или cursor (перемещение курсора).
+	Type string `json:"type"`
+	// Rev — ревизия документа, над которой сделана операция.
+	Rev int64 `json:"rev"`
+	// Op — операция в формате пакета ot: [3, "abc", -2, 5].
+	Op ot.Operation `json:"op"`
+	// Cursor — курсор участника после операции.
+	Cursor *service.CollabSelection `json:"cursor"`
+}
+
+// CollabNote подключает к совместному редактированию заметки.
+// @Summary Совместное редактирование заметки
+// @Description WebSocket: участники правят текст заметки одновременно. Первое сообщение сервера — init с текстом (content),
+// @Description ревизией (rev), своим clientId и списком участников. Участник отправляет {"type": "op", "rev": N, "op": [...], "cursor": {...}},
+// @Description где rev — последняя известная ему ревизия, а op — операция над текстом: число > 0 оставляет символы, < 0 удаляет,
+// @Description строка вставляется; позиции считаются в символах Unicode. Сервер преобразует операцию против принятых после rev,
+// @Description отвечает ack с новой ревизией и рассылает остальным op; одновременные вставки в одну позицию упорядочиваются по
+// @Description времени приёма. Также приходят cursor, join и leave (присутствие участников), saved (заметка сохранена) и error,
+// @Description после которого сервер закрывает соединение — нужно подключиться заново. Участник с ролью viewer или API-ключ
+// @Description без notes:write только наблюдают. Сервер сохраняет текст раз в несколько секунд и когда уходит последний участник;
+// @Description изменения заметки в обход сессии вливаются в документ операцией сервера. Браузер, который не может передать
+// @Description заголовки, подключается с билетом из POST /notes/{id}/collab/ticket; страницы других сайтов допускаются,
+// @Description только если их Origin разрешён настройкой сервера.
+// @Tags notes
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Param ticket query string false "Билет из POST /notes/{id}/collab/ticket вместо заголовков аутентификации"
+// @Success 101 "Соединение WebSocket установлено"
+// @Failure 400 {object} Problem "Некорректный ID или не запрос WebSocket"
+// @Failure 401 {object} Problem "Требуется аутентификация, билет неверен или истёк"
+// @Failure 403 {object} Problem "У API-ключа нет области notes:read или Origin не разрешён"
+// @Failure 404 {object} Problem "Заметка не найдена"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Failure 503 {object} Problem "Сервер останавливается"
+// @Router /notes/{id}/collab [get]
+func (h *Handler) CollabNote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if !websocket.IsWebSocketUpgrade(r) {
+		writeError(w, r, http.StatusBadRequest, "websocket upgrade required")
+		return
+	}
+	if !h.collabOriginAllowed(r) {
+		writeError(w, r, http.StatusForbidden, "origin is not allowed")
+		return
+	}
+
+	client, err := h.Collab.Join(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrNoteNotFound):
+			writeError(w, r, http.StatusNotFound, "note not found")
+		case errors.Is(err, service.ErrCollabClosed):
+			writeError(w, r, http.StatusServiceUnavailable, "server is shutting down")
+		default:
+			writeError(w, r, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+	upgrader := websocket.Upgrader{CheckOrigin: h.collabOriginAllowed}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		client.Leave() // Upgrade уже ответил клиенту
+		return
+	}
+
+	// соединение закрывает писатель, когда сессия закроет client.Out:
+	// так сообщение error успевает уйти до закрытия
+	lang := i18n.FromRequest(r)
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		collabWriter(conn, client, lang)
+	}()
+	defer func() { <-written }()
+
+	conn.SetReadLimit(collabMaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(collabPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(collabPongWait))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			client.Leave()
+			return
+		}
+		var req CollabRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			client.Disconnect("invalid message")
+			return
+		}
+		switch req.Type {
+		case "op":
+			if err := client.Submit(req.Rev, req.Op, req.Cursor); err != nil {
+				client.Disconnect(collabErrorMessage(err, lang))
+				return
+			}
+		case "cursor":
+			if req.Cursor != nil {
+				client.MoveCursor(*req.Cursor)
+			}
+		default:
+			client.Disconnect("unknown message type")
+			return
+		}
+	}
+}
+
+// collabWriter пересылает сообщения сессии в соединение и держит его
+// живым пингами; причины ошибок переводит на язык lang. Когда сессия
+// отключает участника, закрывает соединение. Пишет в соединение только он.
+func collabWriter(conn *websocket.Conn, client *service.CollabClient, lang language.Tag) {
+	ping := time.NewTicker(collabPongWait / 2)
+	defer ping.Stop()
+	defer conn.Close()
+	for {
+		select {
+		case msg, ok := <-client.Out:
+			_ = conn.SetWriteDeadline(time.Now().Add(collabWriteWait))
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if msg.Type == service.CollabError {
+				msg.Error = i18n.Text(lang, msg.Error)
+			}
+			if err := conn.WriteJSON(msg); err != nil {
+				client.Leave()
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(collabWriteWait)); err != nil {
+				client.Leave()
+				return
+			}
+		}
+	}
+}
+
+// collabErrorMessage — причина отключения участника для ошибки Submit;
+// нарушенное правило описывается на языке lang.
+func collabErrorMessage(err error, lang language.Tag) string {
+	var verr *service.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return verr.Violations[0].Localize(lang).Message
+	case errors.Is(err, service.ErrForbidden):
+		return "read-only participant"
+	case errors.Is(err, service.ErrStaleRevision):
+		return "revision is too old"
+	case errors.Is(err, service.ErrValidation):
+		return "operation does not match document"
+	case errors.Is(err, service.ErrCollabClosed):
+		return "session is closed"
+	}
+	return "internal error"
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCollabOriginAllowed(t *testing.T) {
+	h := &Handler{CollabOrigins: []string{"https://app.example.com/"}}
+	for origin, want := range map[string]bool{
+		"":                         true, // не браузер
+		"http://notes.example":     true, // тот же хост
+		"https://app.example.com":  true,
+		"https://APP.example.com":  true,
+		"https://evil.example":     false,
+		"http://app.example.com":   false,
+		"https://notes.example.ev": false,
+	} {
+		r := httptest.NewRequest("GET", "http://notes.example/api/v1/notes/1/collab", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if got := h.collabOriginAllowed(r); got != want {
+			t.Errorf("collabOriginAllowed(%q) = %v, want %v", origin, got, want)
+		}
+	}
+}
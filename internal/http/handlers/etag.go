@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// errPreconditionFailed — If-Match не совпал ни с одной версией заметки.
+var errPreconditionFailed = errors.New("precondition failed")
+
+// noteETag возвращает сильный ETag заметки — её версию в кавычках.
+func noteETag(n *core.Note) string {
+	return `"` + strconv.FormatInt(n.Version, 10) + `"`
+}
+
+// parseETags разбирает значение If-Match/If-None-Match. any — значение "*";
+// versions — версии из тегов. weak включает слабое сравнение (RFC 9110,
+// 8.8.3.2), которое требует If-None-Match: тег W/"3" совпадает с версией 3.
+// При сильном сравнении (If-Match) слабые теги не совпадают ни с чем и
+// пропускаются, как и нечисловые.
+func parseETags(header string, weak bool) (versions []int64, any bool) {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil, true
+		}
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		v, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil || v <= 0 {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	return versions, false
+}
+
+// etagMatches сообщает, совпадает ли заголовок If-None-Match с заметкой.
+func etagMatches(header string, n *core.Note) bool {
+	versions, any := parseETags(header, true)
+	if any {
+		return true
+	}
+	for _, v := range versions {
+		if v == n.Version {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion переводит If-Match в версию, которую сервис проверит
+// атомарно вместе с изменением; 0 — без проверки. Если в заголовке
+// несколько тегов, выбирается текущая версия заметки (её возвращает
+// current), если она среди них: её изменение за это время всё равно даст
+// конфликт в репозитории.
+func ifMatchVersion(r *http.Request, current func() (*core.Note, error)) (int64, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, nil
+	}
+
+	versions, any := parseETags(header, false)
+	switch {
+	case any:
+		return 0, nil
+	case len(versions) == 0:
+		return 0, errPreconditionFailed
+	case len(versions) == 1:
+		return versions[0], nil
+	}
+
+	n, err := current()
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range versions {
+		if v == n.Version {
+			return v, nil
+		}
+	}
+	return 0, errPreconditionFailed
+}
+
+// checkIfMatch проверяет предусловие запроса на изменение заметки и при
+// ошибке сам пишет ответ (404, 412 или 428). ok=false — обработку надо
+// прервать.
+func (h *Handler) checkIfMatch(w http.ResponseWriter, r *http.Request, id int64) (version int64, ok bool) {
+	return h.checkPrecondition(w, r, func() (*core.Note, error) { return h.Service.GetNote(r.Context(), id) })
+}
+
+// checkTrashIfMatch — то же для заметки в корзине.
+func (h *Handler) checkTrashIfMatch(w http.ResponseWriter, r *http.Request, id int64) (version int64, ok bool) {
+	return h.checkPrecondition(w, r, func() (*core.Note, error) { return h.Service.GetTrashedNote(r.Context(), id) })
+}
+
+func (h *Handler) checkPrecondition(w http.ResponseWriter, r *http.Request, current func() (*core.Note, error)) (version int64, ok bool) {
+	if h.RequireIfMatch && r.Header.Get("If-Match") == "" {
+		writeError(w, r, http.StatusPreconditionRequired, "If-Match header is required")
+		return 0, false
+	}
+
+	version, err := ifMatchVersion(r, current)
+	switch {
+	case err == nil:
+		return version, true
+	case errors.Is(err, errPreconditionFailed):
+		writeError(w, r, http.StatusPreconditionFailed, "version mismatch")
+	case errors.Is(err, repo.ErrNoteNotFound):
+		writeError(w, r, http.StatusNotFound, "note not found")
+	default:
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+	}
+	return 0, false
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"testing"
+
+	"example.com/notes-api/internal/core"
+)
+
+func TestEtagMatches(t *testing.T) {
+	n := &core.Note{Version: 3}
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"3"`, true},
+		{`W/"3"`, true},
+		{`"1", W/"3"`, true},
+		{`*`, true},
+		{`"2"`, false},
+		{`W/"2"`, false},
+		{`"abc"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, n); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// If-Match сравнивает теги строго: слабый тег не совпадает ни с чем.
+func TestParseETagsStrong(t *testing.T) {
+	versions, any := parseETags(`W/"3", "4"`, false)
+	if any || len(versions) != 1 || versions[0] != 4 {
+		t.Errorf(`parseETags(W/"3", "4") = %v, %v; want [4], false`, versions, any)
+	}
+}
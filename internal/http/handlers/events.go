@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"example.com/notes-api/internal/core/service"
+)
+
+const (
+	// defaultEventsHeartbeat — период комментариев-пингов в потоке
+	// событий, если Handler.EventsHeartbeat не задан.
+	defaultEventsHeartbeat = 15 * time.Second
+	// eventsRetry — через сколько браузер переподключается к оборванному
+	// потоку.
+	eventsRetry = 3 * time.Second
+)
+
+// NoteEvents отдаёт поток изменений заметок.
+// @Summary Поток изменений заметок
+// @Description Server-Sent Events: событие note.created, note.updated или note.deleted (тело — service.NoteEvent)
+// @Description на каждое изменение своих заметок и заметок, которыми поделились с пользователем. Периодически
+// @Description (по умолчанию раз в 15 секунд) приходит комментарий-пинг. Переподключившись с заголовком Last-Event-ID, клиент получит пропущенные события;
+// @Description если их уже нет в буфере сервера, первым придёт событие reset — заметки нужно перечитать целиком.
+// @Description Клиент, который не успевает читать поток, отключается.
+// @Tags notes
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param Last-Event-ID header string false "ID последнего полученного события"
+// @Param lastEventId query string false "То же, что Last-Event-ID, для первого подключения EventSource"
+// @Success 200 {object} service.NoteEvent "Поток событий"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет области notes:read"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Failure 503 {object} Problem "Сервер останавливается"
+// @Router /notes/events [get]
+func (h *Handler) NoteEvents(w http.ResponseWriter, r *http.Request) {
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		// EventSource не умеет заголовки при первом подключении
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+	sub, replay, complete, err := h.Events.Subscribe(r.Context(), lastID)
+	if err != nil {
+		if errors.Is(err, service.ErrEventBusClosed) {
+			writeError(w, r, http.StatusServiceUnavailable, "server is shutting down")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+	defer sub.Close()
+
+	heartbeat := h.EventsHeartbeat
+	if heartbeat <= 0 {
+		heartbeat = defaultEventsHeartbeat
+	}
+	rc := http.NewResponseController(w)
+	// клиент, который перестал читать, не должен держать обработчик
+	// дольше двух пингов
+	extend := func() { _ = rc.SetWriteDeadline(time.Now().Add(2 * heartbeat)) }
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx не должен копить поток
+	w.WriteHeader(http.StatusOK)
+
+	extend()
+	fmt.Fprintf(w, "retry: %d\n\n", eventsRetry.Milliseconds())
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range replay {
+		if err := writeEvent(w, e); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			extend()
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case e, ok := <-sub.C:
+			if !ok {
+				return // отстали или сервер останавливается: клиент переподключится
+			}
+			extend()
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent пишет событие в формате text/event-stream.
+func writeEvent(w io.Writer, e service.NoteEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// GetLimits возвращает ограничения заметок.
+// @Summary Ограничения заметок
+// @Description Возвращает правила, по которым сервер проверяет заметки: длину заголовка в символах и размер текста в байтах,
+// @Description запрещённые символы, форму нормализации Unicode, ограничения тегов и квоту хранилища пользователя.
+// @Description Нулевое ограничение — без ограничения. Управляющие символы запрещены всегда, кроме переводов строк и
+// @Description табуляции в полях с multiline; длина считается после нормализации. Доступен без аутентификации.
+// @Tags limits
+// @Produce json
+// @Success 200 {object} service.Limits "Ограничения"
+// @Failure 429 {object} Problem "Слишком много запросов, см. Retry-After"
+// @Router /limits [get]
+func (h *Handler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.Service.Limits())
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/repo"
+)
+
+// CreateShareLinkRequest модель запроса на создание публичной ссылки.
+// @Description Срок действия и пароль ссылки (оба необязательны)
+type CreateShareLinkRequest struct {
+	// Срок действия; без него ссылка действует до отзыва
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" example:"2024-12-15T12:00:00Z"`
+	// Пароль, который нужно ввести для открытия ссылки (до 72 байт)
+	Password string `json:"password,omitempty" example:"letmein"`
+}
+
+// CreateShareLinkResponse модель ответа на создание публичной ссылки.
+// @Description Ссылка и её токен; токен показывается только в этом ответе
+type CreateShareLinkResponse struct {
+	core.ShareLink
+	// Токен ссылки; сохраните его, повторно он не выдаётся
+	Token string `json:"token" example:"Zr8QpW1c..."`
+	// Путь публичной страницы заметки
+	URL string `json:"url" example:"/api/v1/public/notes/Zr8QpW1c..."`
+}
+
+// writeShareLinkError переводит ошибки операций со ссылками в HTTP-ответ.
+func writeShareLinkError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repo.ErrNoteNotFound):
+		writeError(w, r, http.StatusNotFound, "note not found")
+	case errors.Is(err, repo.ErrShareLinkNotFound):
+		writeError(w, r, http.StatusNotFound, "share link not found")
+	case errors.Is(err, service.ErrValidation):
+		writeValidationError(w, r, err)
+	case errors.Is(err, service.ErrForbidden):
+		writeError(w, r, http.StatusForbidden, "only the owner can manage share links")
+	default:
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// CreateShareLink создаёт публичную ссылку на заметку.
+// @Summary Создать публичную ссылку
+// @Description Выпускает ссылку, по которой заметку может прочитать любой, даже без учётной записи.
+// @Description Токен ссылки возвращается только в этом ответе. Ссылки создаёт только владелец заметки.
+// @Tags links
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Param input body CreateShareLinkRequest false "Срок действия и пароль"
+// @Success 201 {object} CreateShareLinkResponse "Созданная ссылка"
+// @Failure 400 {object} Problem "Некорректный срок действия или пароль"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "Не владелец заметки или у API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка не найдена"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/{id}/links [post]
+func (h *Handler) CreateShareLink(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var input CreateShareLinkRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid JSON")
+			return
+		}
+	}
+
+	link, token, err := h.Service.CreateShareLink(r.Context(), id, input.ExpiresAt, input.Password)
+	if err != nil {
+		writeShareLinkError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(CreateShareLinkResponse{
+		ShareLink: *link,
+		Token:     token,
+		URL:       "/api/v1/public/notes/" + token,
+	})
+}
+
+// ListShareLinks возвращает публичные ссылки на заметку.
+// @Summary Публичные ссылки заметки
+// @Description Ссылки без токенов: начало токена, срок действия, число открытий и время последнего открытия
+// @Tags links
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Success 200 {array} core.ShareLink "Ссылки"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "Не владелец заметки или у API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка не найдена"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/{id}/links [get]
+func (h *Handler) ListShareLinks(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	links, err := h.Service.ListShareLinks(r.Context(), id)
+	if err != nil {
+		writeShareLinkError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(links)
+}
+
+// RevokeShareLink отзывает публичную ссылку.
+// @Summary Отозвать публичную ссылку
+// @Description Ссылка перестаёт открываться сразу
+// @Tags links
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Param linkId path int true "ID ссылки"
+// @Success 204 "Ссылка отозвана"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "Не владелец заметки или у API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка или ссылка не найдены"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/{id}/links/{linkId} [delete]
+func (h *Handler) RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	linkID, err := strconv.ParseInt(chi.URLParam(r, "linkId"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid link id")
+		return
+	}
+
+	if err := h.Service.RevokeShareLink(r.Context(), id, linkID); err != nil {
+		writeShareLinkError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/repo"
+)
+
+// CreateNotebookRequest модель запроса на создание блокнота.
+// @Description Данные для создания блокнота
+type CreateNotebookRequest struct {
+	// Название блокнота (обязательное поле)
+	Name string `json:"name" example:"Работа"`
+	// ID родительского блокнота; без него блокнот создаётся на верхнем уровне
+	ParentID *int64 `json:"parentId,omitempty" example:"1"`
+}
+
+// RenameNotebookRequest модель запроса на переименование блокнота.
+// @Description Новое название блокнота
+type RenameNotebookRequest struct {
+	// Новое название
+	Name string `json:"name" example:"Проекты"`
+}
+
+// MoveNotebookRequest модель запроса на перенос блокнота.
+// @Description Новый родитель блокнота
+type MoveNotebookRequest struct {
+	// ID нового родителя; null — перенос на верхний уровень
+	ParentID *int64 `json:"parentId" example:"1"`
+}
+
+// MoveNoteRequest модель запроса на перенос заметки.
+// @Description Блокнот, в который переносится заметка
+type MoveNoteRequest struct {
+	// ID блокнота; null — заметка вне блокнотов
+	NotebookID *int64 `json:"notebookId" example:"2"`
+}
+
+// writeNotebookError переводит ошибки операций с блокнотами в HTTP-ответ.
+func writeNotebookError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repo.ErrNotebookNotFound):
+		writeError(w, r, http.StatusNotFound, "notebook not found")
+	case errors.Is(err, service.ErrValidation):
+		writeValidationError(w, r, err)
+	case errors.Is(err, service.ErrNotebookCycle):
+		writeError(w, r, http.StatusConflict, "notebook cannot be moved into itself or its descendant")
+	case errors.Is(err, service.ErrNotebookNotEmpty):
+		writeError(w, r, http.StatusConflict, "notebook is not empty")
+	default:
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// CreateNotebook создаёт блокнот.
+// @Summary Создать блокнот
+// @Description Создаёт блокнот на верхнем уровне или внутри другого блокнота
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param input body CreateNotebookRequest true "Данные блокнота"
+// @Success 201 {object} core.Notebook "Созданный блокнот"
+// @Failure 400 {object} Problem "Ошибка валидации"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Родительский блокнот не найден"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notebooks [post]
+func (h *Handler) CreateNotebook(w http.ResponseWriter, r *http.Request) {
+	var input CreateNotebookRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	nb, err := h.Service.CreateNotebook(r.Context(), input.Name, input.ParentID)
+	if err != nil {
+		writeNotebookError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(nb)
+}
+
+// ListNotebooks возвращает все блокноты.
+// @Summary Список блокнотов
+// @Description Возвращает все блокноты плоским списком по возрастанию ID; дерево строится по parentId
+// @Tags notebooks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {array} core.Notebook "Блокноты"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notebooks [get]
+func (h *Handler) ListNotebooks(w http.ResponseWriter, r *http.Request) {
+	notebooks, err := h.Service.ListNotebooks(r.Context())
+	if err != nil {
+		writeNotebookError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(notebooks)
+}
+
+// GetNotebook возвращает блокнот по ID.
+// @Summary Получить блокнот
+// @Tags notebooks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID блокнота"
+// @Success 200 {object} core.Notebook "Блокнот"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Блокнот не найден"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notebooks/{id} [get]
+func (h *Handler) GetNotebook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt64Param(r, "id")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	nb, err := h.Service.GetNotebook(r.Context(), id)
+	if err != nil {
+		writeNotebookError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(nb)
+}
+
+// RenameNotebook переименовывает блокнот.
+// @Summary Переименовать блокнот
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID блокнота"
+// @Param input body RenameNotebookRequest true "Новое название"
+// @Success 200 {object} core.Notebook "Блокнот"
+// @Failure 400 {object} Problem "Ошибка валидации"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Блокнот не найден"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notebooks/{id} [patch]
+func (h *Handler) RenameNotebook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt64Param(r, "id")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var input RenameNotebookRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	nb, err := h.Service.RenameNotebook(r.Context(), id, input.Name)
+	if err != nil {
+		writeNotebookError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(nb)
+}
+
+// MoveNotebook переносит блокнот в другой блокнот.
+// @Summary Перенести блокнот
+// @Description Делает блокнот дочерним для parentId или переносит его на верхний уровень (parentId: null).
+// @Description Перенос блокнота в самого себя или в свой вложенный блокнот отклоняется с 409.
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID блокнота"
+// @Param input body MoveNotebookRequest true "Новый родитель"
+// @Success 200 {object} core.Notebook "Блокнот"
+// @Failure 400 {object} Problem "Некорректные данные"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Блокнот не найден"
+// @Failure 409 {object} Problem "Перенос создал бы цикл"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notebooks/{id}/move [post]
+func (h *Handler) MoveNotebook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt64Param(r, "id")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var input MoveNotebookRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	nb, err := h.Service.MoveNotebook(r.Context(), id, input.ParentID)
+	if err != nil {
+		writeNotebookError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(nb)
+}
+
+// DeleteNotebook удаляет блокнот.
+// @Summary Удалить блокнот
+// @Description Удаляет блокнот. Политика policy определяет судьбу содержимого:
+// @Description reject — удалить, только если в блокноте нет заметок и вложенных блокнотов (иначе 409);
+// @Description cascade — удалить вложенные блокноты, а все их заметки переместить в корзину;
+// @Description root — перенести заметки и вложенные блокноты на верхний уровень.
+// @Description Заметки в корзине из удаляемых блокнотов переносятся на верхний уровень.
+// @Tags notebooks
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID блокнота"
+// @Param policy query string false "Что делать с содержимым" Enums(reject, cascade, root) default(reject)
+// @Success 204 "Блокнот удалён"
+// @Failure 400 {object} Problem "Некорректные параметры"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Блокнот не найден"
+// @Failure 409 {object} Problem "Блокнот не пуст"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notebooks/{id} [delete]
+func (h *Handler) DeleteNotebook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt64Param(r, "id")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	policy := service.DeleteIfEmpty
+	if v := r.URL.Query().Get("policy"); v != "" {
+		policy = service.NotebookDeletePolicy(v)
+		if !policy.Valid() {
+			writeError(w, r, http.StatusBadRequest, "invalid policy")
+			return
+		}
+	}
+
+	if err := h.Service.DeleteNotebook(r.Context(), id, policy); err != nil {
+		writeNotebookError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListNotebookNotes возвращает заметки блокнота.
+// @Summary Заметки блокнота
+// @Description Возвращает страницу заметок блокнота; с recursive=true — и всех вложенных блокнотов.
+// @Description Остальные параметры — как у списка заметок.
+// @Tags notebooks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID блокнота"
+// @Param recursive query bool false "Включать заметки вложенных блокнотов"
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 500)" minimum(1) maximum(500)
+// @Param cursor query string false "Курсор из X-Next-Cursor предыдущей страницы"
+// @Param sort query string false "Поле сортировки" Enums(id, createdAt, updatedAt, title) default(id)
+// @Param order query string false "Направление сортировки" Enums(asc, desc) default(asc)
+// @Param tag query []string false "Тег; параметр можно повторять" collectionFormat(multi)
+// @Param tagMode query string false "all — нужны все теги, any — хотя бы один" Enums(all, any) default(all)
+// @Success 200 {array} core.Note "Заметки"
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
+// @Failure 400 {object} Problem "Некорректные параметры запроса"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Блокнот не найден"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notebooks/{id}/notes [get]
+func (h *Handler) ListNotebookNotes(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt64Param(r, "id")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	q, err := parseNoteQuery(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	var recursive bool
+	switch r.URL.Query().Get("recursive") {
+	case "", "false":
+	case "true":
+		recursive = true
+	default:
+		writeError(w, r, http.StatusBadRequest, "invalid recursive")
+		return
+	}
+
+	page, err := h.Service.ListNotebookNotes(r.Context(), id, recursive, q)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrInvalidCursor):
+			writeError(w, r, http.StatusBadRequest, "invalid cursor")
+		case errors.Is(err, service.ErrValidation):
+			writeValidationError(w, r, err)
+		default:
+			writeNotebookError(w, r, err)
+		}
+		return
+	}
+
+	notes := page.Notes
+	if notes == nil {
+		notes = []core.Note{}
+	}
+
+	if page.Next != nil {
+		w.Header().Set("X-Next-Cursor", repo.EncodeCursor(page.Next))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(notes)
+}
+
+// MoveNote переносит заметку в блокнот.
+// @Summary Перенести заметку
+// @Description Переносит заметку в блокнот notebookId или убирает её из блокнотов (notebookId: null).
+// @Description С заголовком If-Match перенос применяется, только если версия заметки совпадает с ETag.
+// @Tags notebooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Param If-Match header string false "ETag версии заметки (обязателен в строгом режиме)"
+// @Param input body MoveNoteRequest true "Целевой блокнот"
+// @Success 200 {object} core.Note "Заметка"
+// @Header 200 {string} ETag "Новая версия заметки"
+// @Failure 400 {object} Problem "Некорректные данные или блокнот не найден"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка не найдена"
+// @Failure 412 {object} Problem "Версия заметки не совпадает с If-Match"
+// @Failure 428 {object} Problem "Не передан If-Match (строгий режим)"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/{id}/move [post]
+func (h *Handler) MoveNote(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt64Param(r, "id")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var input MoveNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	version, ok := h.checkIfMatch(w, r, id)
+	if !ok {
+		return
+	}
+
+	note, err := h.Service.MoveNote(r.Context(), id, version, input.NotebookID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrNoteNotFound):
+			writeError(w, r, http.StatusNotFound, "note not found")
+		case errors.Is(err, repo.ErrVersionConflict):
+			writeError(w, r, http.StatusPreconditionFailed, "version mismatch")
+		case errors.Is(err, repo.ErrNotebookNotFound):
+			writeError(w, r, http.StatusBadRequest, "notebook not found")
+		default:
+			writeError(w, r, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", noteETag(note))
+	_ = json.NewEncoder(w).Encode(note)
+}
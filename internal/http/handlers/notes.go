@@ -3,19 +3,44 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"example.com/notes-api/internal/core"
 	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/jsonpatch"
 	"example.com/notes-api/internal/repo"
 )
 
 // Handler содержит зависимости для HTTP-обработчиков.
 type Handler struct {
 	Service *service.NoteService
+	// Auth обслуживает /auth.
+	Auth *service.AuthService
+	// Keys обслуживает /keys.
+	Keys *service.APIKeyService
+	// Webhooks обслуживает /webhooks.
+	Webhooks *service.WebhookService
+	// Audit обслуживает /audit.
+	Audit *service.AuditService
+	// Collab обслуживает /notes/{id}/collab.
+	Collab *service.CollabService
+	// CollabOrigins — Origin сторонних страниц, которым можно
+	// подключаться к /notes/{id}/collab, например https://app.example.com;
+	// страницы того же хоста и клиенты без Origin допускаются всегда.
+	CollabOrigins []string
+	// Events обслуживает /notes/events.
+	Events *service.EventBus
+	// EventsHeartbeat — период пингов в /notes/events (0 — 15 секунд).
+	EventsHeartbeat time.Duration
+	// RequireIfMatch — строгий режим: PATCH и DELETE без If-Match
+	// отклоняются с 428 Precondition Required.
+	RequireIfMatch bool
 }
 
 // NewHandler создаёт новый Handler.
@@ -23,6 +48,53 @@ func NewHandler(s *service.NoteService) *Handler {
 	return &Handler{Service: s}
 }
 
+// noteBodyHeadroom — запас тела запроса с заметкой сверх заголовка и
+// текста: имена полей, теги, блокнот.
+const noteBodyHeadroom = 64 << 10
+
+// limitNoteBody ограничивает тело запроса с заметкой размером, который
+// допускают правила из Limits: заголовок и текст наибольшей длины, каждый
+// байт которых в JSON может занять до 6 байт (\u003c), и
+// noteBodyHeadroom. Если у заголовка или текста нет ограничения, тело не
+// ограничивается.
+func (h *Handler) limitNoteBody(w http.ResponseWriter, r *http.Request) {
+	if n := h.noteBodyLimit(); n > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, n+noteBodyHeadroom)
+	}
+}
+
+// noteBodyLimit — наибольший размер заголовка и текста одной заметки в
+// JSON; 0 — без ограничения.
+func (h *Handler) noteBodyLimit() int64 {
+	l := h.Service.Limits()
+	title, content := ruleBytes(l.Title), ruleBytes(l.Content)
+	if title == 0 || content == 0 {
+		return 0
+	}
+	return 6 * (title + content)
+}
+
+// ruleBytes — наибольший размер поля по правилу rule в байтах UTF-8;
+// 0 — без ограничения.
+func ruleBytes(rule service.TextRule) int64 {
+	n := int64(rule.MaxBytes)
+	if rule.MaxLength > 0 && (n == 0 || 4*int64(rule.MaxLength) < n) {
+		n = 4 * int64(rule.MaxLength)
+	}
+	return n
+}
+
+// writeBodyError отвечает на ошибку чтения тела запроса: 413, если тело
+// длиннее допустимого (см. limitNoteBody), иначе 400.
+func writeBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeErrorf(w, r, http.StatusRequestEntityTooLarge, "request body must be at most %d bytes", tooLarge.Limit)
+		return
+	}
+	writeError(w, r, http.StatusBadRequest, "invalid JSON")
+}
+
 // CreateNoteRequest модель запроса на создание заметки.
 // @Description Данные для создания новой заметки
 type CreateNoteRequest struct {
@@ -30,6 +102,10 @@ type CreateNoteRequest struct {
 	Title string `json:"title" example:"Моя первая заметка"`
 	// Содержимое заметки
 	Content string `json:"content" example:"Текст заметки..."`
+	// Теги (опционально); регистр и лишние пробелы не учитываются
+	Tags []string `json:"tags,omitempty" example:"работа,отчёты"`
+	// ID блокнота (опционально)
+	NotebookID *int64 `json:"notebookId,omitempty" example:"2"`
 }
 
 // UpdateNoteRequest модель запроса на обновление заметки.
@@ -39,106 +115,307 @@ type UpdateNoteRequest struct {
 	Title *string `json:"title,omitempty" example:"Обновлённый заголовок"`
 	// Новое содержимое (опционально)
 	Content *string `json:"content,omitempty" example:"Обновлённый текст"`
+	// Новый набор тегов (опционально); заменяет прежний, [] снимает все теги
+	Tags *[]string `json:"tags,omitempty" example:"работа"`
 }
 
-// ErrorResponse модель ответа с ошибкой.
-// @Description Ответ сервера при возникновении ошибки
-type ErrorResponse struct {
-	// Описание ошибки
-	Error string `json:"error" example:"something went wrong"`
-}
-
-// вспомогательная функция для ошибок.
-func writeError(w http.ResponseWriter, status int, msg string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: msg})
+// SearchResultResponse модель результата полнотекстового поиска.
+// @Description Найденная заметка с оценкой релевантности и подсветкой совпадений
+type SearchResultResponse struct {
+	// Найденная заметка
+	Note core.Note `json:"note"`
+	// Релевантность (больше — лучше)
+	Score float64 `json:"score" example:"1.83"`
+	// Заголовок с совпадениями в <mark> (HTML-экранирован)
+	TitleHighlight string `json:"titleHighlight" example:"<mark>Отчёт</mark> за май"`
+	// Фрагмент содержимого с совпадениями в <mark> (HTML-экранирован)
+	Snippet string `json:"snippet" example:"…подготовить <mark>отчёт</mark> к пятнице…"`
 }
 
 // CreateNote создаёт новую заметку.
 // @Summary Создать заметку
-// @Description Создаёт новую заметку с указанным заголовком и содержимым
+// @Description Создаёт новую заметку с указанным заголовком и содержимым. Заголовок и текст проверяются по правилам из GET /limits
+// @Description и приводятся к NFC; ответ 400 перечисляет все нарушения сразу.
 // @Tags notes
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param input body CreateNoteRequest true "Данные заметки"
 // @Success 201 {object} core.Note "Созданная заметка"
-// @Failure 400 {object} ErrorResponse "Ошибка валидации"
-// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Header 201 {string} ETag "Версия заметки"
+// @Failure 400 {object} Problem "Ошибка валидации (пустой или слишком длинный заголовок, запрещённые символы, некорректные теги или неизвестный блокнот)"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 413 {object} Problem "Тело запроса больше, чем допускают ограничения из GET /limits"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Failure 507 {object} Problem "Исчерпана квота на число или объём заметок"
 // @Router /notes [post]
 func (h *Handler) CreateNote(w http.ResponseWriter, r *http.Request) {
 	var input CreateNoteRequest
 
+	h.limitNoteBody(w, r)
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid JSON")
+		writeBodyError(w, r, err)
 		return
 	}
 
-	note, err := h.Service.CreateNote(input.Title, input.Content)
+	note, err := h.Service.CreateNote(r.Context(), service.NoteCreateInput{
+		Title:      input.Title,
+		Content:    input.Content,
+		Tags:       input.Tags,
+		NotebookID: input.NotebookID,
+	})
 	if err != nil {
 		if errors.Is(err, service.ErrValidation) {
-			writeError(w, http.StatusBadRequest, "title is required")
+			writeValidationError(w, r, err)
+			return
+		}
+		if errors.Is(err, repo.ErrNotebookNotFound) {
+			writeError(w, r, http.StatusBadRequest, "notebook not found")
+			return
+		}
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			writeError(w, r, http.StatusInsufficientStorage, "storage quota exceeded")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, r, http.StatusInternalServerError, "internal error")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", noteETag(note))
 	w.WriteHeader(http.StatusCreated) // 201
 	_ = json.NewEncoder(w).Encode(note)
 }
 
-// ListNotes возвращает список всех заметок.
+// ListNotes возвращает страницу заметок.
 // @Summary Список заметок
-// @Description Возвращает массив всех заметок
+// @Description Возвращает страницу заметок с фильтрами и сортировкой.
+// @Description Если есть следующая страница, её курсор передаётся в заголовке X-Next-Cursor.
+// @Description Фильтры по времени строгие; для updatedAt у неизменённой заметки используется createdAt.
 // @Tags notes
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 500)" minimum(1) maximum(500)
+// @Param cursor query string false "Курсор из X-Next-Cursor предыдущей страницы"
+// @Param sort query string false "Поле сортировки" Enums(id, createdAt, updatedAt, title) default(id)
+// @Param order query string false "Направление сортировки" Enums(asc, desc) default(asc)
+// @Param createdAfter query string false "Созданы после (RFC 3339)" format(date-time)
+// @Param createdBefore query string false "Созданы до (RFC 3339)" format(date-time)
+// @Param updatedAfter query string false "Изменены после (RFC 3339)" format(date-time)
+// @Param updatedBefore query string false "Изменены до (RFC 3339)" format(date-time)
+// @Param titlePrefix query string false "Префикс заголовка (с учётом регистра)"
+// @Param tag query []string false "Тег; параметр можно повторять" collectionFormat(multi)
+// @Param tagMode query string false "all — нужны все теги, any — хотя бы один" Enums(all, any) default(all)
 // @Success 200 {array} core.Note "Список заметок"
-// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
+// @Failure 400 {object} Problem "Некорректные параметры запроса"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
 // @Router /notes [get]
 func (h *Handler) ListNotes(w http.ResponseWriter, r *http.Request) {
-	notes, err := h.Service.ListNotes()
+	q, err := parseNoteQuery(r)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.Service.ListNotes(r.Context(), q)
+	if err != nil {
+		if errors.Is(err, repo.ErrInvalidCursor) {
+			writeError(w, r, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		if errors.Is(err, service.ErrValidation) {
+			writeValidationError(w, r, err)
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal error")
 		return
 	}
-	
+
 	// Возвращаем пустой массив вместо null
+	notes := page.Notes
 	if notes == nil {
 		notes = []core.Note{}
 	}
-	
+
+	if page.Next != nil {
+		w.Header().Set("X-Next-Cursor", repo.EncodeCursor(page.Next))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(notes)
 }
 
+// parseNoteQuery разбирает query-параметры списка заметок.
+func parseNoteQuery(r *http.Request) (repo.NoteQuery, error) {
+	params := r.URL.Query()
+	var q repo.NoteQuery
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return q, errors.New("invalid limit")
+		}
+		q.Limit = limit
+	}
+
+	if v := params.Get("sort"); v != "" {
+		q.SortBy = repo.NoteSortField(v)
+		if !q.SortBy.Valid() {
+			return q, errors.New("invalid sort")
+		}
+	}
+	switch params.Get("order") {
+	case "", "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return q, errors.New("invalid order")
+	}
+
+	if v := params.Get("cursor"); v != "" {
+		c, err := repo.DecodeCursor(v)
+		if err != nil {
+			return q, errors.New("invalid cursor")
+		}
+		q.After = c
+	}
+
+	times := []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"createdAfter", &q.CreatedAfter},
+		{"createdBefore", &q.CreatedBefore},
+		{"updatedAfter", &q.UpdatedAfter},
+		{"updatedBefore", &q.UpdatedBefore},
+	}
+	for _, p := range times {
+		v := params.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return q, errors.New("invalid " + p.name)
+		}
+		*p.dst = &t
+	}
+
+	q.TitlePrefix = params.Get("titlePrefix")
+
+	q.Tags = params["tag"]
+	switch params.Get("tagMode") {
+	case "", "all":
+	case "any":
+		q.AnyTag = true
+	default:
+		return q, errors.New("invalid tagMode")
+	}
+	return q, nil
+}
+
+// SearchNotes выполняет полнотекстовый поиск по заметкам.
+// @Summary Поиск заметок
+// @Description Ищет заметки по заголовку и содержимому. Регистр и диакритика (ё/е, é/e) не учитываются,
+// @Description русские и английские словоформы сводятся к общей основе. Все слова запроса должны
+// @Description встречаться в заметке; слова в кавычках ищутся как фраза. Результаты упорядочены по релевантности.
+// @Tags notes
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param q query string true "Поисковый запрос; фраза берётся в кавычки"
+// @Param limit query int false "Максимум результатов (по умолчанию 20, максимум 100)" minimum(1) maximum(100)
+// @Success 200 {array} SearchResultResponse "Результаты поиска"
+// @Failure 400 {object} Problem "Пустой или некорректный запрос"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/search [get]
+func (h *Handler) SearchNotes(w http.ResponseWriter, r *http.Request) {
+	text := r.URL.Query().Get("q")
+	if text == "" {
+		writeError(w, r, http.StatusBadRequest, "query is required")
+		return
+	}
+	var limit int
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, r, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.Service.SearchNotes(r.Context(), text, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrValidation) {
+			writeValidationError(w, r, err)
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	resp := make([]SearchResultResponse, 0, len(results))
+	for _, res := range results {
+		resp = append(resp, SearchResultResponse{
+			Note:           res.Note,
+			Score:          res.Score,
+			TitleHighlight: res.Title,
+			Snippet:        res.Snippet,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
 // GetNote возвращает заметку по ID.
 // @Summary Получить заметку
-// @Description Возвращает заметку по её идентификатору
+// @Description Возвращает свою заметку или заметку, которой поделился другой пользователь. Ответ содержит ETag с версией заметки;
+// @Description если она совпадает с If-None-Match, возвращается 304 без тела.
 // @Tags notes
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "ID заметки"
+// @Param If-None-Match header string false "ETag ранее полученной версии"
 // @Success 200 {object} core.Note "Найденная заметка"
-// @Failure 400 {object} ErrorResponse "Некорректный ID"
-// @Failure 404 {object} ErrorResponse "Заметка не найдена"
-// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Header 200 {string} ETag "Версия заметки"
+// @Success 304 "Заметка не изменилась"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка не найдена"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
 // @Router /notes/{id} [get]
 func (h *Handler) GetNote(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid id")
+		writeError(w, r, http.StatusBadRequest, "invalid id")
 		return
 	}
 
-	note, err := h.Service.GetNote(id)
+	note, err := h.Service.GetNote(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, repo.ErrNoteNotFound) {
-			writeError(w, http.StatusNotFound, "note not found")
+			writeError(w, r, http.StatusNotFound, "note not found")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.Header().Set("ETag", noteETag(note))
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, note) {
+		w.WriteHeader(http.StatusNotModified) // 304
 		return
 	}
 
@@ -148,79 +425,212 @@ func (h *Handler) GetNote(w http.ResponseWriter, r *http.Request) {
 
 // UpdateNote частично обновляет заметку.
 // @Summary Обновить заметку
-// @Description Частично обновляет заметку (PATCH). Можно обновить только title, только content или оба поля.
+// @Description Частично обновляет заметку (PATCH). Формат тела выбирается по Content-Type:
+// @Description application/json — поля UpdateNoteRequest, переданные поля заменяются;
+// @Description application/merge-patch+json — JSON Merge Patch (RFC 7396) к документу NoteDocument {title, content, tags, notebookId},
+// @Description null удаляет поле: {"notebookId": null} выносит заметку из блокнота;
+// @Description application/json-patch+json — JSON Patch (RFC 6902) к тому же документу, например
+// @Description [{"op": "test", "path": "/title", "value": "Черновик"}, {"op": "add", "path": "/tags/-", "value": "готово"}].
+// @Description Патч применяется к текущей версии заметки целиком или не применяется вовсе; результат проверяется по правилам
+// @Description из GET /limits. Не прошедшая операция test или отсутствующий путь — 409, другой Content-Type — 415.
+// @Description Перенести заметку в другой блокнот патчем может только владелец.
+// @Description С заголовком If-Match изменение применяется, только если версия заметки совпадает с ETag.
 // @Tags notes
-// @Accept json
+// @Accept json,application/merge-patch+json,application/json-patch+json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "ID заметки"
-// @Param input body UpdateNoteRequest true "Данные для обновления"
+// @Param If-Match header string false "ETag версии, которую клиент изменяет (обязателен в строгом режиме)"
+// @Param input body UpdateNoteRequest true "Данные для обновления, JSON Merge Patch или JSON Patch"
 // @Success 200 {object} core.Note "Обновлённая заметка"
-// @Failure 400 {object} ErrorResponse "Некорректные данные"
-// @Failure 404 {object} ErrorResponse "Заметка не найдена"
-// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Header 200 {string} ETag "Новая версия заметки"
+// @Failure 400 {object} Problem "Некорректные данные или патч (правила — в GET /limits), блокнот не найден"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "Роль viewer, перенос в блокнот не владельцем или у API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка не найдена"
+// @Failure 409 {object} Problem "Операция test JSON Patch не прошла или пути нет в документе"
+// @Failure 412 {object} Problem "Версия заметки не совпадает с If-Match"
+// @Failure 413 {object} Problem "Тело запроса больше, чем допускают ограничения из GET /limits"
+// @Failure 415 {object} Problem "Неподдерживаемый Content-Type; допустимые — в заголовке Accept-Patch"
+// @Failure 428 {object} Problem "Не передан If-Match (строгий режим)"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Failure 507 {object} Problem "Исчерпана квота на число или объём заметок"
 // @Router /notes/{id} [patch]
 func (h *Handler) UpdateNote(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid id")
+		writeError(w, r, http.StatusBadRequest, "invalid id")
 		return
 	}
 
-	var input UpdateNoteRequest
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid JSON")
+	// patch == nil — обычный JSON с полями UpdateNoteRequest
+	var (
+		input UpdateNoteRequest
+		patch service.NotePatch
+	)
+	h.limitNoteBody(w, r)
+	switch patchMediaType(r) {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			writeBodyError(w, r, err)
+			return
+		}
+	case MergePatchContentType:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeBodyError(w, r, err)
+			return
+		}
+		if !json.Valid(body) {
+			writeError(w, r, http.StatusBadRequest, "invalid JSON")
+			return
+		}
+		patch = func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, body)
+		}
+	case JSONPatchContentType:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeBodyError(w, r, err)
+			return
+		}
+		ops, err := jsonpatch.Parse(body)
+		if err != nil {
+			var opErr *jsonpatch.OpError
+			if errors.As(err, &opErr) {
+				writeErrorf(w, r, http.StatusBadRequest, "invalid JSON Patch operation %d", opErr.Index)
+			} else {
+				writeError(w, r, http.StatusBadRequest, "invalid JSON Patch")
+			}
+			return
+		}
+		patch = ops.Apply
+	default:
+		w.Header().Set("Accept-Patch", acceptPatch)
+		writeError(w, r, http.StatusUnsupportedMediaType, "unsupported content type")
 		return
 	}
 
-	// Конвертируем в service.NoteUpdateInput
-	updateInput := service.NoteUpdateInput{
-		Title:   input.Title,
-		Content: input.Content,
+	version, ok := h.checkIfMatch(w, r, id)
+	if !ok {
+		return
 	}
 
-	note, err := h.Service.UpdateNote(id, updateInput)
+	var note *core.Note
+	if patch != nil {
+		note, err = h.Service.PatchNote(r.Context(), id, version, patch)
+	} else {
+		note, err = h.Service.UpdateNote(r.Context(), id, version, service.NoteUpdateInput{
+			Title:   input.Title,
+			Content: input.Content,
+			Tags:    input.Tags,
+		})
+	}
 	if err != nil {
-		if errors.Is(err, repo.ErrNoteNotFound) {
-			writeError(w, http.StatusNotFound, "note not found")
-			return
+		var opErr *jsonpatch.OpError
+		switch {
+		case errors.Is(err, repo.ErrNoteNotFound):
+			writeError(w, r, http.StatusNotFound, "note not found")
+		case errors.Is(err, repo.ErrVersionConflict):
+			writeError(w, r, http.StatusPreconditionFailed, "version mismatch")
+		case errors.Is(err, service.ErrValidation):
+			writeValidationError(w, r, err)
+		case errors.Is(err, service.ErrNotOwner):
+			writeError(w, r, http.StatusForbidden, "only the owner can move the note")
+		case errors.Is(err, service.ErrForbidden):
+			writeError(w, r, http.StatusForbidden, "viewer cannot edit the note")
+		case errors.Is(err, repo.ErrNotebookNotFound):
+			writeError(w, r, http.StatusBadRequest, "notebook not found")
+		case errors.Is(err, service.ErrQuotaExceeded):
+			writeError(w, r, http.StatusInsufficientStorage, "storage quota exceeded")
+		case errors.As(err, &opErr) && errors.Is(err, jsonpatch.ErrTestFailed):
+			writeErrorf(w, r, http.StatusConflict, "JSON Patch operation %d: test failed", opErr.Index)
+		case errors.As(err, &opErr) && errors.Is(err, jsonpatch.ErrPath):
+			writeErrorf(w, r, http.StatusConflict, "JSON Patch operation %d: path %s does not exist", opErr.Index, opErr.Path)
+		default:
+			writeError(w, r, http.StatusInternalServerError, "internal error")
 		}
-		if errors.Is(err, service.ErrValidation) {
-			writeError(w, http.StatusBadRequest, "invalid data")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", noteETag(note))
 	_ = json.NewEncoder(w).Encode(note)
 }
 
-// DeleteNote удаляет заметку.
+const (
+	// MergePatchContentType — тело PATCH в формате JSON Merge Patch (RFC 7396).
+	MergePatchContentType = "application/merge-patch+json"
+	// JSONPatchContentType — тело PATCH в формате JSON Patch (RFC 6902).
+	JSONPatchContentType = "application/json-patch+json"
+)
+
+// acceptPatch — значение заголовка Accept-Patch: форматы тела PATCH
+// /notes/{id}.
+const acceptPatch = "application/json, " + MergePatchContentType + ", " + JSONPatchContentType
+
+// patchMediaType возвращает тип тела запроса без параметров; без
+// Content-Type тело считается обычным JSON.
+func patchMediaType(r *http.Request) string {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return "application/json"
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+// DeleteNote перемещает заметку в корзину.
 // @Summary Удалить заметку
-// @Description Удаляет заметку по ID. При успехе возвращает 204 No Content.
+// @Description Перемещает заметку в корзину: она пропадает из списка и поиска, но её можно восстановить
+// @Description через POST /notes/{id}/restore до истечения срока хранения. При успехе возвращает 204 No Content.
+// @Description С заголовком If-Match заметка удаляется, только если её версия совпадает с ETag.
 // @Tags notes
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "ID заметки"
-// @Success 204 "Заметка удалена"
-// @Failure 400 {object} ErrorResponse "Некорректный ID"
-// @Failure 404 {object} ErrorResponse "Заметка не найдена"
-// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Param If-Match header string false "ETag удаляемой версии (обязателен в строгом режиме)"
+// @Success 204 "Заметка перемещена в корзину"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "Не владелец заметки или у API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка не найдена"
+// @Failure 412 {object} Problem "Версия заметки не совпадает с If-Match"
+// @Failure 428 {object} Problem "Не передан If-Match (строгий режим)"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
 // @Router /notes/{id} [delete]
 func (h *Handler) DeleteNote(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid id")
+		writeError(w, r, http.StatusBadRequest, "invalid id")
 		return
 	}
 
-	if err := h.Service.DeleteNote(id); err != nil {
+	version, ok := h.checkIfMatch(w, r, id)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteNote(r.Context(), id, version); err != nil {
 		if errors.Is(err, repo.ErrNoteNotFound) {
-			writeError(w, http.StatusNotFound, "note not found")
+			writeError(w, r, http.StatusNotFound, "note not found")
+			return
+		}
+		if errors.Is(err, repo.ErrVersionConflict) {
+			writeError(w, r, http.StatusPreconditionFailed, "version mismatch")
+			return
+		}
+		if errors.Is(err, service.ErrForbidden) {
+			writeError(w, r, http.StatusForbidden, "only the owner can delete the note")
 			return
 		}
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, r, http.StatusInternalServerError, "internal error")
 		return
 	}
 
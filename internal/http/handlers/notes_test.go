@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/repo"
+)
+
+// newLimitedHandler — Handler с заметкой 1 пользователя 1 и правилами,
+// при которых тело запроса ограничено 6*(40+100)+noteBodyHeadroom байт.
+func newLimitedHandler(t *testing.T) (*Handler, context.Context) {
+	t.Helper()
+	svc := service.NewNoteService(repo.NewNoteRepoMem(), service.WithNoteLimits(service.NoteLimits{
+		Title:   service.TextRule{MaxLength: 10},
+		Content: service.TextRule{MaxBytes: 100, Multiline: true},
+	}))
+	ctx := core.WithPrincipal(context.Background(), core.Principal{UserID: 1})
+	if _, err := svc.CreateNote(ctx, service.NoteCreateInput{Title: "t"}); err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+	return NewHandler(svc), ctx
+}
+
+func TestNoteBodyLimit(t *testing.T) {
+	h, ctx := newLimitedHandler(t)
+	limit := 6*(40+100) + noteBodyHeadroom
+	fields := func(content string) string { return `{"title": "t", "content": "` + content + `"}` }
+	ops := func(content string) string {
+		return `[{"op": "replace", "path": "/content", "value": "` + content + `"}]`
+	}
+
+	tests := []struct {
+		name, method, contentType string
+		handler                   http.HandlerFunc
+		body                      func(content string) string
+	}{
+		{"create", http.MethodPost, "application/json", h.CreateNote, fields},
+		{"update", http.MethodPatch, "application/json", h.UpdateNote, fields},
+		{"merge patch", http.MethodPatch, MergePatchContentType, h.UpdateNote, fields},
+		{"json patch", http.MethodPatch, JSONPatchContentType, h.UpdateNote, ops},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "1")
+			ctx := context.WithValue(ctx, chi.RouteCtxKey, rctx)
+
+			r := httptest.NewRequest(tt.method, "/notes/1", strings.NewReader(tt.body(strings.Repeat("a", limit)))).WithContext(ctx)
+			r.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			tt.handler(w, r)
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("status = %d, want 413; body %s", w.Code, w.Body)
+			}
+
+			// текст сверх правил, но в пределах тела — обычная ошибка валидации
+			r = httptest.NewRequest(tt.method, "/notes/1", strings.NewReader(tt.body(strings.Repeat("a", 101)))).WithContext(ctx)
+			r.Header.Set("Content-Type", tt.contentType)
+			w = httptest.NewRecorder()
+			tt.handler(w, r)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400; body %s", w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/i18n"
+)
+
+const (
+	// ProblemContentType — тип ответов с ошибкой (RFC 7807).
+	ProblemContentType = "application/problem+json"
+	// ProblemTypeValidation — тип ошибки проверки входных данных;
+	// нарушенные правила перечислены в Problem.Errors.
+	ProblemTypeValidation = "/problems/validation-error"
+)
+
+// Problem модель ответа с ошибкой.
+// @Description Ошибка в формате RFC 7807 (application/problem+json)
+type Problem struct {
+	// Тип ошибки: about:blank — смысл передаёт статус, /problems/validation-error — неверные входные данные
+	Type string `json:"type" example:"/problems/validation-error"`
+	// Краткое описание типа ошибки на языке из Accept-Language
+	Title string `json:"title" example:"Validation failed"`
+	// HTTP-статус ответа
+	Status int `json:"status" example:"400"`
+	// Описание этого случая на языке из Accept-Language
+	Detail string `json:"detail,omitempty" example:"title is required"`
+	// ID запроса (X-Request-Id); по нему ошибку можно найти в логах сервера
+	Instance string `json:"instance,omitempty" example:"host/AbCdEf-000001"`
+	// Нарушенные правила (только для /problems/validation-error)
+	Errors []service.Violation `json:"errors,omitempty"`
+}
+
+// WriteProblem отвечает ошибкой p. Пустые Type, Title и Instance
+// заполняются по статусу и ID запроса. Title, Detail и сообщения Errors
+// переводятся на язык из Accept-Language (см. i18n.Negotiate).
+func WriteProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	p = completeProblem(r, p)
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("Content-Language", i18n.FromRequest(r).String())
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// completeProblem заполняет и переводит p, как WriteProblem, но ничего
+// не пишет в ответ.
+func completeProblem(r *http.Request, p Problem) Problem {
+	lang := i18n.FromRequest(r)
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	p.Title = i18n.Text(lang, p.Title)
+	p.Detail = i18n.Text(lang, p.Detail)
+	if p.Errors != nil {
+		errs := make([]service.Violation, len(p.Errors))
+		for i, v := range p.Errors {
+			errs[i] = v.Localize(lang)
+		}
+		p.Errors = errs
+	}
+	if p.Instance == "" {
+		p.Instance = middleware.GetReqID(r.Context())
+	}
+	return p
+}
+
+// вспомогательная функция для ошибок.
+func writeError(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	WriteProblem(w, r, Problem{Status: status, Detail: detail})
+}
+
+// writeErrorf — writeError с сообщением fmt.Sprintf(format, args...)
+// на языке клиента; format — ключ каталога i18n.
+func writeErrorf(w http.ResponseWriter, r *http.Request, status int, format string, args ...any) {
+	writeError(w, r, status, i18n.Sprintf(i18n.FromRequest(r), format, args...))
+}
+
+// writeValidationError отвечает 400 со списком нарушенных правил из
+// service.ValidationError.
+func writeValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	WriteProblem(w, r, validationProblem(r, err))
+}
+
+// validationProblem — ответ 400 для writeValidationError.
+func validationProblem(r *http.Request, err error) Problem {
+	p := Problem{
+		Type:   ProblemTypeValidation,
+		Title:  "Validation failed",
+		Status: http.StatusBadRequest,
+		Detail: "invalid data",
+	}
+	var verr *service.ValidationError
+	if errors.As(err, &verr) {
+		lang := i18n.FromRequest(r)
+		p.Errors = make([]service.Violation, len(verr.Violations))
+		msgs := make([]string, len(verr.Violations))
+		for i, v := range verr.Violations {
+			p.Errors[i] = v.Localize(lang)
+			msgs[i] = p.Errors[i].Message
+		}
+		p.Detail = strings.Join(msgs, "; ")
+	}
+	return p
+}
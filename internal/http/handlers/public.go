@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/text/language"
+
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/i18n"
+	"example.com/notes-api/internal/repo"
+)
+
+// SharePasswordHeader — заголовок с паролем защищённой публичной ссылки.
+const SharePasswordHeader = "X-Share-Password"
+
+// PublicNote модель заметки, открытой по публичной ссылке.
+// @Description Заметка без служебных полей (владелец, блокнот, версия)
+type PublicNote struct {
+	// Заголовок заметки
+	Title string `json:"title" example:"Моя заметка"`
+	// Содержимое заметки
+	Content string `json:"content" example:"Текст заметки..."`
+	// Теги заметки
+	Tags []string `json:"tags,omitempty" example:"работа"`
+	// Дата и время создания
+	CreatedAt time.Time `json:"createdAt" example:"2024-12-08T12:00:00Z"`
+	// Дата и время последнего обновления
+	UpdatedAt *time.Time `json:"updatedAt,omitempty" example:"2024-12-08T13:00:00Z"`
+}
+
+// publicPage — данные HTML-страницы публичной ссылки: заметка, форма
+// пароля или сообщение об ошибке. Тексты страницы переводятся на язык
+// Lang из Accept-Language, как и сообщения об ошибках API.
+type publicPage struct {
+	Lang          language.Tag
+	Title         string
+	Note          *PublicNote
+	NeedPassword  bool
+	WrongPassword bool
+	Message       string
+}
+
+// T переводит текст страницы на язык страницы.
+func (p publicPage) T(s string) string {
+	return i18n.Text(p.Lang, s)
+}
+
+// Date форматирует время так, как принято в языке страницы.
+func (p publicPage) Date(t time.Time) string {
+	return t.Format(p.T(publicDateLayout))
+}
+
+// publicDateLayout — формат даты на странице; каталоги i18n переводят
+// его в формат своего языка.
+const publicDateLayout = "Jan 2, 2006 15:04"
+
+var publicTemplate = template.Must(template.New("public").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 46rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+.meta { color: #777; font-size: .9rem; }
+.tag { display: inline-block; background: #eee; border-radius: .3rem; padding: 0 .4rem; margin-right: .3rem; }
+pre { white-space: pre-wrap; word-wrap: break-word; font: inherit; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+{{- if .Note}}
+<h1>{{.Note.Title}}</h1>
+<p class="meta">{{.Date .Note.CreatedAt}}{{if .Note.UpdatedAt}}, {{.T "updated"}} {{.Date .Note.UpdatedAt}}{{end}} (UTC)</p>
+{{- if .Note.Tags}}
+<p>{{range .Note.Tags}}<span class="tag">{{.}}</span>{{end}}</p>
+{{- end}}
+<pre>{{.Note.Content}}</pre>
+{{- else if .NeedPassword}}
+<h1>{{.T "The note is protected by a password"}}</h1>
+{{- if .WrongPassword}}
+<p class="error">{{.T "Wrong password."}}</p>
+{{- end}}
+<form method="post">
+<input type="password" name="password" autofocus required>
+<button type="submit">{{.T "Open"}}</button>
+</form>
+{{- else}}
+<h1>{{.Message}}</h1>
+{{- end}}
+</body>
+</html>
+`))
+
+// wantsHTML выбирает формат ответа: параметр format=html|json, а без
+// него — HTML, если клиент его принимает (браузер), иначе JSON.
+func wantsHTML(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "html":
+		return true
+	case "json":
+		return false
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func writePublicPage(w http.ResponseWriter, r *http.Request, status int, page publicPage) {
+	page.Lang = i18n.FromRequest(r)
+	if page.Note == nil {
+		// заголовок страницы с заметкой — заголовок заметки, его не переводят
+		page.Title, page.Message = page.T(page.Title), page.T(page.Message)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Language", page.Lang.String())
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'")
+	w.WriteHeader(status)
+	_ = publicTemplate.Execute(w, page)
+}
+
+// OpenShareLink открывает заметку по публичной ссылке.
+// @Summary Открыть публичную ссылку
+// @Description Возвращает заметку по токену ссылки без аутентификации: браузеру — HTML-страницей, остальным — JSON
+// @Description (формат можно задать параметром format). Пароль защищённой ссылки передаётся в заголовке X-Share-Password
+// @Description или полем password формы (POST); HTML-страница сама показывает форму пароля. Каждое открытие учитывается.
+// @Tags public
+// @Accept x-www-form-urlencoded
+// @Produce json,html
+// @Param token path string true "Токен ссылки"
+// @Param format query string false "Формат ответа" Enums(json, html)
+// @Param X-Share-Password header string false "Пароль ссылки"
+// @Success 200 {object} PublicNote "Заметка"
+// @Failure 403 {object} Problem "Нужен пароль или пароль неверен"
+// @Failure 404 {object} Problem "Ссылка не найдена, отозвана или истекла"
+// @Failure 429 {object} Problem "Слишком много запросов с этого адреса, см. Retry-After"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /public/notes/{token} [get]
+// @Router /public/notes/{token} [post]
+func (h *Handler) OpenShareLink(w http.ResponseWriter, r *http.Request) {
+	// токен в URL не должен утекать ни в кэши, ни в Referer, ни в поиск
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.Header().Set("Vary", "Accept, Accept-Language")
+	html := wantsHTML(r)
+
+	password := r.Header.Get(SharePasswordHeader)
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, 4<<10)
+		password = r.PostFormValue("password")
+	}
+
+	note, err := h.Service.OpenShareLink(chi.URLParam(r, "token"), password)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrShareLinkNotFound):
+			if html {
+				writePublicPage(w, r, http.StatusNotFound, publicPage{Title: "Link is invalid", Message: "The link was not found, revoked or has expired"})
+				return
+			}
+			writeError(w, r, http.StatusNotFound, "share link not found")
+		case errors.Is(err, service.ErrLinkPassword):
+			if html {
+				writePublicPage(w, r, http.StatusForbidden, publicPage{Title: "Password required", NeedPassword: true, WrongPassword: password != ""})
+				return
+			}
+			writeError(w, r, http.StatusForbidden, "share link password is missing or wrong")
+		default:
+			writeError(w, r, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+
+	pub := PublicNote{
+		Title:     note.Title,
+		Content:   note.Content,
+		Tags:      note.Tags,
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+	}
+	if html {
+		writePublicPage(w, r, http.StatusOK, publicPage{Title: note.Title, Note: &pub})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(pub)
+}
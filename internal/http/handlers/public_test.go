@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/repo"
+)
+
+func TestPublicPageLanguage(t *testing.T) {
+	svc := service.NewNoteService(repo.NewNoteRepoMem(), service.WithShareLinks(repo.NewShareLinkRepoMem()))
+	h := NewHandler(svc)
+	ctx := core.WithPrincipal(context.Background(), core.Principal{UserID: 1})
+	// заголовок заметки совпадает с текстом из каталога, но не переводится
+	n, err := svc.CreateNote(ctx, service.NoteCreateInput{Title: "Open", Content: "текст"})
+	if err != nil {
+		t.Fatalf("CreateNote: %v", err)
+	}
+	_, open, err := svc.CreateShareLink(ctx, n.ID, nil, "")
+	if err != nil {
+		t.Fatalf("CreateShareLink: %v", err)
+	}
+	_, locked, err := svc.CreateShareLink(ctx, n.ID, nil, "secret")
+	if err != nil {
+		t.Fatalf("CreateShareLink: %v", err)
+	}
+
+	tests := []struct {
+		name, token, acceptLanguage string
+		status                      int
+		lang                        string
+		want, notWant               []string
+	}{
+		{"note en", open, "en-US", http.StatusOK, "en", []string{"<h1>Open</h1>", n.CreatedAt.Format("Jan 2, 2006 15:04")}, nil},
+		{"note ru", open, "ru", http.StatusOK, "ru", []string{"<h1>Open</h1>", "<title>Open</title>", n.CreatedAt.Format("02.01.2006 15:04")}, []string{"Открыть"}},
+		{"password en", locked, "", http.StatusForbidden, "en", []string{"<title>Password required</title>", "protected by a password", `<button type="submit">Open</button>`}, []string{"Wrong password"}},
+		{"password ru", locked, "ru-RU,ru;q=0.9", http.StatusForbidden, "ru", []string{"<title>Нужен пароль</title>", "Заметка защищена паролем", "Открыть"}, nil},
+		{"not found en", "nope", "de, en;q=0.5", http.StatusNotFound, "en", []string{"<title>Link is invalid</title>", "revoked or has expired"}, nil},
+		{"not found ru", "nope", "ru", http.StatusNotFound, "ru", []string{"<title>Ссылка недействительна</title>", "отозвана или истекла"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("token", tt.token)
+			r := httptest.NewRequest(http.MethodGet, "/public/notes/"+tt.token, nil)
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			r.Header.Set("Accept", "text/html")
+			r.Header.Set("Accept-Language", tt.acceptLanguage)
+			w := httptest.NewRecorder()
+			h.OpenShareLink(w, r)
+
+			body := w.Body.String()
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Content-Language"); got != tt.lang {
+				t.Errorf("Content-Language = %q, want %q", got, tt.lang)
+			}
+			want := append([]string{`<html lang="` + tt.lang + `">`}, tt.want...)
+			for _, s := range want {
+				if !strings.Contains(body, s) {
+					t.Errorf("body lacks %q:\n%s", s, body)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(body, s) {
+					t.Errorf("body contains %q:\n%s", s, body)
+				}
+			}
+		})
+	}
+
+	// неверный пароль из формы
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("token", locked)
+	r := httptest.NewRequest(http.MethodPost, "/public/notes/"+locked, strings.NewReader("password=wrong"))
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Accept", "text/html")
+	r.Header.Set("Accept-Language", "ru")
+	w := httptest.NewRecorder()
+	h.OpenShareLink(w, r)
+	if !strings.Contains(w.Body.String(), "Неверный пароль.") {
+		t.Errorf("wrong password page:\n%s", w.Body)
+	}
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/textdiff"
+)
+
+// RevisionDiffResponse модель построчной разницы между ревизиями.
+// @Description Разница между двумя ревизиями заметки
+type RevisionDiffResponse struct {
+	// Исходная ревизия
+	From int64 `json:"from" example:"1"`
+	// Конечная ревизия
+	To int64 `json:"to" example:"3"`
+	// Изменения заголовка
+	Title []textdiff.Line `json:"title"`
+	// Построчные изменения содержимого
+	Content []textdiff.Line `json:"content"`
+	// Изменения содержимого в unified-виде (строки с префиксами « », «+», «-»)
+	Unified string `json:"unified" example:" первая строка\n-старая строка\n+новая строка\n"`
+}
+
+// parseInt64Param разбирает числовой параметр пути.
+func parseInt64Param(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+}
+
+// writeRevisionError переводит ошибки истории в HTTP-ответ.
+func writeRevisionError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repo.ErrNoteNotFound):
+		writeError(w, r, http.StatusNotFound, "note not found")
+	case errors.Is(err, repo.ErrRevisionNotFound):
+		writeError(w, r, http.StatusNotFound, "revision not found")
+	default:
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// ListRevisions возвращает историю заметки.
+// @Summary История заметки
+// @Description Возвращает сохранённые ревизии заметки по возрастанию номера.
+// @Description Старые ревизии удаляются согласно настройкам хранения; последняя сохраняется всегда.
+// @Tags revisions
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Success 200 {array} core.NoteRevision "Ревизии заметки"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка не найдена"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/{id}/revisions [get]
+func (h *Handler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt64Param(r, "id")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	revs, err := h.Service.ListRevisions(r.Context(), id)
+	if err != nil {
+		writeRevisionError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(revs)
+}
+
+// GetRevision возвращает одну ревизию заметки.
+// @Summary Получить ревизию
+// @Description Возвращает заголовок и содержимое заметки в указанной ревизии
+// @Tags revisions
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Param rev path int true "Номер ревизии"
+// @Success 200 {object} core.NoteRevision "Ревизия"
+// @Failure 400 {object} Problem "Некорректный ID или номер ревизии"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка или ревизия не найдена"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/{id}/revisions/{rev} [get]
+func (h *Handler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt64Param(r, "id")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	rev, err := parseInt64Param(r, "rev")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid revision")
+		return
+	}
+
+	revision, err := h.Service.GetRevision(r.Context(), id, rev)
+	if err != nil {
+		writeRevisionError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(revision)
+}
+
+// DiffRevisions сравнивает две ревизии заметки.
+// @Summary Разница между ревизиями
+// @Description Построчно сравнивает заголовок и содержимое двух ревизий заметки
+// @Tags revisions
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Param from query int true "Исходная ревизия"
+// @Param to query int false "Конечная ревизия (по умолчанию — текущая версия)"
+// @Success 200 {object} RevisionDiffResponse "Разница между ревизиями"
+// @Failure 400 {object} Problem "Некорректные параметры"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка или ревизия не найдена"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/{id}/revisions/diff [get]
+func (h *Handler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt64Param(r, "id")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	from, err := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid from")
+		return
+	}
+	var to int64
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = strconv.ParseInt(v, 10, 64); err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid to")
+			return
+		}
+	}
+
+	diff, err := h.Service.DiffRevisions(r.Context(), id, from, to)
+	if err != nil {
+		writeRevisionError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(RevisionDiffResponse{
+		From:    diff.From,
+		To:      diff.To,
+		Title:   diff.Title,
+		Content: diff.Content,
+		Unified: textdiff.Unified(diff.Content),
+	})
+}
+
+// RestoreRevision восстанавливает заметку из ревизии.
+// @Summary Восстановить ревизию
+// @Description Возвращает заметке заголовок и содержимое из ревизии. Это обычное изменение:
+// @Description версия заметки растёт и в истории появляется новая ревизия. Поддерживает If-Match.
+// @Tags revisions
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Param rev path int true "Номер восстанавливаемой ревизии"
+// @Param If-Match header string false "ETag текущей версии заметки (обязателен в строгом режиме)"
+// @Success 200 {object} core.Note "Восстановленная заметка"
+// @Header 200 {string} ETag "Новая версия заметки"
+// @Failure 400 {object} Problem "Некорректный ID, номер ревизии или данные"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка или ревизия не найдена"
+// @Failure 412 {object} Problem "Версия заметки не совпадает с If-Match"
+// @Failure 428 {object} Problem "Не передан If-Match (строгий режим)"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Failure 507 {object} Problem "Исчерпана квота на число или объём заметок"
+// @Router /notes/{id}/revisions/{rev}/restore [post]
+func (h *Handler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt64Param(r, "id")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	rev, err := parseInt64Param(r, "rev")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid revision")
+		return
+	}
+
+	version, ok := h.checkIfMatch(w, r, id)
+	if !ok {
+		return
+	}
+
+	note, err := h.Service.RestoreRevision(r.Context(), id, rev, version)
+	if err != nil {
+		if errors.Is(err, repo.ErrVersionConflict) {
+			writeError(w, r, http.StatusPreconditionFailed, "version mismatch")
+			return
+		}
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			writeError(w, r, http.StatusInsufficientStorage, "storage quota exceeded")
+			return
+		}
+		if errors.Is(err, service.ErrValidation) {
+			writeValidationError(w, r, err)
+			return
+		}
+		writeRevisionError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", noteETag(note))
+	_ = json.NewEncoder(w).Encode(note)
+}
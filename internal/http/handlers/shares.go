@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/repo"
+)
+
+// ShareRequest модель запроса на выдачу доступа.
+// @Description Роль пользователя в заметке
+type ShareRequest struct {
+	// Роль: viewer (только чтение) или editor (чтение и изменение)
+	Role core.ShareRole `json:"role" example:"editor" enums:"viewer,editor"`
+}
+
+// writeShareError переводит ошибки операций с доступом в HTTP-ответ.
+func writeShareError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repo.ErrNoteNotFound):
+		writeError(w, r, http.StatusNotFound, "note not found")
+	case errors.Is(err, repo.ErrUserNotFound):
+		writeError(w, r, http.StatusNotFound, "user not found")
+	case errors.Is(err, repo.ErrShareNotFound):
+		writeError(w, r, http.StatusNotFound, "share not found")
+	case errors.Is(err, service.ErrValidation):
+		writeValidationError(w, r, err)
+	case errors.Is(err, service.ErrForbidden):
+		writeError(w, r, http.StatusForbidden, "only the owner can manage access")
+	default:
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// ShareNote открывает заметку другому пользователю.
+// @Summary Поделиться заметкой
+// @Description Выдаёт пользователю доступ к заметке или меняет его роль. viewer может только читать заметку,
+// @Description editor — ещё и изменять её (PATCH), но не удалять и не делиться дальше. Делиться может только владелец.
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Param username path string true "Имя пользователя"
+// @Param input body ShareRequest true "Роль"
+// @Success 200 {object} core.Share "Выданный доступ"
+// @Failure 400 {object} Problem "Некорректная роль или попытка поделиться с собой"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "Не владелец заметки или у API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка или пользователь не найдены"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/{id}/shares/{username} [put]
+func (h *Handler) ShareNote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	var input ShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	share, err := h.Service.ShareNote(r.Context(), id, chi.URLParam(r, "username"), input.Role)
+	if err != nil {
+		writeShareError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(share)
+}
+
+// UnshareNote отзывает доступ к заметке.
+// @Summary Отозвать доступ
+// @Tags shares
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Param username path string true "Имя пользователя"
+// @Success 204 "Доступ отозван"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "Не владелец заметки или у API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка, пользователь или доступ не найдены"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/{id}/shares/{username} [delete]
+func (h *Handler) UnshareNote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	if err := h.Service.UnshareNote(r.Context(), id, chi.URLParam(r, "username")); err != nil {
+		writeShareError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListShares возвращает, у кого есть доступ к заметке.
+// @Summary Доступ к заметке
+// @Description Список пользователей, которым владелец открыл заметку, с их ролями
+// @Tags shares
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Success 200 {array} core.Share "Выданные доступы"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "Не владелец заметки или у API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка не найдена"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/{id}/shares [get]
+func (h *Handler) ListShares(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	shares, err := h.Service.ListShares(r.Context(), id)
+	if err != nil {
+		writeShareError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(shares)
+}
+
+// ListSharedWithMe возвращает заметки, которыми поделились с пользователем.
+// @Summary Доступные мне заметки
+// @Description Заметки других пользователей, к которым у текущего пользователя есть доступ, и его роль в каждой
+// @Tags shares
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {array} core.SharedNote "Заметки и роли"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/shared [get]
+func (h *Handler) ListSharedWithMe(w http.ResponseWriter, r *http.Request) {
+	notes, err := h.Service.ListSharedWithMe(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(notes)
+}
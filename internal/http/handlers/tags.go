@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/notes-api/internal/core/service"
+)
+
+// RenameTagRequest модель запроса на переименование тега.
+// @Description Новое имя тега
+type RenameTagRequest struct {
+	// Новое имя тега; если у заметки уже есть такой тег, теги сливаются
+	Name string `json:"name" example:"проекты"`
+}
+
+// TagChangeResponse модель результата переименования тега.
+// @Description Итоговое имя тега и число изменённых заметок
+type TagChangeResponse struct {
+	// Тег после изменения
+	Tag string `json:"tag" example:"проекты"`
+	// Число изменённых заметок (включая заметки в корзине)
+	Notes int `json:"notes" example:"3"`
+}
+
+// tagParam извлекает тег из пути. chi сопоставляет маршрут по RawPath,
+// если он задан (например, в пути есть %2F), и тогда параметр приходит
+// неразобранным — его нужно декодировать, иначе он уже декодирован.
+func tagParam(r *http.Request) (string, error) {
+	tag := chi.URLParam(r, "tag")
+	if r.URL.RawPath == "" {
+		return tag, nil
+	}
+	return url.PathUnescape(tag)
+}
+
+// writeTagError переводит ошибки операций с тегами в HTTP-ответ.
+func writeTagError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		writeValidationError(w, r, err)
+	case errors.Is(err, service.ErrTagNotFound):
+		writeError(w, r, http.StatusNotFound, "tag not found")
+	default:
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// ListTags возвращает теги с числом заметок.
+// @Summary Список тегов
+// @Description Возвращает теги заметок (без учёта корзины) и число заметок с каждым, по алфавиту
+// @Tags tags
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {array} repo.TagCount "Теги"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /tags [get]
+func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.Service.ListTags(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tags)
+}
+
+// RenameTag переименовывает тег во всех заметках.
+// @Summary Переименовать тег
+// @Description Переименовывает тег во всех заметках, включая корзину. Версии изменённых заметок увеличиваются.
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param tag path string true "Тег"
+// @Param input body RenameTagRequest true "Новое имя"
+// @Success 200 {object} TagChangeResponse "Тег переименован"
+// @Failure 400 {object} Problem "Некорректный тег"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Тег не найден"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /tags/{tag} [patch]
+func (h *Handler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	tag, err := tagParam(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid tag")
+		return
+	}
+
+	var input RenameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	n, err := h.Service.RenameTag(r.Context(), tag, input.Name)
+	if err != nil {
+		writeTagError(w, r, err)
+		return
+	}
+	name, _ := service.NormalizeTag(input.Name) // уже проверено сервисом
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(TagChangeResponse{Tag: name, Notes: n})
+}
+
+// DeleteTag снимает тег со всех заметок.
+// @Summary Удалить тег
+// @Description Снимает тег со всех заметок, включая корзину. Сами заметки не удаляются.
+// @Tags tags
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param tag path string true "Тег"
+// @Success 204 "Тег удалён"
+// @Failure 400 {object} Problem "Некорректный тег"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Тег не найден"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /tags/{tag} [delete]
+func (h *Handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	tag, err := tagParam(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid tag")
+		return
+	}
+
+	if _, err := h.Service.DeleteTag(r.Context(), tag); err != nil {
+		writeTagError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
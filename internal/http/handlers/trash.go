@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/repo"
+)
+
+// writeTrashError переводит ошибки операций с корзиной в HTTP-ответ.
+func writeTrashError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repo.ErrNoteNotFound):
+		writeError(w, r, http.StatusNotFound, "note not found")
+	case errors.Is(err, repo.ErrVersionConflict):
+		writeError(w, r, http.StatusPreconditionFailed, "version mismatch")
+	case errors.Is(err, service.ErrNotInTrash):
+		writeError(w, r, http.StatusConflict, "note is not in trash")
+	default:
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// ListTrash возвращает страницу заметок в корзине.
+// @Summary Корзина
+// @Description Возвращает страницу удалённых заметок (с полем deletedAt). Параметры — как у списка заметок.
+// @Description Заметки удаляются из корзины насовсем по истечении срока хранения.
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 500)" minimum(1) maximum(500)
+// @Param cursor query string false "Курсор из X-Next-Cursor предыдущей страницы"
+// @Param sort query string false "Поле сортировки" Enums(id, createdAt, updatedAt, title) default(id)
+// @Param order query string false "Направление сортировки" Enums(asc, desc) default(asc)
+// @Param createdAfter query string false "Созданы после (RFC 3339)" format(date-time)
+// @Param createdBefore query string false "Созданы до (RFC 3339)" format(date-time)
+// @Param updatedAfter query string false "Изменены после (RFC 3339)" format(date-time)
+// @Param updatedBefore query string false "Изменены до (RFC 3339)" format(date-time)
+// @Param titlePrefix query string false "Префикс заголовка (с учётом регистра)"
+// @Param tag query []string false "Тег; параметр можно повторять" collectionFormat(multi)
+// @Param tagMode query string false "all — нужны все теги, any — хотя бы один" Enums(all, any) default(all)
+// @Success 200 {array} core.Note "Заметки в корзине"
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
+// @Failure 400 {object} Problem "Некорректные параметры запроса"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/trash [get]
+func (h *Handler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	q, err := parseNoteQuery(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	q.Trashed = true
+
+	page, err := h.Service.ListNotes(r.Context(), q)
+	if err != nil {
+		if errors.Is(err, repo.ErrInvalidCursor) {
+			writeError(w, r, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		if errors.Is(err, service.ErrValidation) {
+			writeValidationError(w, r, err)
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	notes := page.Notes
+	if notes == nil {
+		notes = []core.Note{}
+	}
+
+	if page.Next != nil {
+		w.Header().Set("X-Next-Cursor", repo.EncodeCursor(page.Next))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(notes)
+}
+
+// RestoreNote возвращает заметку из корзины.
+// @Summary Восстановить заметку
+// @Description Возвращает удалённую заметку из корзины. Версия заметки увеличивается.
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Param If-Match header string false "ETag версии заметки в корзине (обязателен в строгом режиме)"
+// @Success 200 {object} core.Note "Восстановленная заметка"
+// @Header 200 {string} ETag "Новая версия заметки"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка не найдена"
+// @Failure 409 {object} Problem "Заметка не в корзине"
+// @Failure 412 {object} Problem "Версия заметки не совпадает с If-Match"
+// @Failure 428 {object} Problem "Не передан If-Match (строгий режим)"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/{id}/restore [post]
+func (h *Handler) RestoreNote(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt64Param(r, "id")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	version, ok := h.checkTrashIfMatch(w, r, id)
+	if !ok {
+		return
+	}
+
+	note, err := h.Service.RestoreNote(r.Context(), id, version)
+	if err != nil {
+		writeTrashError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", noteETag(note))
+	_ = json.NewEncoder(w).Encode(note)
+}
+
+// PurgeNote удаляет заметку из корзины насовсем.
+// @Summary Удалить заметку насовсем
+// @Description Безвозвратно удаляет заметку из корзины вместе с историей изменений.
+// @Description Заметку вне корзины нужно сначала удалить через DELETE /notes/{id}.
+// @Tags trash
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID заметки"
+// @Param If-Match header string false "ETag версии заметки в корзине (обязателен в строгом режиме)"
+// @Success 204 "Заметка удалена насовсем"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет нужной области доступа"
+// @Failure 404 {object} Problem "Заметка не найдена"
+// @Failure 409 {object} Problem "Заметка не в корзине"
+// @Failure 412 {object} Problem "Версия заметки не совпадает с If-Match"
+// @Failure 428 {object} Problem "Не передан If-Match (строгий режим)"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /notes/trash/{id} [delete]
+func (h *Handler) PurgeNote(w http.ResponseWriter, r *http.Request) {
+	id, err := parseInt64Param(r, "id")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	version, ok := h.checkTrashIfMatch(w, r, id)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.PurgeNote(r.Context(), id, version); err != nil {
+		writeTrashError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
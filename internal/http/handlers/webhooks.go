@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/repo"
+)
+
+// CreateWebhookRequest модель запроса на регистрацию веб-хука.
+// @Description Адрес получателя и события, на которые он подписан
+type CreateWebhookRequest struct {
+	// Адрес получателя (http или https)
+	URL string `json:"url" example:"https://example.com/hooks/notes"`
+	// События: note.created, note.updated, note.deleted
+	Events []string `json:"events" example:"note.created,note.updated,note.deleted"`
+}
+
+// CreateWebhookResponse модель ответа на регистрацию веб-хука.
+// @Description Веб-хук и секрет подписи; секрет показывается только в этом ответе
+type CreateWebhookResponse struct {
+	core.Webhook
+	// Секрет для проверки X-Webhook-Signature; сохраните его, повторно он не выдаётся
+	Secret string `json:"secret" example:"whsec_Qm9vZ2xl..."`
+}
+
+// writeWebhookError переводит ошибки операций с веб-хуками в HTTP-ответ.
+func writeWebhookError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, repo.ErrWebhookNotFound):
+		writeError(w, r, http.StatusNotFound, "webhook not found")
+	case errors.Is(err, repo.ErrDeliveryNotFound):
+		writeError(w, r, http.StatusNotFound, "delivery not found")
+	case errors.Is(err, service.ErrTooManyWebhooks):
+		writeError(w, r, http.StatusConflict, "too many webhooks")
+	case errors.Is(err, service.ErrValidation):
+		writeValidationError(w, r, err)
+	default:
+		writeError(w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// CreateWebhook регистрирует веб-хук.
+// @Summary Зарегистрировать веб-хук
+// @Description Сервер будет отправлять на адрес POST-запросы с событиями заметок пользователя.
+// @Description Запрос подписан: X-Webhook-Signature = "t=<unix-время>,v1=<hex HMAC-SHA256 от "<t>.<тело>" с секретом веб-хука>".
+// @Description Неудачные доставки повторяются с растущими паузами; после последней попытки доставка попадает в dead letter.
+// @Description Адрес не может указывать на loopback, частные и link-local сети, кроме разрешённых настройкой сервера.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param input body CreateWebhookRequest true "Адрес и события"
+// @Success 201 {object} CreateWebhookResponse "Зарегистрированный веб-хук"
+// @Failure 400 {object} Problem "Некорректный или внутренний адрес, неизвестные события"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет области webhooks:manage"
+// @Failure 409 {object} Problem "Слишком много веб-хуков"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /webhooks [post]
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var input CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	hook, secret, err := h.Webhooks.Create(r.Context(), input.URL, input.Events)
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(CreateWebhookResponse{Webhook: *hook, Secret: secret})
+}
+
+// ListWebhooks возвращает веб-хуки пользователя.
+// @Summary Список веб-хуков
+// @Description Возвращает веб-хуки текущего пользователя без секретов
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {array} core.Webhook "Веб-хуки"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет области webhooks:manage"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /webhooks [get]
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.Webhooks.List(r.Context())
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(hooks)
+}
+
+// DeleteWebhook удаляет веб-хук.
+// @Summary Удалить веб-хук
+// @Description Удаляет веб-хук вместе с журналом доставок; неотправленные события не будут доставлены
+// @Tags webhooks
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID веб-хука"
+// @Success 204 "Веб-хук удалён"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет области webhooks:manage"
+// @Failure 404 {object} Problem "Веб-хук не найден"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+
+	if err := h.Webhooks.Delete(r.Context(), id); err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries возвращает журнал доставок веб-хука.
+// @Summary Журнал доставок
+// @Description Возвращает последние доставки веб-хука от новых к старым: событие, тело запроса, число попыток,
+// @Description время следующей попытки, ответ получателя и ошибку. status=dead — dead letter: доставки, для которых
+// @Description попытки исчерпаны. Завершённые доставки хранятся ограниченное время (флаг -webhook-log-retention).
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID веб-хука"
+// @Param status query string false "Состояние доставки" Enums(pending, delivered, dead)
+// @Param limit query int false "Сколько доставок вернуть (по умолчанию 50, максимум 500)" minimum(1) maximum(500)
+// @Success 200 {array} core.WebhookDelivery "Доставки"
+// @Failure 400 {object} Problem "Некорректные параметры запроса"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет области webhooks:manage"
+// @Failure 404 {object} Problem "Веб-хук не найден"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /webhooks/{id}/deliveries [get]
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			writeError(w, r, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	status := core.DeliveryStatus(r.URL.Query().Get("status"))
+	deliveries, err := h.Webhooks.ListDeliveries(r.Context(), id, status, limit)
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(deliveries)
+}
+
+// RetryWebhookDelivery повторяет доставку.
+// @Summary Повторить доставку
+// @Description Ставит доставку в очередь заново с полным набором попыток — например, чтобы отправить событие из dead letter
+// @Description после того, как получатель починен. Отправка происходит в фоне.
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "ID веб-хука"
+// @Param deliveryId path int true "ID доставки"
+// @Success 202 {object} core.WebhookDelivery "Доставка в очереди"
+// @Failure 400 {object} Problem "Некорректный ID"
+// @Failure 401 {object} Problem "Требуется аутентификация"
+// @Failure 403 {object} Problem "У API-ключа нет области webhooks:manage"
+// @Failure 404 {object} Problem "Веб-хук или доставка не найдены"
+// @Failure 500 {object} Problem "Внутренняя ошибка сервера"
+// @Router /webhooks/{id}/deliveries/{deliveryId}/retry [post]
+func (h *Handler) RetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	deliveryID, err := strconv.ParseInt(chi.URLParam(r, "deliveryId"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid delivery id")
+		return
+	}
+
+	d, err := h.Webhooks.RetryDelivery(r.Context(), id, deliveryID)
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(d)
+}
@@ -0,0 +1,249 @@
+package httpx
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig — откуда брать ключи и какие claims требовать.
+// Хотя бы один источник ключей обязателен.
+type JWTConfig struct {
+	// HMACKey — общий секрет для HS256.
+	HMACKey []byte
+	// PublicKeys — ключи RS256 (*rsa.PublicKey) и EdDSA (ed25519.PublicKey)
+	// без идентификатора; подходят к токенам с любым kid.
+	PublicKeys []crypto.PublicKey
+	// JWKSFile — локальный файл JWK Set (RFC 7517) с ключами RSA, OKP
+	// (Ed25519) и oct; ключ выбирается по kid из заголовка токена.
+	JWKSFile string
+
+	// Issuer и Audience — обязательные значения iss и aud; пустые не
+	// проверяются.
+	Issuer   string
+	Audience string
+	// Leeway — допустимое расхождение часов при проверке exp и nbf.
+	Leeway time.Duration
+}
+
+// JWTVerifier проверяет подпись и claims токенов. exp обязателен:
+// бессрочный токен, утёкший однажды, действовал бы всегда.
+type JWTVerifier struct {
+	hmac   [][]byte
+	public []crypto.PublicKey
+	byKID  map[string]any // []byte, *rsa.PublicKey или ed25519.PublicKey
+	parser *jwt.Parser
+}
+
+// NewJWTVerifier загружает ключи из cfg.
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	v := &JWTVerifier{byKID: make(map[string]any)}
+	if len(cfg.HMACKey) > 0 {
+		v.hmac = append(v.hmac, cfg.HMACKey)
+	}
+	for _, key := range cfg.PublicKeys {
+		switch key.(type) {
+		case *rsa.PublicKey, ed25519.PublicKey:
+			v.public = append(v.public, key)
+		default:
+			return nil, fmt.Errorf("jwt: unsupported public key type %T", key)
+		}
+	}
+	if cfg.JWKSFile != "" {
+		if err := v.loadJWKS(cfg.JWKSFile); err != nil {
+			return nil, err
+		}
+	}
+	if len(v.hmac) == 0 && len(v.public) == 0 && len(v.byKID) == 0 {
+		return nil, errors.New("jwt: no verification keys configured")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v, nil
+}
+
+// Verify проверяет токен и возвращает его claims.
+func (v *JWTVerifier) Verify(token string) (*jwt.RegisteredClaims, error) {
+	var claims jwt.RegisteredClaims
+	if _, err := v.parser.ParseWithClaims(token, &claims, v.key); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// key подбирает ключи под алгоритм токена: по kid, если он есть в JWKS,
+// иначе — все ключи без kid подходящего типа, а для токена без kid —
+// и ключи JWKS.
+func (v *JWTVerifier) key(t *jwt.Token) (any, error) {
+	alg := t.Method.Alg()
+	kid, hasKID := t.Header["kid"].(string)
+	if hasKID {
+		if key, ok := v.byKID[kid]; ok {
+			if !keyFits(alg, key) {
+				return nil, fmt.Errorf("key %q does not fit %s", kid, alg)
+			}
+			return key, nil
+		}
+	}
+
+	var set jwt.VerificationKeySet
+	if !hasKID {
+		for _, key := range v.byKID {
+			if keyFits(alg, key) {
+				set.Keys = append(set.Keys, key)
+			}
+		}
+	}
+	for _, key := range v.hmac {
+		if keyFits(alg, key) {
+			set.Keys = append(set.Keys, key)
+		}
+	}
+	for _, key := range v.public {
+		if keyFits(alg, key) {
+			set.Keys = append(set.Keys, key)
+		}
+	}
+	if len(set.Keys) == 0 {
+		return nil, fmt.Errorf("no key for %s", alg)
+	}
+	return set, nil
+}
+
+func keyFits(alg string, key any) bool {
+	switch key.(type) {
+	case []byte:
+		return alg == "HS256"
+	case *rsa.PublicKey:
+		return alg == "RS256"
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	}
+	return false
+}
+
+// jwk — ключ из JWK Set; поля, не нужные для RS256/EdDSA/HS256, опущены.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	K   string `json:"k"`
+}
+
+// loadJWKS читает JWK Set. Ключи с use, отличным от sig, пропускаются;
+// ключ без kid подходит к любому токену, как ключ из PublicKeys.
+func (v *JWTVerifier) loadJWKS(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("jwks %s: %w", path, err)
+	}
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.parse()
+		if err != nil {
+			return fmt.Errorf("jwks %s: key %d: %w", path, i, err)
+		}
+		switch {
+		case k.Kid != "":
+			v.byKID[k.Kid] = key
+		case k.Kty == "oct":
+			v.hmac = append(v.hmac, key.([]byte))
+		default:
+			v.public = append(v.public, key)
+		}
+	}
+	return nil
+}
+
+func (k jwk) parse() (any, error) {
+	b64 := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, errors.New("unsupported RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("bad Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		secret, err := b64.DecodeString(k.K)
+		if err != nil {
+			return nil, fmt.Errorf("k: %w", err)
+		}
+		return secret, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// LoadPublicKeyPEM читает открытый ключ RSA или Ed25519 в формате PEM
+// (PUBLIC KEY или RSA PUBLIC KEY).
+func LoadPublicKeyPEM(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", path)
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+}
@@ -0,0 +1,200 @@
+package httpx
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/repo"
+)
+
+var testHMACKey = []byte("test-secret-test-secret-test-sec")
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.RegisteredClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return s
+}
+
+func validClaims() jwt.RegisteredClaims {
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		Subject:   "svc-user",
+		Issuer:    "https://issuer.example",
+		Audience:  jwt.ClaimStrings{"notes-api"},
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		NotBefore: jwt.NewNumericDate(now.Add(-time.Minute)),
+	}
+}
+
+func TestJWTVerifierClaims(t *testing.T) {
+	v, err := NewJWTVerifier(JWTConfig{
+		HMACKey:  testHMACKey,
+		Issuer:   "https://issuer.example",
+		Audience: "notes-api",
+	})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(c *jwt.RegisteredClaims)
+		want   error
+	}{
+		{"valid", func(*jwt.RegisteredClaims) {}, nil},
+		{"expired", func(c *jwt.RegisteredClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }, jwt.ErrTokenExpired},
+		{"noExp", func(c *jwt.RegisteredClaims) { c.ExpiresAt = nil }, jwt.ErrTokenRequiredClaimMissing},
+		{"notYetValid", func(c *jwt.RegisteredClaims) { c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Hour)) }, jwt.ErrTokenNotValidYet},
+		{"wrongAudience", func(c *jwt.RegisteredClaims) { c.Audience = jwt.ClaimStrings{"other"} }, jwt.ErrTokenInvalidAudience},
+		{"wrongIssuer", func(c *jwt.RegisteredClaims) { c.Issuer = "https://evil.example" }, jwt.ErrTokenInvalidIssuer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.change(&claims)
+			got, err := v.Verify(sign(t, jwt.SigningMethodHS256, testHMACKey, "", claims))
+			if tt.want == nil {
+				if err != nil || got.Subject != "svc-user" {
+					t.Fatalf("Verify = %+v, %v", got, err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify: err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte("another-secret"), "", validClaims())); !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+		t.Errorf("wrong key: err = %v, want ErrTokenSignatureInvalid", err)
+	}
+	none := sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", validClaims())
+	if _, err := v.Verify(none); err == nil {
+		t.Errorf("alg=none accepted")
+	}
+}
+
+func TestJWTVerifierKeys(t *testing.T) {
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRSA, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b64 := base64.RawURLEncoding
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64.EncodeToString(rsaPriv.N.Bytes()), "e": b64.EncodeToString(big.NewInt(int64(rsaPriv.E)).Bytes())},
+		{"kty": "RSA", "kid": "rsa-enc", "use": "enc", "n": b64.EncodeToString(otherRSA.N.Bytes()), "e": "AQAB"},
+	}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := NewJWTVerifier(JWTConfig{PublicKeys: []crypto.PublicKey{edPub}, JWKSFile: path})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"EdDSA", sign(t, jwt.SigningMethodEdDSA, edPriv, "", validClaims()), true},
+		{"RS256ByKID", sign(t, jwt.SigningMethodRS256, rsaPriv, "rsa-1", validClaims()), true},
+		{"RS256NoKID", sign(t, jwt.SigningMethodRS256, rsaPriv, "", validClaims()), true},
+		{"RS256EncKey", sign(t, jwt.SigningMethodRS256, otherRSA, "rsa-enc", validClaims()), false},
+		{"HS256NoKey", sign(t, jwt.SigningMethodHS256, testHMACKey, "", validClaims()), false},
+	}
+	for _, tt := range tests {
+		if _, err := v.Verify(tt.token); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+
+	if _, err := NewJWTVerifier(JWTConfig{}); err == nil {
+		t.Errorf("NewJWTVerifier without keys: want error")
+	}
+}
+
+func TestAuthenticatorMiddleware(t *testing.T) {
+	verifier, err := NewJWTVerifier(JWTConfig{HMACKey: testHMACKey})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+	auth := service.NewAuthService(repo.NewUserRepoMem(), repo.NewSessionRepoMem(), time.Hour)
+	a := &Authenticator{Sessions: auth, JWT: verifier}
+
+	var got core.Principal
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = core.PrincipalFrom(r.Context())
+	}))
+
+	expired := validClaims()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+	tests := []struct {
+		name      string
+		header    string
+		status    int
+		challenge string
+	}{
+		{"none", "", http.StatusUnauthorized, `Bearer realm="notes-api"`},
+		{"basic", "Basic YTpi", http.StatusUnauthorized, `Bearer realm="notes-api"`},
+		{"empty", "Bearer ", http.StatusBadRequest, `error="invalid_request"`},
+		{"expired", "Bearer " + sign(t, jwt.SigningMethodHS256, testHMACKey, "", expired), http.StatusUnauthorized, `error="invalid_token", error_description="token is expired"`},
+		{"unknownSession", "Bearer abc", http.StatusUnauthorized, `error="invalid_token"`},
+		{"valid", "Bearer " + sign(t, jwt.SigningMethodHS256, testHMACKey, "", validClaims()), http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notes", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		if c := rec.Header().Get("WWW-Authenticate"); !strings.Contains(c, tt.challenge) || (tt.challenge == "") != (c == "") {
+			t.Errorf("%s: WWW-Authenticate = %q, want %q", tt.name, c, tt.challenge)
+		}
+	}
+
+	if got.Subject != "svc-user" || got.UserID == 0 {
+		t.Errorf("principal = %+v, want subject svc-user with a user ID", got)
+	}
+}
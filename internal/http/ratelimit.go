@@ -0,0 +1,317 @@
+package httpx
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/http/handlers"
+)
+
+// Limit — Requests запросов за Per; столько же запросов можно сделать
+// подряд, после чего они восстанавливаются равномерно. Нулевой Limit —
+// без ограничения.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseLimit разбирает лимит вида "60/1m" или "10/s" (единица без числа —
+// одна секунда, минута или час). "" и "0" — без ограничения.
+func ParseLimit(s string) (Limit, error) {
+	if s == "" || s == "0" {
+		return Limit{}, nil
+	}
+	n, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("rate limit %q: want N/duration", s)
+	}
+	requests, err := strconv.Atoi(n)
+	if err != nil || requests < 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: bad request count", s)
+	}
+	switch per {
+	case "s", "m", "h":
+		per = "1" + per
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: bad duration", s)
+	}
+	return Limit{Requests: requests, Per: d}, nil
+}
+
+// Set разбирает лимит из флага командной строки (flag.Value).
+func (l *Limit) Set(s string) error {
+	parsed, err := ParseLimit(s)
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+func (l Limit) String() string {
+	if l.Requests == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// bucket — корзина токенов одного клиента.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter ограничивает частоту запросов каждого клиента алгоритмом
+// token bucket. Клиент — API-ключ, пользователь или, до входа, IP-адрес.
+// nil — без ограничения.
+type RateLimiter struct {
+	limit Limit
+	rate  float64 // токенов в секунду
+	now   func() time.Time
+	key   func(r *http.Request) string
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter создаёт ограничитель; для нулевого limit возвращает nil.
+func NewRateLimiter(limit Limit) *RateLimiter {
+	if limit.Requests <= 0 || limit.Per <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		limit:   limit,
+		rate:    float64(limit.Requests) / limit.Per.Seconds(),
+		now:     time.Now,
+		key:     clientKey,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// NewIPRateLimiter создаёт ограничитель, который считает запросы по
+// IP-адресу, даже если клиент вошёл: его ставят перед аутентификацией,
+// чтобы ограничить и запросы с неверными учётными данными.
+func NewIPRateLimiter(limit Limit) *RateLimiter {
+	l := NewRateLimiter(limit)
+	if l != nil {
+		l.key = func(r *http.Request) string { return "ip:" + clientIP(r) }
+	}
+	return l
+}
+
+// decision — результат проверки запроса.
+type decision struct {
+	allowed    bool
+	remaining  int
+	reset      time.Duration // до полного восстановления корзины
+	retryAfter time.Duration // до следующего разрешённого запроса
+}
+
+// allow списывает токен из корзины клиента key, если он есть.
+func (l *RateLimiter) allow(key string) decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	burst := float64(l.limit.Requests)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	d := decision{allowed: b.tokens >= 1}
+	if d.allowed {
+		b.tokens--
+	} else {
+		d.retryAfter = time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	d.remaining = int(b.tokens)
+	d.reset = time.Duration((burst - b.tokens) / l.rate * float64(time.Second))
+	return d
+}
+
+// sweep раз в период лимита удаляет корзины, которые успели наполниться:
+// такие клиенты неотличимы от новых, а память под них не растёт.
+// Вызывается под l.mu.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.limit.Per {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.limit.Per {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientKey определяет, чей лимит расходует запрос: API-ключа, если
+// запрос подписан ключом, иначе пользователя, а для запросов без
+// аутентификации — IP-адреса.
+func clientKey(r *http.Request) string {
+	if p, ok := core.PrincipalFrom(r.Context()); ok {
+		if p.Scopes != nil {
+			return "key:" + p.Subject
+		}
+		return "user:" + strconv.FormatInt(p.UserID, 10)
+	}
+	return "ip:" + clientIP(r)
+}
+
+// ceilSeconds округляет вверх до целых секунд, как требуют Retry-After
+// и RateLimit-Reset.
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// Middleware пропускает запрос, если у клиента остались токены, и
+// сообщает остаток в заголовках RateLimit-* (draft-ietf-httpapi-ratelimit-headers).
+// Иначе отвечает 429 с Retry-After.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := l.allow(l.key(r))
+
+		h := w.Header()
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", l.limit.Requests, int64(l.limit.Per.Seconds())))
+		h.Set("RateLimit-Limit", strconv.Itoa(l.limit.Requests))
+		h.Set("RateLimit-Remaining", strconv.Itoa(d.remaining))
+		h.Set("RateLimit-Reset", ceilSeconds(d.reset))
+		if !d.allowed {
+			h.Set("Retry-After", ceilSeconds(d.retryAfter))
+			handlers.WriteProblem(w, r, handlers.Problem{Status: http.StatusTooManyRequests, Detail: "rate limit exceeded"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RateLimits — лимиты запросов по группам маршрутов; у каждой группы
+// свои корзины. Нулевой Limit — без ограничения.
+type RateLimits struct {
+	// IP — все запросы к /api/v1 по IP-адресу, до аутентификации:
+	// ограничивает и попытки с неверным токеном или ключом.
+	IP Limit
+	// API — все маршруты /api/v1, требующие входа.
+	API Limit
+	// Write — дополнительно маршруты, изменяющие данные.
+	Write Limit
+	// Public — регистрация, вход и публичные ссылки, по IP-адресу:
+	// защищает от подбора паролей.
+	Public Limit
+	// Routes — дополнительно лимиты отдельных маршрутов, у каждого
+	// маршрута свои корзины.
+	Routes RouteLimits
+}
+
+// RouteLimits — лимиты отдельных маршрутов; ключ — метод и шаблон
+// маршрута, например "POST /api/v1/notes" или "GET /api/v1/notes/{id}".
+type RouteLimits map[string]Limit
+
+// Set разбирает лимит маршрута вида "POST /api/v1/notes=30/1m" (flag.Value);
+// флаг можно указать несколько раз.
+func (rl *RouteLimits) Set(s string) error {
+	route, limit, ok := strings.Cut(s, "=")
+	method, pattern, _ := strings.Cut(route, " ")
+	if !ok || method == "" || !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("route rate limit %q: want \"METHOD /pattern=N/duration\"", s)
+	}
+	l, err := ParseLimit(limit)
+	if err != nil {
+		return err
+	}
+	if *rl == nil {
+		*rl = make(RouteLimits)
+	}
+	(*rl)[strings.ToUpper(method)+" "+routePattern(pattern)] = l
+	return nil
+}
+
+func (rl RouteLimits) String() string {
+	routes := make([]string, 0, len(rl))
+	for route, l := range rl {
+		routes = append(routes, route+"="+l.String())
+	}
+	sort.Strings(routes)
+	return strings.Join(routes, ",")
+}
+
+// routePattern приводит шаблон маршрута к виду, который возвращает
+// chi.Context.RoutePattern: без завершающего "/".
+func routePattern(p string) string {
+	if p != "/" {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
+
+// routeLimiter — ограничители отдельных маршрутов.
+type routeLimiter map[string]*RateLimiter
+
+func newRouteLimiter(routes RouteLimits) routeLimiter {
+	rl := make(routeLimiter)
+	for route, limit := range routes {
+		if l := NewRateLimiter(limit); l != nil {
+			rl[route] = l
+		}
+	}
+	return rl
+}
+
+// Middleware применяет лимит маршрута запроса, если он задан. Шаблон
+// маршрута известен, только когда chi его уже нашёл, поэтому middleware
+// ставится на сам маршрут (r.With), а не на группу.
+func (rl routeLimiter) Middleware(next http.Handler) http.Handler {
+	if len(rl) == 0 {
+		return next
+	}
+	limited := make(map[string]http.Handler, len(rl))
+	for route, l := range rl {
+		limited[route] = l.Middleware(next)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := limited[r.Method+" "+chi.RouteContext(r.Context()).RoutePattern()]; ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CheckRouteLimits проверяет, что у каждого лимита из routes есть
+// маршрут в router: опечатка в шаблоне иначе молча оставила бы маршрут
+// без лимита.
+func CheckRouteLimits(router chi.Routes, routes RouteLimits) error {
+	known := make(map[string]bool)
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		known[method+" "+routePattern(route)] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for route := range routes {
+		if !known[route] {
+			return fmt.Errorf("route rate limit %q: no such route", route)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/core/service"
+	"example.com/notes-api/internal/http/handlers"
+	"example.com/notes-api/internal/repo"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Limit
+		ok   bool
+	}{
+		{"", Limit{}, true},
+		{"0", Limit{}, true},
+		{"60/1m", Limit{Requests: 60, Per: time.Minute}, true},
+		{"10/s", Limit{Requests: 10, Per: time.Second}, true},
+		{"100/2h", Limit{Requests: 100, Per: 2 * time.Hour}, true},
+		{"60", Limit{}, false},
+		{"x/1m", Limit{}, false},
+		{"-1/1m", Limit{}, false},
+		{"5/0s", Limit{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, %v; want %+v, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(Limit{Requests: 2, Per: 10 * time.Second})
+	l.now = func() time.Time { return now }
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(remoteAddr string, p *core.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notes", nil)
+		req.RemoteAddr = remoteAddr
+		if p != nil {
+			req = req.WithContext(core.WithPrincipal(req.Context(), *p))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		rec := do("10.0.0.1:1000", nil)
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != wantRemaining {
+			t.Fatalf("request %d: status %d, remaining %q", i, rec.Code, rec.Header().Get("RateLimit-Remaining"))
+		}
+	}
+
+	rec := do("10.0.0.1:2000", nil) // другой порт — тот же клиент
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("over limit: status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "5" {
+		t.Errorf("Retry-After = %q, want 5", got)
+	}
+	if got := rec.Header().Get("RateLimit-Reset"); got != "10" {
+		t.Errorf("RateLimit-Reset = %q, want 10", got)
+	}
+	if got := rec.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Errorf("RateLimit-Limit = %q, want 2", got)
+	}
+
+	// у другого IP, пользователя и API-ключа того же пользователя свои корзины
+	if rec := do("10.0.0.2:1000", nil); rec.Code != http.StatusOK {
+		t.Errorf("other IP: status = %d", rec.Code)
+	}
+	if rec := do("10.0.0.1:1000", &core.Principal{UserID: 1}); rec.Code != http.StatusOK {
+		t.Errorf("user: status = %d", rec.Code)
+	}
+	if rec := do("10.0.0.1:1000", &core.Principal{UserID: 1, Subject: "abcdefgh", Scopes: []string{core.ScopeNotesRead}}); rec.Code != http.StatusOK {
+		t.Errorf("API key: status = %d", rec.Code)
+	}
+
+	now = now.Add(5 * time.Second) // восстановился один токен
+	if rec := do("10.0.0.1:1000", nil); rec.Code != http.StatusOK {
+		t.Errorf("after refill: status = %d", rec.Code)
+	}
+	if rec := do("10.0.0.1:1000", nil); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("after refill, second request: status = %d, want 429", rec.Code)
+	}
+
+	if NewRateLimiter(Limit{}) != nil {
+		t.Errorf("zero limit: want nil limiter")
+	}
+}
+
+// newLimitedRouter — роутер с лимитами limits и токен сессии alice.
+func newLimitedRouter(t *testing.T, limits RateLimits) (*chi.Mux, string) {
+	t.Helper()
+	auth := service.NewAuthService(repo.NewUserRepoMem(), repo.NewSessionRepoMem(), time.Hour)
+	if _, err := auth.Register("alice", "correct horse"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	token, _, err := auth.Login("alice", "correct horse")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	h := handlers.NewHandler(service.NewNoteService(repo.NewNoteRepoMem()))
+	h.Auth = auth
+	return NewRouter(h, &Authenticator{Sessions: auth}, limits), token
+}
+
+func serve(h http.Handler, method, path, token, body string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRouteLimits(t *testing.T) {
+	limits := RateLimits{Routes: RouteLimits{"POST /api/v1/notes": {Requests: 1, Per: time.Minute}}}
+	router, token := newLimitedRouter(t, limits)
+
+	if code := serve(router, http.MethodPost, "/api/v1/notes", token, `{"title": "a"}`); code != http.StatusCreated {
+		t.Fatalf("first create: status = %d, want 201", code)
+	}
+	if code := serve(router, http.MethodPost, "/api/v1/notes/", token, `{"title": "b"}`); code != http.StatusTooManyRequests {
+		t.Errorf("second create: status = %d, want 429", code)
+	}
+	// у других маршрутов свои корзины
+	for i := 0; i < 3; i++ {
+		if code := serve(router, http.MethodGet, "/api/v1/notes", token, ""); code != http.StatusOK {
+			t.Errorf("list %d: status = %d, want 200", i, code)
+		}
+	}
+	if code := serve(router, http.MethodPatch, "/api/v1/notes/1", token, `{"title": "c"}`); code != http.StatusOK {
+		t.Errorf("update: status = %d, want 200", code)
+	}
+
+	if err := CheckRouteLimits(router, limits.Routes); err != nil {
+		t.Errorf("CheckRouteLimits: %v", err)
+	}
+	typo := RouteLimits{"POST /api/v1/note": {Requests: 1, Per: time.Minute}}
+	if err := CheckRouteLimits(router, typo); err == nil {
+		t.Error("CheckRouteLimits: unknown route accepted")
+	}
+}
+
+func TestIPLimitBeforeAuthentication(t *testing.T) {
+	router, _ := newLimitedRouter(t, RateLimits{
+		IP:  Limit{Requests: 2, Per: time.Minute},
+		API: Limit{Requests: 100, Per: time.Minute},
+	})
+	for i := 0; i < 2; i++ {
+		if code := serve(router, http.MethodGet, "/api/v1/notes", "guess", ""); code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want 401", i, code)
+		}
+	}
+	if code := serve(router, http.MethodGet, "/api/v1/notes", "guess", ""); code != http.StatusTooManyRequests {
+		t.Errorf("third attempt: status = %d, want 429", code)
+	}
+	// /health не входит в API
+	if code := serve(router, http.MethodGet, "/health", "", ""); code != http.StatusOK {
+		t.Errorf("health: status = %d, want 200", code)
+	}
+}
+
+func TestRouteLimitsFlag(t *testing.T) {
+	var rl RouteLimits
+	for _, s := range []string{"post /api/v1/notes/=30/1m", "GET /api/v1/notes/{id}=0"} {
+		if err := rl.Set(s); err != nil {
+			t.Fatalf("Set(%q): %v", s, err)
+		}
+	}
+	if got, want := rl.String(), "GET /api/v1/notes/{id}=0,POST /api/v1/notes=30/1m0s"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+	for _, s := range []string{"/api/v1/notes=30/1m", "POST api/v1/notes=30/1m", "POST /api/v1/notes", "POST /api/v1/notes=x"} {
+		if err := rl.Set(s); err == nil {
+			t.Errorf("Set(%q): want error", s)
+		}
+	}
+}
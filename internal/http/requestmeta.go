@@ -0,0 +1,32 @@
+package httpx
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"example.com/notes-api/internal/core"
+)
+
+// RequestMeta кладёт в контекст ID запроса (ставится после
+// middleware.RequestID) и IP клиента для журнала аудита.
+func RequestMeta(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := core.WithRequestMeta(r.Context(), core.RequestMeta{
+			RequestID: middleware.GetReqID(r.Context()),
+			ClientIP:  clientIP(r),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// clientIP — адрес, с которого пришёл запрос. Заголовкам вроде
+// X-Forwarded-For сервер не доверяет: перед ним нет прокси.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -7,15 +7,27 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 
+	"example.com/notes-api/internal/core"
 	"example.com/notes-api/internal/http/handlers"
 )
 
-// NewRouter создаёт и настраивает HTTP роутер.
-func NewRouter(h *handlers.Handler) *chi.Mux {
+// NewRouter создаёт и настраивает HTTP роутер. /health и /docs доступны
+// без аутентификации, API (кроме регистрации и входа) — через authn, и
+// каждый маршрут проверяет нужную ему область доступа. limits задаёт
+// ограничения частоты запросов по группам маршрутов и отдельным маршрутам.
+func NewRouter(h *handlers.Handler, authn *Authenticator, limits RateLimits) *chi.Mux {
 	r := chi.NewRouter()
+	ipLimit := NewIPRateLimiter(limits.IP).Middleware
+	apiLimit := NewRateLimiter(limits.API).Middleware
+	writeLimit := NewRateLimiter(limits.Write).Middleware
+	publicLimit := NewRateLimiter(limits.Public).Middleware
+	// лимиты отдельных маршрутов: ставятся на каждый маршрут, см.
+	// routeLimiter.Middleware
+	route := newRouteLimiter(limits.Routes).Middleware
 
 	// базовые middleware
 	r.Use(middleware.RequestID)
+	r.Use(RequestMeta)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 
@@ -32,12 +44,112 @@ func NewRouter(h *handlers.Handler) *chi.Mux {
 
 	// основное API
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Route("/notes", func(r chi.Router) {
-			r.Post("/", h.CreateNote)       // POST /api/v1/notes
-			r.Get("/", h.ListNotes)         // GET  /api/v1/notes
-			r.Get("/{id}", h.GetNote)       // GET  /api/v1/notes/{id}
-			r.Patch("/{id}", h.UpdateNote)  // PATCH /api/v1/notes/{id}
-			r.Delete("/{id}", h.DeleteNote) // DELETE /api/v1/notes/{id}
+		// лимит по IP-адресу до аутентификации: запросы с неверным
+		// токеном или ключом до apiLimit не доходят
+		r.Use(ipLimit)
+
+		r.Route("/auth", func(r chi.Router) {
+			r.With(publicLimit, route).Post("/register", h.Register)
+			r.With(publicLimit, route).Post("/login", h.Login)
+			r.With(authn.Middleware, apiLimit, route).Post("/logout", h.Logout)
+			r.With(authn.Middleware, apiLimit, route).Get("/me", h.Me)
+		})
+
+		// публичные ссылки открываются без входа; POST — форма пароля
+		r.With(publicLimit, route).Get("/public/notes/{token}", h.OpenShareLink)
+		r.With(publicLimit, route).Post("/public/notes/{token}", h.OpenShareLink)
+
+		// ограничения заметок нужны клиенту и до входа
+		r.With(apiLimit, route).Get("/limits", h.GetLimits)
+
+		// ключи выпускает только человек: у API-ключа нет keys:manage
+		r.Route("/keys", func(r chi.Router) {
+			r.Use(authn.Middleware, apiLimit, RequireScope(core.ScopeKeysManage))
+			r.With(route).Post("/", h.CreateAPIKey)
+			r.With(route).Get("/", h.ListAPIKeys)
+			r.With(route).Delete("/{id}", h.RevokeAPIKey)
+		})
+
+		// веб-хуки получают заметки, поэтому у API-ключа нужна отдельная
+		// область
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Use(authn.Middleware, apiLimit, RequireScope(core.ScopeWebhooksManage))
+			r.With(route).Post("/", h.CreateWebhook)
+			r.With(route).Get("/", h.ListWebhooks)
+			r.With(route).Delete("/{id}", h.DeleteWebhook)
+			r.With(route).Get("/{id}/deliveries", h.ListWebhookDeliveries) // ?status=dead — dead letter
+			r.With(route).Post("/{id}/deliveries/{deliveryId}/retry", h.RetryWebhookDelivery)
+		})
+
+		// журнал аудита: права администратора проверяет сервис
+		r.With(authn.Middleware, apiLimit, route).Get("/audit", h.ListAuditEvents)
+
+		// заметки, блокноты и теги — только после входа: свои и открытые
+		// другими пользователями;
+		// чтение требует notes:read, изменения — notes:write и расходуют
+		// ещё и отдельный лимит записи
+		read := chi.Chain(RequireScope(core.ScopeNotesRead), route).Handler
+		write := chi.Chain(RequireScope(core.ScopeNotesWrite), writeLimit, route).Handler
+		// совместное редактирование по WebSocket: вызывающего определяет
+		// заголовок или билет из ?ticket=; права на запись проверяет сессия
+		r.With(authn.CollabTicket(h.Collab), apiLimit, read).Get("/notes/{id}/collab", h.CollabNote)
+
+		r.Group(func(r chi.Router) {
+			r.Use(authn.Middleware, apiLimit)
+
+			r.With(write).Post("/notes:batch", h.BatchNotes) // пакет create/update/delete
+
+			r.Route("/notes", func(r chi.Router) {
+				r.With(write).Post("/", h.CreateNote)           // POST /api/v1/notes
+				r.With(read).Get("/", h.ListNotes)              // GET  /api/v1/notes
+				r.With(read).Get("/search", h.SearchNotes)      // GET  /api/v1/notes/search?q=
+				r.With(read).Get("/events", h.NoteEvents)       // SSE: изменения заметок
+				r.With(read).Get("/shared", h.ListSharedWithMe) // доступные мне чужие заметки
+				r.With(read).Get("/{id}", h.GetNote)            // GET  /api/v1/notes/{id}
+				r.With(write).Patch("/{id}", h.UpdateNote)      // PATCH /api/v1/notes/{id}
+				r.With(write).Delete("/{id}", h.DeleteNote)     // DELETE /api/v1/notes/{id} — в корзину
+
+				// корзина
+				r.With(read).Get("/trash", h.ListTrash)
+				r.With(write).Delete("/trash/{id}", h.PurgeNote) // удалить насовсем
+				r.With(write).Post("/{id}/restore", h.RestoreNote)
+				r.With(write).Post("/{id}/move", h.MoveNote) // в другой блокнот
+
+				// билет для подключения к совместному редактированию из браузера
+				r.With(read).Post("/{id}/collab/ticket", h.CreateCollabTicket)
+
+				// история изменений
+				r.With(read).Get("/{id}/revisions", h.ListRevisions)
+				r.With(read).Get("/{id}/revisions/diff", h.DiffRevisions) // ?from=&to=
+				r.With(read).Get("/{id}/revisions/{rev}", h.GetRevision)
+				r.With(write).Post("/{id}/revisions/{rev}/restore", h.RestoreRevision)
+
+				// совместный доступ: управляет только владелец
+				r.With(read).Get("/{id}/shares", h.ListShares)
+				r.With(write).Put("/{id}/shares/{username}", h.ShareNote) // {"role": "viewer"|"editor"}
+				r.With(write).Delete("/{id}/shares/{username}", h.UnshareNote)
+
+				// публичные ссылки только для чтения
+				r.With(read).Get("/{id}/links", h.ListShareLinks)
+				r.With(write).Post("/{id}/links", h.CreateShareLink) // {"expiresAt": ..., "password": ...}
+				r.With(write).Delete("/{id}/links/{linkId}", h.RevokeShareLink)
+			})
+
+			r.Route("/notebooks", func(r chi.Router) {
+				r.With(write).Post("/", h.CreateNotebook)
+				r.With(read).Get("/", h.ListNotebooks)
+				r.With(read).Get("/{id}", h.GetNotebook)
+				r.With(write).Patch("/{id}", h.RenameNotebook)
+				r.With(write).Delete("/{id}", h.DeleteNotebook) // ?policy=reject|cascade|root
+				r.With(write).Post("/{id}/move", h.MoveNotebook)
+				r.With(read).Get("/{id}/notes", h.ListNotebookNotes) // ?recursive=true
+			})
+
+			r.Route("/tags", func(r chi.Router) {
+				r.With(read).Get("/", h.ListTags)
+				r.With(write).Patch("/{tag}", h.RenameTag)
+				r.With(write).Delete("/{tag}", h.DeleteTag)
+			})
 		})
 	})
 
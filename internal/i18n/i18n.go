@@ -0,0 +1,51 @@
+// Package i18n — перевод сообщений об ошибках на язык клиента.
+//
+// Ключ сообщения — его английский текст (для сообщений с аргументами —
+// строка формата fmt); каталог сопоставляет ему перевод. Сообщения без
+// перевода остаются английскими.
+package i18n
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/text/language"
+)
+
+// Supported — языки сообщений; первый — язык по умолчанию.
+var Supported = []language.Tag{language.English, language.Russian}
+
+var (
+	matcher  = language.NewMatcher(Supported)
+	catalogs = map[language.Tag]map[string]string{
+		language.Russian: ru,
+	}
+)
+
+// Negotiate выбирает язык по значению заголовка Accept-Language с учётом
+// весов q; если ни один из языков клиента не поддерживается — английский.
+func Negotiate(acceptLanguage string) language.Tag {
+	_, i := language.MatchStrings(matcher, acceptLanguage)
+	return Supported[i]
+}
+
+// FromRequest — Negotiate для заголовка Accept-Language запроса r.
+func FromRequest(r *http.Request) language.Tag {
+	return Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// Text возвращает перевод сообщения s на язык lang или s, если перевода
+// нет. Строка не форматируется, поэтому ей можно передать и уже
+// переведённый текст.
+func Text(lang language.Tag, s string) string {
+	if t, ok := catalogs[lang][s]; ok {
+		return t
+	}
+	return s
+}
+
+// Sprintf переводит строку формата format на язык lang и подставляет
+// в неё args.
+func Sprintf(lang language.Tag, format string, args ...any) string {
+	return fmt.Sprintf(Text(lang, format), args...)
+}
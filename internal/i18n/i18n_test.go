@@ -0,0 +1,70 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   language.Tag
+	}{
+		{"", language.English},
+		{"ru", language.Russian},
+		{"ru-RU,ru;q=0.9,en-US;q=0.8", language.Russian},
+		{"en-US,en;q=0.9,ru;q=0.8", language.English},
+		{"de;q=1.0, ru;q=0.5", language.Russian},
+		{"fr, de", language.English},
+		{"uk", language.English},
+		{"*", language.English},
+		{"not a language", language.English},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	tests := []struct {
+		lang   language.Tag
+		format string
+		args   []any
+		want   string
+	}{
+		{language.English, "%s must be at most %d characters", []any{"title", 200}, "title must be at most 200 characters"},
+		{language.Russian, "%s must be at most %d characters", []any{"title", 200}, "title: длина в символах — не больше 200"},
+		{language.Russian, "note not found", nil, "заметка не найдена"},
+		{language.Russian, "no such message %d", []any{1}, "no such message 1"},
+	}
+	for _, tt := range tests {
+		if got := Sprintf(tt.lang, tt.format, tt.args...); got != tt.want {
+			t.Errorf("Sprintf(%v, %q) = %q, want %q", tt.lang, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestTextDoesNotFormat(t *testing.T) {
+	const s = "100% done"
+	if got := Text(language.Russian, s); got != s {
+		t.Errorf("Text(%q) = %q", s, got)
+	}
+}
+
+// Перевод должен принимать те же аргументы, что и ключ.
+func TestCatalogVerbs(t *testing.T) {
+	verb := regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+	for _, lang := range Supported {
+		for key, msg := range catalogs[lang] {
+			want, got := verb.FindAllString(key, -1), verb.FindAllString(msg, -1)
+			if !slices.Equal(got, want) {
+				t.Errorf("%v: %q has verbs %v, key %q has %v", lang, msg, got, key, want)
+			}
+		}
+	}
+}
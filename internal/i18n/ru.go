@@ -0,0 +1,159 @@
+package i18n
+
+// ru — сообщения на русском. Аргументы подставляются в том же порядке,
+// что и в английском ключе.
+var ru = map[string]string{
+	// заголовки ответов с ошибкой (Problem.Title)
+	"Bad Request":            "Неверный запрос",
+	"Unauthorized":           "Требуется аутентификация",
+	"Forbidden":              "Доступ запрещён",
+	"Not Found":              "Не найдено",
+	"Conflict":               "Конфликт",
+	"Precondition Failed":    "Версия не совпадает",
+	"Precondition Required":  "Требуется условие",
+	"Too Many Requests":      "Слишком много запросов",
+	"Internal Server Error":  "Внутренняя ошибка сервера",
+	"Service Unavailable":    "Сервис недоступен",
+	"Insufficient Storage":   "Недостаточно места",
+	"Failed Dependency":      "Не выполнено из-за другой операции",
+	"Unsupported Media Type": "Неподдерживаемый тип данных",
+	"Validation failed":      "Неверные данные",
+
+	// нарушенные правила проверки входных данных
+	"%s is required":                                            "%s: обязательное поле",
+	"%s must not be empty":                                      "%s: значение не должно быть пустым",
+	"%s must be at least %d characters":                         "%s: длина в символах — не меньше %d",
+	"%s must be at most %d characters":                          "%s: длина в символах — не больше %d",
+	"%s must be at least %d bytes":                              "%s: размер в байтах — не меньше %d",
+	"%s must be at most %d bytes":                               "%s: размер в байтах — не больше %d",
+	"%s must be in the future":                                  "%s: момент времени должен быть в будущем",
+	"%s must be after %s":                                       "%s: значение должно быть позже %s",
+	"%s must be valid UTF-8":                                    "%s: текст должен быть в кодировке UTF-8",
+	"%s must not contain control characters":                    "%s: управляющие символы недопустимы",
+	"%s must not contain %q":                                    "%s: символ %q недопустим",
+	"%s must not contain commas":                                "%s: запятые недопустимы",
+	"%s must be one of %s":                                      "%s: допустимые значения — %s",
+	"%q is not one of %s":                                       "%q: допустимые значения — %s",
+	"at most %d tags are allowed":                               "число тегов — не больше %d",
+	"at most %d operations are allowed":                         "число операций — не больше %d",
+	"%s must be an absolute http or https URL":                  "%s: нужен абсолютный URL со схемой http или https",
+	"%s must not contain credentials":                           "%s: URL не должен содержать имя пользователя и пароль",
+	"%s must not point to a private address":                    "%s: URL не должен указывать на внутренний адрес",
+	"%s may contain only letters a-z, digits, '_', '.' and '-'": "%s: допустимы только буквы a-z, цифры, «_», «.» и «-»",
+	"search query is empty":                                     "поисковый запрос пуст",
+	"the owner already has full access":                         "у владельца уже есть полный доступ",
+	"invalid data":                                              "неверные данные",
+	"patched note must be a JSON object":                        "после патча заметка должна быть объектом JSON",
+	"%s must be a string":                                       "%s: значение должно быть строкой",
+	"%s must be an array of strings":                            "%s: значение должно быть массивом строк",
+	"%s must be an integer or null":                             "%s: значение должно быть целым числом или null",
+	"%s cannot be changed":                                      "%s: поле нельзя изменить",
+
+	// неверные параметры и тело запроса
+	"invalid JSON":          "тело запроса — неверный JSON",
+	"invalid id":            "неверный ID",
+	"invalid link id":       "неверный ID ссылки",
+	"invalid delivery id":   "неверный ID доставки",
+	"invalid revision":      "неверный номер ревизии",
+	"invalid tag":           "неверный тег",
+	"invalid cursor":        "неверный курсор",
+	"invalid limit":         "неверный параметр limit",
+	"invalid sort":          "неверный параметр sort",
+	"invalid order":         "неверный параметр order",
+	"invalid tagMode":       "неверный параметр tagMode",
+	"invalid createdAfter":  "неверный параметр createdAfter",
+	"invalid createdBefore": "неверный параметр createdBefore",
+	"invalid updatedAfter":  "неверный параметр updatedAfter",
+	"invalid updatedBefore": "неверный параметр updatedBefore",
+	"invalid noteId":        "неверный параметр noteId",
+	"invalid since":         "неверный параметр since",
+	"invalid until":         "неверный параметр until",
+	"invalid from":          "неверный параметр from",
+	"invalid to":            "неверный параметр to",
+	"invalid recursive":     "неверный параметр recursive",
+	"invalid policy":        "неверный параметр policy",
+	"query is required":     "нужен параметр q",
+
+	// размер тела запроса
+	"request body must be at most %d bytes": "тело запроса должно быть не больше %d байт",
+
+	// аутентификация и доступ
+	"authentication required":                 "требуется аутентификация",
+	"malformed Authorization header":          "неверный заголовок Authorization",
+	"JWT is not accepted":                     "JWT не принимаются",
+	"token has no subject":                    "в токене нет sub",
+	"token is invalid or expired":             "токен неверен или истёк",
+	"token is expired":                        "срок действия токена истёк",
+	"token is not valid yet":                  "токен ещё не действует",
+	"token has invalid audience":              "неверный aud токена",
+	"token has invalid issuer":                "неверный iss токена",
+	"token is missing required claim":         "в токене нет обязательного поля",
+	"token is malformed":                      "токен повреждён",
+	"token signature is invalid":              "неверная подпись токена",
+	"API keys are not accepted":               "API-ключи не принимаются",
+	"API key is invalid or expired":           "API-ключ неверен или истёк",
+	"ticket is invalid or expired":            "билет неверен или истёк",
+	"credentials lack scope %s":               "нет области доступа %s",
+	"invalid username or password":            "неверное имя пользователя или пароль",
+	"username is already taken":               "имя пользователя уже занято",
+	"admin access required":                   "нужны права администратора",
+	"viewer cannot edit the note":             "роль viewer не позволяет изменять заметку",
+	"only the owner can delete the note":      "удалить заметку может только владелец",
+	"only the owner can manage access":        "управлять доступом может только владелец",
+	"only the owner can manage share links":   "управлять публичными ссылками может только владелец",
+	"share link password is missing or wrong": "пароль ссылки не передан или неверен",
+	"rate limit exceeded":                     "превышен лимит запросов",
+
+	// ненайденные объекты и конфликты
+	"note not found":        "заметка не найдена",
+	"notebook not found":    "блокнот не найден",
+	"revision not found":    "ревизия не найдена",
+	"tag not found":         "тег не найден",
+	"user not found":        "пользователь не найден",
+	"share not found":       "доступ не найден",
+	"share link not found":  "ссылка не найдена",
+	"api key not found":     "API-ключ не найден",
+	"webhook not found":     "веб-хук не найден",
+	"delivery not found":    "доставка не найдена",
+	"note is not in trash":  "заметка не в корзине",
+	"notebook is not empty": "блокнот не пуст",
+	"notebook cannot be moved into itself or its descendant": "блокнот нельзя перенести в него самого или во вложенный блокнот",
+	"version mismatch":            "версия заметки не совпадает",
+	"If-Match header is required": "нужен заголовок If-Match",
+	"storage quota exceeded":      "квота хранилища исчерпана",
+	"too many webhooks":           "слишком много веб-хуков",
+	"internal error":              "внутренняя ошибка",
+	"server is shutting down":     "сервер останавливается",
+
+	// совместное редактирование
+	"websocket upgrade required":        "нужен запрос WebSocket",
+	"origin is not allowed":             "подключение с этого Origin не разрешено",
+	"invalid message":                   "неверное сообщение",
+	"unknown message type":              "неизвестный тип сообщения",
+	"read-only participant":             "участник только наблюдает",
+	"revision is too old":               "ревизия слишком старая",
+	"operation does not match document": "операция не соответствует документу",
+	"session is closed":                 "сессия закрыта",
+	"note is no longer available":       "заметка больше недоступна",
+
+	// пакет операций
+	"operation %d failed, nothing was applied": "операция № %d не удалась, пакет не применён",
+
+	// HTML-страница публичной ссылки
+	"Password required":                   "Нужен пароль",
+	"The note is protected by a password": "Заметка защищена паролем",
+	"Wrong password.":                     "Неверный пароль.",
+	"Open":                                "Открыть",
+	"updated":                             "изменена",
+	"Jan 2, 2006 15:04":                   "02.01.2006 15:04",
+	"Link is invalid":                     "Ссылка недействительна",
+	"The link was not found, revoked or has expired": "Ссылка не найдена, отозвана или истекла",
+
+	// JSON Merge Patch и JSON Patch
+	"unsupported content type":                        "неподдерживаемый Content-Type",
+	"invalid JSON Patch":                              "тело запроса — неверный JSON Patch",
+	"invalid JSON Patch operation %d":                 "неверная операция JSON Patch № %d",
+	"only the owner can move the note":                "перенести заметку в другой блокнот может только владелец",
+	"JSON Patch operation %d: test failed":            "операция JSON Patch № %d: проверка test не прошла",
+	"JSON Patch operation %d: path %s does not exist": "операция JSON Patch № %d: пути %s нет в заметке",
+}
@@ -0,0 +1,419 @@
+// Package jsonpatch — изменение JSON-документов по JSON Merge Patch
+// (RFC 7396) и JSON Patch (RFC 6902).
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalid — патч записан неверно: не JSON, неизвестная операция,
+	// нет обязательного поля или неверный JSON Pointer.
+	ErrInvalid = errors.New("invalid patch")
+	// ErrPath — в документе нет значения или родителя по пути операции.
+	ErrPath = errors.New("path does not exist")
+	// ErrTestFailed — значение не совпало с операцией test.
+	ErrTestFailed = errors.New("test failed")
+)
+
+// OpError — ошибка операции JSON Patch с номером Index (с нуля).
+type OpError struct {
+	Index int
+	Op    string
+	Path  string
+	Err   error
+}
+
+func (e *OpError) Error() string {
+	return fmt.Sprintf("jsonpatch: operation %d (%s %s): %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+func (e *OpError) Unwrap() error { return e.Err }
+
+// MergePatch применяет JSON Merge Patch patch к документу doc: члены
+// объекта patch заменяют одноимённые члены doc рекурсивно, null удаляет
+// член, а патч, который не объект, заменяет документ целиком.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decode(patch)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	return json.Marshal(merge(target, p))
+}
+
+func merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = merge(t[k], v)
+		}
+	}
+	return t
+}
+
+// Operation — операция JSON Patch.
+type Operation struct {
+	// Op — add, remove, replace, move, copy или test.
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	// From — источник для move и copy.
+	From string `json:"from,omitempty"`
+	// Value — значение для add, replace и test; null — тоже значение.
+	Value json.RawMessage `json:"value,omitempty"`
+
+	path, from []string
+	value      any
+}
+
+// Patch — документ JSON Patch: операции применяются по порядку.
+type Patch []Operation
+
+// Parse разбирает документ JSON Patch и проверяет операции. Ошибка
+// операции — *OpError с ErrInvalid.
+func Parse(data []byte) (Patch, error) {
+	var p Patch
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, ErrInvalid
+	}
+	for i := range p {
+		op := &p[i]
+		if err := op.prepare(); err != nil {
+			return nil, &OpError{Index: i, Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+	return p, nil
+}
+
+func (op *Operation) prepare() error {
+	var err error
+	if op.path, err = parsePointer(op.Path); err != nil {
+		return err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return ErrInvalid
+		}
+		if op.value, err = decode(op.Value); err != nil {
+			return ErrInvalid
+		}
+	case "remove":
+		if len(op.path) == 0 {
+			return ErrInvalid // документ целиком не удаляется
+		}
+	case "move", "copy":
+		if op.from, err = parsePointer(op.From); err != nil {
+			return err
+		}
+		// нельзя перенести значение внутрь него самого
+		if op.Op == "move" && len(op.path) > len(op.from) && isPrefix(op.from, op.path) {
+			return ErrInvalid
+		}
+	default:
+		return ErrInvalid
+	}
+	return nil
+}
+
+// Apply применяет операции к документу doc и возвращает новый документ.
+// Если операция не удалась, возвращается *OpError, а doc не меняется.
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range p {
+		if root, err = op.apply(root); err != nil {
+			return nil, &OpError{Index: i, Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+	return json.Marshal(root)
+}
+
+func (op Operation) apply(root any) (any, error) {
+	switch op.Op {
+	case "add":
+		return add(root, op.path, deepCopy(op.value))
+	case "remove":
+		root, _, err := remove(root, op.path)
+		return root, err
+	case "replace":
+		root, _, err := remove(root, op.path)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, op.path, deepCopy(op.value))
+	case "move":
+		if isPrefix(op.from, op.path) && len(op.from) == len(op.path) {
+			if _, err := get(root, op.from); err != nil {
+				return nil, err
+			}
+			return root, nil
+		}
+		root, v, err := remove(root, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, op.path, v)
+	case "copy":
+		v, err := get(root, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, op.path, deepCopy(v))
+	case "test":
+		v, err := get(root, op.path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(v, op.value) {
+			return nil, ErrTestFailed
+		}
+		return root, nil
+	}
+	return nil, ErrInvalid
+}
+
+// decode разбирает JSON, сохраняя числа как json.Number, чтобы большие
+// целые не теряли точность.
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("jsonpatch: trailing data after JSON value")
+	}
+	return v, nil
+}
+
+// parsePointer разбирает JSON Pointer (RFC 6901) на токены; "" —
+// документ целиком.
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s[0] != '/' {
+		return nil, ErrInvalid
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, t := range tokens {
+		if strings.Contains(strings.ReplaceAll(strings.ReplaceAll(t, "~0", ""), "~1", ""), "~") {
+			return nil, ErrInvalid
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// index разбирает индекс массива длины n; "-" (после последнего
+// элемента) допустим, только если allowEnd.
+func index(token string, n int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return n, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, ErrPath
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 {
+		return 0, ErrPath
+	}
+	limit := n
+	if allowEnd {
+		limit++
+	}
+	if i >= limit {
+		return 0, ErrPath
+	}
+	return i, nil
+}
+
+func get(node any, path []string) (any, error) {
+	for _, t := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			v, ok := n[t]
+			if !ok {
+				return nil, ErrPath
+			}
+			node = v
+		case []any:
+			i, err := index(t, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, ErrPath
+		}
+	}
+	return node, nil
+}
+
+// edit вызывает f для контейнера, в котором лежит значение по пути path
+// (len(path) > 0), и записывает результат f на место контейнера.
+func edit(node any, path []string, f func(parent any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return f(node, path[0])
+	}
+	child, err := get(node, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	if child, err = edit(child, path[1:], f); err != nil {
+		return nil, err
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		n[path[0]] = child
+	case []any:
+		i, _ := index(path[0], len(n), false)
+		n[i] = child
+	}
+	return node, nil
+}
+
+func add(root any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return edit(root, path, func(parent any, key string) (any, error) {
+		switch n := parent.(type) {
+		case map[string]any:
+			n[key] = value
+			return n, nil
+		case []any:
+			i, err := index(key, len(n), true)
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+		return nil, ErrPath
+	})
+}
+
+// remove удаляет значение по пути path и возвращает его.
+func remove(root any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, root, nil // replace документа целиком
+	}
+	var removed any
+	root, err := edit(root, path, func(parent any, key string) (any, error) {
+		switch n := parent.(type) {
+		case map[string]any:
+			v, ok := n[key]
+			if !ok {
+				return nil, ErrPath
+			}
+			removed = v
+			delete(n, key)
+			return n, nil
+		case []any:
+			i, err := index(key, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = n[i]
+			return append(n[:i:i], n[i+1:]...), nil
+		}
+		return nil, ErrPath
+	})
+	return root, removed, err
+}
+
+func deepCopy(v any) any {
+	switch n := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(n))
+		for k, e := range n {
+			m[k] = deepCopy(e)
+		}
+		return m
+	case []any:
+		a := make([]any, len(n))
+		for i, e := range n {
+			a[i] = deepCopy(e)
+		}
+		return a
+	}
+	return v
+}
+
+// equal сравнивает значения JSON по RFC 6902: числа — по значению,
+// объекты — без учёта порядка членов.
+func equal(a, b any) bool {
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if x == y {
+			return true
+		}
+		f, err1 := x.Float64()
+		g, err2 := y.Float64()
+		return err1 == nil && err2 == nil && f == g
+	}
+	return a == b
+}
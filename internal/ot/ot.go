@@ -0,0 +1,310 @@
+// Package ot — операционное преобразование (operational transformation)
+// текста: правки, их применение к документу и преобразование
+// одновременных правок так, чтобы все копии документа сошлись.
+package ot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrInvalid — операция записана неверно.
+	ErrInvalid = errors.New("invalid operation")
+	// ErrLength — операция рассчитана на документ другой длины.
+	ErrLength = errors.New("operation length does not match document")
+)
+
+// Component — шаг операции: ровно одно из полей не нулевое.
+type Component struct {
+	// Retain — сколько символов оставить как есть.
+	Retain int
+	// Insert — текст, который вставляется в текущую позицию.
+	Insert string
+	// Delete — сколько символов удалить.
+	Delete int
+}
+
+// Operation — правка текста: шаги проходят документ от начала до конца,
+// так что сумма Retain и Delete равна длине документа. Позиции и длины
+// считаются в символах Unicode (рунах).
+//
+// В JSON операция — массив: положительное число оставляет столько
+// символов, отрицательное удаляет, строка вставляется:
+//
+//	[3, "abc", -2, 5]
+//
+// Методы Retain, Insert и Delete дописывают шаг, объединяя его с
+// соседним того же вида; вставка всегда ставится перед соседним
+// удалением, поэтому одинаковые правки записываются одинаково. Как и
+// append, они могут менять общий с исходной операцией массив:
+// продолжайте правку через возвращённое значение.
+type Operation []Component
+
+// Retain дописывает шаг «оставить n символов».
+func (o Operation) Retain(n int) Operation {
+	if n <= 0 {
+		return o
+	}
+	if last := len(o) - 1; last >= 0 && o[last].Retain > 0 {
+		o[last].Retain += n
+		return o
+	}
+	return append(o, Component{Retain: n})
+}
+
+// Insert дописывает шаг «вставить s».
+func (o Operation) Insert(s string) Operation {
+	if s == "" {
+		return o
+	}
+	last := len(o) - 1
+	switch {
+	case last >= 0 && o[last].Insert != "":
+		o[last].Insert += s
+	case last >= 0 && o[last].Delete > 0:
+		// вставка и удаление в одной позиции: вставка идёт первой
+		if last > 0 && o[last-1].Insert != "" {
+			o[last-1].Insert += s
+			break
+		}
+		o = append(o, o[last])
+		o[last] = Component{Insert: s}
+	default:
+		o = append(o, Component{Insert: s})
+	}
+	return o
+}
+
+// Delete дописывает шаг «удалить n символов».
+func (o Operation) Delete(n int) Operation {
+	if n <= 0 {
+		return o
+	}
+	if last := len(o) - 1; last >= 0 && o[last].Delete > 0 {
+		o[last].Delete += n
+		return o
+	}
+	return append(o, Component{Delete: n})
+}
+
+// BaseLen — длина документа, к которому применима операция.
+func (o Operation) BaseLen() int {
+	n := 0
+	for _, c := range o {
+		n += c.Retain + c.Delete
+	}
+	return n
+}
+
+// TargetLen — длина документа после операции.
+func (o Operation) TargetLen() int {
+	n := 0
+	for _, c := range o {
+		n += c.Retain + utf8.RuneCountInString(c.Insert)
+	}
+	return n
+}
+
+// IsNoop сообщает, что операция не меняет документ.
+func (o Operation) IsNoop() bool {
+	for _, c := range o {
+		if c.Retain == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply применяет операцию к doc.
+func (o Operation) Apply(doc string) (string, error) {
+	runes := []rune(doc)
+	if o.BaseLen() != len(runes) {
+		return "", ErrLength
+	}
+	var b strings.Builder
+	b.Grow(len(doc))
+	pos := 0
+	for _, c := range o {
+		switch {
+		case c.Retain > 0:
+			b.WriteString(string(runes[pos : pos+c.Retain]))
+			pos += c.Retain
+		case c.Insert != "":
+			b.WriteString(c.Insert)
+		default:
+			pos += c.Delete
+		}
+	}
+	return b.String(), nil
+}
+
+// TransformIndex переносит позицию pos в документе до операции в
+// документ после неё. Вставка ровно в pos позицию не сдвигает: курсор
+// другого участника остаётся перед вставленным текстом.
+func (o Operation) TransformIndex(pos int) int {
+	at, out := 0, pos
+	for _, c := range o {
+		if at >= pos {
+			break
+		}
+		switch {
+		case c.Retain > 0:
+			at += c.Retain
+		case c.Insert != "":
+			out += utf8.RuneCountInString(c.Insert)
+		default:
+			out -= min(c.Delete, pos-at)
+			at += c.Delete
+		}
+	}
+	return out
+}
+
+// Transform преобразует одновременные операции a и b над одним
+// документом: a1 применяется после b, b1 — после a, и результат
+// одинаков: b∘a1 == a∘b1. Если обе вставляют текст в одну позицию,
+// вставка a оказывается первой.
+func Transform(a, b Operation) (a1, b1 Operation, err error) {
+	if a.BaseLen() != b.BaseLen() {
+		return nil, nil, ErrLength
+	}
+	x, y := newReader(a), newReader(b)
+	for !x.done() || !y.done() {
+		if ins := x.peek().Insert; ins != "" {
+			a1 = a1.Insert(ins)
+			b1 = b1.Retain(utf8.RuneCountInString(ins))
+			x.next(0)
+			continue
+		}
+		if ins := y.peek().Insert; ins != "" {
+			a1 = a1.Retain(utf8.RuneCountInString(ins))
+			b1 = b1.Insert(ins)
+			y.next(0)
+			continue
+		}
+		ca, cb := x.peek(), y.peek()
+		n := min(ca.Retain+ca.Delete, cb.Retain+cb.Delete)
+		switch {
+		case ca.Retain > 0 && cb.Retain > 0:
+			a1, b1 = a1.Retain(n), b1.Retain(n)
+		case ca.Delete > 0 && cb.Retain > 0:
+			a1 = a1.Delete(n)
+		case ca.Retain > 0 && cb.Delete > 0:
+			b1 = b1.Delete(n)
+		}
+		// оба удаляют одно и то же — в преобразованных ничего не остаётся
+		x.next(n)
+		y.next(n)
+	}
+	return a1, b1, nil
+}
+
+// Diff возвращает операцию, превращающую a в b: общие начало и конец
+// остаются, середина заменяется.
+func Diff(a, b string) Operation {
+	x, y := []rune(a), []rune(b)
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+	return Operation{}.
+		Retain(prefix).
+		Insert(string(y[prefix : len(y)-suffix])).
+		Delete(len(x) - prefix - suffix).
+		Retain(suffix)
+}
+
+// reader выдаёт шаги операции, позволяя взять часть Retain или Delete.
+type reader struct {
+	op  Operation
+	i   int
+	cur Component
+}
+
+func newReader(op Operation) *reader {
+	r := &reader{op: op}
+	r.load()
+	return r
+}
+
+func (r *reader) load() {
+	r.cur = Component{}
+	if r.i < len(r.op) {
+		r.cur = r.op[r.i]
+	}
+}
+
+func (r *reader) done() bool { return r.i >= len(r.op) }
+
+// peek — текущий шаг; после конца операции — пустой Component.
+func (r *reader) peek() Component { return r.cur }
+
+// next берёт n символов текущего Retain или Delete (вставку — целиком).
+func (r *reader) next(n int) {
+	switch {
+	case r.cur.Retain > n:
+		r.cur.Retain -= n
+		return
+	case r.cur.Delete > n:
+		r.cur.Delete -= n
+		return
+	}
+	r.i++
+	r.load()
+}
+
+// MarshalJSON записывает операцию массивом чисел и строк.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	out := make([]any, 0, len(o))
+	for _, c := range o {
+		switch {
+		case c.Retain > 0:
+			out = append(out, c.Retain)
+		case c.Insert != "":
+			out = append(out, c.Insert)
+		default:
+			out = append(out, -c.Delete)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON читает операцию из массива чисел и строк и приводит её к
+// обычной записи. Нули, пустые строки и дробные числа — ErrInvalid.
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return ErrInvalid
+	}
+	var op Operation
+	for _, item := range raw {
+		item = bytes.TrimSpace(item)
+		if len(item) > 0 && item[0] == '"' {
+			var s string
+			if err := json.Unmarshal(item, &s); err != nil || s == "" {
+				return ErrInvalid
+			}
+			op = op.Insert(s)
+			continue
+		}
+		var n int
+		if err := json.Unmarshal(item, &n); err != nil || n == 0 {
+			return ErrInvalid
+		}
+		if n > 0 {
+			op = op.Retain(n)
+		} else {
+			op = op.Delete(-n)
+		}
+	}
+	*o = op
+	return nil
+}
@@ -0,0 +1,171 @@
+package ot
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		op   Operation
+		want string
+	}{
+		{"insert", "ac", Operation{}.Retain(1).Insert("b").Retain(1), "abc"},
+		{"delete", "abc", Operation{}.Retain(1).Delete(1).Retain(1), "ac"},
+		{"replace", "привет", Operation{}.Delete(3).Insert("ПРИ").Retain(3), "ПРИвет"},
+		{"empty", "", Operation{}.Insert("x"), "x"},
+		{"noop", "abc", Operation{}.Retain(3), "abc"},
+	}
+	for _, tt := range tests {
+		got, err := tt.op.Apply(tt.doc)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Apply = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, err := (Operation{}.Retain(2)).Apply("abc"); err != ErrLength {
+		t.Errorf("short op: err = %v, want ErrLength", err)
+	}
+}
+
+func TestBuilderNormalizes(t *testing.T) {
+	got := Operation{}.Retain(1).Retain(2).Delete(1).Insert("a").Delete(2).Insert("b")
+	want := Operation{{Retain: 3}, {Insert: "ab"}, {Delete: 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		a, b Operation
+		want string
+	}{
+		{"sameInsertPoint", "ac", Operation{}.Retain(1).Insert("X").Retain(1), Operation{}.Retain(1).Insert("Y").Retain(1), "aXYc"},
+		{"insertInsideDelete", "abcd", Operation{}.Retain(1).Delete(2).Retain(1), Operation{}.Retain(2).Insert("X").Retain(2), "aXd"},
+		{"overlappingDeletes", "abcdef", Operation{}.Retain(1).Delete(3).Retain(2), Operation{}.Retain(2).Delete(3).Retain(1), "af"},
+		{"disjoint", "abc", Operation{}.Insert(">").Retain(3), Operation{}.Retain(3).Insert("<"), ">abc<"},
+	}
+	for _, tt := range tests {
+		a1, b1, err := Transform(tt.a, tt.b)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		ab := mustApply(t, mustApply(t, tt.doc, tt.a), b1)
+		ba := mustApply(t, mustApply(t, tt.doc, tt.b), a1)
+		if ab != tt.want || ba != tt.want {
+			t.Errorf("%s: a∘b1 = %q, b∘a1 = %q; want %q", tt.name, ab, ba, tt.want)
+		}
+	}
+
+	if _, _, err := Transform(Operation{}.Retain(1), Operation{}.Retain(2)); err != ErrLength {
+		t.Errorf("different lengths: err = %v, want ErrLength", err)
+	}
+}
+
+// TestTransformConverges проверяет сходимость на случайных правках.
+func TestTransformConverges(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		doc := randomText(rng, rng.Intn(12))
+		a, b := randomOp(rng, doc), randomOp(rng, doc)
+		a1, b1, err := Transform(a, b)
+		if err != nil {
+			t.Fatalf("Transform(%v, %v): %v", a, b, err)
+		}
+		ab := mustApply(t, mustApply(t, doc, a), b1)
+		ba := mustApply(t, mustApply(t, doc, b), a1)
+		if ab != ba {
+			t.Fatalf("doc %q, a %v, b %v: a∘b1 = %q, b∘a1 = %q", doc, a, b, ab, ba)
+		}
+	}
+}
+
+func TestTransformIndex(t *testing.T) {
+	op := Operation{}.Retain(2).Insert("xy").Delete(2).Retain(2) // "abcdef" -> "abxyef"
+	for pos, want := range []int{0, 1, 2, 4, 4, 5, 6} {
+		if got := op.TransformIndex(pos); got != want {
+			t.Errorf("TransformIndex(%d) = %d, want %d", pos, got, want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	pairs := [][2]string{{"", ""}, {"abc", "abc"}, {"abc", "axc"}, {"", "new"}, {"old", ""}, {"ёжик", "ёлка"}, {"aaa", "aa"}}
+	for _, p := range pairs {
+		op := Diff(p[0], p[1])
+		if got := mustApply(t, p[0], op); got != p[1] {
+			t.Errorf("Diff(%q, %q) gives %q", p[0], p[1], got)
+		}
+	}
+	if !Diff("same", "same").IsNoop() {
+		t.Error("Diff of equal texts is not a no-op")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	op := Operation{}.Retain(3).Insert("abc").Delete(2).Retain(5)
+	data, err := json.Marshal(op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `[3,"abc",-2,5]` {
+		t.Errorf("Marshal = %s", data)
+	}
+	var back Operation
+	if err := json.Unmarshal(data, &back); err != nil || !reflect.DeepEqual(back, op) {
+		t.Errorf("Unmarshal = %+v, %v; want %+v", back, err, op)
+	}
+
+	for _, bad := range []string{`{}`, `[0]`, `[""]`, `[1.5]`, `[true]`, `["a", null]`} {
+		if err := json.Unmarshal([]byte(bad), &back); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded", bad)
+		}
+	}
+}
+
+func mustApply(t *testing.T, doc string, op Operation) string {
+	t.Helper()
+	out, err := op.Apply(doc)
+	if err != nil {
+		t.Fatalf("Apply(%q, %v): %v", doc, op, err)
+	}
+	return out
+}
+
+func randomText(rng *rand.Rand, n int) string {
+	const alphabet = "abcё\n"
+	r := []rune(alphabet)
+	out := make([]rune, n)
+	for i := range out {
+		out[i] = r[rng.Intn(len(r))]
+	}
+	return string(out)
+}
+
+func randomOp(rng *rand.Rand, doc string) Operation {
+	var op Operation
+	left := len([]rune(doc))
+	for left > 0 {
+		n := 1 + rng.Intn(left)
+		switch rng.Intn(3) {
+		case 0:
+			op = op.Retain(n)
+		case 1:
+			op = op.Delete(n)
+		default:
+			op = op.Insert(randomText(rng, 1+rng.Intn(3))).Retain(n)
+		}
+		left -= n
+	}
+	if rng.Intn(2) == 0 {
+		op = op.Insert(randomText(rng, 1+rng.Intn(3)))
+	}
+	return op
+}
@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKeyRepository — хранилище API-ключей. Ключ принадлежит одному
+// пользователю: List и Delete видят только его ключи, а GetByHash ищет
+// среди всех — при аутентификации владелец ещё не известен.
+type APIKeyRepository interface {
+	Create(k core.APIKey) (int64, error)
+	GetByHash(keyHash string) (*core.APIKey, error)
+	// List возвращает ключи пользователя по возрастанию ID.
+	List(userID int64) ([]core.APIKey, error)
+	// Delete отзывает ключ; чужой ключ — ErrAPIKeyNotFound.
+	Delete(userID, id int64) error
+	// Touch отмечает время последнего использования ключа.
+	Touch(id int64, at time.Time) error
+}
+
+// APIKeyRepoMem — in-memory реализация APIKeyRepository. Как и сессии,
+// ключи не переживают перезапуск.
+type APIKeyRepoMem struct {
+	mu   sync.RWMutex
+	keys map[int64]*core.APIKey
+	next int64
+}
+
+func NewAPIKeyRepoMem() *APIKeyRepoMem {
+	return &APIKeyRepoMem{
+		keys: make(map[int64]*core.APIKey),
+	}
+}
+
+func cloneAPIKey(k *core.APIKey) *core.APIKey {
+	c := *k
+	c.Scopes = append([]string(nil), k.Scopes...)
+	if k.ExpiresAt != nil {
+		t := *k.ExpiresAt
+		c.ExpiresAt = &t
+	}
+	if k.LastUsedAt != nil {
+		t := *k.LastUsedAt
+		c.LastUsedAt = &t
+	}
+	return &c
+}
+
+func (r *APIKeyRepoMem) Create(k core.APIKey) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.next++
+	k.ID = r.next
+	r.keys[k.ID] = cloneAPIKey(&k)
+	return k.ID, nil
+}
+
+func (r *APIKeyRepoMem) GetByHash(keyHash string) (*core.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, k := range r.keys {
+		if k.KeyHash == keyHash {
+			return cloneAPIKey(k), nil
+		}
+	}
+	return nil, ErrAPIKeyNotFound
+}
+
+func (r *APIKeyRepoMem) List(userID int64) ([]core.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]core.APIKey, 0)
+	for _, k := range r.keys {
+		if k.UserID == userID {
+			out = append(out, *cloneAPIKey(k))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *APIKeyRepoMem) Delete(userID, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k, ok := r.keys[id]
+	if !ok || k.UserID != userID {
+		return ErrAPIKeyNotFound
+	}
+	delete(r.keys, id)
+	return nil
+}
+
+func (r *APIKeyRepoMem) Touch(id int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k, ok := r.keys[id]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	at = at.UTC()
+	k.LastUsedAt = &at
+	return nil
+}
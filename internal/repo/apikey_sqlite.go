@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+// Области хранятся одной строкой через пробел, как scope в OAuth.
+const apiKeySchemaSQLite = `
+CREATE TABLE IF NOT EXISTS api_keys (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id      INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	name         TEXT    NOT NULL,
+	prefix       TEXT    NOT NULL,
+	key_hash     TEXT    NOT NULL UNIQUE,
+	scopes       TEXT    NOT NULL,
+	created_at   INTEGER NOT NULL,
+	expires_at   INTEGER,
+	last_used_at INTEGER
+);
+CREATE INDEX IF NOT EXISTS api_keys_user ON api_keys (user_id);`
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at`
+
+// APIKeyRepoSQLite — реализация APIKeyRepository поверх SQLite.
+type APIKeyRepoSQLite struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepoSQLite создаёт репозиторий и при необходимости таблицу
+// ключей. Таблица ссылается на users, поэтому схема пользователей
+// создаётся тоже.
+func NewAPIKeyRepoSQLite(db *sql.DB) (*APIKeyRepoSQLite, error) {
+	if _, err := db.Exec(userSchemaSQLite + apiKeySchemaSQLite); err != nil {
+		return nil, err
+	}
+	return &APIKeyRepoSQLite{db: db}, nil
+}
+
+func scanAPIKey(s rowScanner) (*core.APIKey, error) {
+	var (
+		k          core.APIKey
+		scopes     string
+		createdAt  int64
+		expiresAt  sql.NullInt64
+		lastUsedAt sql.NullInt64
+	)
+	err := s.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.KeyHash, &scopes, &createdAt, &expiresAt, &lastUsedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	k.Scopes = strings.Fields(scopes)
+	k.CreatedAt = time.Unix(0, createdAt).UTC()
+	k.ExpiresAt = timeFromNull(expiresAt)
+	k.LastUsedAt = timeFromNull(lastUsedAt)
+	return &k, nil
+}
+
+func (r *APIKeyRepoSQLite) Create(k core.APIKey) (int64, error) {
+	res, err := r.db.Exec(
+		`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		k.UserID, k.Name, k.Prefix, k.KeyHash, strings.Join(k.Scopes, " "),
+		k.CreatedAt.UnixNano(), nullTime(k.ExpiresAt), nullTime(k.LastUsedAt),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *APIKeyRepoSQLite) GetByHash(keyHash string) (*core.APIKey, error) {
+	return scanAPIKey(r.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = ?`, keyHash))
+}
+
+func (r *APIKeyRepoSQLite) List(userID int64) ([]core.APIKey, error) {
+	rows, err := r.db.Query(`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]core.APIKey, 0)
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *k)
+	}
+	return out, rows.Err()
+}
+
+func (r *APIKeyRepoSQLite) Delete(userID, id int64) error {
+	return apiKeyAffected(r.db.Exec(`DELETE FROM api_keys WHERE id = ? AND user_id = ?`, id, userID))
+}
+
+func (r *APIKeyRepoSQLite) Touch(id int64, at time.Time) error {
+	return apiKeyAffected(r.db.Exec(`UPDATE api_keys SET last_used_at = ? WHERE id = ?`, at.UnixNano(), id))
+}
+
+// apiKeyAffected превращает «ни одна строка не затронута» в
+// ErrAPIKeyNotFound.
+func apiKeyAffected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
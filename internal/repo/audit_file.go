@@ -0,0 +1,197 @@
+package repo
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+
+	"example.com/notes-api/internal/core"
+)
+
+// auditMaxLine — максимальная длина строки журнала аудита: событие
+// содержит текст заметки до и после изменения.
+const auditMaxLine = 64 << 20
+
+// errAuditLineTooLong — строка журнала длиннее auditMaxLine.
+var errAuditLineTooLong = errors.New("audit line too long")
+
+// auditEntry — событие в индексе журнала: ID и смещение его строки.
+type auditEntry struct {
+	id     int64
+	offset int64
+}
+
+// AuditLogFile — AuditLog в файле JSON Lines: одно событие на строку.
+// Файл только дописывается, поэтому его удобно отдавать внешним системам
+// сбора логов. В памяти хранятся смещения строк, так что Query читает
+// файл с первого события после q.After и не мешает записи.
+type AuditLogFile struct {
+	mu   sync.Mutex
+	f    *os.File
+	next int64
+	// index — события по возрастанию ID; size — конец последней строки.
+	index []auditEntry
+	size  int64
+}
+
+// OpenAuditLogFile открывает (или создаёт) журнал в path. Недописанная
+// последняя строка, оставшаяся после сбоя, обрезается; строки длиннее
+// auditMaxLine и повреждённые пропускаются.
+func OpenAuditLogFile(path string) (*AuditLogFile, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	l := &AuditLogFile{f: f}
+	l.size, err = scanAudit(f, 0, func(e core.AuditEvent, offset int64) bool {
+		l.index = append(l.index, auditEntry{id: e.ID, offset: offset})
+		l.next = e.ID
+		return true
+	})
+	if err == nil {
+		err = l.truncate(l.size)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// scanAudit читает события из r, пока fn возвращает true; offset —
+// смещение начала r в файле. Возвращает смещение конца последней целой
+// строки.
+func scanAudit(r io.Reader, offset int64, fn func(e core.AuditEvent, offset int64) bool) (int64, error) {
+	br := bufio.NewReader(r)
+	for {
+		line, n, err := readAuditLine(br)
+		if errors.Is(err, io.EOF) {
+			return offset, nil // пусто или недописанная строка
+		}
+		if errors.Is(err, errAuditLineTooLong) {
+			log.Printf("audit: skipping %d-byte line at offset %d", n, offset)
+			offset += n
+			continue
+		}
+		if err != nil {
+			return offset, err
+		}
+
+		var e core.AuditEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			log.Printf("audit: skipping malformed line at offset %d: %v", offset, err)
+		} else if !fn(e, offset) {
+			return offset, nil
+		}
+		offset += n
+	}
+}
+
+// readAuditLine читает строку вместе с '\n' и возвращает её и её длину.
+// Строку длиннее auditMaxLine она дочитывает, не сохраняя, и возвращает
+// errAuditLineTooLong; недописанную — io.EOF.
+func readAuditLine(br *bufio.Reader) ([]byte, int64, error) {
+	line, err := br.ReadSlice('\n')
+	n := int64(len(line))
+	if !errors.Is(err, bufio.ErrBufferFull) {
+		return line, n, err
+	}
+	// длинная строка: дочитываем её целиком
+	buf := append([]byte(nil), line...)
+	for errors.Is(err, bufio.ErrBufferFull) {
+		line, err = br.ReadSlice('\n')
+		n += int64(len(line))
+		if buf != nil && len(buf)+len(line) <= auditMaxLine {
+			buf = append(buf, line...)
+		} else {
+			buf = nil
+		}
+	}
+	switch {
+	case err != nil:
+		return nil, n, err
+	case buf == nil:
+		return nil, n, errAuditLineTooLong
+	}
+	return buf, n, nil
+}
+
+// truncate отрезает всё после offset.
+func (l *AuditLogFile) truncate(offset int64) error {
+	st, err := l.f.Stat()
+	if err != nil {
+		return err
+	}
+	if st.Size() > offset {
+		log.Printf("audit: truncating incomplete line at offset %d", offset)
+		return l.f.Truncate(offset)
+	}
+	return nil
+}
+
+// Close закрывает файл журнала.
+func (l *AuditLogFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+// Append дописывает событие. Если строка вышла бы длиннее auditMaxLine,
+// из события удаляются значения полей до и после изменения: остаётся,
+// какие поля изменились.
+func (l *AuditLogFile) Append(e core.AuditEvent) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.ID = l.next + 1
+	line, err := json.Marshal(e)
+	if err == nil && len(line) >= auditMaxLine {
+		changes := make([]core.FieldChange, len(e.Changes))
+		for i, c := range e.Changes {
+			changes[i] = core.FieldChange{Field: c.Field}
+		}
+		e.Changes = changes
+		line, err = json.Marshal(e)
+	}
+	if err != nil {
+		return 0, err
+	}
+	line = append(line, '\n')
+	if _, err := l.f.WriteAt(line, l.size); err != nil {
+		// недописанная строка закрыла бы следующие
+		_ = l.f.Truncate(l.size)
+		return 0, err
+	}
+	l.index = append(l.index, auditEntry{id: e.ID, offset: l.size})
+	l.size += int64(len(line))
+	l.next = e.ID
+	return e.ID, nil
+}
+
+// Query находит по индексу первое событие после q.After и читает файл с
+// него. Строки до l.size уже не меняются, поэтому чтение идёт без
+// мьютекса, параллельно с Append.
+func (l *AuditLogFile) Query(q AuditQuery) (AuditPage, error) {
+	l.mu.Lock()
+	index, size := l.index, l.size
+	l.mu.Unlock()
+
+	var page AuditPage
+	i := sort.Search(len(index), func(i int) bool { return index[i].id > q.After })
+	if i == len(index) {
+		return page, nil
+	}
+	start := index[i].offset
+	_, err := scanAudit(io.NewSectionReader(l.f, start, size-start), start, func(e core.AuditEvent, _ int64) bool {
+		return !page.add(q, e)
+	})
+	if err != nil {
+		return AuditPage{}, err
+	}
+	return page, nil
+}
@@ -0,0 +1,103 @@
+package repo
+
+import (
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+// AuditLog — журнал аудита изменений заметок. События только
+// добавляются; ID выдаются по возрастанию.
+type AuditLog interface {
+	// Append сохраняет событие и возвращает его ID; e.ID игнорируется.
+	Append(e core.AuditEvent) (int64, error)
+	// Query возвращает события по возрастанию ID.
+	Query(q AuditQuery) (AuditPage, error)
+}
+
+// AuditQuery — фильтр журнала аудита; нулевые поля не ограничивают
+// выборку.
+type AuditQuery struct {
+	NoteID int64
+	// Actor — имя пользователя, выполнившего изменение.
+	Actor string
+	// Since и Until — полуинтервал времени [Since, Until).
+	Since *time.Time
+	Until *time.Time
+
+	// After — ID последнего события предыдущей страницы.
+	After int64
+	// Limit — максимум событий на странице; 0 — без ограничения.
+	Limit int
+}
+
+func (q AuditQuery) match(e *core.AuditEvent) bool {
+	switch {
+	case e.ID <= q.After:
+		return false
+	case q.NoteID != 0 && e.NoteID != q.NoteID:
+		return false
+	case q.Actor != "" && e.Actor != q.Actor:
+		return false
+	case q.Since != nil && e.Time.Before(*q.Since):
+		return false
+	case q.Until != nil && !e.Time.Before(*q.Until):
+		return false
+	}
+	return true
+}
+
+// AuditPage — одна страница журнала аудита.
+type AuditPage struct {
+	Events []core.AuditEvent
+	// Next — значение After для следующей страницы; 0, если это последняя.
+	Next int64
+}
+
+// add добавляет подходящее событие на страницу и сообщает, заполнена ли
+// она. Событие сверх Limit только отмечает, что есть следующая страница.
+func (p *AuditPage) add(q AuditQuery, e core.AuditEvent) (full bool) {
+	if !q.match(&e) {
+		return false
+	}
+	if q.Limit > 0 && len(p.Events) == q.Limit {
+		p.Next = p.Events[len(p.Events)-1].ID
+		return true
+	}
+	p.Events = append(p.Events, e)
+	return false
+}
+
+// AuditLogMem — in-memory реализация AuditLog.
+type AuditLogMem struct {
+	mu     sync.RWMutex
+	events []core.AuditEvent
+}
+
+func NewAuditLogMem() *AuditLogMem {
+	return &AuditLogMem{}
+}
+
+func (l *AuditLogMem) Append(e core.AuditEvent) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.ID = int64(len(l.events)) + 1
+	e.Changes = append([]core.FieldChange(nil), e.Changes...)
+	l.events = append(l.events, e)
+	return e.ID, nil
+}
+
+func (l *AuditLogMem) Query(q AuditQuery) (AuditPage, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var page AuditPage
+	for _, e := range l.events[min(int(max(q.After, 0)), len(l.events)):] {
+		if page.add(q, e) {
+			break
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,140 @@
+package repo_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/repo/repotest"
+)
+
+func TestAuditLogMem(t *testing.T) {
+	repotest.RunAuditLog(t, func(t *testing.T) repo.AuditLog {
+		return repo.NewAuditLogMem()
+	})
+}
+
+func TestAuditLogFile(t *testing.T) {
+	repotest.RunAuditLog(t, func(t *testing.T) repo.AuditLog {
+		l, err := repo.OpenAuditLogFile(filepath.Join(t.TempDir(), "audit.jsonl"))
+		if err != nil {
+			t.Fatalf("OpenAuditLogFile: %v", err)
+		}
+		t.Cleanup(func() { _ = l.Close() })
+		return l
+	})
+}
+
+func TestAuditLogFileReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := repo.OpenAuditLogFile(path)
+	if err != nil {
+		t.Fatalf("OpenAuditLogFile: %v", err)
+	}
+	for range 2 {
+		if _, err := l.Append(core.AuditEvent{Time: time.Now().UTC(), Action: core.AuditCreate, NoteID: 1}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	_ = l.Close()
+
+	// сбой посреди записи оставляет недописанную строку
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"id":3,"action":"upd`)
+	_ = f.Close()
+
+	l, err = repo.OpenAuditLogFile(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer l.Close()
+	id, err := l.Append(core.AuditEvent{Time: time.Now().UTC(), Action: core.AuditDelete, NoteID: 1})
+	if err != nil || id != 3 {
+		t.Fatalf("Append after reopen = %d, %v; want 3", id, err)
+	}
+	page, err := l.Query(repo.AuditQuery{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(page.Events) != 3 || page.Events[2].Action != core.AuditDelete {
+		t.Errorf("events after reopen = %+v, want 3 with the last delete", page.Events)
+	}
+}
+
+// Строка длиннее, чем журнал читает, не мешает открыть его, а слишком
+// большое событие записывается без значений полей.
+func TestAuditLogFileLongLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	huge := strings.Repeat("a", 64<<20)
+	content := `{"id":1,"action":"create","noteId":1}` + "\n" +
+		`{"id":2,"action":"update","noteId":1,"changes":[{"field":"content","after":"` + huge + `"}]}` + "\n" +
+		`{"id":3,"action":"update","noteId":1}` + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := repo.OpenAuditLogFile(path)
+	if err != nil {
+		t.Fatalf("OpenAuditLogFile: %v", err)
+	}
+	defer l.Close()
+	id, err := l.Append(core.AuditEvent{Action: core.AuditUpdate, NoteID: 1, Changes: []core.FieldChange{
+		{Field: "title", Before: "a", After: "b"},
+		{Field: "content", Before: "", After: huge},
+	}})
+	if err != nil || id != 4 {
+		t.Fatalf("Append = %d, %v; want 4", id, err)
+	}
+
+	page, err := l.Query(repo.AuditQuery{After: 1})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(page.Events) != 2 || page.Events[0].ID != 3 || page.Events[1].ID != 4 {
+		t.Fatalf("events = %+v, want 3 and 4", page.Events)
+	}
+	changes := page.Events[1].Changes
+	if len(changes) != 2 || changes[0].Field != "title" || changes[0].After != nil || changes[1].Field != "content" || changes[1].After != nil {
+		t.Errorf("changes of a huge event = %+v, want fields without values", changes)
+	}
+}
+
+func TestAuditLogFileConcurrentQuery(t *testing.T) {
+	l, err := repo.OpenAuditLogFile(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("OpenAuditLogFile: %v", err)
+	}
+	defer l.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			if _, err := l.Append(core.AuditEvent{Action: core.AuditCreate, NoteID: int64(i)}); err != nil {
+				t.Errorf("Append: %v", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		page, err := l.Query(repo.AuditQuery{After: int64(i), Limit: 10})
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		for j, e := range page.Events {
+			if e.ID != int64(i+j+1) {
+				t.Fatalf("Query(After %d): event %d has ID %d", i, j, e.ID)
+			}
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,132 @@
+package repo
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+var ErrShareLinkNotFound = errors.New("share link not found")
+
+// ShareLinkRepository — хранилище публичных ссылок на заметки. Управлять
+// ссылками может только владелец заметки, поэтому ListByNote и Delete
+// принимают его ID; GetByHash ищет среди всех ссылок.
+type ShareLinkRepository interface {
+	Create(l core.ShareLink) (int64, error)
+	GetByHash(tokenHash string) (*core.ShareLink, error)
+	// ListByNote возвращает ссылки на заметку по возрастанию ID.
+	ListByNote(ownerID, noteID int64) ([]core.ShareLink, error)
+	// Delete отзывает ссылку; чужая ссылка или ссылка на другую
+	// заметку — ErrShareLinkNotFound.
+	Delete(ownerID, noteID, id int64) error
+	// DeleteByNote удаляет все ссылки на заметку.
+	DeleteByNote(noteID int64) error
+	// RecordAccess увеличивает счётчик открытий ссылки и запоминает время
+	// последнего открытия.
+	RecordAccess(id int64, at time.Time) error
+}
+
+// ShareLinkRepoMem — in-memory реализация ShareLinkRepository.
+type ShareLinkRepoMem struct {
+	mu    sync.RWMutex
+	links map[int64]*core.ShareLink
+	next  int64
+}
+
+func NewShareLinkRepoMem() *ShareLinkRepoMem {
+	return &ShareLinkRepoMem{
+		links: make(map[int64]*core.ShareLink),
+	}
+}
+
+func cloneShareLink(l *core.ShareLink) *core.ShareLink {
+	c := *l
+	c.PasswordHash = append([]byte(nil), l.PasswordHash...)
+	c.Protected = len(l.PasswordHash) > 0
+	if l.ExpiresAt != nil {
+		t := *l.ExpiresAt
+		c.ExpiresAt = &t
+	}
+	if l.LastAccessAt != nil {
+		t := *l.LastAccessAt
+		c.LastAccessAt = &t
+	}
+	return &c
+}
+
+func (r *ShareLinkRepoMem) Create(l core.ShareLink) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.next++
+	l.ID = r.next
+	r.links[l.ID] = cloneShareLink(&l)
+	return l.ID, nil
+}
+
+func (r *ShareLinkRepoMem) GetByHash(tokenHash string) (*core.ShareLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, l := range r.links {
+		if l.TokenHash == tokenHash {
+			return cloneShareLink(l), nil
+		}
+	}
+	return nil, ErrShareLinkNotFound
+}
+
+func (r *ShareLinkRepoMem) ListByNote(ownerID, noteID int64) ([]core.ShareLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]core.ShareLink, 0)
+	for _, l := range r.links {
+		if l.OwnerID == ownerID && l.NoteID == noteID {
+			out = append(out, *cloneShareLink(l))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *ShareLinkRepoMem) Delete(ownerID, noteID, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.links[id]
+	if !ok || l.OwnerID != ownerID || l.NoteID != noteID {
+		return ErrShareLinkNotFound
+	}
+	delete(r.links, id)
+	return nil
+}
+
+func (r *ShareLinkRepoMem) DeleteByNote(noteID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, l := range r.links {
+		if l.NoteID == noteID {
+			delete(r.links, id)
+		}
+	}
+	return nil
+}
+
+func (r *ShareLinkRepoMem) RecordAccess(id int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.links[id]
+	if !ok {
+		return ErrShareLinkNotFound
+	}
+	at = at.UTC()
+	l.AccessCount++
+	l.LastAccessAt = &at
+	return nil
+}
@@ -0,0 +1,132 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+// Ссылки удаляются вместе с заметкой.
+const shareLinkSchemaSQLite = `
+CREATE TABLE IF NOT EXISTS share_links (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	note_id        INTEGER NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+	owner_id       INTEGER NOT NULL,
+	prefix         TEXT    NOT NULL,
+	token_hash     TEXT    NOT NULL UNIQUE,
+	password_hash  BLOB,
+	created_at     INTEGER NOT NULL,
+	expires_at     INTEGER,
+	access_count   INTEGER NOT NULL DEFAULT 0,
+	last_access_at INTEGER
+);
+CREATE INDEX IF NOT EXISTS share_links_note ON share_links (note_id, id);`
+
+const shareLinkColumns = `id, note_id, owner_id, prefix, token_hash, password_hash, created_at, expires_at, access_count, last_access_at`
+
+// ShareLinkRepoSQLite — реализация ShareLinkRepository поверх SQLite.
+type ShareLinkRepoSQLite struct {
+	db *sql.DB
+}
+
+// NewShareLinkRepoSQLite создаёт репозиторий и при необходимости таблицу
+// ссылок. Таблицу заметок создаёт NewNoteRepoSQLite.
+func NewShareLinkRepoSQLite(db *sql.DB) (*ShareLinkRepoSQLite, error) {
+	if _, err := db.Exec(shareLinkSchemaSQLite); err != nil {
+		return nil, err
+	}
+	return &ShareLinkRepoSQLite{db: db}, nil
+}
+
+func scanShareLink(s rowScanner) (*core.ShareLink, error) {
+	var (
+		l            core.ShareLink
+		createdAt    int64
+		expiresAt    sql.NullInt64
+		lastAccessAt sql.NullInt64
+	)
+	err := s.Scan(&l.ID, &l.NoteID, &l.OwnerID, &l.Prefix, &l.TokenHash, &l.PasswordHash,
+		&createdAt, &expiresAt, &l.AccessCount, &lastAccessAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrShareLinkNotFound
+		}
+		return nil, err
+	}
+	l.Protected = len(l.PasswordHash) > 0
+	l.CreatedAt = time.Unix(0, createdAt).UTC()
+	l.ExpiresAt = timeFromNull(expiresAt)
+	l.LastAccessAt = timeFromNull(lastAccessAt)
+	return &l, nil
+}
+
+func (r *ShareLinkRepoSQLite) Create(l core.ShareLink) (int64, error) {
+	res, err := r.db.Exec(
+		`INSERT INTO share_links (note_id, owner_id, prefix, token_hash, password_hash, created_at, expires_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		l.NoteID, l.OwnerID, l.Prefix, l.TokenHash, l.PasswordHash, l.CreatedAt.UnixNano(), nullTime(l.ExpiresAt),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *ShareLinkRepoSQLite) GetByHash(tokenHash string) (*core.ShareLink, error) {
+	return scanShareLink(r.db.QueryRow(`SELECT `+shareLinkColumns+` FROM share_links WHERE token_hash = ?`, tokenHash))
+}
+
+func (r *ShareLinkRepoSQLite) ListByNote(ownerID, noteID int64) ([]core.ShareLink, error) {
+	rows, err := r.db.Query(
+		`SELECT `+shareLinkColumns+` FROM share_links WHERE owner_id = ? AND note_id = ? ORDER BY id`, ownerID, noteID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]core.ShareLink, 0)
+	for rows.Next() {
+		l, err := scanShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *l)
+	}
+	return out, rows.Err()
+}
+
+func (r *ShareLinkRepoSQLite) Delete(ownerID, noteID, id int64) error {
+	return shareLinkAffected(r.db.Exec(
+		`DELETE FROM share_links WHERE id = ? AND owner_id = ? AND note_id = ?`, id, ownerID, noteID,
+	))
+}
+
+func (r *ShareLinkRepoSQLite) DeleteByNote(noteID int64) error {
+	_, err := r.db.Exec(`DELETE FROM share_links WHERE note_id = ?`, noteID)
+	return err
+}
+
+func (r *ShareLinkRepoSQLite) RecordAccess(id int64, at time.Time) error {
+	return shareLinkAffected(r.db.Exec(
+		`UPDATE share_links SET access_count = access_count + 1, last_access_at = ? WHERE id = ?`, at.UnixNano(), id,
+	))
+}
+
+// shareLinkAffected превращает «ни одна строка не затронута» в
+// ErrShareLinkNotFound.
+func shareLinkAffected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrShareLinkNotFound
+	}
+	return nil
+}
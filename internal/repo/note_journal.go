@@ -0,0 +1,358 @@
+package repo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"example.com/notes-api/internal/core"
+)
+
+const (
+	journalFileName  = "notes.journal"
+	snapshotFileName = "notes.snapshot"
+
+	defaultSnapshotEvery = 1000
+
+	// Заголовок записи: длина полезной нагрузки и её CRC32.
+	journalHeaderSize = 8
+	// Защита от мусорной длины в повреждённом заголовке.
+	journalMaxRecord = 64 << 20
+)
+
+// ErrJournalRecordTooLarge — изменение не помещается в одну запись
+// журнала.
+var ErrJournalRecordTooLarge = errors.New("journal record too large")
+
+// JournalOptions — настройки журнала для NoteRepoMem.
+type JournalOptions struct {
+	// SnapshotEvery — через сколько записей журнал сжимается в снимок.
+	// 0 означает значение по умолчанию (1000).
+	SnapshotEvery int
+	// Sync — вызывать fsync после каждой записи (надёжнее, но медленнее).
+	Sync bool
+}
+
+type journalOp string
+
+const (
+	journalCreate journalOp = "create"
+	journalUpdate journalOp = "update"
+	journalDelete journalOp = "delete"
+	// journalBatch — записи одной транзакции NoteRepoMem.Batch.
+	journalBatch journalOp = "batch"
+)
+
+// journalRecord — одна запись журнала. Для create/update хранится полное
+// состояние заметки, поэтому повторное применение записи идемпотентно.
+type journalRecord struct {
+	Op    journalOp       `json:"op"`
+	ID    int64           `json:"id"`
+	Note  *core.Note      `json:"note,omitempty"`
+	Batch []journalRecord `json:"batch,omitempty"`
+}
+
+// journalSnapshot — сжатое состояние репозитория.
+type journalSnapshot struct {
+	Next  int64       `json:"next"`
+	Notes []core.Note `json:"notes"`
+}
+
+// journal — append-only файл с записями вида [len][crc32][json].
+type journal struct {
+	dir     string
+	f       *os.File
+	opts    JournalOptions
+	records int // записей с момента последнего снимка
+}
+
+// OpenNoteRepoMem создаёт in-memory репозиторий, который переживает
+// перезапуск: состояние восстанавливается из снимка и журнала в dir,
+// а каждая последующая мутация сначала пишется в журнал.
+func OpenNoteRepoMem(dir string, opts JournalOptions) (*NoteRepoMem, error) {
+	if opts.SnapshotEvery <= 0 {
+		opts.SnapshotEvery = defaultSnapshotEvery
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	r := NewNoteRepoMem()
+	if err := r.loadSnapshot(filepath.Join(dir, snapshotFileName)); err != nil {
+		return nil, fmt.Errorf("load snapshot: %w", err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, journalFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	n, err := r.replayJournal(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("replay journal: %w", err)
+	}
+
+	r.journal = &journal{dir: dir, f: f, opts: opts, records: n}
+	return r, nil
+}
+
+// Close закрывает файл журнала. Для репозитория без журнала — no-op.
+func (r *NoteRepoMem) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.journal == nil {
+		return nil
+	}
+	err := r.journal.f.Close()
+	r.journal = nil
+	return err
+}
+
+// Snapshot принудительно сжимает журнал в снимок.
+func (r *NoteRepoMem) Snapshot() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.journal == nil {
+		return nil
+	}
+	return r.journal.snapshot(r.next, r.notes)
+}
+
+func (r *NoteRepoMem) loadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap journalSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	r.next = snap.Next
+	for i := range snap.Notes {
+		n := snap.Notes[i]
+		if n.Version == 0 {
+			n.Version = 1 // снимок до появления версий
+		}
+		r.put(n.ID, &n)
+		if n.ID > r.next {
+			r.next = n.ID
+		}
+	}
+	return nil
+}
+
+// replayJournal применяет записи журнала поверх снимка. Повреждённый или
+// недописанный хвост обрезается; возвращается число применённых записей.
+func (r *NoteRepoMem) replayJournal(f *os.File) (int, error) {
+	br := bufio.NewReader(f)
+	var (
+		offset  int64
+		applied int
+		header  [journalHeaderSize]byte
+	)
+	for {
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return applied, truncateJournal(f, offset, err)
+		}
+		size := binary.BigEndian.Uint32(header[0:4])
+		sum := binary.BigEndian.Uint32(header[4:8])
+		if size > journalMaxRecord {
+			return applied, truncateJournal(f, offset, fmt.Errorf("record size %d too large", size))
+		}
+
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return applied, truncateJournal(f, offset, err)
+		}
+		if crc32.ChecksumIEEE(payload) != sum {
+			return applied, truncateJournal(f, offset, errors.New("checksum mismatch"))
+		}
+
+		var rec journalRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return applied, truncateJournal(f, offset, err)
+		}
+		r.apply(rec)
+
+		offset += journalHeaderSize + int64(size)
+		applied++
+	}
+
+	_, err := f.Seek(offset, io.SeekStart)
+	return applied, err
+}
+
+// truncateJournal отрезает всё начиная с offset — первой записи, которую
+// не удалось прочитать.
+func truncateJournal(f *os.File, offset int64, cause error) error {
+	log.Printf("journal: truncating corrupted tail at offset %d: %v", offset, cause)
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	_, err := f.Seek(offset, io.SeekStart)
+	return err
+}
+
+func (r *NoteRepoMem) apply(rec journalRecord) {
+	switch rec.Op {
+	case journalCreate, journalUpdate:
+		if rec.Note == nil {
+			return
+		}
+		n := *rec.Note
+		if n.Version == 0 {
+			n.Version = 1 // журнал до появления версий
+		}
+		r.put(rec.ID, &n)
+	case journalDelete:
+		r.put(rec.ID, nil)
+	case journalBatch:
+		for _, sub := range rec.Batch {
+			r.apply(sub)
+		}
+	}
+	if rec.ID > r.next {
+		r.next = rec.ID
+	}
+}
+
+// append дописывает запись в журнал. Вызывается под r.mu до изменения
+// состояния в памяти (write-ahead). Если запись не удалась, журнал
+// обрезается до прежней длины: иначе недописанная запись закрыла бы все
+// следующие (при восстановлении хвост за ней отбрасывается), а
+// несинхронизированная вернулась бы после перезапуска, хотя вызывающий
+// получил ошибку.
+func (j *journal) append(rec journalRecord) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	// при восстановлении такая запись сочлась бы повреждённой и вместе со
+	// всеми следующими была бы отброшена
+	if len(payload) > journalMaxRecord {
+		return fmt.Errorf("%w: %d bytes, at most %d", ErrJournalRecordTooLarge, len(payload), journalMaxRecord)
+	}
+	buf := make([]byte, journalHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	copy(buf[journalHeaderSize:], payload)
+
+	offset, err := j.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	_, err = j.f.Write(buf)
+	if err == nil && j.opts.Sync {
+		err = j.f.Sync()
+	}
+	if err != nil {
+		j.rewind(offset, err)
+		return err
+	}
+	j.records++
+	return nil
+}
+
+// rewind отрезает неудачную запись, начавшуюся с offset.
+func (j *journal) rewind(offset int64, cause error) {
+	if err := j.f.Truncate(offset); err != nil {
+		log.Printf("journal: cannot truncate failed record at offset %d (%v): %v", offset, cause, err)
+		return
+	}
+	if _, err := j.f.Seek(offset, io.SeekStart); err != nil {
+		log.Printf("journal: cannot seek to offset %d: %v", offset, err)
+		return
+	}
+	if j.opts.Sync {
+		_ = j.f.Sync()
+	}
+}
+
+// shouldSnapshot сообщает, пора ли сжимать журнал.
+func (j *journal) shouldSnapshot() bool {
+	return j.records >= j.opts.SnapshotEvery
+}
+
+// snapshot атомарно (через rename) записывает текущее состояние и
+// очищает журнал. Если процесс упадёт между rename и очисткой, при старте
+// записи журнала просто применятся повторно — они идемпотентны.
+func (j *journal) snapshot(next int64, notes map[int64]*core.Note) error {
+	snap := journalSnapshot{Next: next, Notes: make([]core.Note, 0, len(notes))}
+	for _, n := range notes {
+		snap.Notes = append(snap.Notes, *n)
+	}
+	sort.Slice(snap.Notes, func(a, b int) bool { return snap.Notes[a].ID < snap.Notes[b].ID })
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(j.dir, snapshotFileName, data); err != nil {
+		return err
+	}
+
+	if err := j.f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := j.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	j.records = 0
+	return j.f.Sync()
+}
+
+// writeFileAtomic записывает файл name в каталоге dir целиком или не
+// записывает вовсе: данные пишутся во временный файл, который затем
+// переименовывается поверх name.
+func writeFileAtomic(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // после успешного rename файла уже нет
+
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		return err
+	}
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+	return nil
+}
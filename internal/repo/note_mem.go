@@ -2,6 +2,8 @@ package repo
 
 import (
     "errors"
+    "log"
+    "slices"
     "sync"
     "time"
 
@@ -9,94 +11,384 @@ import (
 )
 
 var (
-    ErrNoteNotFound = errors.New("note not found")
+    ErrNoteNotFound    = errors.New("note not found")
+    ErrVersionConflict = errors.New("note version conflict")
 )
 
+// AllOwners — значение ownerID/NoteQuery.OwnerID, снимающее фильтр по
+// владельцу. Только для фоновых задач (очистка корзины, построение
+// индекса); запросы пользователей всегда передают ID владельца.
+const AllOwners int64 = -1
+
 // NoteRepository — интерфейс репозитория.
+//
+// Методы, работающие с одной заметкой, принимают ownerID: заметка другого
+// владельца для них не существует (ErrNoteNotFound), так что реализация
+// не может выдать чужую заметку по ошибке вызывающего.
 type NoteRepository interface {
+    // Create сохраняет заметку с владельцем note.OwnerID.
     Create(note core.Note) (int64, error)
+    // GetAll возвращает все заметки всех владельцев, включая заметки
+    // в корзине. Только для фоновых задач.
     GetAll() ([]core.Note, error)
-    GetByID(id int64) (*core.Note, error)
-    Update(id int64, updateFn func(*core.Note) error) (*core.Note, error)
-    Delete(id int64) error
+    // Find возвращает страницу заметок владельца q.OwnerID с фильтрами,
+    // сортировкой и курсором.
+    Find(q NoteQuery) (NotePage, error)
+    // GetByID находит заметку, в том числе в корзине (DeletedAt != nil).
+    GetByID(ownerID, id int64) (*core.Note, error)
+    // Update и Delete при version != 0 атомарно проверяют, что текущая
+    // версия заметки равна version, иначе возвращают ErrVersionConflict.
+    // Update увеличивает версию на единицу; перемещение в корзину и
+    // восстановление — это Update, меняющий DeletedAt. Владельца
+    // updateFn изменить не может.
+    Update(ownerID, id int64, version int64, updateFn func(*core.Note) error) (*core.Note, error)
+    // Delete удаляет заметку безвозвратно.
+    Delete(ownerID, id int64, version int64) error
+    // TagCounts возвращает теги заметок владельца вне корзины с числом
+    // заметок у каждого, по алфавиту.
+    TagCounts(ownerID int64) ([]TagCount, error)
+    // Usage возвращает, сколько заметок у владельца и сколько места они
+    // занимают; заметки в корзине учитываются.
+    Usage(ownerID int64) (NoteUsage, error)
+    // Batch выполняет fn как одну транзакцию: изменения, сделанные через
+    // tx, сохраняются все вместе, если fn вернул nil, и не сохраняются
+    // вовсе, если fn вернул ошибку (её и возвращает Batch). Чтения через
+    // tx видят изменения, уже сделанные в транзакции; tx нельзя
+    // использовать после возврата из fn. Пока fn выполняется, другие
+    // изменения заметок ждут, поэтому fn не должен обращаться к другим
+    // хранилищам. Batch у tx выполняет fn в той же транзакции.
+    Batch(fn func(tx NoteRepository) error) error
+}
+
+// NoteUsage — объём заметок одного владельца.
+type NoteUsage struct {
+    Notes int
+    // Bytes — суммарный размер заголовков и содержимого в байтах (UTF-8).
+    Bytes int64
+}
+
+// NoteSize — размер заметки, по которому считается NoteUsage.Bytes.
+func NoteSize(title, content string) int64 {
+    return int64(len(title) + len(content))
+}
+
+// TagCount — тег и число заметок с ним.
+type TagCount struct {
+    Tag   string `json:"tag" example:"работа"`
+    Count int    `json:"count" example:"3"`
 }
 
 // NoteRepoMem — in-memory реализация.
+// Если репозиторий открыт через OpenNoteRepoMem, мутации пишутся в журнал.
 type NoteRepoMem struct {
-    mu    sync.RWMutex
-    notes map[int64]*core.Note
-    next  int64
+    mu      sync.RWMutex
+    notes   map[int64]*core.Note
+    usages  map[int64]NoteUsage // объём заметок по владельцам, см. put
+    next    int64
+    journal *journal
 }
 
 func NewNoteRepoMem() *NoteRepoMem {
     return &NoteRepoMem{
-        notes: make(map[int64]*core.Note),
+        notes:  make(map[int64]*core.Note),
+        usages: make(map[int64]NoteUsage),
+    }
+}
+
+// put заменяет заметку id на n (nil — удаляет её) и пересчитывает объём
+// заметок владельцев, чтобы Usage не перебирал все заметки. Все
+// изменения r.notes идут через put. Вызывается под r.mu.
+func (r *NoteRepoMem) put(id int64, n *core.Note) {
+    if old, ok := r.notes[id]; ok {
+        r.addUsage(old, -1)
+        delete(r.notes, id)
+    }
+    if n != nil {
+        r.notes[id] = n
+        r.addUsage(n, 1)
+    }
+}
+
+// addUsage прибавляет заметку n к объёму её владельца (sign = 1) или
+// вычитает её (sign = -1).
+func (r *NoteRepoMem) addUsage(n *core.Note, sign int) {
+    u := r.usages[n.OwnerID]
+    u.Notes += sign
+    u.Bytes += int64(sign) * NoteSize(n.Title, n.Content)
+    if u.Notes == 0 {
+        delete(r.usages, n.OwnerID)
+        return
     }
+    r.usages[n.OwnerID] = u
 }
 
 func (r *NoteRepoMem) Create(n core.Note) (int64, error) {
     r.mu.Lock()
     defer r.mu.Unlock()
+    return r.create(n, r.persist)
+}
 
-    r.next++
-    n.ID = r.next
+// create — Create под r.mu; запись журнала передаётся в persist до
+// изменения r.notes (см. NoteRepoMem.persist).
+func (r *NoteRepoMem) create(n core.Note, persist func(journalRecord) error) (int64, error) {
+    n = *cloneNote(&n)
+    n.ID = r.next + 1
+    n.Version = 1
     now := time.Now().UTC()
     n.CreatedAt = now
     n.UpdatedAt = nil
 
-    r.notes[n.ID] = &n
+    if err := persist(journalRecord{Op: journalCreate, ID: n.ID, Note: &n}); err != nil {
+        return 0, err
+    }
+    r.next = n.ID
+    r.put(n.ID, &n)
     return n.ID, nil
 }
 
 func (r *NoteRepoMem) GetAll() ([]core.Note, error) {
     r.mu.RLock()
     defer r.mu.RUnlock()
+    return r.getAll(), nil
+}
 
+func (r *NoteRepoMem) getAll() []core.Note {
     result := make([]core.Note, 0, len(r.notes))
     for _, n := range r.notes {
-        result = append(result, *n)
+        result = append(result, *cloneNote(n))
     }
-    return result, nil
+    return result
 }
 
-func (r *NoteRepoMem) GetByID(id int64) (*core.Note, error) {
+func (r *NoteRepoMem) Find(q NoteQuery) (NotePage, error) {
     r.mu.RLock()
     defer r.mu.RUnlock()
+    return r.find(q)
+}
 
+func (r *NoteRepoMem) find(q NoteQuery) (NotePage, error) {
+    notes := make([]*core.Note, 0, len(r.notes))
+    for _, n := range r.notes {
+        notes = append(notes, n)
+    }
+    return findInSlice(notes, q)
+}
+
+// owned возвращает заметку, если она принадлежит ownerID. Вызывается под r.mu.
+func (r *NoteRepoMem) owned(ownerID, id int64) (*core.Note, bool) {
     n, ok := r.notes[id]
+    if !ok || (ownerID != AllOwners && n.OwnerID != ownerID) {
+        return nil, false
+    }
+    return n, true
+}
+
+func (r *NoteRepoMem) GetByID(ownerID, id int64) (*core.Note, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    return r.getByID(ownerID, id)
+}
+
+func (r *NoteRepoMem) getByID(ownerID, id int64) (*core.Note, error) {
+    n, ok := r.owned(ownerID, id)
     if !ok {
         return nil, ErrNoteNotFound
     }
-    copy := *n
-    return &copy, nil
+    return cloneNote(n), nil
 }
 
-func (r *NoteRepoMem) Update(id int64, updateFn func(*core.Note) error) (*core.Note, error) {
+func (r *NoteRepoMem) Update(ownerID, id int64, version int64, updateFn func(*core.Note) error) (*core.Note, error) {
     r.mu.Lock()
     defer r.mu.Unlock()
+    return r.update(ownerID, id, version, updateFn, r.persist)
+}
 
-    n, ok := r.notes[id]
+// update — Update под r.mu; persist — как в create.
+func (r *NoteRepoMem) update(ownerID, id int64, version int64, updateFn func(*core.Note) error, persist func(journalRecord) error) (*core.Note, error) {
+    n, ok := r.owned(ownerID, id)
     if !ok {
         return nil, ErrNoteNotFound
     }
+    if version != 0 && n.Version != version {
+        return nil, ErrVersionConflict
+    }
 
-    if err := updateFn(n); err != nil {
+    // updateFn работает с копией: при ошибке исходная заметка не меняется
+    updated := *cloneNote(n)
+    if err := updateFn(&updated); err != nil {
         return nil, err
     }
     now := time.Now().UTC()
-    n.UpdatedAt = &now
+    updated.ID = id
+    updated.OwnerID = n.OwnerID
+    updated.Version = n.Version + 1
+    updated.UpdatedAt = &now
 
-    copy := *n
-    return &copy, nil
+    if err := persist(journalRecord{Op: journalUpdate, ID: id, Note: &updated}); err != nil {
+        return nil, err
+    }
+    r.put(id, &updated)
+    return cloneNote(&updated), nil
 }
 
-func (r *NoteRepoMem) Delete(id int64) error {
+func (r *NoteRepoMem) Delete(ownerID, id int64, version int64) error {
     r.mu.Lock()
     defer r.mu.Unlock()
+    return r.delete(ownerID, id, version, r.persist)
+}
 
-    if _, ok := r.notes[id]; !ok {
+// delete — Delete под r.mu; persist — как в create.
+func (r *NoteRepoMem) delete(ownerID, id int64, version int64, persist func(journalRecord) error) error {
+    n, ok := r.owned(ownerID, id)
+    if !ok {
         return ErrNoteNotFound
     }
-    delete(r.notes, id)
+    if version != 0 && n.Version != version {
+        return ErrVersionConflict
+    }
+    if err := persist(journalRecord{Op: journalDelete, ID: id}); err != nil {
+        return err
+    }
+    r.put(id, nil)
+    return nil
+}
+
+func (r *NoteRepoMem) TagCounts(ownerID int64) ([]TagCount, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    return r.tagCounts(ownerID), nil
+}
+
+func (r *NoteRepoMem) tagCounts(ownerID int64) []TagCount {
+    notes := make([]*core.Note, 0, len(r.notes))
+    for _, n := range r.notes {
+        if ownerID == AllOwners || n.OwnerID == ownerID {
+            notes = append(notes, n)
+        }
+    }
+    return countTags(notes)
+}
+
+func (r *NoteRepoMem) Usage(ownerID int64) (NoteUsage, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    return r.usage(ownerID), nil
+}
+
+func (r *NoteRepoMem) usage(ownerID int64) NoteUsage {
+    return r.usages[ownerID]
+}
+
+// Batch удерживает r.mu всю транзакцию. Изменения через tx сразу
+// попадают в r.notes, а их отмена запоминается; в журнал пакет пишется
+// одной записью только после fn, так что после сбоя он восстановится
+// целиком или не восстановится вовсе.
+func (r *NoteRepoMem) Batch(fn func(tx NoteRepository) error) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    tx := &noteTxMem{r: r, next: r.next}
+    err := fn(tx)
+    // состояние до пакета нужно и при ошибке, и для снимка в persist
+    tx.rollback()
+    if err != nil || len(tx.records) == 0 {
+        return err
+    }
+    rec := tx.records[0]
+    if len(tx.records) > 1 {
+        rec = journalRecord{Op: journalBatch, Batch: tx.records}
+    }
+    if err := r.persist(rec); err != nil {
+        return err
+    }
+    r.apply(rec)
+    return nil
+}
+
+// noteTxMem — транзакция NoteRepoMem.Batch; все методы вызываются под r.mu.
+type noteTxMem struct {
+    r       *NoteRepoMem
+    next    int64           // r.next до транзакции
+    records []journalRecord // записи журнала в порядке изменений
+    undo    []*core.Note    // прежнее состояние заметки records[i].ID; nil — её не было
+}
+
+// stage откладывает запись журнала до конца транзакции и запоминает, как
+// отменить изменение. Вызывается вместо persist, до изменения r.notes.
+func (t *noteTxMem) stage(rec journalRecord) error {
+    t.records = append(t.records, rec)
+    t.undo = append(t.undo, t.r.notes[rec.ID])
     return nil
 }
+
+// rollback возвращает r.notes и r.next в состояние до транзакции.
+func (t *noteTxMem) rollback() {
+    for i := len(t.records) - 1; i >= 0; i-- {
+        t.r.put(t.records[i].ID, t.undo[i])
+    }
+    t.r.next = t.next
+}
+
+func (t *noteTxMem) Create(n core.Note) (int64, error) {
+    return t.r.create(n, t.stage)
+}
+
+func (t *noteTxMem) GetAll() ([]core.Note, error) {
+    return t.r.getAll(), nil
+}
+
+func (t *noteTxMem) Find(q NoteQuery) (NotePage, error) {
+    return t.r.find(q)
+}
+
+func (t *noteTxMem) GetByID(ownerID, id int64) (*core.Note, error) {
+    return t.r.getByID(ownerID, id)
+}
+
+func (t *noteTxMem) Update(ownerID, id int64, version int64, updateFn func(*core.Note) error) (*core.Note, error) {
+    return t.r.update(ownerID, id, version, updateFn, t.stage)
+}
+
+func (t *noteTxMem) Delete(ownerID, id int64, version int64) error {
+    return t.r.delete(ownerID, id, version, t.stage)
+}
+
+func (t *noteTxMem) TagCounts(ownerID int64) ([]TagCount, error) {
+    return t.r.tagCounts(ownerID), nil
+}
+
+func (t *noteTxMem) Usage(ownerID int64) (NoteUsage, error) {
+    return t.r.usage(ownerID), nil
+}
+
+func (t *noteTxMem) Batch(fn func(tx NoteRepository) error) error {
+    return fn(t)
+}
+
+// cloneNote копирует заметку вместе со срезом тегов и указателями, чтобы
+// вызывающий не мог изменить заметку в репозитории через общие данные.
+func cloneNote(n *core.Note) *core.Note {
+    c := *n
+    c.Tags = slices.Clone(n.Tags)
+    if n.NotebookID != nil {
+        id := *n.NotebookID
+        c.NotebookID = &id
+    }
+    return &c
+}
+
+// persist пишет запись в журнал, если он есть. Вызывается под r.mu до
+// изменения r.notes, поэтому снимок, сделанный здесь, в точности
+// соответствует уже записанному журналу.
+func (r *NoteRepoMem) persist(rec journalRecord) error {
+    if r.journal == nil {
+        return nil
+    }
+    if r.journal.shouldSnapshot() {
+        // ошибка сжатия не критична: запись всё равно попадёт в журнал
+        if err := r.journal.snapshot(r.next, r.notes); err != nil {
+            log.Printf("journal: snapshot failed: %v", err)
+        }
+    }
+    return r.journal.append(rec)
+}
@@ -0,0 +1,162 @@
+package repo_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/repo/repotest"
+)
+
+func TestNoteRepoMem(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.NoteRepository {
+		return repo.NewNoteRepoMem()
+	})
+}
+
+func TestNoteRepoMemJournal(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.NoteRepository {
+		r, err := repo.OpenNoteRepoMem(t.TempDir(), repo.JournalOptions{SnapshotEvery: 7})
+		if err != nil {
+			t.Fatalf("OpenNoteRepoMem: %v", err)
+		}
+		t.Cleanup(func() { _ = r.Close() })
+		return r
+	})
+}
+
+func TestNoteRepoMemJournalReplay(t *testing.T) {
+	dir := t.TempDir()
+	opts := repo.JournalOptions{SnapshotEvery: 3}
+
+	r, err := repo.OpenNoteRepoMem(dir, opts)
+	if err != nil {
+		t.Fatalf("OpenNoteRepoMem: %v", err)
+	}
+	var last int64
+	for _, title := range []string{"a", "b", "c", "d", "e"} {
+		if last, err = r.Create(core.Note{OwnerID: 1, Title: title}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if _, err := r.Update(1, 2, 0, func(n *core.Note) error { n.Content = "updated"; return nil }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := r.Delete(1, last, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// недописанная запись в конце журнала
+	f, err := os.OpenFile(filepath.Join(dir, "notes.journal"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open journal: %v", err)
+	}
+	if _, err := f.Write([]byte{0, 0, 0, 100, 1, 2, 3, 4, '{'}); err != nil {
+		t.Fatalf("write garbage: %v", err)
+	}
+	_ = f.Close()
+
+	r, err = repo.OpenNoteRepoMem(dir, opts)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer r.Close()
+
+	all, err := r.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("restored %d notes, want 4", len(all))
+	}
+	if n, err := r.GetByID(1, 2); err != nil || n.Content != "updated" {
+		t.Errorf("note 2 = %+v, %v; want content %q", n, err, "updated")
+	}
+	if id, err := r.Create(core.Note{Title: "f"}); err != nil || id <= last {
+		t.Errorf("Create after replay = %d, %v; want id > %d", id, err, last)
+	}
+}
+
+// Пакет пишется в журнал одной записью и восстанавливается целиком.
+func TestNoteRepoMemJournalBatch(t *testing.T) {
+	dir := t.TempDir()
+	r, err := repo.OpenNoteRepoMem(dir, repo.JournalOptions{})
+	if err != nil {
+		t.Fatalf("OpenNoteRepoMem: %v", err)
+	}
+	id, err := r.Create(core.Note{OwnerID: 1, Title: "a"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	err = r.Batch(func(tx repo.NoteRepository) error {
+		if _, err := tx.Create(core.Note{OwnerID: 1, Title: "b"}); err != nil {
+			return err
+		}
+		_, err := tx.Update(1, id, 0, func(n *core.Note) error { n.Title = "a2"; return nil })
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r, err = repo.OpenNoteRepoMem(dir, repo.JournalOptions{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer r.Close()
+
+	all, err := r.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("restored %d notes, want 2", len(all))
+	}
+	if n, err := r.GetByID(1, id); err != nil || n.Title != "a2" || n.Version != 2 {
+		t.Errorf("note %d = %+v, %v; want title a2, version 2", id, n, err)
+	}
+}
+
+// Запись больше, чем журнал прочитает при восстановлении, отклоняется, а
+// не отбрасывается вместе с последующими при перезапуске.
+func TestNoteRepoMemJournalRecordTooLarge(t *testing.T) {
+	dir := t.TempDir()
+	r, err := repo.OpenNoteRepoMem(dir, repo.JournalOptions{})
+	if err != nil {
+		t.Fatalf("OpenNoteRepoMem: %v", err)
+	}
+	huge := strings.Repeat("a", 64<<20)
+	if _, err := r.Create(core.Note{OwnerID: 1, Title: "huge", Content: huge}); !errors.Is(err, repo.ErrJournalRecordTooLarge) {
+		t.Fatalf("Create: err = %v, want ErrJournalRecordTooLarge", err)
+	}
+	id, err := r.Create(core.Note{OwnerID: 1, Title: "a"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := r.Update(1, id, 0, func(n *core.Note) error { n.Content = huge; return nil }); !errors.Is(err, repo.ErrJournalRecordTooLarge) {
+		t.Fatalf("Update: err = %v, want ErrJournalRecordTooLarge", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r, err = repo.OpenNoteRepoMem(dir, repo.JournalOptions{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer r.Close()
+	all, err := r.GetAll()
+	if err != nil || len(all) != 1 || all[0].Title != "a" || all[0].Content != "" {
+		t.Errorf("GetAll = %+v, %v; want note a unchanged", all, err)
+	}
+}
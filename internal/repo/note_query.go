@@ -0,0 +1,278 @@
+package repo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// NoteSortField — поле, по которому сортируется выборка заметок.
+type NoteSortField string
+
+const (
+	SortByID        NoteSortField = "id"
+	SortByCreatedAt NoteSortField = "createdAt"
+	SortByUpdatedAt NoteSortField = "updatedAt"
+	SortByTitle     NoteSortField = "title"
+)
+
+// Valid сообщает, поддерживается ли поле сортировки.
+func (f NoteSortField) Valid() bool {
+	switch f {
+	case SortByID, SortByCreatedAt, SortByUpdatedAt, SortByTitle:
+		return true
+	}
+	return false
+}
+
+// NoteQuery — параметры выборки заметок для NoteRepository.Find.
+//
+// Для сортировки и фильтров по updatedAt у ни разу не изменённой заметки
+// используется время создания. Фильтры по времени строгие (после/до),
+// TitlePrefix чувствителен к регистру. Без Trashed выбираются только
+// заметки вне корзины, с Trashed — только заметки в корзине. Теги в Tags
+// сравниваются как есть: нормализует их сервис.
+type NoteQuery struct {
+	// OwnerID — чьи заметки выбирать; AllOwners — всех владельцев.
+	OwnerID int64
+
+	// Limit — максимум заметок на странице; 0 — без ограничения.
+	Limit int
+	// After — курсор с предыдущей страницы; nil — с начала.
+	After *NoteCursor
+
+	SortBy NoteSortField // по умолчанию SortByID
+	Desc   bool
+
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	TitlePrefix   string
+
+	// NotebookIDs — заметка должна лежать в одном из этих блокнотов.
+	NotebookIDs []int64
+
+	// Tags — заметка должна иметь все эти теги, а при AnyTag — хотя бы один.
+	Tags   []string
+	AnyTag bool
+
+	Trashed       bool
+	DeletedBefore *time.Time // только вместе с Trashed
+}
+
+// NotePage — одна страница выборки.
+type NotePage struct {
+	Notes []core.Note
+	// Next — курсор следующей страницы; nil, если это последняя.
+	Next *NoteCursor
+}
+
+// NoteCursor — позиция в отсортированной выборке: ключ сортировки
+// последней выданной заметки и её ID, чтобы порядок был однозначным.
+// Курсор привязан к сортировке, с которой получен.
+type NoteCursor struct {
+	SortBy NoteSortField `json:"s"`
+	Desc   bool          `json:"d,omitempty"`
+	ID     int64         `json:"i"`
+	Time   int64         `json:"t,omitempty"` // createdAt/updatedAt, нс Unix
+	Title  string        `json:"v,omitempty"`
+}
+
+// EncodeCursor превращает курсор в непрозрачную строку для клиента.
+func EncodeCursor(c *NoteCursor) string {
+	data, _ := json.Marshal(c) // маршалинг плоской структуры не падает
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor разбирает строку, полученную из EncodeCursor.
+func DecodeCursor(s string) (*NoteCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c NoteCursor
+	if err := json.Unmarshal(data, &c); err != nil || !c.SortBy.Valid() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// normalize подставляет значения по умолчанию и проверяет, что курсор
+// получен с той же сортировкой.
+func (q NoteQuery) normalize() (NoteQuery, error) {
+	if q.SortBy == "" {
+		q.SortBy = SortByID
+	}
+	if !q.SortBy.Valid() {
+		return q, ErrInvalidCursor
+	}
+	if q.After != nil && (q.After.SortBy != q.SortBy || q.After.Desc != q.Desc) {
+		return q, ErrInvalidCursor
+	}
+	return q, nil
+}
+
+// effectiveUpdatedAt — время последнего изменения или создания.
+func effectiveUpdatedAt(n *core.Note) time.Time {
+	if n.UpdatedAt != nil {
+		return *n.UpdatedAt
+	}
+	return n.CreatedAt
+}
+
+// cursorFor строит курсор, указывающий на заметку n.
+func (q NoteQuery) cursorFor(n *core.Note) *NoteCursor {
+	c := &NoteCursor{SortBy: q.SortBy, Desc: q.Desc, ID: n.ID}
+	switch q.SortBy {
+	case SortByCreatedAt:
+		c.Time = n.CreatedAt.UnixNano()
+	case SortByUpdatedAt:
+		c.Time = effectiveUpdatedAt(n).UnixNano()
+	case SortByTitle:
+		c.Title = n.Title
+	}
+	return c
+}
+
+// matches проверяет фильтры запроса (без учёта курсора).
+func (q NoteQuery) matches(n *core.Note) bool {
+	if q.OwnerID != AllOwners && n.OwnerID != q.OwnerID {
+		return false
+	}
+	if q.Trashed != (n.DeletedAt != nil) {
+		return false
+	}
+	if q.DeletedBefore != nil && (n.DeletedAt == nil || !n.DeletedAt.Before(*q.DeletedBefore)) {
+		return false
+	}
+	if q.CreatedAfter != nil && !n.CreatedAt.After(*q.CreatedAfter) {
+		return false
+	}
+	if q.CreatedBefore != nil && !n.CreatedAt.Before(*q.CreatedBefore) {
+		return false
+	}
+	updated := effectiveUpdatedAt(n)
+	if q.UpdatedAfter != nil && !updated.After(*q.UpdatedAfter) {
+		return false
+	}
+	if q.UpdatedBefore != nil && !updated.Before(*q.UpdatedBefore) {
+		return false
+	}
+	if q.TitlePrefix != "" && !strings.HasPrefix(n.Title, q.TitlePrefix) {
+		return false
+	}
+	if len(q.NotebookIDs) > 0 && (n.NotebookID == nil || !slices.Contains(q.NotebookIDs, *n.NotebookID)) {
+		return false
+	}
+	if len(q.Tags) > 0 && !q.matchesTags(n.Tags) {
+		return false
+	}
+	return true
+}
+
+func (q NoteQuery) matchesTags(tags []string) bool {
+	for _, want := range q.Tags {
+		has := slices.Contains(tags, want)
+		if q.AnyTag && has {
+			return true
+		}
+		if !q.AnyTag && !has {
+			return false
+		}
+	}
+	return !q.AnyTag
+}
+
+// countTags считает теги заметок вне корзины.
+func countTags(notes []*core.Note) []TagCount {
+	counts := make(map[string]int)
+	for _, n := range notes {
+		if n.DeletedAt != nil {
+			continue
+		}
+		for _, tag := range n.Tags {
+			counts[tag]++
+		}
+	}
+	result := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		result = append(result, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Tag < result[j].Tag })
+	return result
+}
+
+// compareCursors сравнивает позиции в порядке возрастания.
+func compareCursors(a, b *NoteCursor) int {
+	switch {
+	case a.Time != b.Time:
+		if a.Time < b.Time {
+			return -1
+		}
+		return 1
+	case a.Title != b.Title:
+		return strings.Compare(a.Title, b.Title)
+	case a.ID != b.ID:
+		if a.ID < b.ID {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+// findInSlice применяет запрос к заметкам в памяти.
+func findInSlice(notes []*core.Note, q NoteQuery) (NotePage, error) {
+	q, err := q.normalize()
+	if err != nil {
+		return NotePage{}, err
+	}
+
+	type entry struct {
+		note *core.Note
+		pos  *NoteCursor
+	}
+	entries := make([]entry, 0, len(notes))
+	for _, n := range notes {
+		if !q.matches(n) {
+			continue
+		}
+		pos := q.cursorFor(n)
+		if q.After != nil {
+			c := compareCursors(pos, q.After)
+			if (!q.Desc && c <= 0) || (q.Desc && c >= 0) {
+				continue
+			}
+		}
+		entries = append(entries, entry{note: n, pos: pos})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		c := compareCursors(entries[i].pos, entries[j].pos)
+		if q.Desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	page := NotePage{Notes: make([]core.Note, 0, len(entries))}
+	for i, e := range entries {
+		if q.Limit > 0 && i == q.Limit {
+			page.Next = entries[i-1].pos
+			break
+		}
+		page.Notes = append(page.Notes, *cloneNote(e.note))
+	}
+	return page, nil
+}
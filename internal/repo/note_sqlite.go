@@ -0,0 +1,554 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+const noteSchemaSQLite = `
+CREATE TABLE IF NOT EXISTS notes (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	title       TEXT    NOT NULL,
+	content     TEXT    NOT NULL,
+	version     INTEGER NOT NULL DEFAULT 1,
+	created_at  INTEGER NOT NULL,
+	updated_at  INTEGER,
+	deleted_at  INTEGER,
+	notebook_id INTEGER,
+	owner_id    INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS notes_created_at ON notes (created_at, id);
+CREATE INDEX IF NOT EXISTS notes_updated_at ON notes (COALESCE(updated_at, created_at), id);
+CREATE INDEX IF NOT EXISTS notes_title ON notes (title, id);
+CREATE TABLE IF NOT EXISTS note_tags (
+	note_id INTEGER NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+	tag     TEXT    NOT NULL,
+	PRIMARY KEY (note_id, tag)
+);
+CREATE INDEX IF NOT EXISTS note_tags_tag ON note_tags (tag, note_id);`
+
+const noteColumnsSQLite = `id, title, content, version, created_at, updated_at, deleted_at, notebook_id, owner_id`
+
+// NoteRepoSQLite — реализация NoteRepository поверх встроенной SQLite.
+// Время хранится в наносекундах Unix (UTC), чтобы сортировка в SQL
+// совпадала с сортировкой time.Time.
+type NoteRepoSQLite struct {
+	db *sql.DB
+}
+
+// NewNoteRepoSQLite создаёт репозиторий и при необходимости схему.
+func NewNoteRepoSQLite(db *sql.DB) (*NoteRepoSQLite, error) {
+	if _, err := db.Exec(noteSchemaSQLite); err != nil {
+		return nil, err
+	}
+	if err := ensureColumn(db, "notes", "version", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+		return nil, err
+	}
+	if err := ensureColumn(db, "notes", "deleted_at", "INTEGER"); err != nil {
+		return nil, err
+	}
+	if err := ensureColumn(db, "notes", "notebook_id", "INTEGER"); err != nil {
+		return nil, err
+	}
+	// заметки, созданные до появления пользователей, получают владельца 0
+	// и не видны никому, пока их не передадут пользователю
+	if err := ensureColumn(db, "notes", "owner_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS notes_notebook ON notes (notebook_id, id);
+		CREATE INDEX IF NOT EXISTS notes_owner ON notes (owner_id, id);`); err != nil {
+		return nil, err
+	}
+	return &NoteRepoSQLite{db: db}, nil
+}
+
+// rowScanner — общее для *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanNote(s rowScanner) (*core.Note, error) {
+	var (
+		n          core.Note
+		createdAt  int64
+		updatedAt  sql.NullInt64
+		deletedAt  sql.NullInt64
+		notebookID sql.NullInt64
+	)
+	if err := s.Scan(&n.ID, &n.Title, &n.Content, &n.Version, &createdAt, &updatedAt, &deletedAt, &notebookID, &n.OwnerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoteNotFound
+		}
+		return nil, err
+	}
+	n.CreatedAt = time.Unix(0, createdAt).UTC()
+	n.UpdatedAt = timeFromNull(updatedAt)
+	n.DeletedAt = timeFromNull(deletedAt)
+	if notebookID.Valid {
+		n.NotebookID = &notebookID.Int64
+	}
+	return &n, nil
+}
+
+func timeFromNull(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := time.Unix(0, v.Int64).UTC()
+	return &t
+}
+
+func nullTime(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixNano(), Valid: true}
+}
+
+// querier — общее для *sql.DB и *sql.Tx.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// tagsChunk — сколько ID подставляется в один запрос тегов, чтобы не
+// упереться в ограничение SQLite на число параметров.
+const tagsChunk = 500
+
+// loadTags заполняет Tags у заметок из таблицы note_tags.
+func loadTags(q querier, notes []core.Note) error {
+	byID := make(map[int64]*core.Note, len(notes))
+	for i := range notes {
+		notes[i].Tags = nil
+		byID[notes[i].ID] = &notes[i]
+	}
+	for start := 0; start < len(notes); start += tagsChunk {
+		end := min(start+tagsChunk, len(notes))
+		args := make([]any, 0, end-start)
+		for _, n := range notes[start:end] {
+			args = append(args, n.ID)
+		}
+		rows, err := q.Query(`SELECT note_id, tag FROM note_tags WHERE note_id IN (`+placeholders(len(args))+`) ORDER BY note_id, tag`, args...)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var (
+				id  int64
+				tag string
+			)
+			if err := rows.Scan(&id, &tag); err != nil {
+				rows.Close()
+				return err
+			}
+			n := byID[id]
+			n.Tags = append(n.Tags, tag)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveTags заменяет теги заметки.
+func saveTags(q querier, id int64, tags []string) error {
+	if _, err := q.Exec(`DELETE FROM note_tags WHERE note_id = ?`, id); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := q.Exec(`INSERT OR IGNORE INTO note_tags (note_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// placeholders возвращает «?, ?, …» из n параметров.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func (r *NoteRepoSQLite) Create(n core.Note) (int64, error) {
+	var id int64
+	err := r.Batch(func(tx NoteRepository) error {
+		var err error
+		id, err = tx.Create(n)
+		return err
+	})
+	return id, err
+}
+
+func (r *NoteRepoSQLite) GetAll() ([]core.Note, error) {
+	return getAllNotesSQLite(r.db)
+}
+
+func getAllNotesSQLite(q querier) ([]core.Note, error) {
+	rows, err := q.Query(`SELECT ` + noteColumnsSQLite + ` FROM notes ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]core.Note, 0)
+	for rows.Next() {
+		n, err := scanNote(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close() // соединение одно: освобождаем его перед запросом тегов
+	return result, loadTags(q, result)
+}
+
+// sortExprSQLite — выражение ORDER BY для поля сортировки; для updatedAt
+// берётся время создания, если заметка не менялась (как в NoteRepoMem).
+var sortExprSQLite = map[NoteSortField]string{
+	SortByID:        "id",
+	SortByCreatedAt: "created_at",
+	SortByUpdatedAt: "COALESCE(updated_at, created_at)",
+	SortByTitle:     "title",
+}
+
+func (r *NoteRepoSQLite) Find(q NoteQuery) (NotePage, error) {
+	return findNotesSQLite(r.db, q)
+}
+
+func findNotesSQLite(db querier, q NoteQuery) (NotePage, error) {
+	q, err := q.normalize()
+	if err != nil {
+		return NotePage{}, err
+	}
+
+	var (
+		where []string
+		args  []any
+	)
+	if q.OwnerID != AllOwners {
+		where = append(where, "owner_id = ?")
+		args = append(args, q.OwnerID)
+	}
+	if q.Trashed {
+		where = append(where, "deleted_at IS NOT NULL")
+	} else {
+		where = append(where, "deleted_at IS NULL")
+	}
+	if q.DeletedBefore != nil {
+		where = append(where, "deleted_at < ?")
+		args = append(args, q.DeletedBefore.UnixNano())
+	}
+	if q.CreatedAfter != nil {
+		where = append(where, "created_at > ?")
+		args = append(args, q.CreatedAfter.UnixNano())
+	}
+	if q.CreatedBefore != nil {
+		where = append(where, "created_at < ?")
+		args = append(args, q.CreatedBefore.UnixNano())
+	}
+	if q.UpdatedAfter != nil {
+		where = append(where, "COALESCE(updated_at, created_at) > ?")
+		args = append(args, q.UpdatedAfter.UnixNano())
+	}
+	if q.UpdatedBefore != nil {
+		where = append(where, "COALESCE(updated_at, created_at) < ?")
+		args = append(args, q.UpdatedBefore.UnixNano())
+	}
+	if q.TitlePrefix != "" {
+		// substr вместо LIKE: LIKE не учитывает регистр и требует экранирования
+		where = append(where, "substr(title, 1, length(?)) = ?")
+		args = append(args, q.TitlePrefix, q.TitlePrefix)
+	}
+	if len(q.NotebookIDs) > 0 {
+		where = append(where, "notebook_id IN ("+placeholders(len(q.NotebookIDs))+")")
+		for _, id := range q.NotebookIDs {
+			args = append(args, id)
+		}
+	}
+	if len(q.Tags) > 0 {
+		tags := uniqueStrings(q.Tags)
+		cond := "id IN (SELECT note_id FROM note_tags WHERE tag IN (" + placeholders(len(tags)) + ")"
+		for _, tag := range tags {
+			args = append(args, tag)
+		}
+		if !q.AnyTag {
+			cond += " GROUP BY note_id HAVING COUNT(*) = ?"
+			args = append(args, len(tags))
+		}
+		where = append(where, cond+")")
+	}
+
+	key := sortExprSQLite[q.SortBy]
+	op, dir := ">", "ASC"
+	if q.Desc {
+		op, dir = "<", "DESC"
+	}
+	if c := q.After; c != nil {
+		switch q.SortBy {
+		case SortByID:
+			where = append(where, "id "+op+" ?")
+			args = append(args, c.ID)
+		case SortByTitle:
+			where = append(where, "(title "+op+" ? OR (title = ? AND id "+op+" ?))")
+			args = append(args, c.Title, c.Title, c.ID)
+		default:
+			where = append(where, "("+key+" "+op+" ? OR ("+key+" = ? AND id "+op+" ?))")
+			args = append(args, c.Time, c.Time, c.ID)
+		}
+	}
+
+	query := `SELECT ` + noteColumnsSQLite + ` FROM notes`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + key + " " + dir
+	if q.SortBy != SortByID {
+		query += ", id " + dir
+	}
+	if q.Limit > 0 {
+		// одна лишняя строка показывает, есть ли следующая страница
+		query += " LIMIT ?"
+		args = append(args, q.Limit+1)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return NotePage{}, err
+	}
+	defer rows.Close()
+
+	page := NotePage{Notes: make([]core.Note, 0)}
+	for rows.Next() {
+		n, err := scanNote(rows)
+		if err != nil {
+			return NotePage{}, err
+		}
+		if q.Limit > 0 && len(page.Notes) == q.Limit {
+			page.Next = q.cursorFor(&page.Notes[len(page.Notes)-1])
+			break
+		}
+		page.Notes = append(page.Notes, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return NotePage{}, err
+	}
+	rows.Close()
+	return page, loadTags(db, page.Notes)
+}
+
+func (r *NoteRepoSQLite) GetByID(ownerID, id int64) (*core.Note, error) {
+	return getNoteSQLite(r.db, ownerID, id)
+}
+
+// getNoteSQLite читает заметку владельца вместе с тегами.
+func getNoteSQLite(q querier, ownerID, id int64) (*core.Note, error) {
+	rows, err := q.Query(`SELECT `+noteColumnsSQLite+` FROM notes WHERE id = ? AND (? = ? OR owner_id = ?)`, id, ownerID, AllOwners, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoteNotFound
+	}
+	n, err := scanNote(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	notes := []core.Note{*n}
+	if err := loadTags(q, notes); err != nil {
+		return nil, err
+	}
+	return &notes[0], nil
+}
+
+// Update выполняет чтение, updateFn и запись в одной транзакции:
+// если updateFn вернул ошибку, транзакция откатывается и заметка не меняется.
+func (r *NoteRepoSQLite) Update(ownerID, id int64, version int64, updateFn func(*core.Note) error) (*core.Note, error) {
+	var n *core.Note
+	err := r.Batch(func(tx NoteRepository) error {
+		var err error
+		n, err = tx.Update(ownerID, id, version, updateFn)
+		return err
+	})
+	return n, err
+}
+
+func (r *NoteRepoSQLite) Delete(ownerID, id int64, version int64) error {
+	return r.Batch(func(tx NoteRepository) error {
+		return tx.Delete(ownerID, id, version)
+	})
+}
+
+func (r *NoteRepoSQLite) TagCounts(ownerID int64) ([]TagCount, error) {
+	return tagCountsSQLite(r.db, ownerID)
+}
+
+func tagCountsSQLite(q querier, ownerID int64) ([]TagCount, error) {
+	rows, err := q.Query(`
+		SELECT t.tag, COUNT(*) FROM note_tags t
+		JOIN notes n ON n.id = t.note_id
+		WHERE n.deleted_at IS NULL AND (? = ? OR n.owner_id = ?)
+		GROUP BY t.tag ORDER BY t.tag`, ownerID, AllOwners, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]TagCount, 0)
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, err
+		}
+		result = append(result, tc)
+	}
+	return result, rows.Err()
+}
+
+func (r *NoteRepoSQLite) Usage(ownerID int64) (NoteUsage, error) {
+	return usageSQLite(r.db, ownerID)
+}
+
+func usageSQLite(q querier, ownerID int64) (NoteUsage, error) {
+	var u NoteUsage
+	err := q.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(LENGTH(CAST(title AS BLOB)) + LENGTH(CAST(content AS BLOB))), 0)
+		FROM notes WHERE owner_id = ?`, ownerID).Scan(&u.Notes, &u.Bytes)
+	return u, err
+}
+
+// Batch выполняет fn в транзакции SQLite; Create, Update и Delete — это
+// пакеты из одной операции.
+func (r *NoteRepoSQLite) Batch(fn func(tx NoteRepository) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // после Commit — no-op
+
+	if err := fn(noteTxSQLite{tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// noteTxSQLite — NoteRepository внутри открытой транзакции.
+type noteTxSQLite struct {
+	tx *sql.Tx
+}
+
+func (t noteTxSQLite) Create(n core.Note) (int64, error) {
+	now := time.Now().UTC()
+	res, err := t.tx.Exec(
+		`INSERT INTO notes (title, content, version, created_at, updated_at, notebook_id, owner_id) VALUES (?, ?, 1, ?, NULL, ?, ?)`,
+		n.Title, n.Content, now.UnixNano(), nullID(n.NotebookID), n.OwnerID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, saveTags(t.tx, id, n.Tags)
+}
+
+func (t noteTxSQLite) GetAll() ([]core.Note, error) {
+	return getAllNotesSQLite(t.tx)
+}
+
+func (t noteTxSQLite) Find(q NoteQuery) (NotePage, error) {
+	return findNotesSQLite(t.tx, q)
+}
+
+func (t noteTxSQLite) GetByID(ownerID, id int64) (*core.Note, error) {
+	return getNoteSQLite(t.tx, ownerID, id)
+}
+
+func (t noteTxSQLite) Update(ownerID, id int64, version int64, updateFn func(*core.Note) error) (*core.Note, error) {
+	n, err := getNoteSQLite(t.tx, ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && n.Version != version {
+		return nil, ErrVersionConflict
+	}
+
+	current, owner := n.Version, n.OwnerID
+	if err := updateFn(n); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	n.ID = id
+	n.OwnerID = owner
+	n.Version = current + 1
+	n.UpdatedAt = &now
+
+	if _, err := t.tx.Exec(
+		`UPDATE notes SET title = ?, content = ?, version = ?, updated_at = ?, deleted_at = ?, notebook_id = ? WHERE id = ?`,
+		n.Title, n.Content, n.Version, nullTime(n.UpdatedAt), nullTime(n.DeletedAt), nullID(n.NotebookID), id,
+	); err != nil {
+		return nil, err
+	}
+	if err := saveTags(t.tx, id, n.Tags); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (t noteTxSQLite) Delete(ownerID, id int64, version int64) error {
+	var current int64
+	if err := t.tx.QueryRow(
+		`SELECT version FROM notes WHERE id = ? AND (? = ? OR owner_id = ?)`, id, ownerID, AllOwners, ownerID,
+	).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoteNotFound
+		}
+		return err
+	}
+	if version != 0 && current != version {
+		return ErrVersionConflict
+	}
+	_, err := t.tx.Exec(`DELETE FROM notes WHERE id = ?`, id)
+	return err
+}
+
+func (t noteTxSQLite) TagCounts(ownerID int64) ([]TagCount, error) {
+	return tagCountsSQLite(t.tx, ownerID)
+}
+
+func (t noteTxSQLite) Usage(ownerID int64) (NoteUsage, error) {
+	return usageSQLite(t.tx, ownerID)
+}
+
+func (t noteTxSQLite) Batch(fn func(tx NoteRepository) error) error {
+	return fn(t)
+}
+
+// uniqueStrings возвращает значения без повторов в исходном порядке.
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package repo_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/repo/repotest"
+)
+
+func TestNoteRepoSQLite(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repo.NoteRepository {
+		db := openTestDB(t)
+		r, err := repo.NewNoteRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewNoteRepoSQLite: %v", err)
+		}
+		return r
+	})
+}
+
+// openTestDB открывает пустую базу SQLite во временном каталоге теста и
+// закрывает её по окончании теста.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := repo.OpenSQLite(filepath.Join(t.TempDir(), "notes.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// Символы URI в пути не открывают другой файл и не подменяют прагмы.
+func TestOpenSQLiteEscapesPath(t *testing.T) {
+	dir := t.TempDir()
+	name := "notes?_pragma=journal_mode(DELETE)&x=#1%41.db"
+	db, err := repo.OpenSQLite(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer db.Close()
+
+	var mode string
+	if err := db.QueryRow(`PRAGMA journal_mode`).Scan(&mode); err != nil || mode != "wal" {
+		t.Errorf("journal_mode = %q, %v; want wal", mode, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+		t.Errorf("database file: %v", err)
+	}
+}
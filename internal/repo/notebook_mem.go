@@ -0,0 +1,205 @@
+package repo
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+var (
+	ErrNotebookNotFound = errors.New("notebook not found")
+)
+
+// NotebookRepository — хранилище блокнотов. Целостность дерева (родитель
+// существует, нет циклов) проверяет сервис. Как и в NoteRepository,
+// блокнот другого владельца для методов с ownerID не существует.
+type NotebookRepository interface {
+	// Create сохраняет блокнот с владельцем nb.OwnerID.
+	Create(nb core.Notebook) (int64, error)
+	GetByID(ownerID, id int64) (*core.Notebook, error)
+	// List возвращает все блокноты владельца по возрастанию ID.
+	List(ownerID int64) ([]core.Notebook, error)
+	Update(ownerID, id int64, updateFn func(*core.Notebook) error) (*core.Notebook, error)
+	Delete(ownerID, id int64) error
+}
+
+const notebooksFileName = "notebooks.json"
+
+// NotebookRepoMem — in-memory реализация NotebookRepository. Открытая через
+// OpenNotebookRepoMem, после каждого изменения записывает блокноты в файл.
+type NotebookRepoMem struct {
+	mu        sync.RWMutex
+	notebooks map[int64]*core.Notebook
+	next      int64
+	dir       string // "" — без сохранения на диск
+}
+
+func NewNotebookRepoMem() *NotebookRepoMem {
+	return &NotebookRepoMem{
+		notebooks: make(map[int64]*core.Notebook),
+	}
+}
+
+// notebooksFile — содержимое notebooks.json.
+type notebooksFile struct {
+	Next      int64           `json:"next"`
+	Notebooks []core.Notebook `json:"notebooks"`
+}
+
+// OpenNotebookRepoMem загружает блокноты из каталога dir (если файл уже
+// есть) и сохраняет туда каждое изменение. Заметки журнала ссылаются на
+// блокноты по ID, поэтому без сохранения после перезапуска эти ссылки
+// повисли бы или указали на чужой блокнот.
+func OpenNotebookRepoMem(dir string) (*NotebookRepoMem, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	r := NewNotebookRepoMem()
+	r.dir = dir
+
+	data, err := os.ReadFile(filepath.Join(dir, notebooksFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f notebooksFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	r.next = f.Next
+	for i := range f.Notebooks {
+		nb := &f.Notebooks[i]
+		r.notebooks[nb.ID] = cloneNotebook(nb)
+		if nb.ID > r.next {
+			r.next = nb.ID
+		}
+	}
+	return r, nil
+}
+
+// save записывает блокноты в файл. Вызывается под r.mu.
+func (r *NotebookRepoMem) save() error {
+	if r.dir == "" {
+		return nil
+	}
+	f := notebooksFile{Next: r.next, Notebooks: make([]core.Notebook, 0, len(r.notebooks))}
+	for _, nb := range r.notebooks {
+		f.Notebooks = append(f.Notebooks, *nb)
+	}
+	sort.Slice(f.Notebooks, func(i, j int) bool { return f.Notebooks[i].ID < f.Notebooks[j].ID })
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.dir, notebooksFileName, data)
+}
+
+// cloneNotebook копирует блокнот вместе с указателем на родителя.
+func cloneNotebook(nb *core.Notebook) *core.Notebook {
+	c := *nb
+	if nb.ParentID != nil {
+		parent := *nb.ParentID
+		c.ParentID = &parent
+	}
+	return &c
+}
+
+func (r *NotebookRepoMem) Create(nb core.Notebook) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.next++
+	nb.ID = r.next
+	nb.CreatedAt = time.Now().UTC()
+	nb.UpdatedAt = nil
+	r.notebooks[nb.ID] = cloneNotebook(&nb)
+	if err := r.save(); err != nil {
+		delete(r.notebooks, nb.ID)
+		return 0, err
+	}
+	return nb.ID, nil
+}
+
+// owned возвращает блокнот, если он принадлежит ownerID. Вызывается под r.mu.
+func (r *NotebookRepoMem) owned(ownerID, id int64) (*core.Notebook, bool) {
+	nb, ok := r.notebooks[id]
+	if !ok || nb.OwnerID != ownerID {
+		return nil, false
+	}
+	return nb, true
+}
+
+func (r *NotebookRepoMem) GetByID(ownerID, id int64) (*core.Notebook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nb, ok := r.owned(ownerID, id)
+	if !ok {
+		return nil, ErrNotebookNotFound
+	}
+	return cloneNotebook(nb), nil
+}
+
+func (r *NotebookRepoMem) List(ownerID int64) ([]core.Notebook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]core.Notebook, 0)
+	for _, nb := range r.notebooks {
+		if nb.OwnerID == ownerID {
+			result = append(result, *cloneNotebook(nb))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func (r *NotebookRepoMem) Update(ownerID, id int64, updateFn func(*core.Notebook) error) (*core.Notebook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nb, ok := r.owned(ownerID, id)
+	if !ok {
+		return nil, ErrNotebookNotFound
+	}
+	updated := cloneNotebook(nb)
+	if err := updateFn(updated); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	updated.ID = id
+	updated.OwnerID = nb.OwnerID
+	updated.CreatedAt = nb.CreatedAt
+	updated.UpdatedAt = &now
+	r.notebooks[id] = updated
+	if err := r.save(); err != nil {
+		r.notebooks[id] = nb
+		return nil, err
+	}
+	return cloneNotebook(updated), nil
+}
+
+func (r *NotebookRepoMem) Delete(ownerID, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	nb, ok := r.owned(ownerID, id)
+	if !ok {
+		return ErrNotebookNotFound
+	}
+	delete(r.notebooks, id)
+	if err := r.save(); err != nil {
+		r.notebooks[id] = nb
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+const notebookSchemaSQLite = `
+CREATE TABLE IF NOT EXISTS notebooks (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	name       TEXT    NOT NULL,
+	parent_id  INTEGER REFERENCES notebooks (id),
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER,
+	owner_id   INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS notebooks_parent ON notebooks (parent_id);`
+
+const notebookColumnsSQLite = `id, name, parent_id, created_at, updated_at, owner_id`
+
+// NotebookRepoSQLite — реализация NotebookRepository поверх SQLite.
+type NotebookRepoSQLite struct {
+	db *sql.DB
+}
+
+// NewNotebookRepoSQLite создаёт репозиторий и при необходимости схему.
+func NewNotebookRepoSQLite(db *sql.DB) (*NotebookRepoSQLite, error) {
+	if _, err := db.Exec(notebookSchemaSQLite); err != nil {
+		return nil, err
+	}
+	if err := ensureColumn(db, "notebooks", "owner_id", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS notebooks_owner ON notebooks (owner_id, id)`); err != nil {
+		return nil, err
+	}
+	return &NotebookRepoSQLite{db: db}, nil
+}
+
+func scanNotebook(s rowScanner) (*core.Notebook, error) {
+	var (
+		nb        core.Notebook
+		parentID  sql.NullInt64
+		createdAt int64
+		updatedAt sql.NullInt64
+	)
+	if err := s.Scan(&nb.ID, &nb.Name, &parentID, &createdAt, &updatedAt, &nb.OwnerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotebookNotFound
+		}
+		return nil, err
+	}
+	if parentID.Valid {
+		nb.ParentID = &parentID.Int64
+	}
+	nb.CreatedAt = time.Unix(0, createdAt).UTC()
+	nb.UpdatedAt = timeFromNull(updatedAt)
+	return &nb, nil
+}
+
+func nullID(id *int64) sql.NullInt64 {
+	if id == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *id, Valid: true}
+}
+
+func (r *NotebookRepoSQLite) Create(nb core.Notebook) (int64, error) {
+	res, err := r.db.Exec(
+		`INSERT INTO notebooks (name, parent_id, created_at, owner_id) VALUES (?, ?, ?, ?)`,
+		nb.Name, nullID(nb.ParentID), time.Now().UTC().UnixNano(), nb.OwnerID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *NotebookRepoSQLite) GetByID(ownerID, id int64) (*core.Notebook, error) {
+	return scanNotebook(r.db.QueryRow(
+		`SELECT `+notebookColumnsSQLite+` FROM notebooks WHERE id = ? AND owner_id = ?`, id, ownerID,
+	))
+}
+
+func (r *NotebookRepoSQLite) List(ownerID int64) ([]core.Notebook, error) {
+	rows, err := r.db.Query(`SELECT `+notebookColumnsSQLite+` FROM notebooks WHERE owner_id = ? ORDER BY id`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]core.Notebook, 0)
+	for rows.Next() {
+		nb, err := scanNotebook(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *nb)
+	}
+	return result, rows.Err()
+}
+
+func (r *NotebookRepoSQLite) Update(ownerID, id int64, updateFn func(*core.Notebook) error) (*core.Notebook, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // после Commit — no-op
+
+	nb, err := scanNotebook(tx.QueryRow(
+		`SELECT `+notebookColumnsSQLite+` FROM notebooks WHERE id = ? AND owner_id = ?`, id, ownerID,
+	))
+	if err != nil {
+		return nil, err
+	}
+	createdAt := nb.CreatedAt
+	if err := updateFn(nb); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	nb.ID = id
+	nb.OwnerID = ownerID
+	nb.CreatedAt = createdAt
+	nb.UpdatedAt = &now
+
+	if _, err := tx.Exec(
+		`UPDATE notebooks SET name = ?, parent_id = ?, updated_at = ? WHERE id = ?`,
+		nb.Name, nullID(nb.ParentID), nullTime(nb.UpdatedAt), id,
+	); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return nb, nil
+}
+
+func (r *NotebookRepoSQLite) Delete(ownerID, id int64) error {
+	res, err := r.db.Exec(`DELETE FROM notebooks WHERE id = ? AND owner_id = ?`, id, ownerID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotebookNotFound
+	}
+	return nil
+}
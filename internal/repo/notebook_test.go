@@ -0,0 +1,70 @@
+package repo_test
+
+import (
+	"testing"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/repo/repotest"
+)
+
+func TestNotebookRepoMem(t *testing.T) {
+	repotest.RunNotebooks(t, func(t *testing.T) repo.NotebookRepository {
+		return repo.NewNotebookRepoMem()
+	})
+}
+
+func TestNotebookRepoMemFile(t *testing.T) {
+	repotest.RunNotebooks(t, func(t *testing.T) repo.NotebookRepository {
+		r, err := repo.OpenNotebookRepoMem(t.TempDir())
+		if err != nil {
+			t.Fatalf("OpenNotebookRepoMem: %v", err)
+		}
+		return r
+	})
+}
+
+func TestNotebookRepoMemReload(t *testing.T) {
+	dir := t.TempDir()
+	r, err := repo.OpenNotebookRepoMem(dir)
+	if err != nil {
+		t.Fatalf("OpenNotebookRepoMem: %v", err)
+	}
+	parent, err := r.Create(core.Notebook{Name: "Работа", OwnerID: 1})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	child, err := r.Create(core.Notebook{Name: "Проекты", OwnerID: 1, ParentID: &parent})
+	if err != nil {
+		t.Fatalf("Create child: %v", err)
+	}
+	if err := r.Delete(1, parent); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	r, err = repo.OpenNotebookRepoMem(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	nb, err := r.GetByID(1, child)
+	if err != nil || nb.Name != "Проекты" || nb.ParentID == nil || *nb.ParentID != parent {
+		t.Errorf("reloaded notebook = %+v, %v", nb, err)
+	}
+	if _, err := r.GetByID(1, parent); err != repo.ErrNotebookNotFound {
+		t.Errorf("deleted notebook after reload: err = %v, want ErrNotebookNotFound", err)
+	}
+	if next, err := r.Create(core.Notebook{Name: "Дом", OwnerID: 1}); err != nil || next <= child {
+		t.Errorf("Create after reload = %d, %v; want id > %d", next, err, child)
+	}
+}
+
+func TestNotebookRepoSQLite(t *testing.T) {
+	repotest.RunNotebooks(t, func(t *testing.T) repo.NotebookRepository {
+		db := openTestDB(t)
+		r, err := repo.NewNotebookRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewNotebookRepoSQLite: %v", err)
+		}
+		return r
+	})
+}
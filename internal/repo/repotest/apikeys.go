@@ -0,0 +1,108 @@
+package repotest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// APIKeyFactory создаёт новое пустое хранилище ключей и пользователей, на
+// которых они ссылаются, для одного подтеста.
+type APIKeyFactory func(t *testing.T) (repo.UserRepository, repo.APIKeyRepository)
+
+// RunAPIKeys прогоняет проверки контракта APIKeyRepository.
+func RunAPIKeys(t *testing.T, newRepo APIKeyFactory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, users repo.UserRepository, keys repo.APIKeyRepository)
+	}{
+		{"CreateGet", testAPIKeyCreateGet},
+		{"OwnerIsolation", testAPIKeyOwnerIsolation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, keys := newRepo(t)
+			tt.fn(t, users, keys)
+		})
+	}
+}
+
+func mustCreateAPIKey(t *testing.T, r repo.APIKeyRepository, k core.APIKey) int64 {
+	t.Helper()
+	if k.CreatedAt.IsZero() {
+		k.CreatedAt = time.Now().UTC()
+	}
+	id, err := r.Create(k)
+	if err != nil {
+		t.Fatalf("Create(%q): %v", k.Name, err)
+	}
+	return id
+}
+
+func testAPIKeyCreateGet(t *testing.T, users repo.UserRepository, r repo.APIKeyRepository) {
+	user := mustCreateUser(t, users, "alice")
+	expires := time.Now().UTC().Add(time.Hour)
+	id := mustCreateAPIKey(t, r, core.APIKey{
+		UserID: user, Name: "ci", Prefix: "nk_abc", KeyHash: "hash-ci",
+		Scopes: []string{core.ScopeNotesRead, core.ScopeNotesWrite}, ExpiresAt: &expires,
+	})
+
+	k, err := r.GetByHash("hash-ci")
+	if err != nil {
+		t.Fatalf("GetByHash: %v", err)
+	}
+	if k.ID != id || k.UserID != user || k.Name != "ci" || k.Prefix != "nk_abc" ||
+		!slices.Equal(k.Scopes, []string{core.ScopeNotesRead, core.ScopeNotesWrite}) ||
+		k.ExpiresAt == nil || !k.ExpiresAt.Equal(expires) || k.LastUsedAt != nil {
+		t.Errorf("GetByHash = %+v", k)
+	}
+	if _, err := r.GetByHash("other"); !errors.Is(err, repo.ErrAPIKeyNotFound) {
+		t.Errorf("GetByHash(missing): err = %v, want ErrAPIKeyNotFound", err)
+	}
+
+	used := time.Now().UTC()
+	if err := r.Touch(id, used); err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+	if k, err := r.GetByHash("hash-ci"); err != nil || k.LastUsedAt == nil || !k.LastUsedAt.Equal(used) {
+		t.Errorf("after Touch = %+v, %v; want LastUsedAt %v", k, err, used)
+	}
+	if err := r.Touch(999, used); !errors.Is(err, repo.ErrAPIKeyNotFound) {
+		t.Errorf("Touch(missing): err = %v, want ErrAPIKeyNotFound", err)
+	}
+}
+
+func testAPIKeyOwnerIsolation(t *testing.T, users repo.UserRepository, r repo.APIKeyRepository) {
+	alice := mustCreateUser(t, users, "alice")
+	bob := mustCreateUser(t, users, "bob")
+	a1 := mustCreateAPIKey(t, r, core.APIKey{UserID: alice, Name: "a1", KeyHash: "h1", Scopes: []string{core.ScopeNotesRead}})
+	a2 := mustCreateAPIKey(t, r, core.APIKey{UserID: alice, Name: "a2", KeyHash: "h2", Scopes: []string{core.ScopeNotesRead}})
+	b1 := mustCreateAPIKey(t, r, core.APIKey{UserID: bob, Name: "b1", KeyHash: "h3", Scopes: []string{core.ScopeNotesRead}})
+
+	list, err := r.List(alice)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != a1 || list[1].ID != a2 {
+		t.Errorf("List(alice) = %+v, want [%d %d]", list, a1, a2)
+	}
+
+	if err := r.Delete(alice, b1); !errors.Is(err, repo.ErrAPIKeyNotFound) {
+		t.Errorf("Delete(other owner): err = %v, want ErrAPIKeyNotFound", err)
+	}
+	if err := r.Delete(alice, a1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := r.GetByHash("h1"); !errors.Is(err, repo.ErrAPIKeyNotFound) {
+		t.Errorf("GetByHash after Delete: err = %v, want ErrAPIKeyNotFound", err)
+	}
+	if list, err := r.List(bob); err != nil || len(list) != 1 || list[0].ID != b1 {
+		t.Errorf("List(bob) = %+v, %v", list, err)
+	}
+}
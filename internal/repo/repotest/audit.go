@@ -0,0 +1,153 @@
+package repotest
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// AuditFactory создаёт новый пустой журнал аудита для одного подтеста.
+type AuditFactory func(t *testing.T) repo.AuditLog
+
+// RunAuditLog прогоняет проверки контракта AuditLog.
+func RunAuditLog(t *testing.T, newLog AuditFactory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, l repo.AuditLog)
+	}{
+		{"AppendQuery", testAuditAppendQuery},
+		{"Filters", testAuditFilters},
+		{"Paging", testAuditPaging},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newLog(t))
+		})
+	}
+}
+
+var auditEpoch = time.Date(2024, 12, 8, 12, 0, 0, 0, time.UTC)
+
+func mustAppendAudit(t *testing.T, l repo.AuditLog, e core.AuditEvent) int64 {
+	t.Helper()
+	id, err := l.Append(e)
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	return id
+}
+
+func auditIDs(t *testing.T, l repo.AuditLog, q repo.AuditQuery) []int64 {
+	t.Helper()
+	page, err := l.Query(q)
+	if err != nil {
+		t.Fatalf("Query(%+v): %v", q, err)
+	}
+	var ids []int64
+	for _, e := range page.Events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func testAuditAppendQuery(t *testing.T, l repo.AuditLog) {
+	want := core.AuditEvent{
+		ID: 42, Time: auditEpoch, Action: core.AuditUpdate, NoteID: 7, OwnerID: owner, Version: 3,
+		ActorID: 2, Actor: "bob", Subject: "bob", RequestID: "host/abc-000001", ClientIP: "203.0.113.7",
+		Changes: []core.FieldChange{
+			{Field: "title", Before: "old", After: "new"},
+			{Field: "tags", After: []string{"a", "b"}},
+		},
+	}
+	first := mustAppendAudit(t, l, want)
+	second := mustAppendAudit(t, l, core.AuditEvent{Time: auditEpoch, Action: core.AuditPurge, NoteID: 7})
+	if first < 1 || second <= first {
+		t.Fatalf("IDs = %d, %d; want ascending from 1", first, second)
+	}
+
+	page, err := l.Query(repo.AuditQuery{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(page.Events) != 2 || page.Next != 0 {
+		t.Fatalf("Query = %+v, want 2 events on one page", page)
+	}
+	want.ID = first
+	gotJSON, _ := json.Marshal(page.Events[0])
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("event = %s, want %s", gotJSON, wantJSON)
+	}
+	if !page.Events[0].Time.Equal(auditEpoch) {
+		t.Errorf("Time = %v, want %v", page.Events[0].Time, auditEpoch)
+	}
+}
+
+func testAuditFilters(t *testing.T, l repo.AuditLog) {
+	a := mustAppendAudit(t, l, core.AuditEvent{Time: auditEpoch, Action: core.AuditCreate, NoteID: 1, Actor: "alice"})
+	b := mustAppendAudit(t, l, core.AuditEvent{Time: auditEpoch.Add(time.Hour), Action: core.AuditUpdate, NoteID: 1, Actor: "bob"})
+	c := mustAppendAudit(t, l, core.AuditEvent{Time: auditEpoch.Add(2 * time.Hour), Action: core.AuditCreate, NoteID: 2, Actor: "alice"})
+	system := mustAppendAudit(t, l, core.AuditEvent{Time: auditEpoch.Add(3 * time.Hour), Action: core.AuditPurge, NoteID: 2})
+
+	since := auditEpoch.Add(time.Hour)
+	until := auditEpoch.Add(2 * time.Hour)
+	tests := []struct {
+		name string
+		q    repo.AuditQuery
+		want []int64
+	}{
+		{"all", repo.AuditQuery{}, []int64{a, b, c, system}},
+		{"note", repo.AuditQuery{NoteID: 1}, []int64{a, b}},
+		{"actor", repo.AuditQuery{Actor: "alice"}, []int64{a, c}},
+		{"noteAndActor", repo.AuditQuery{NoteID: 2, Actor: "alice"}, []int64{c}},
+		{"since", repo.AuditQuery{Since: &since}, []int64{b, c, system}},
+		{"until", repo.AuditQuery{Until: &until}, []int64{a, b}},
+		{"range", repo.AuditQuery{Since: &since, Until: &until}, []int64{b}},
+		{"unknownActor", repo.AuditQuery{Actor: "carol"}, nil},
+	}
+	for _, tt := range tests {
+		if got := auditIDs(t, l, tt.q); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: IDs = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testAuditPaging(t *testing.T, l repo.AuditLog) {
+	var want []int64
+	for i := range 5 {
+		want = append(want, mustAppendAudit(t, l, core.AuditEvent{Time: auditEpoch, Action: core.AuditUpdate, NoteID: int64(i%2 + 1)}))
+	}
+
+	var got []int64
+	q := repo.AuditQuery{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("too many pages")
+		}
+		page, err := l.Query(q)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		for _, e := range page.Events {
+			got = append(got, e.ID)
+		}
+		if page.Next == 0 {
+			break
+		}
+		q.After = page.Next
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("paged IDs = %v, want %v", got, want)
+	}
+
+	// последняя полная страница не ссылается на пустую следующую
+	if page, _ := l.Query(repo.AuditQuery{NoteID: 2, Limit: 2}); page.Next != 0 || len(page.Events) != 2 {
+		t.Errorf("exact page = %+v, want 2 events and no next", page)
+	}
+}
@@ -0,0 +1,125 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// ShareLinkFactory создаёт новые пустые хранилища заметок и публичных
+// ссылок на них для одного подтеста.
+type ShareLinkFactory func(t *testing.T) (repo.NoteRepository, repo.ShareLinkRepository)
+
+// RunShareLinks прогоняет проверки контракта ShareLinkRepository.
+func RunShareLinks(t *testing.T, newRepo ShareLinkFactory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, notes repo.NoteRepository, links repo.ShareLinkRepository)
+	}{
+		{"CreateGet", testShareLinkCreateGet},
+		{"ListDelete", testShareLinkListDelete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes, links := newRepo(t)
+			tt.fn(t, notes, links)
+		})
+	}
+}
+
+func mustCreateShareLink(t *testing.T, r repo.ShareLinkRepository, noteID int64, hash string) int64 {
+	t.Helper()
+	id, err := r.Create(core.ShareLink{NoteID: noteID, OwnerID: owner, Prefix: hash, TokenHash: hash, CreatedAt: time.Now().UTC()})
+	if err != nil {
+		t.Fatalf("Create(%q): %v", hash, err)
+	}
+	return id
+}
+
+func testShareLinkCreateGet(t *testing.T, notes repo.NoteRepository, r repo.ShareLinkRepository) {
+	note := mustCreate(t, notes, "public", "")
+	expires := time.Now().UTC().Add(time.Hour)
+	id, err := r.Create(core.ShareLink{
+		NoteID: note, OwnerID: owner, Prefix: "abc", TokenHash: "hash-1",
+		PasswordHash: []byte("bcrypt"), CreatedAt: time.Now().UTC(), ExpiresAt: &expires,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	open := mustCreateShareLink(t, r, note, "hash-2")
+
+	l, err := r.GetByHash("hash-1")
+	if err != nil {
+		t.Fatalf("GetByHash: %v", err)
+	}
+	if l.ID != id || l.NoteID != note || l.OwnerID != owner || string(l.PasswordHash) != "bcrypt" || !l.Protected ||
+		l.ExpiresAt == nil || !l.ExpiresAt.Equal(expires) || l.AccessCount != 0 || l.LastAccessAt != nil {
+		t.Errorf("GetByHash = %+v", l)
+	}
+	if l, err := r.GetByHash("hash-2"); err != nil || l.ID != open || l.Protected {
+		t.Errorf("GetByHash(no password) = %+v, %v; want unprotected", l, err)
+	}
+	if _, err := r.GetByHash("missing"); !errors.Is(err, repo.ErrShareLinkNotFound) {
+		t.Errorf("GetByHash(missing): err = %v, want ErrShareLinkNotFound", err)
+	}
+
+	at := time.Now().UTC()
+	for range 2 {
+		if err := r.RecordAccess(id, at); err != nil {
+			t.Fatalf("RecordAccess: %v", err)
+		}
+	}
+	if l, err := r.GetByHash("hash-1"); err != nil || l.AccessCount != 2 || l.LastAccessAt == nil || !l.LastAccessAt.Equal(at) {
+		t.Errorf("after RecordAccess = %+v, %v; want 2 accesses at %v", l, err, at)
+	}
+	if err := r.RecordAccess(999, at); !errors.Is(err, repo.ErrShareLinkNotFound) {
+		t.Errorf("RecordAccess(missing): err = %v, want ErrShareLinkNotFound", err)
+	}
+}
+
+func testShareLinkListDelete(t *testing.T, notes repo.NoteRepository, r repo.ShareLinkRepository) {
+	n1 := mustCreate(t, notes, "one", "")
+	n2 := mustCreate(t, notes, "two", "")
+	a := mustCreateShareLink(t, r, n1, "a")
+	b := mustCreateShareLink(t, r, n1, "b")
+	c := mustCreateShareLink(t, r, n2, "c")
+
+	list, err := r.ListByNote(owner, n1)
+	if err != nil {
+		t.Fatalf("ListByNote: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != a || list[1].ID != b {
+		t.Errorf("ListByNote = %+v, want [%d %d]", list, a, b)
+	}
+	if list, err := r.ListByNote(owner+1, n1); err != nil || len(list) != 0 {
+		t.Errorf("ListByNote(other owner) = %+v, %v; want empty", list, err)
+	}
+
+	if err := r.Delete(owner+1, n1, a); !errors.Is(err, repo.ErrShareLinkNotFound) {
+		t.Errorf("Delete(other owner): err = %v, want ErrShareLinkNotFound", err)
+	}
+	if err := r.Delete(owner, n2, a); !errors.Is(err, repo.ErrShareLinkNotFound) {
+		t.Errorf("Delete(other note): err = %v, want ErrShareLinkNotFound", err)
+	}
+	if err := r.Delete(owner, n1, a); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := r.GetByHash("a"); !errors.Is(err, repo.ErrShareLinkNotFound) {
+		t.Errorf("GetByHash after Delete: err = %v, want ErrShareLinkNotFound", err)
+	}
+
+	if err := r.DeleteByNote(n1); err != nil {
+		t.Fatalf("DeleteByNote: %v", err)
+	}
+	if _, err := r.GetByHash("b"); !errors.Is(err, repo.ErrShareLinkNotFound) {
+		t.Errorf("GetByHash after DeleteByNote: err = %v, want ErrShareLinkNotFound", err)
+	}
+	if l, err := r.GetByHash("c"); err != nil || l.ID != c {
+		t.Errorf("other note's link = %+v, %v; want it kept", l, err)
+	}
+}
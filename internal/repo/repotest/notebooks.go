@@ -0,0 +1,150 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// NotebookFactory создаёт новое пустое хранилище блокнотов для одного подтеста.
+type NotebookFactory func(t *testing.T) repo.NotebookRepository
+
+// RunNotebooks прогоняет проверки контракта NotebookRepository.
+func RunNotebooks(t *testing.T, newRepo NotebookFactory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, r repo.NotebookRepository)
+	}{
+		{"CreateGetList", testNotebooksCreateGetList},
+		{"Update", testNotebooksUpdate},
+		{"Delete", testNotebooksDelete},
+		{"OwnerIsolation", testNotebooksOwnerIsolation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func mustCreateNotebook(t *testing.T, r repo.NotebookRepository, name string, parent *int64) int64 {
+	t.Helper()
+	id, err := r.Create(core.Notebook{OwnerID: owner, Name: name, ParentID: parent})
+	if err != nil {
+		t.Fatalf("Create(%q): %v", name, err)
+	}
+	return id
+}
+
+func testNotebooksCreateGetList(t *testing.T, r repo.NotebookRepository) {
+	root := mustCreateNotebook(t, r, "root", nil)
+	child := mustCreateNotebook(t, r, "child", &root)
+
+	nb, err := r.GetByID(owner, child)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if nb.Name != "child" || nb.ParentID == nil || *nb.ParentID != root {
+		t.Errorf("GetByID = %+v, want child of %d", nb, root)
+	}
+	if nb.CreatedAt.IsZero() || nb.UpdatedAt != nil {
+		t.Errorf("timestamps = %v / %v, want CreatedAt set and UpdatedAt nil", nb.CreatedAt, nb.UpdatedAt)
+	}
+
+	list, err := r.List(owner)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != root || list[1].ID != child || list[0].ParentID != nil {
+		t.Errorf("List = %+v, want [root, child]", list)
+	}
+
+	if _, err := r.GetByID(owner, 999); !errors.Is(err, repo.ErrNotebookNotFound) {
+		t.Errorf("GetByID(missing): err = %v, want ErrNotebookNotFound", err)
+	}
+}
+
+func testNotebooksUpdate(t *testing.T, r repo.NotebookRepository) {
+	a := mustCreateNotebook(t, r, "a", nil)
+	b := mustCreateNotebook(t, r, "b", &a)
+
+	updated, err := r.Update(owner, b, func(nb *core.Notebook) error {
+		nb.Name = "renamed"
+		nb.ParentID = nil
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Name != "renamed" || updated.ParentID != nil || updated.UpdatedAt == nil {
+		t.Errorf("Update returned %+v", updated)
+	}
+	if nb, _ := r.GetByID(owner, b); nb.Name != "renamed" || nb.ParentID != nil {
+		t.Errorf("stored notebook = %+v", nb)
+	}
+
+	errReject := errors.New("rejected")
+	if _, err := r.Update(owner, b, func(nb *core.Notebook) error {
+		nb.Name = "partial"
+		return errReject
+	}); !errors.Is(err, errReject) {
+		t.Fatalf("Update: err = %v, want the updateFn error", err)
+	}
+	if nb, _ := r.GetByID(owner, b); nb.Name != "renamed" {
+		t.Errorf("notebook changed after rejected update: %+v", nb)
+	}
+
+	if _, err := r.Update(owner, 999, func(*core.Notebook) error { return nil }); !errors.Is(err, repo.ErrNotebookNotFound) {
+		t.Errorf("Update(missing): err = %v, want ErrNotebookNotFound", err)
+	}
+}
+
+func testNotebooksDelete(t *testing.T, r repo.NotebookRepository) {
+	id := mustCreateNotebook(t, r, "gone", nil)
+	if err := r.Delete(owner, id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := r.GetByID(owner, id); !errors.Is(err, repo.ErrNotebookNotFound) {
+		t.Errorf("GetByID after Delete: err = %v, want ErrNotebookNotFound", err)
+	}
+	if err := r.Delete(owner, id); !errors.Is(err, repo.ErrNotebookNotFound) {
+		t.Errorf("second Delete: err = %v, want ErrNotebookNotFound", err)
+	}
+	if next := mustCreateNotebook(t, r, "next", nil); next <= id {
+		t.Errorf("ID reused after delete: %d <= %d", next, id)
+	}
+}
+
+func testNotebooksOwnerIsolation(t *testing.T, r repo.NotebookRepository) {
+	const other int64 = 2
+	mine := mustCreateNotebook(t, r, "mine", nil)
+	theirs, err := r.Create(core.Notebook{OwnerID: other, Name: "theirs"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := r.GetByID(owner, theirs); !errors.Is(err, repo.ErrNotebookNotFound) {
+		t.Errorf("GetByID(foreign): err = %v, want ErrNotebookNotFound", err)
+	}
+	if _, err := r.Update(owner, theirs, func(nb *core.Notebook) error { return nil }); !errors.Is(err, repo.ErrNotebookNotFound) {
+		t.Errorf("Update(foreign): err = %v, want ErrNotebookNotFound", err)
+	}
+	if err := r.Delete(owner, theirs); !errors.Is(err, repo.ErrNotebookNotFound) {
+		t.Errorf("Delete(foreign): err = %v, want ErrNotebookNotFound", err)
+	}
+
+	list, err := r.List(owner)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != mine || list[0].OwnerID != owner {
+		t.Errorf("List(owner) = %+v, want only %d", list, mine)
+	}
+	if nb, err := r.GetByID(other, theirs); err != nil || nb.OwnerID != other {
+		t.Errorf("GetByID(other) = %+v, %v", nb, err)
+	}
+}
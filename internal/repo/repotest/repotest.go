@@ -0,0 +1,1020 @@
+// Package repotest — набор тестов соответствия для реализаций
+// repo.NoteRepository. Новое хранилище считается корректным, если проходит
+// Run:
+//
+//	func TestNoteRepoFoo(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) repo.NoteRepository {
+//			return foo.New(t.TempDir())
+//		})
+//	}
+package repotest
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// owner — владелец заметок и блокнотов в проверках, которым не важна
+// изоляция владельцев.
+const owner int64 = 1
+
+// Factory создаёт новый пустой репозиторий для одного подтеста.
+// Освобождение ресурсов — через t.Cleanup.
+type Factory func(t *testing.T) repo.NoteRepository
+
+// Run прогоняет все проверки контракта NoteRepository.
+func Run(t *testing.T, newRepo Factory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, r repo.NoteRepository)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"CreateAssignsIDAndTimestamps", testCreateAssignsIDAndTimestamps},
+		{"IDsMonotonic", testIDsMonotonic},
+		{"GetAll", testGetAll},
+		{"NotFound", testNotFound},
+		{"Update", testUpdate},
+		{"UpdateRollback", testUpdateRollback},
+		{"Delete", testDelete},
+		{"Batch", testBatch},
+		{"BatchRollback", testBatchRollback},
+		{"ReturnsCopies", testReturnsCopies},
+		{"Versions", testVersions},
+		{"VersionConflict", testVersionConflict},
+		{"FindSortAndPaginate", testFindSortAndPaginate},
+		{"FindFilters", testFindFilters},
+		{"FindCursorMismatch", testFindCursorMismatch},
+		{"FindTrashed", testFindTrashed},
+		{"Tags", testTags},
+		{"FindTags", testFindTags},
+		{"TagCounts", testTagCounts},
+		{"Notebook", testNotebook},
+		{"OwnerIsolation", testOwnerIsolation},
+		{"Usage", testUsage},
+		{"ConcurrentCreate", testConcurrentCreate},
+		{"ConcurrentUpdate", testConcurrentUpdate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func mustCreate(t *testing.T, r repo.NoteRepository, title, content string) int64 {
+	t.Helper()
+	id, err := r.Create(core.Note{OwnerID: owner, Title: title, Content: content})
+	if err != nil {
+		t.Fatalf("Create(%q): %v", title, err)
+	}
+	return id
+}
+
+func mustGet(t *testing.T, r repo.NoteRepository, id int64) *core.Note {
+	t.Helper()
+	n, err := r.GetByID(owner, id)
+	if err != nil {
+		t.Fatalf("GetByID(%d): %v", id, err)
+	}
+	return n
+}
+
+func testCreateAndGet(t *testing.T, r repo.NoteRepository) {
+	id := mustCreate(t, r, "Заголовок", "Текст")
+
+	n := mustGet(t, r, id)
+	if n.ID != id {
+		t.Errorf("ID = %d, want %d", n.ID, id)
+	}
+	if n.Title != "Заголовок" || n.Content != "Текст" {
+		t.Errorf("got title=%q content=%q", n.Title, n.Content)
+	}
+}
+
+func testCreateAssignsIDAndTimestamps(t *testing.T, r repo.NoteRepository) {
+	updated := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Now().UTC().Add(-time.Second)
+
+	id, err := r.Create(core.Note{
+		OwnerID:   owner,
+		ID:        42_000,
+		Title:     "t",
+		CreatedAt: time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: &updated,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	after := time.Now().UTC().Add(time.Second)
+
+	if id <= 0 {
+		t.Fatalf("Create returned id %d, want > 0", id)
+	}
+	if id == 42_000 {
+		t.Errorf("Create kept caller-supplied ID")
+	}
+
+	n := mustGet(t, r, id)
+	if n.CreatedAt.Before(before) || n.CreatedAt.After(after) {
+		t.Errorf("CreatedAt = %v, want between %v and %v", n.CreatedAt, before, after)
+	}
+	if n.CreatedAt.Location() != time.UTC {
+		t.Errorf("CreatedAt location = %v, want UTC", n.CreatedAt.Location())
+	}
+	if n.UpdatedAt != nil {
+		t.Errorf("UpdatedAt = %v, want nil for a new note", n.UpdatedAt)
+	}
+}
+
+func testIDsMonotonic(t *testing.T, r repo.NoteRepository) {
+	var prev int64
+	for i := 0; i < 5; i++ {
+		id := mustCreate(t, r, "n"+strconv.Itoa(i), "")
+		if id <= prev {
+			t.Fatalf("id %d after %d is not increasing", id, prev)
+		}
+		prev = id
+	}
+
+	// удалённый ID не выдаётся повторно
+	if err := r.Delete(owner, prev, 0); err != nil {
+		t.Fatalf("Delete(%d): %v", prev, err)
+	}
+	if id := mustCreate(t, r, "after delete", ""); id <= prev {
+		t.Errorf("id %d after deleting %d, want > %d", id, prev, prev)
+	}
+}
+
+func testGetAll(t *testing.T, r repo.NoteRepository) {
+	all, err := r.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll on empty repo: %v", err)
+	}
+	if len(all) != 0 {
+		t.Fatalf("GetAll on empty repo returned %d notes", len(all))
+	}
+
+	want := map[int64]string{}
+	for _, title := range []string{"a", "b", "c"} {
+		want[mustCreate(t, r, title, "")] = title
+	}
+
+	all, err = r.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(all) != len(want) {
+		t.Fatalf("GetAll returned %d notes, want %d", len(all), len(want))
+	}
+	for _, n := range all {
+		if want[n.ID] != n.Title {
+			t.Errorf("note %d: title %q, want %q", n.ID, n.Title, want[n.ID])
+		}
+	}
+}
+
+func testNotFound(t *testing.T, r repo.NoteRepository) {
+	const missing = 999_999
+
+	if _, err := r.GetByID(owner, missing); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("GetByID: err = %v, want ErrNoteNotFound", err)
+	}
+
+	called := false
+	_, err := r.Update(owner, missing, 0, func(*core.Note) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("Update: err = %v, want ErrNoteNotFound", err)
+	}
+	if called {
+		t.Errorf("Update called updateFn for a missing note")
+	}
+
+	if err := r.Delete(owner, missing, 0); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("Delete: err = %v, want ErrNoteNotFound", err)
+	}
+}
+
+func testUpdate(t *testing.T, r repo.NoteRepository) {
+	id := mustCreate(t, r, "old", "old content")
+	created := mustGet(t, r, id)
+
+	got, err := r.Update(owner, id, 0, func(n *core.Note) error {
+		n.Title = "new"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got.ID != id || got.Title != "new" || got.Content != "old content" {
+		t.Errorf("Update returned %+v", got)
+	}
+	if got.UpdatedAt == nil {
+		t.Fatalf("UpdatedAt not set after Update")
+	}
+	if got.UpdatedAt.Before(created.CreatedAt) {
+		t.Errorf("UpdatedAt %v before CreatedAt %v", got.UpdatedAt, created.CreatedAt)
+	}
+
+	stored := mustGet(t, r, id)
+	if stored.Title != "new" {
+		t.Errorf("stored title = %q, want %q", stored.Title, "new")
+	}
+	if !stored.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("CreatedAt changed: %v -> %v", created.CreatedAt, stored.CreatedAt)
+	}
+}
+
+func testUpdateRollback(t *testing.T, r repo.NoteRepository) {
+	id := mustCreate(t, r, "keep", "keep content")
+	errReject := errors.New("rejected")
+
+	_, err := r.Update(owner, id, 0, func(n *core.Note) error {
+		n.Title = "partial"
+		n.Content = "partial"
+		return errReject
+	})
+	if !errors.Is(err, errReject) {
+		t.Fatalf("Update: err = %v, want the updateFn error", err)
+	}
+
+	n := mustGet(t, r, id)
+	if n.Title != "keep" || n.Content != "keep content" {
+		t.Errorf("note changed after rejected update: %+v", n)
+	}
+	if n.UpdatedAt != nil {
+		t.Errorf("UpdatedAt set after rejected update: %v", n.UpdatedAt)
+	}
+}
+
+func testDelete(t *testing.T, r repo.NoteRepository) {
+	keep := mustCreate(t, r, "keep", "")
+	id := mustCreate(t, r, "drop", "")
+
+	if err := r.Delete(owner, id, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := r.GetByID(owner, id); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("GetByID after Delete: err = %v, want ErrNoteNotFound", err)
+	}
+	if err := r.Delete(owner, id, 0); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("second Delete: err = %v, want ErrNoteNotFound", err)
+	}
+	if _, err := r.Update(owner, id, 0, func(*core.Note) error { return nil }); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("Update after Delete: err = %v, want ErrNoteNotFound", err)
+	}
+
+	all, err := r.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(all) != 1 || all[0].ID != keep {
+		t.Errorf("GetAll after Delete = %+v, want only note %d", all, keep)
+	}
+}
+
+func testBatch(t *testing.T, r repo.NoteRepository) {
+	keep := mustCreate(t, r, "keep", "")
+	drop := mustCreate(t, r, "drop", "")
+
+	var created int64
+	err := r.Batch(func(tx repo.NoteRepository) error {
+		var err error
+		if created, err = tx.Create(core.Note{OwnerID: owner, Title: "new"}); err != nil {
+			return err
+		}
+		// изменения видны внутри транзакции
+		if n, err := tx.GetByID(owner, created); err != nil || n.Title != "new" {
+			t.Errorf("GetByID in batch = %+v, %v", n, err)
+		}
+		if _, err := tx.Update(owner, keep, 1, func(n *core.Note) error {
+			n.Title = "kept"
+			return nil
+		}); err != nil {
+			return err
+		}
+		return tx.Delete(owner, drop, 0)
+	})
+	if err != nil {
+		t.Fatalf("Batch: %v", err)
+	}
+
+	if n := mustGet(t, r, created); n.Title != "new" || n.Version != 1 {
+		t.Errorf("created note = %+v", n)
+	}
+	if n := mustGet(t, r, keep); n.Title != "kept" || n.Version != 2 {
+		t.Errorf("updated note = %+v", n)
+	}
+	if _, err := r.GetByID(owner, drop); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("GetByID(deleted): err = %v, want ErrNoteNotFound", err)
+	}
+}
+
+func testBatchRollback(t *testing.T, r repo.NoteRepository) {
+	keep := mustCreate(t, r, "keep", "")
+	drop := mustCreate(t, r, "drop", "")
+	before, err := r.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	errReject := errors.New("rejected")
+
+	var created int64
+	err = r.Batch(func(tx repo.NoteRepository) error {
+		var err error
+		if created, err = tx.Create(core.Note{OwnerID: owner, Title: "new"}); err != nil {
+			return err
+		}
+		if _, err := tx.Update(owner, keep, 0, func(n *core.Note) error {
+			n.Title = "partial"
+			return nil
+		}); err != nil {
+			return err
+		}
+		if err := tx.Delete(owner, drop, 0); err != nil {
+			return err
+		}
+		return errReject
+	})
+	if !errors.Is(err, errReject) {
+		t.Fatalf("Batch: err = %v, want the fn error", err)
+	}
+
+	after, err := r.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(after) != len(before) {
+		t.Fatalf("GetAll after rollback = %+v, want %+v", after, before)
+	}
+	if n := mustGet(t, r, keep); n.Title != "keep" || n.Version != 1 {
+		t.Errorf("note changed after rollback: %+v", n)
+	}
+	mustGet(t, r, drop)
+	if _, err := r.GetByID(owner, created); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("GetByID(created in rolled back batch): err = %v, want ErrNoteNotFound", err)
+	}
+	// ID из отменённой транзакции не должен достаться другой заметке
+	// раньше, чем ID, выданные до неё
+	if id := mustCreate(t, r, "next", ""); id <= drop {
+		t.Errorf("Create after rollback = %d, want > %d", id, drop)
+	}
+}
+
+func testVersions(t *testing.T, r repo.NoteRepository) {
+	id := mustCreate(t, r, "v", "")
+	if v := mustGet(t, r, id).Version; v != 1 {
+		t.Fatalf("Version after Create = %d, want 1", v)
+	}
+
+	for want := int64(2); want <= 4; want++ {
+		n, err := r.Update(owner, id, want-1, func(n *core.Note) error {
+			n.Version = 100 // репозиторий сам ведёт версию
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Update(version %d): %v", want-1, err)
+		}
+		if n.Version != want {
+			t.Errorf("Update returned version %d, want %d", n.Version, want)
+		}
+	}
+	if v := mustGet(t, r, id).Version; v != 4 {
+		t.Errorf("stored Version = %d, want 4", v)
+	}
+
+	// version 0 — без проверки, но версия всё равно растёт
+	if n, err := r.Update(owner, id, 0, func(*core.Note) error { return nil }); err != nil || n.Version != 5 {
+		t.Errorf("unconditional Update = %+v, %v; want version 5", n, err)
+	}
+
+	// отклонённое изменение не увеличивает версию
+	_, _ = r.Update(owner, id, 0, func(*core.Note) error { return errors.New("rejected") })
+	if v := mustGet(t, r, id).Version; v != 5 {
+		t.Errorf("Version after rejected Update = %d, want 5", v)
+	}
+}
+
+func testVersionConflict(t *testing.T, r repo.NoteRepository) {
+	id := mustCreate(t, r, "original", "")
+	if _, err := r.Update(owner, id, 1, func(n *core.Note) error { n.Title = "first"; return nil }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	called := false
+	_, err := r.Update(owner, id, 1, func(n *core.Note) error {
+		called = true
+		n.Title = "stale"
+		return nil
+	})
+	if !errors.Is(err, repo.ErrVersionConflict) {
+		t.Errorf("stale Update: err = %v, want ErrVersionConflict", err)
+	}
+	if called {
+		t.Errorf("stale Update called updateFn")
+	}
+	if n := mustGet(t, r, id); n.Title != "first" || n.Version != 2 {
+		t.Errorf("note after stale Update = %+v", n)
+	}
+
+	if err := r.Delete(owner, id, 1); !errors.Is(err, repo.ErrVersionConflict) {
+		t.Errorf("stale Delete: err = %v, want ErrVersionConflict", err)
+	}
+	mustGet(t, r, id)
+
+	// отсутствие заметки важнее несовпадения версии
+	if _, err := r.Update(owner, 999_999, 1, func(*core.Note) error { return nil }); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("Update missing with version: err = %v, want ErrNoteNotFound", err)
+	}
+	if err := r.Delete(owner, 999_999, 1); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("Delete missing with version: err = %v, want ErrNoteNotFound", err)
+	}
+
+	if err := r.Delete(owner, id, 2); err != nil {
+		t.Fatalf("Delete with current version: %v", err)
+	}
+	if _, err := r.GetByID(owner, id); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("GetByID after Delete: err = %v, want ErrNoteNotFound", err)
+	}
+}
+
+func testReturnsCopies(t *testing.T, r repo.NoteRepository) {
+	id := mustCreate(t, r, "original", "")
+
+	n := mustGet(t, r, id)
+	n.Title = "mutated"
+
+	updated, err := r.Update(owner, id, 0, func(*core.Note) error { return nil })
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	updated.Title = "mutated again"
+
+	all, err := r.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	all[0].Title = "mutated via GetAll"
+
+	if got := mustGet(t, r, id); got.Title != "original" {
+		t.Errorf("stored title = %q, want %q", got.Title, "original")
+	}
+}
+
+func testConcurrentCreate(t *testing.T, r repo.NoteRepository) {
+	const workers, perWorker = 8, 25
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		ids = make(map[int64]bool)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				id, err := r.Create(core.Note{OwnerID: owner, Title: "c"})
+				if err != nil {
+					t.Errorf("Create: %v", err)
+					return
+				}
+				mu.Lock()
+				if ids[id] {
+					t.Errorf("duplicate id %d", id)
+				}
+				ids[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	all, err := r.GetAll()
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(all) != workers*perWorker {
+		t.Errorf("GetAll returned %d notes, want %d", len(all), workers*perWorker)
+	}
+}
+
+// testConcurrentUpdate проверяет, что Update атомарен: каждое
+// read-modify-write увеличивает счётчик, и ни одно не теряется.
+func testConcurrentUpdate(t *testing.T, r repo.NoteRepository) {
+	const workers, perWorker = 8, 25
+	id := mustCreate(t, r, "counter", "0")
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				_, err := r.Update(owner, id, 0, func(n *core.Note) error {
+					v, err := strconv.Atoi(n.Content)
+					if err != nil {
+						return err
+					}
+					n.Content = strconv.Itoa(v + 1)
+					return nil
+				})
+				if err != nil {
+					t.Errorf("Update: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := mustGet(t, r, id).Content, strconv.Itoa(workers*perWorker); got != want {
+		t.Errorf("counter = %s, want %s", got, want)
+	}
+}
+
+// findAllPages листает выборку страницами по limit и возвращает ID.
+// Без q.OwnerID выбираются заметки owner.
+func findAllPages(t *testing.T, r repo.NoteRepository, q repo.NoteQuery) []int64 {
+	t.Helper()
+	if q.OwnerID == 0 {
+		q.OwnerID = owner
+	}
+	var ids []int64
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatalf("pagination does not terminate")
+		}
+		page, err := r.Find(q)
+		if err != nil {
+			t.Fatalf("Find(%+v): %v", q, err)
+		}
+		if q.Limit > 0 && len(page.Notes) > q.Limit {
+			t.Fatalf("Find returned %d notes, limit %d", len(page.Notes), q.Limit)
+		}
+		for _, n := range page.Notes {
+			ids = append(ids, n.ID)
+		}
+		if page.Next == nil {
+			return ids
+		}
+		q.After = page.Next
+	}
+}
+
+func reversed(ids []int64) []int64 {
+	out := make([]int64, len(ids))
+	for i, id := range ids {
+		out[len(ids)-1-i] = id
+	}
+	return out
+}
+
+func testFindSortAndPaginate(t *testing.T, r repo.NoteRepository) {
+	// Заголовки идут не в порядке создания; «b» повторяется, чтобы
+	// проверить разрешение равных ключей по ID.
+	titles := []string{"b", "a", "d", "b", "c"}
+	ids := make([]int64, len(titles))
+	for i, title := range titles {
+		ids[i] = mustCreate(t, r, title, "")
+		time.Sleep(time.Millisecond)
+	}
+	// первая заметка изменена последней
+	if _, err := r.Update(owner, ids[0], 0, func(*core.Note) error { return nil }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	byTitle := []int64{ids[1], ids[0], ids[3], ids[4], ids[2]}
+	byUpdated := []int64{ids[1], ids[2], ids[3], ids[4], ids[0]}
+
+	tests := []struct {
+		sort repo.NoteSortField
+		want []int64
+	}{
+		{repo.SortByID, ids},
+		{repo.SortByCreatedAt, ids},
+		{repo.SortByUpdatedAt, byUpdated},
+		{repo.SortByTitle, byTitle},
+	}
+	for _, tt := range tests {
+		for _, limit := range []int{0, 1, 2, 5, 10} {
+			got := findAllPages(t, r, repo.NoteQuery{SortBy: tt.sort, Limit: limit})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sort=%s limit=%d: got %v, want %v", tt.sort, limit, got, tt.want)
+			}
+
+			got = findAllPages(t, r, repo.NoteQuery{SortBy: tt.sort, Desc: true, Limit: limit})
+			if want := reversed(tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("sort=%s desc limit=%d: got %v, want %v", tt.sort, limit, got, want)
+			}
+		}
+	}
+
+	// пустая сортировка — по ID
+	if got := findAllPages(t, r, repo.NoteQuery{Limit: 2}); !reflect.DeepEqual(got, ids) {
+		t.Errorf("default sort: got %v, want %v", got, ids)
+	}
+}
+
+func testFindFilters(t *testing.T, r repo.NoteRepository) {
+	first := mustCreate(t, r, "Отчёт за май", "")
+	time.Sleep(2 * time.Millisecond)
+	mid := time.Now().UTC()
+	time.Sleep(2 * time.Millisecond)
+	second := mustCreate(t, r, "Отчёт за июнь", "")
+	third := mustCreate(t, r, "отчёт в нижнем регистре", "")
+	time.Sleep(2 * time.Millisecond)
+	beforeUpdate := time.Now().UTC()
+	time.Sleep(2 * time.Millisecond)
+	if _, err := r.Update(owner, first, 0, func(*core.Note) error { return nil }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		q    repo.NoteQuery
+		want []int64
+	}{
+		{"none", repo.NoteQuery{}, []int64{first, second, third}},
+		{"titlePrefix", repo.NoteQuery{TitlePrefix: "Отчёт"}, []int64{first, second}},
+		{"titlePrefixNoMatch", repo.NoteQuery{TitlePrefix: "Plan"}, nil},
+		{"createdAfter", repo.NoteQuery{CreatedAfter: &mid}, []int64{second, third}},
+		{"createdBefore", repo.NoteQuery{CreatedBefore: &mid}, []int64{first}},
+		{"updatedAfter", repo.NoteQuery{UpdatedAfter: &beforeUpdate}, []int64{first}},
+		{"updatedBefore", repo.NoteQuery{UpdatedBefore: &beforeUpdate}, []int64{second, third}},
+		{"combined", repo.NoteQuery{TitlePrefix: "Отчёт", CreatedAfter: &mid}, []int64{second}},
+	}
+	for _, tt := range tests {
+		got := findAllPages(t, r, tt.q)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testFindCursorMismatch(t *testing.T, r repo.NoteRepository) {
+	for i := 0; i < 3; i++ {
+		mustCreate(t, r, "n"+strconv.Itoa(i), "")
+	}
+	page, err := r.Find(repo.NoteQuery{OwnerID: owner, SortBy: repo.SortByTitle, Limit: 1})
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if page.Next == nil {
+		t.Fatalf("Find returned no next cursor")
+	}
+
+	// курсор приходит от клиента как строка
+	c, err := repo.DecodeCursor(repo.EncodeCursor(page.Next))
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if _, err := r.Find(repo.NoteQuery{OwnerID: owner, SortBy: repo.SortByID, After: c}); !errors.Is(err, repo.ErrInvalidCursor) {
+		t.Errorf("cursor with different sort: err = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := repo.DecodeCursor("not a cursor"); !errors.Is(err, repo.ErrInvalidCursor) {
+		t.Errorf("DecodeCursor(garbage): err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func testFindTrashed(t *testing.T, r repo.NoteRepository) {
+	live := mustCreate(t, r, "live", "")
+	old := mustCreate(t, r, "old", "")
+	recent := mustCreate(t, r, "recent", "")
+
+	trash := func(id int64, at time.Time) {
+		t.Helper()
+		if _, err := r.Update(owner, id, 0, func(n *core.Note) error {
+			n.DeletedAt = &at
+			return nil
+		}); err != nil {
+			t.Fatalf("Update(%d): %v", id, err)
+		}
+	}
+	cutoff := time.Now().UTC()
+	trash(old, cutoff.Add(-time.Hour))
+	trash(recent, cutoff.Add(time.Hour))
+
+	// DeletedAt сохраняется и виден через GetByID
+	if n := mustGet(t, r, old); n.DeletedAt == nil || !n.DeletedAt.Equal(cutoff.Add(-time.Hour)) {
+		t.Errorf("DeletedAt = %v, want %v", n.DeletedAt, cutoff.Add(-time.Hour))
+	}
+
+	tests := []struct {
+		name string
+		q    repo.NoteQuery
+		want []int64
+	}{
+		{"live", repo.NoteQuery{}, []int64{live}},
+		{"trashed", repo.NoteQuery{Trashed: true}, []int64{old, recent}},
+		{"trashedPaged", repo.NoteQuery{Trashed: true, Limit: 1}, []int64{old, recent}},
+		{"deletedBefore", repo.NoteQuery{Trashed: true, DeletedBefore: &cutoff}, []int64{old}},
+	}
+	for _, tt := range tests {
+		got := findAllPages(t, r, tt.q)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// восстановление возвращает заметку в обычную выборку
+	if _, err := r.Update(owner, old, 0, func(n *core.Note) error {
+		n.DeletedAt = nil
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := findAllPages(t, r, repo.NoteQuery{}); !reflect.DeepEqual(got, []int64{live, old}) {
+		t.Errorf("after restore: got %v, want %v", got, []int64{live, old})
+	}
+}
+
+func mustCreateTagged(t *testing.T, r repo.NoteRepository, title string, tags ...string) int64 {
+	t.Helper()
+	id, err := r.Create(core.Note{OwnerID: owner, Title: title, Tags: tags})
+	if err != nil {
+		t.Fatalf("Create(%q): %v", title, err)
+	}
+	return id
+}
+
+func testTags(t *testing.T, r repo.NoteRepository) {
+	tags := []string{"a", "b"}
+	id := mustCreateTagged(t, r, "tagged", tags...)
+	tags[0] = "changed" // репозиторий не должен хранить срез вызывающего
+
+	if got := mustGet(t, r, id).Tags; !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Tags after Create = %v, want [a b]", got)
+	}
+
+	got := mustGet(t, r, id)
+	got.Tags[0] = "mutated"
+	if again := mustGet(t, r, id).Tags; again[0] != "a" {
+		t.Errorf("Tags changed through returned note: %v", again)
+	}
+
+	updated, err := r.Update(owner, id, 0, func(n *core.Note) error {
+		n.Tags = []string{"c"}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if !reflect.DeepEqual(updated.Tags, []string{"c"}) {
+		t.Errorf("Update returned Tags = %v, want [c]", updated.Tags)
+	}
+	if got := mustGet(t, r, id).Tags; !reflect.DeepEqual(got, []string{"c"}) {
+		t.Errorf("Tags after Update = %v, want [c]", got)
+	}
+
+	// изменение тегов в отклонённом updateFn не должно просочиться
+	errReject := errors.New("rejected")
+	_, err = r.Update(owner, id, 0, func(n *core.Note) error {
+		n.Tags[0] = "rolled back"
+		return errReject
+	})
+	if !errors.Is(err, errReject) {
+		t.Fatalf("Update: err = %v, want the updateFn error", err)
+	}
+	if got := mustGet(t, r, id).Tags; !reflect.DeepEqual(got, []string{"c"}) {
+		t.Errorf("Tags after rollback = %v, want [c]", got)
+	}
+
+	if _, err := r.Update(owner, id, 0, func(n *core.Note) error {
+		n.Tags = nil
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := mustGet(t, r, id).Tags; len(got) != 0 {
+		t.Errorf("Tags after clearing = %v, want none", got)
+	}
+}
+
+func testFindTags(t *testing.T, r repo.NoteRepository) {
+	ab := mustCreateTagged(t, r, "ab", "a", "b")
+	a := mustCreateTagged(t, r, "a", "a")
+	c := mustCreateTagged(t, r, "c", "c")
+	mustCreateTagged(t, r, "none")
+
+	tests := []struct {
+		name string
+		q    repo.NoteQuery
+		want []int64
+	}{
+		{"one", repo.NoteQuery{Tags: []string{"a"}}, []int64{ab, a}},
+		{"all", repo.NoteQuery{Tags: []string{"a", "b"}}, []int64{ab}},
+		{"allDuplicate", repo.NoteQuery{Tags: []string{"a", "a"}}, []int64{ab, a}},
+		{"any", repo.NoteQuery{Tags: []string{"b", "c"}, AnyTag: true}, []int64{ab, c}},
+		{"unknown", repo.NoteQuery{Tags: []string{"x"}}, nil},
+		{"paged", repo.NoteQuery{Tags: []string{"a", "c"}, AnyTag: true, Limit: 1}, []int64{ab, a, c}},
+	}
+	for _, tt := range tests {
+		got := findAllPages(t, r, tt.q)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	page, err := r.Find(repo.NoteQuery{OwnerID: owner, Tags: []string{"b"}})
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(page.Notes) != 1 || !reflect.DeepEqual(page.Notes[0].Tags, []string{"a", "b"}) {
+		t.Errorf("Find returned %+v, want note with tags [a b]", page.Notes)
+	}
+}
+
+func testTagCounts(t *testing.T, r repo.NoteRepository) {
+	mustCreateTagged(t, r, "1", "work", "urgent")
+	mustCreateTagged(t, r, "2", "work")
+	trashed := mustCreateTagged(t, r, "3", "work", "old")
+	if _, err := r.Update(owner, trashed, 0, func(n *core.Note) error {
+		now := time.Now().UTC()
+		n.DeletedAt = &now
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	got, err := r.TagCounts(owner)
+	if err != nil {
+		t.Fatalf("TagCounts: %v", err)
+	}
+	want := []repo.TagCount{{Tag: "urgent", Count: 1}, {Tag: "work", Count: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TagCounts = %v, want %v", got, want)
+	}
+}
+
+func testNotebook(t *testing.T, r repo.NoteRepository) {
+	nb1, nb2 := int64(1), int64(2)
+	a, err := r.Create(core.Note{OwnerID: owner, Title: "a", NotebookID: &nb1})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	b, err := r.Create(core.Note{OwnerID: owner, Title: "b", NotebookID: &nb2})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	root := mustCreate(t, r, "root", "")
+
+	if n := mustGet(t, r, a); n.NotebookID == nil || *n.NotebookID != nb1 {
+		t.Errorf("NotebookID = %v, want %d", n.NotebookID, nb1)
+	}
+	if n := mustGet(t, r, root); n.NotebookID != nil {
+		t.Errorf("NotebookID = %v, want nil", *n.NotebookID)
+	}
+
+	tests := []struct {
+		name string
+		ids  []int64
+		want []int64
+	}{
+		{"one", []int64{nb1}, []int64{a}},
+		{"several", []int64{nb1, nb2}, []int64{a, b}},
+		{"empty", []int64{99}, nil},
+	}
+	for _, tt := range tests {
+		got := findAllPages(t, r, repo.NoteQuery{NotebookIDs: tt.ids})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// перенос в корень
+	if _, err := r.Update(owner, a, 0, func(n *core.Note) error {
+		n.NotebookID = nil
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if n := mustGet(t, r, a); n.NotebookID != nil {
+		t.Errorf("NotebookID after move = %v, want nil", *n.NotebookID)
+	}
+}
+
+func testOwnerIsolation(t *testing.T, r repo.NoteRepository) {
+	const other int64 = 2
+	mine := mustCreateTagged(t, r, "mine", "shared")
+	theirs, err := r.Create(core.Note{OwnerID: other, Title: "theirs", Tags: []string{"shared", "private"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if n := mustGet(t, r, mine); n.OwnerID != owner {
+		t.Errorf("OwnerID = %d, want %d", n.OwnerID, owner)
+	}
+	if _, err := r.GetByID(owner, theirs); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("GetByID(foreign): err = %v, want ErrNoteNotFound", err)
+	}
+	if _, err := r.Update(owner, theirs, 0, func(n *core.Note) error {
+		n.Title = "stolen"
+		return nil
+	}); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("Update(foreign): err = %v, want ErrNoteNotFound", err)
+	}
+	if err := r.Delete(owner, theirs, 0); !errors.Is(err, repo.ErrNoteNotFound) {
+		t.Errorf("Delete(foreign): err = %v, want ErrNoteNotFound", err)
+	}
+
+	// updateFn не может передать заметку другому владельцу
+	if _, err := r.Update(owner, mine, 0, func(n *core.Note) error {
+		n.OwnerID = other
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if n := mustGet(t, r, mine); n.OwnerID != owner {
+		t.Errorf("OwnerID after update = %d, want %d", n.OwnerID, owner)
+	}
+
+	if got := findAllPages(t, r, repo.NoteQuery{}); !reflect.DeepEqual(got, []int64{mine}) {
+		t.Errorf("Find(owner) = %v, want [%d]", got, mine)
+	}
+	if got := findAllPages(t, r, repo.NoteQuery{OwnerID: other}); !reflect.DeepEqual(got, []int64{theirs}) {
+		t.Errorf("Find(other) = %v, want [%d]", got, theirs)
+	}
+	if got := findAllPages(t, r, repo.NoteQuery{OwnerID: repo.AllOwners}); !reflect.DeepEqual(got, []int64{mine, theirs}) {
+		t.Errorf("Find(AllOwners) = %v, want [%d %d]", got, mine, theirs)
+	}
+
+	got, err := r.TagCounts(owner)
+	if err != nil {
+		t.Fatalf("TagCounts: %v", err)
+	}
+	if want := []repo.TagCount{{Tag: "shared", Count: 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TagCounts = %v, want %v", got, want)
+	}
+
+	if n, err := r.GetByID(repo.AllOwners, theirs); err != nil || n.Title != "theirs" {
+		t.Errorf("GetByID(AllOwners) = %+v, %v", n, err)
+	}
+}
+
+func testUsage(t *testing.T, r repo.NoteRepository) {
+	if u, err := r.Usage(owner); err != nil || u != (repo.NoteUsage{}) {
+		t.Errorf("Usage(empty) = %+v, %v; want zero", u, err)
+	}
+
+	id := mustCreate(t, r, "заголовок", "abc")
+	mustCreate(t, r, "t", "")
+	if _, err := r.Create(core.Note{OwnerID: owner + 1, Title: "other", Content: "ignored"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// заметка в корзине тоже занимает место
+	if _, err := r.Update(owner, id, 0, func(n *core.Note) error {
+		now := time.Now().UTC()
+		n.DeletedAt = &now
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	want := repo.NoteUsage{Notes: 2, Bytes: repo.NoteSize("заголовок", "abc") + repo.NoteSize("t", "")}
+	if u, err := r.Usage(owner); err != nil || u != want {
+		t.Errorf("Usage = %+v, %v; want %+v", u, err, want)
+	}
+
+	// объём следует за изменением, удалением и отменённым пакетом
+	if _, err := r.Update(owner, id, 0, func(n *core.Note) error {
+		n.Content = "abcdef"
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	errAbort := errors.New("abort")
+	if err := r.Batch(func(tx repo.NoteRepository) error {
+		if _, err := tx.Create(core.Note{OwnerID: owner, Title: "rolled back"}); err != nil {
+			return err
+		}
+		if err := tx.Delete(owner, id, 0); err != nil {
+			return err
+		}
+		return errAbort
+	}); !errors.Is(err, errAbort) {
+		t.Fatalf("Batch: err = %v, want errAbort", err)
+	}
+	want = repo.NoteUsage{Notes: 2, Bytes: repo.NoteSize("заголовок", "abcdef") + repo.NoteSize("t", "")}
+	if u, err := r.Usage(owner); err != nil || u != want {
+		t.Errorf("Usage after update = %+v, %v; want %+v", u, err, want)
+	}
+	if err := r.Delete(owner, id, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	want = repo.NoteUsage{Notes: 1, Bytes: repo.NoteSize("t", "")}
+	if u, err := r.Usage(owner); err != nil || u != want {
+		t.Errorf("Usage after delete = %+v, %v; want %+v", u, err, want)
+	}
+}
@@ -0,0 +1,169 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// RevisionFactory создаёт новый пустой журнал ревизий для одного подтеста.
+type RevisionFactory func(t *testing.T) repo.RevisionRepository
+
+// RunRevisions прогоняет проверки контракта RevisionRepository.
+func RunRevisions(t *testing.T, newRepo RevisionFactory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, r repo.RevisionRepository)
+	}{
+		{"AppendListGet", testRevisionsAppendListGet},
+		{"PruneKeepLast", testRevisionsPruneKeepLast},
+		{"PruneMaxAge", testRevisionsPruneMaxAge},
+		{"DeleteAll", testRevisionsDeleteAll},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func appendRevisions(t *testing.T, r repo.RevisionRepository, noteID int64, createdAt ...time.Time) {
+	t.Helper()
+	for i, at := range createdAt {
+		rev := core.NoteRevision{
+			NoteID:    noteID,
+			Revision:  int64(i + 1),
+			Title:     "title",
+			Content:   "content",
+			CreatedAt: at,
+		}
+		if err := r.Append(rev); err != nil {
+			t.Fatalf("Append(%d/%d): %v", noteID, rev.Revision, err)
+		}
+	}
+}
+
+func revisionNumbers(t *testing.T, r repo.RevisionRepository, noteID int64) []int64 {
+	t.Helper()
+	revs, err := r.List(noteID)
+	if err != nil {
+		t.Fatalf("List(%d): %v", noteID, err)
+	}
+	nums := make([]int64, len(revs))
+	for i, rev := range revs {
+		if rev.NoteID != noteID {
+			t.Errorf("List(%d) returned revision of note %d", noteID, rev.NoteID)
+		}
+		nums[i] = rev.Revision
+	}
+	return nums
+}
+
+func equalInt64s(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func testRevisionsAppendListGet(t *testing.T, r repo.RevisionRepository) {
+	now := time.Now().UTC()
+	// добавляем не по порядку: List всё равно сортирует по номеру
+	for _, n := range []int64{2, 1, 3} {
+		if err := r.Append(core.NoteRevision{NoteID: 1, Revision: n, Title: "t", CreatedAt: now}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	appendRevisions(t, r, 2, now)
+
+	if got := revisionNumbers(t, r, 1); !equalInt64s(got, []int64{1, 2, 3}) {
+		t.Errorf("List(1) = %v, want [1 2 3]", got)
+	}
+	if got := revisionNumbers(t, r, 3); len(got) != 0 {
+		t.Errorf("List of note without revisions = %v, want empty", got)
+	}
+
+	rev, err := r.Get(1, 2)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if rev.NoteID != 1 || rev.Revision != 2 || rev.Title != "t" || !rev.CreatedAt.Equal(now) {
+		t.Errorf("Get(1, 2) = %+v", rev)
+	}
+	if _, err := r.Get(1, 99); !errors.Is(err, repo.ErrRevisionNotFound) {
+		t.Errorf("Get missing: err = %v, want ErrRevisionNotFound", err)
+	}
+}
+
+func testRevisionsPruneKeepLast(t *testing.T, r repo.RevisionRepository) {
+	now := time.Now().UTC()
+	appendRevisions(t, r, 1, now, now, now, now, now)
+	appendRevisions(t, r, 2, now, now)
+
+	if err := r.Prune(1, repo.RevisionRetention{KeepLast: 2}); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if got := revisionNumbers(t, r, 1); !equalInt64s(got, []int64{4, 5}) {
+		t.Errorf("after Prune(KeepLast: 2) = %v, want [4 5]", got)
+	}
+	if got := revisionNumbers(t, r, 2); !equalInt64s(got, []int64{1, 2}) {
+		t.Errorf("Prune touched another note: %v", got)
+	}
+
+	if err := r.Prune(1, repo.RevisionRetention{}); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if got := revisionNumbers(t, r, 1); !equalInt64s(got, []int64{4, 5}) {
+		t.Errorf("Prune without limits removed revisions: %v", got)
+	}
+}
+
+func testRevisionsPruneMaxAge(t *testing.T, r repo.RevisionRepository) {
+	now := time.Now().UTC()
+	old := now.Add(-48 * time.Hour)
+	appendRevisions(t, r, 1, old, old, now)
+	appendRevisions(t, r, 2, old, old)
+
+	policy := repo.RevisionRetention{MaxAge: 24 * time.Hour}
+	for _, id := range []int64{1, 2} {
+		if err := r.Prune(id, policy); err != nil {
+			t.Fatalf("Prune(%d): %v", id, err)
+		}
+	}
+	if got := revisionNumbers(t, r, 1); !equalInt64s(got, []int64{3}) {
+		t.Errorf("note 1 after Prune(MaxAge) = %v, want [3]", got)
+	}
+	// последняя ревизия остаётся, даже если она старая
+	if got := revisionNumbers(t, r, 2); !equalInt64s(got, []int64{2}) {
+		t.Errorf("note 2 after Prune(MaxAge) = %v, want [2]", got)
+	}
+}
+
+func testRevisionsDeleteAll(t *testing.T, r repo.RevisionRepository) {
+	now := time.Now().UTC()
+	appendRevisions(t, r, 1, now, now)
+	appendRevisions(t, r, 2, now)
+
+	if err := r.DeleteAll(1); err != nil {
+		t.Fatalf("DeleteAll: %v", err)
+	}
+	if got := revisionNumbers(t, r, 1); len(got) != 0 {
+		t.Errorf("revisions after DeleteAll = %v", got)
+	}
+	if got := revisionNumbers(t, r, 2); !equalInt64s(got, []int64{1}) {
+		t.Errorf("DeleteAll touched another note: %v", got)
+	}
+	if err := r.DeleteAll(42); err != nil {
+		t.Errorf("DeleteAll of unknown note: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// ShareFactory создаёт новые пустые хранилища заметок, пользователей и
+// доступов (доступ ссылается на заметку и пользователя) для одного
+// подтеста.
+type ShareFactory func(t *testing.T) (repo.NoteRepository, repo.UserRepository, repo.ShareRepository)
+
+// RunShares прогоняет проверки контракта ShareRepository.
+func RunShares(t *testing.T, newRepo ShareFactory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, notes repo.NoteRepository, users repo.UserRepository, shares repo.ShareRepository)
+	}{
+		{"PutGet", testSharesPutGet},
+		{"List", testSharesList},
+		{"Delete", testSharesDelete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes, users, shares := newRepo(t)
+			tt.fn(t, notes, users, shares)
+		})
+	}
+}
+
+func mustPutShare(t *testing.T, r repo.ShareRepository, noteID, userID int64, role core.ShareRole) {
+	t.Helper()
+	s := core.Share{NoteID: noteID, UserID: userID, Role: role, CreatedAt: time.Now().UTC()}
+	if err := r.Put(s); err != nil {
+		t.Fatalf("Put(%d, %d): %v", noteID, userID, err)
+	}
+}
+
+func testSharesPutGet(t *testing.T, notes repo.NoteRepository, users repo.UserRepository, r repo.ShareRepository) {
+	note := mustCreate(t, notes, "shared", "")
+	bob := mustCreateUser(t, users, "bob")
+
+	created := time.Now().UTC().Add(-time.Hour)
+	if err := r.Put(core.Share{NoteID: note, UserID: bob, Role: core.RoleViewer, CreatedAt: created}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	s, err := r.Get(note, bob)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if s.Role != core.RoleViewer || !s.CreatedAt.Equal(created) {
+		t.Errorf("Get = %+v, want viewer created at %v", s, created)
+	}
+
+	mustPutShare(t, r, note, bob, core.RoleEditor)
+	if s, err := r.Get(note, bob); err != nil || s.Role != core.RoleEditor || !s.CreatedAt.Equal(created) {
+		t.Errorf("Get after role change = %+v, %v; want editor with original CreatedAt", s, err)
+	}
+
+	if _, err := r.Get(note, 999); !errors.Is(err, repo.ErrShareNotFound) {
+		t.Errorf("Get(missing): err = %v, want ErrShareNotFound", err)
+	}
+}
+
+func testSharesList(t *testing.T, notes repo.NoteRepository, users repo.UserRepository, r repo.ShareRepository) {
+	n1 := mustCreate(t, notes, "one", "")
+	n2 := mustCreate(t, notes, "two", "")
+	bob := mustCreateUser(t, users, "bob")
+	carol := mustCreateUser(t, users, "carol")
+
+	mustPutShare(t, r, n1, carol, core.RoleViewer)
+	mustPutShare(t, r, n1, bob, core.RoleEditor)
+	mustPutShare(t, r, n2, bob, core.RoleViewer)
+
+	byNote, err := r.ListByNote(n1)
+	if err != nil {
+		t.Fatalf("ListByNote: %v", err)
+	}
+	if len(byNote) != 2 || byNote[0].UserID != bob || byNote[1].UserID != carol {
+		t.Errorf("ListByNote = %+v, want [%d %d]", byNote, bob, carol)
+	}
+
+	byUser, err := r.ListByUser(bob)
+	if err != nil {
+		t.Fatalf("ListByUser: %v", err)
+	}
+	if len(byUser) != 2 || byUser[0].NoteID != n1 || byUser[0].Role != core.RoleEditor || byUser[1].NoteID != n2 {
+		t.Errorf("ListByUser = %+v, want notes [%d %d]", byUser, n1, n2)
+	}
+
+	if list, err := r.ListByNote(999); err != nil || len(list) != 0 {
+		t.Errorf("ListByNote(missing) = %+v, %v; want empty", list, err)
+	}
+}
+
+func testSharesDelete(t *testing.T, notes repo.NoteRepository, users repo.UserRepository, r repo.ShareRepository) {
+	n1 := mustCreate(t, notes, "one", "")
+	n2 := mustCreate(t, notes, "two", "")
+	bob := mustCreateUser(t, users, "bob")
+	carol := mustCreateUser(t, users, "carol")
+
+	mustPutShare(t, r, n1, bob, core.RoleViewer)
+	mustPutShare(t, r, n1, carol, core.RoleViewer)
+	mustPutShare(t, r, n2, bob, core.RoleViewer)
+
+	if err := r.Delete(n1, bob); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := r.Delete(n1, bob); !errors.Is(err, repo.ErrShareNotFound) {
+		t.Errorf("second Delete: err = %v, want ErrShareNotFound", err)
+	}
+
+	if err := r.DeleteByNote(n1); err != nil {
+		t.Fatalf("DeleteByNote: %v", err)
+	}
+	if list, err := r.ListByNote(n1); err != nil || len(list) != 0 {
+		t.Errorf("ListByNote after DeleteByNote = %+v, %v; want empty", list, err)
+	}
+	if _, err := r.Get(n2, bob); err != nil {
+		t.Errorf("other note's share: err = %v, want it kept", err)
+	}
+}
@@ -0,0 +1,110 @@
+package repotest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// UserFactory создаёт новое пустое хранилище пользователей и сессий для
+// одного подтеста.
+type UserFactory func(t *testing.T) (repo.UserRepository, repo.SessionRepository)
+
+// RunUsers прогоняет проверки контрактов UserRepository и SessionRepository.
+func RunUsers(t *testing.T, newRepo UserFactory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, users repo.UserRepository, sessions repo.SessionRepository)
+	}{
+		{"Users", testUsers},
+		{"Sessions", testSessions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, sessions := newRepo(t)
+			tt.fn(t, users, sessions)
+		})
+	}
+}
+
+func mustCreateUser(t *testing.T, r repo.UserRepository, username string) int64 {
+	t.Helper()
+	id, err := r.Create(core.User{Username: username, PasswordHash: []byte("hash-" + username)})
+	if err != nil {
+		t.Fatalf("Create(%q): %v", username, err)
+	}
+	return id
+}
+
+func testUsers(t *testing.T, r repo.UserRepository, _ repo.SessionRepository) {
+	alice := mustCreateUser(t, r, "alice")
+	bob := mustCreateUser(t, r, "bob")
+	if alice <= 0 || bob <= alice {
+		t.Errorf("IDs = %d, %d, want increasing and > 0", alice, bob)
+	}
+
+	u, err := r.GetByID(alice)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if u.Username != "alice" || string(u.PasswordHash) != "hash-alice" || u.CreatedAt.IsZero() {
+		t.Errorf("GetByID = %+v", u)
+	}
+	if u, err := r.GetByUsername("bob"); err != nil || u.ID != bob {
+		t.Errorf("GetByUsername = %+v, %v; want ID %d", u, err, bob)
+	}
+
+	if _, err := r.Create(core.User{Username: "alice", PasswordHash: []byte("x")}); !errors.Is(err, repo.ErrUserExists) {
+		t.Errorf("Create(duplicate): err = %v, want ErrUserExists", err)
+	}
+	if _, err := r.GetByID(999); !errors.Is(err, repo.ErrUserNotFound) {
+		t.Errorf("GetByID(missing): err = %v, want ErrUserNotFound", err)
+	}
+	if _, err := r.GetByUsername("carol"); !errors.Is(err, repo.ErrUserNotFound) {
+		t.Errorf("GetByUsername(missing): err = %v, want ErrUserNotFound", err)
+	}
+}
+
+func testSessions(t *testing.T, users repo.UserRepository, r repo.SessionRepository) {
+	user := mustCreateUser(t, users, "alice")
+	now := time.Now().UTC()
+
+	live := core.Session{TokenHash: "live", UserID: user, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	expired := core.Session{TokenHash: "expired", UserID: user, CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+	for _, s := range []core.Session{live, expired} {
+		if err := r.Create(s); err != nil {
+			t.Fatalf("Create(%s): %v", s.TokenHash, err)
+		}
+	}
+
+	s, err := r.Get("live")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if s.UserID != user || !s.ExpiresAt.Equal(live.ExpiresAt) {
+		t.Errorf("Get = %+v, want %+v", s, live)
+	}
+
+	n, err := r.DeleteExpired(now)
+	if err != nil || n != 1 {
+		t.Errorf("DeleteExpired = %d, %v; want 1", n, err)
+	}
+	if _, err := r.Get("expired"); !errors.Is(err, repo.ErrSessionNotFound) {
+		t.Errorf("Get(expired): err = %v, want ErrSessionNotFound", err)
+	}
+
+	if err := r.Delete("live"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := r.Get("live"); !errors.Is(err, repo.ErrSessionNotFound) {
+		t.Errorf("Get after Delete: err = %v, want ErrSessionNotFound", err)
+	}
+	if err := r.Delete("live"); !errors.Is(err, repo.ErrSessionNotFound) {
+		t.Errorf("second Delete: err = %v, want ErrSessionNotFound", err)
+	}
+}
@@ -0,0 +1,220 @@
+package repotest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+)
+
+// WebhookFactory создаёт новое пустое хранилище веб-хуков и
+// пользователей, на которых они ссылаются, для одного подтеста.
+type WebhookFactory func(t *testing.T) (repo.UserRepository, repo.WebhookRepository)
+
+// RunWebhooks прогоняет проверки контракта WebhookRepository.
+func RunWebhooks(t *testing.T, newRepo WebhookFactory) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, users repo.UserRepository, hooks repo.WebhookRepository)
+	}{
+		{"CreateGet", testWebhookCreateGet},
+		{"Deliveries", testWebhookDeliveries},
+		{"DueAndPrune", testWebhookDueAndPrune},
+		{"DeleteCascades", testWebhookDeleteCascades},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, hooks := newRepo(t)
+			tt.fn(t, users, hooks)
+		})
+	}
+}
+
+func mustCreateWebhook(t *testing.T, r repo.WebhookRepository, userID int64) int64 {
+	t.Helper()
+	id, err := r.Create(core.Webhook{
+		UserID: userID, URL: "https://example.com/hook", Events: []string{core.EventNoteCreated, core.EventNoteDeleted},
+		Secret: "whsec_test", CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return id
+}
+
+func mustAddDelivery(t *testing.T, r repo.WebhookRepository, userID, hookID int64, next time.Time) int64 {
+	t.Helper()
+	id, err := r.AddDelivery(core.WebhookDelivery{
+		WebhookID: hookID, UserID: userID, Event: core.EventNoteCreated, NoteID: 1,
+		Payload: []byte(`{"type":"note.created"}`), Status: core.DeliveryPending,
+		CreatedAt: time.Now().UTC(), NextAttemptAt: &next,
+	})
+	if err != nil {
+		t.Fatalf("AddDelivery: %v", err)
+	}
+	return id
+}
+
+func testWebhookCreateGet(t *testing.T, users repo.UserRepository, r repo.WebhookRepository) {
+	alice := mustCreateUser(t, users, "alice")
+	bob := mustCreateUser(t, users, "bob")
+	id := mustCreateWebhook(t, r, alice)
+	second := mustCreateWebhook(t, r, alice)
+
+	h, err := r.Get(alice, id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if h.ID != id || h.UserID != alice || h.URL != "https://example.com/hook" || h.Secret != "whsec_test" ||
+		!slices.Equal(h.Events, []string{core.EventNoteCreated, core.EventNoteDeleted}) || h.CreatedAt.IsZero() {
+		t.Errorf("Get = %+v", h)
+	}
+	if _, err := r.Get(bob, id); !errors.Is(err, repo.ErrWebhookNotFound) {
+		t.Errorf("Get as other user: err = %v, want ErrWebhookNotFound", err)
+	}
+
+	list, err := r.List(alice)
+	if err != nil || len(list) != 2 || list[0].ID != id || list[1].ID != second {
+		t.Errorf("List = %+v, %v; want [%d %d]", list, err, id, second)
+	}
+	if list, _ := r.List(bob); len(list) != 0 {
+		t.Errorf("List(bob) = %+v, want empty", list)
+	}
+
+	if err := r.Delete(bob, id); !errors.Is(err, repo.ErrWebhookNotFound) {
+		t.Errorf("Delete as other user: err = %v, want ErrWebhookNotFound", err)
+	}
+	if err := r.Delete(alice, id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := r.Get(alice, id); !errors.Is(err, repo.ErrWebhookNotFound) {
+		t.Errorf("Get after Delete: err = %v, want ErrWebhookNotFound", err)
+	}
+}
+
+func testWebhookDeliveries(t *testing.T, users repo.UserRepository, r repo.WebhookRepository) {
+	alice := mustCreateUser(t, users, "alice")
+	bob := mustCreateUser(t, users, "bob")
+	hook := mustCreateWebhook(t, r, alice)
+	other := mustCreateWebhook(t, r, alice)
+	now := time.Now().UTC()
+
+	first := mustAddDelivery(t, r, alice, hook, now)
+	second := mustAddDelivery(t, r, alice, hook, now)
+	mustAddDelivery(t, r, alice, other, now)
+
+	d, err := r.GetDelivery(alice, hook, first)
+	if err != nil {
+		t.Fatalf("GetDelivery: %v", err)
+	}
+	if d.Status != core.DeliveryPending || string(d.Payload) != `{"type":"note.created"}` || d.NextAttemptAt == nil {
+		t.Errorf("GetDelivery = %+v", d)
+	}
+	for _, tt := range []struct{ user, hook int64 }{{bob, hook}, {alice, other}} {
+		if _, err := r.GetDelivery(tt.user, tt.hook, first); !errors.Is(err, repo.ErrDeliveryNotFound) {
+			t.Errorf("GetDelivery(%d, %d): err = %v, want ErrDeliveryNotFound", tt.user, tt.hook, err)
+		}
+	}
+
+	d.Status = core.DeliveryDead
+	d.Attempts = 3
+	d.NextAttemptAt = nil
+	d.LastAttemptAt = &now
+	d.ResponseStatus = 503
+	d.LastError = "unexpected status 503"
+	if err := r.UpdateDelivery(*d); err != nil {
+		t.Fatalf("UpdateDelivery: %v", err)
+	}
+	got, _ := r.GetDelivery(alice, hook, first)
+	if got.Status != core.DeliveryDead || got.Attempts != 3 || got.NextAttemptAt != nil ||
+		got.LastAttemptAt == nil || got.ResponseStatus != 503 || got.LastError != "unexpected status 503" {
+		t.Errorf("after UpdateDelivery = %+v", got)
+	}
+
+	list, err := r.ListDeliveries(repo.DeliveryQuery{UserID: alice, WebhookID: hook})
+	if err != nil || len(list) != 2 || list[0].ID != second || list[1].ID != first {
+		t.Errorf("ListDeliveries = %+v, %v; want [%d %d]", list, err, second, first)
+	}
+	dead, _ := r.ListDeliveries(repo.DeliveryQuery{UserID: alice, WebhookID: hook, Status: core.DeliveryDead})
+	if len(dead) != 1 || dead[0].ID != first {
+		t.Errorf("dead deliveries = %+v, want [%d]", dead, first)
+	}
+	if page, _ := r.ListDeliveries(repo.DeliveryQuery{UserID: alice, WebhookID: hook, Limit: 1}); len(page) != 1 || page[0].ID != second {
+		t.Errorf("limited deliveries = %+v, want [%d]", page, second)
+	}
+	if list, _ := r.ListDeliveries(repo.DeliveryQuery{UserID: bob, WebhookID: hook}); len(list) != 0 {
+		t.Errorf("deliveries as other user = %+v, want empty", list)
+	}
+
+	if _, err := r.AddDelivery(core.WebhookDelivery{WebhookID: 999, UserID: alice, Status: core.DeliveryPending, Payload: []byte(`{}`)}); !errors.Is(err, repo.ErrWebhookNotFound) {
+		t.Errorf("AddDelivery to missing webhook: err = %v, want ErrWebhookNotFound", err)
+	}
+	if err := r.UpdateDelivery(core.WebhookDelivery{ID: 999}); !errors.Is(err, repo.ErrDeliveryNotFound) {
+		t.Errorf("UpdateDelivery missing: err = %v, want ErrDeliveryNotFound", err)
+	}
+}
+
+func testWebhookDueAndPrune(t *testing.T, users repo.UserRepository, r repo.WebhookRepository) {
+	alice := mustCreateUser(t, users, "alice")
+	hook := mustCreateWebhook(t, r, alice)
+	now := time.Now().UTC()
+
+	due := mustAddDelivery(t, r, alice, hook, now.Add(-time.Minute))
+	dueNow := mustAddDelivery(t, r, alice, hook, now)
+	mustAddDelivery(t, r, alice, hook, now.Add(time.Minute))
+	done := mustAddDelivery(t, r, alice, hook, now.Add(-time.Hour))
+
+	d, _ := r.GetDelivery(alice, hook, done)
+	old := now.Add(-48 * time.Hour)
+	d.Status, d.NextAttemptAt, d.LastAttemptAt = core.DeliveryDelivered, nil, &old
+	if err := r.UpdateDelivery(*d); err != nil {
+		t.Fatalf("UpdateDelivery: %v", err)
+	}
+
+	list, err := r.DueDeliveries(now, 0)
+	if err != nil {
+		t.Fatalf("DueDeliveries: %v", err)
+	}
+	var ids []int64
+	for _, d := range list {
+		ids = append(ids, d.ID)
+	}
+	if !slices.Equal(ids, []int64{due, dueNow}) {
+		t.Errorf("DueDeliveries = %v, want [%d %d]", ids, due, dueNow)
+	}
+	if list, _ := r.DueDeliveries(now, 1); len(list) != 1 || list[0].ID != due {
+		t.Errorf("DueDeliveries limit 1 = %+v, want [%d]", list, due)
+	}
+
+	n, err := r.PruneDeliveries(now.Add(-24 * time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("PruneDeliveries = %d, %v; want 1", n, err)
+	}
+	if _, err := r.GetDelivery(alice, hook, done); !errors.Is(err, repo.ErrDeliveryNotFound) {
+		t.Errorf("pruned delivery: err = %v, want ErrDeliveryNotFound", err)
+	}
+	if list, _ := r.ListDeliveries(repo.DeliveryQuery{UserID: alice, WebhookID: hook}); len(list) != 3 {
+		t.Errorf("pending deliveries after prune = %d, want 3", len(list))
+	}
+}
+
+func testWebhookDeleteCascades(t *testing.T, users repo.UserRepository, r repo.WebhookRepository) {
+	alice := mustCreateUser(t, users, "alice")
+	hook := mustCreateWebhook(t, r, alice)
+	id := mustAddDelivery(t, r, alice, hook, time.Now().UTC())
+
+	if err := r.Delete(alice, hook); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := r.GetDelivery(alice, hook, id); !errors.Is(err, repo.ErrDeliveryNotFound) {
+		t.Errorf("delivery of deleted webhook: err = %v, want ErrDeliveryNotFound", err)
+	}
+	if list, _ := r.DueDeliveries(time.Now().Add(time.Hour), 0); len(list) != 0 {
+		t.Errorf("DueDeliveries after Delete = %+v, want empty", list)
+	}
+}
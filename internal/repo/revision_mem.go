@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+// RevisionRepository — журнал ревизий заметок. Ревизии неизменяемы:
+// их можно только добавить или удалить при очистке.
+type RevisionRepository interface {
+	// Append сохраняет ревизию; повтор того же номера заменяет её.
+	Append(rev core.NoteRevision) error
+	// List возвращает ревизии заметки по возрастанию номера.
+	List(noteID int64) ([]core.NoteRevision, error)
+	Get(noteID, revision int64) (*core.NoteRevision, error)
+	// Prune удаляет старые ревизии заметки согласно политике хранения.
+	Prune(noteID int64, policy RevisionRetention) error
+	// DeleteAll удаляет все ревизии заметки.
+	DeleteAll(noteID int64) error
+}
+
+// RevisionRetention — политика хранения ревизий. Нулевые поля снимают
+// соответствующее ограничение; последняя ревизия не удаляется никогда.
+type RevisionRetention struct {
+	// KeepLast — сколько последних ревизий хранить.
+	KeepLast int
+	// MaxAge — сколько хранить ревизию после её создания.
+	MaxAge time.Duration
+}
+
+// keep сообщает, остаётся ли ревизия с индексом i (по возрастанию номера)
+// из total при данной политике.
+func (p RevisionRetention) keep(rev core.NoteRevision, i, total int, now time.Time) bool {
+	if i == total-1 {
+		return true
+	}
+	if p.KeepLast > 0 && i < total-p.KeepLast {
+		return false
+	}
+	if p.MaxAge > 0 && now.Sub(rev.CreatedAt) > p.MaxAge {
+		return false
+	}
+	return true
+}
+
+// RevisionRepoMem — in-memory реализация RevisionRepository.
+type RevisionRepoMem struct {
+	mu   sync.RWMutex
+	revs map[int64][]core.NoteRevision // по возрастанию номера
+}
+
+func NewRevisionRepoMem() *RevisionRepoMem {
+	return &RevisionRepoMem{
+		revs: make(map[int64][]core.NoteRevision),
+	}
+}
+
+func (r *RevisionRepoMem) Append(rev core.NoteRevision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := r.revs[rev.NoteID]
+	i := sort.Search(len(list), func(i int) bool { return list[i].Revision >= rev.Revision })
+	if i < len(list) && list[i].Revision == rev.Revision {
+		list[i] = rev
+		return nil
+	}
+	list = append(list, core.NoteRevision{})
+	copy(list[i+1:], list[i:])
+	list[i] = rev
+	r.revs[rev.NoteID] = list
+	return nil
+}
+
+func (r *RevisionRepoMem) List(noteID int64) ([]core.NoteRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := r.revs[noteID]
+	result := make([]core.NoteRevision, len(list))
+	copy(result, list)
+	return result, nil
+}
+
+func (r *RevisionRepoMem) Get(noteID, revision int64) (*core.NoteRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rev := range r.revs[noteID] {
+		if rev.Revision == revision {
+			found := rev
+			return &found, nil
+		}
+	}
+	return nil, ErrRevisionNotFound
+}
+
+func (r *RevisionRepoMem) Prune(noteID int64, policy RevisionRetention) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := r.revs[noteID]
+	now := time.Now().UTC()
+	kept := list[:0]
+	for i, rev := range list {
+		if policy.keep(rev, i, len(list), now) {
+			kept = append(kept, rev)
+		}
+	}
+	r.revs[noteID] = kept
+	return nil
+}
+
+func (r *RevisionRepoMem) DeleteAll(noteID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.revs, noteID)
+	return nil
+}
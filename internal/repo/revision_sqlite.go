@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+const revisionSchemaSQLite = `
+CREATE TABLE IF NOT EXISTS note_revisions (
+	note_id    INTEGER NOT NULL,
+	revision   INTEGER NOT NULL,
+	title      TEXT    NOT NULL,
+	content    TEXT    NOT NULL,
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY (note_id, revision)
+);`
+
+// RevisionRepoSQLite — реализация RevisionRepository поверх SQLite.
+type RevisionRepoSQLite struct {
+	db *sql.DB
+}
+
+// NewRevisionRepoSQLite создаёт репозиторий и при необходимости схему.
+func NewRevisionRepoSQLite(db *sql.DB) (*RevisionRepoSQLite, error) {
+	if _, err := db.Exec(revisionSchemaSQLite); err != nil {
+		return nil, err
+	}
+	return &RevisionRepoSQLite{db: db}, nil
+}
+
+func scanRevision(s rowScanner) (*core.NoteRevision, error) {
+	var (
+		rev       core.NoteRevision
+		createdAt int64
+	)
+	if err := s.Scan(&rev.NoteID, &rev.Revision, &rev.Title, &rev.Content, &createdAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	rev.CreatedAt = time.Unix(0, createdAt).UTC()
+	return &rev, nil
+}
+
+func (r *RevisionRepoSQLite) Append(rev core.NoteRevision) error {
+	_, err := r.db.Exec(
+		`INSERT OR REPLACE INTO note_revisions (note_id, revision, title, content, created_at) VALUES (?, ?, ?, ?, ?)`,
+		rev.NoteID, rev.Revision, rev.Title, rev.Content, rev.CreatedAt.UnixNano(),
+	)
+	return err
+}
+
+func (r *RevisionRepoSQLite) List(noteID int64) ([]core.NoteRevision, error) {
+	rows, err := r.db.Query(
+		`SELECT note_id, revision, title, content, created_at FROM note_revisions WHERE note_id = ? ORDER BY revision`,
+		noteID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]core.NoteRevision, 0)
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *rev)
+	}
+	return result, rows.Err()
+}
+
+func (r *RevisionRepoSQLite) Get(noteID, revision int64) (*core.NoteRevision, error) {
+	return scanRevision(r.db.QueryRow(
+		`SELECT note_id, revision, title, content, created_at FROM note_revisions WHERE note_id = ? AND revision = ?`,
+		noteID, revision,
+	))
+}
+
+func (r *RevisionRepoSQLite) Prune(noteID int64, policy RevisionRetention) error {
+	revs, err := r.List(noteID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // после Commit — no-op
+
+	for i, rev := range revs {
+		if policy.keep(rev, i, len(revs), now) {
+			continue
+		}
+		if _, err := tx.Exec(`DELETE FROM note_revisions WHERE note_id = ? AND revision = ?`, noteID, rev.Revision); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *RevisionRepoSQLite) DeleteAll(noteID int64) error {
+	_, err := r.db.Exec(`DELETE FROM note_revisions WHERE note_id = ?`, noteID)
+	return err
+}
@@ -0,0 +1,25 @@
+package repo_test
+
+import (
+	"testing"
+
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/repo/repotest"
+)
+
+func TestRevisionRepoMem(t *testing.T) {
+	repotest.RunRevisions(t, func(t *testing.T) repo.RevisionRepository {
+		return repo.NewRevisionRepoMem()
+	})
+}
+
+func TestRevisionRepoSQLite(t *testing.T) {
+	repotest.RunRevisions(t, func(t *testing.T) repo.RevisionRepository {
+		db := openTestDB(t)
+		r, err := repo.NewRevisionRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewRevisionRepoSQLite: %v", err)
+		}
+		return r
+	})
+}
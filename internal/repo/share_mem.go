@@ -0,0 +1,120 @@
+package repo
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"example.com/notes-api/internal/core"
+)
+
+var ErrShareNotFound = errors.New("share not found")
+
+// ShareRepository — хранилище доступов к заметкам. Ключ — пара
+// (заметка, пользователь); права проверяет сервис.
+type ShareRepository interface {
+	// Put выдаёт доступ или меняет роль уже выданного; CreatedAt при
+	// смене роли не меняется.
+	Put(s core.Share) error
+	Get(noteID, userID int64) (*core.Share, error)
+	Delete(noteID, userID int64) error
+	// DeleteByNote удаляет все доступы к заметке.
+	DeleteByNote(noteID int64) error
+	// ListByNote возвращает доступы к заметке по возрастанию UserID.
+	ListByNote(noteID int64) ([]core.Share, error)
+	// ListByUser возвращает доступы пользователя по возрастанию NoteID.
+	ListByUser(userID int64) ([]core.Share, error)
+}
+
+type shareKey struct {
+	noteID, userID int64
+}
+
+// ShareRepoMem — in-memory реализация ShareRepository. Username не
+// хранится: его подставляет сервис.
+type ShareRepoMem struct {
+	mu     sync.RWMutex
+	shares map[shareKey]core.Share
+}
+
+func NewShareRepoMem() *ShareRepoMem {
+	return &ShareRepoMem{
+		shares: make(map[shareKey]core.Share),
+	}
+}
+
+func (r *ShareRepoMem) Put(s core.Share) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := shareKey{s.NoteID, s.UserID}
+	if old, ok := r.shares[k]; ok {
+		s.CreatedAt = old.CreatedAt
+	}
+	s.Username = ""
+	r.shares[k] = s
+	return nil
+}
+
+func (r *ShareRepoMem) Get(noteID, userID int64) (*core.Share, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.shares[shareKey{noteID, userID}]
+	if !ok {
+		return nil, ErrShareNotFound
+	}
+	return &s, nil
+}
+
+func (r *ShareRepoMem) Delete(noteID, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := shareKey{noteID, userID}
+	if _, ok := r.shares[k]; !ok {
+		return ErrShareNotFound
+	}
+	delete(r.shares, k)
+	return nil
+}
+
+func (r *ShareRepoMem) DeleteByNote(noteID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k := range r.shares {
+		if k.noteID == noteID {
+			delete(r.shares, k)
+		}
+	}
+	return nil
+}
+
+func (r *ShareRepoMem) ListByNote(noteID int64) ([]core.Share, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]core.Share, 0)
+	for k, s := range r.shares {
+		if k.noteID == noteID {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UserID < out[j].UserID })
+	return out, nil
+}
+
+func (r *ShareRepoMem) ListByUser(userID int64) ([]core.Share, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]core.Share, 0)
+	for k, s := range r.shares {
+		if k.userID == userID {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].NoteID < out[j].NoteID })
+	return out, nil
+}
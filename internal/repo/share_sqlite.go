@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+// Доступы удаляются вместе с заметкой или пользователем.
+const shareSchemaSQLite = `
+CREATE TABLE IF NOT EXISTS note_shares (
+	note_id    INTEGER NOT NULL REFERENCES notes (id) ON DELETE CASCADE,
+	user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	role       TEXT    NOT NULL,
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY (note_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS note_shares_user ON note_shares (user_id, note_id);`
+
+// ShareRepoSQLite — реализация ShareRepository поверх SQLite.
+type ShareRepoSQLite struct {
+	db *sql.DB
+}
+
+// NewShareRepoSQLite создаёт репозиторий и при необходимости таблицу
+// доступов. Таблицы заметок и пользователей создают их репозитории.
+func NewShareRepoSQLite(db *sql.DB) (*ShareRepoSQLite, error) {
+	if _, err := db.Exec(shareSchemaSQLite); err != nil {
+		return nil, err
+	}
+	return &ShareRepoSQLite{db: db}, nil
+}
+
+func scanShare(s rowScanner) (*core.Share, error) {
+	var (
+		sh        core.Share
+		role      string
+		createdAt int64
+	)
+	if err := s.Scan(&sh.NoteID, &sh.UserID, &role, &createdAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrShareNotFound
+		}
+		return nil, err
+	}
+	sh.Role = core.ShareRole(role)
+	sh.CreatedAt = time.Unix(0, createdAt).UTC()
+	return &sh, nil
+}
+
+func (r *ShareRepoSQLite) Put(s core.Share) error {
+	_, err := r.db.Exec(
+		`INSERT INTO note_shares (note_id, user_id, role, created_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (note_id, user_id) DO UPDATE SET role = excluded.role`,
+		s.NoteID, s.UserID, string(s.Role), s.CreatedAt.UnixNano(),
+	)
+	return err
+}
+
+func (r *ShareRepoSQLite) Get(noteID, userID int64) (*core.Share, error) {
+	return scanShare(r.db.QueryRow(
+		`SELECT note_id, user_id, role, created_at FROM note_shares WHERE note_id = ? AND user_id = ?`, noteID, userID,
+	))
+}
+
+func (r *ShareRepoSQLite) Delete(noteID, userID int64) error {
+	res, err := r.db.Exec(`DELETE FROM note_shares WHERE note_id = ? AND user_id = ?`, noteID, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrShareNotFound
+	}
+	return nil
+}
+
+func (r *ShareRepoSQLite) DeleteByNote(noteID int64) error {
+	_, err := r.db.Exec(`DELETE FROM note_shares WHERE note_id = ?`, noteID)
+	return err
+}
+
+func (r *ShareRepoSQLite) ListByNote(noteID int64) ([]core.Share, error) {
+	return r.list(`SELECT note_id, user_id, role, created_at FROM note_shares WHERE note_id = ? ORDER BY user_id`, noteID)
+}
+
+func (r *ShareRepoSQLite) ListByUser(userID int64) ([]core.Share, error) {
+	return r.list(`SELECT note_id, user_id, role, created_at FROM note_shares WHERE user_id = ? ORDER BY note_id`, userID)
+}
+
+func (r *ShareRepoSQLite) list(query string, arg int64) ([]core.Share, error) {
+	rows, err := r.db.Query(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]core.Share, 0)
+	for rows.Next() {
+		s, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *s)
+	}
+	return out, rows.Err()
+}
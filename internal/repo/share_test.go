@@ -0,0 +1,54 @@
+package repo_test
+
+import (
+	"testing"
+
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/repo/repotest"
+)
+
+func TestShareRepoMem(t *testing.T) {
+	repotest.RunShares(t, func(t *testing.T) (repo.NoteRepository, repo.UserRepository, repo.ShareRepository) {
+		return repo.NewNoteRepoMem(), repo.NewUserRepoMem(), repo.NewShareRepoMem()
+	})
+}
+
+func TestShareRepoSQLite(t *testing.T) {
+	repotest.RunShares(t, func(t *testing.T) (repo.NoteRepository, repo.UserRepository, repo.ShareRepository) {
+		db := openTestDB(t)
+		notes, err := repo.NewNoteRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewNoteRepoSQLite: %v", err)
+		}
+		users, err := repo.NewUserRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewUserRepoSQLite: %v", err)
+		}
+		shares, err := repo.NewShareRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewShareRepoSQLite: %v", err)
+		}
+		return notes, users, shares
+	})
+}
+
+func TestShareLinkRepoMem(t *testing.T) {
+	repotest.RunShareLinks(t, func(t *testing.T) (repo.NoteRepository, repo.ShareLinkRepository) {
+		return repo.NewNoteRepoMem(), repo.NewShareLinkRepoMem()
+	})
+}
+
+func TestShareLinkRepoSQLite(t *testing.T) {
+	repotest.RunShareLinks(t, func(t *testing.T) (repo.NoteRepository, repo.ShareLinkRepository) {
+		db := openTestDB(t)
+		notes, err := repo.NewNoteRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewNoteRepoSQLite: %v", err)
+		}
+		links, err := repo.NewShareLinkRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewShareLinkRepoSQLite: %v", err)
+		}
+		return notes, links
+	})
+}
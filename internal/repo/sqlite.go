@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	_ "modernc.org/sqlite" // pure-Go драйвер SQLite (без cgo)
+)
+
+// OpenSQLite открывает (или создаёт) файл базы SQLite по указанному пути.
+// Соединение одно: SQLite всё равно сериализует запись, а так транзакции
+// из Update не упираются в SQLITE_BUSY.
+func OpenSQLite(path string) (*sql.DB, error) {
+	dsn := url.URL{
+		Scheme:   "file",
+		Opaque:   sqlitePathEscaper.Replace(path),
+		RawQuery: "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)",
+	}
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// sqlitePathEscaper экранирует в пути символы, которые в URI-имени
+// файла SQLite начинают параметры, фрагмент или escape-последовательность:
+// иначе путь с ними открыл бы другой файл или подменил прагмы.
+var sqlitePathEscaper = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+
+// ensureColumn добавляет колонку в существующую таблицу, если её ещё нет:
+// так база, созданная прежней версией сервиса, догоняет текущую схему.
+func ensureColumn(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close() // соединение одно: освобождаем его перед ALTER
+
+	_, err = db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
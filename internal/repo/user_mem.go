@@ -0,0 +1,225 @@
+package repo
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrUserExists      = errors.New("user already exists")
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// UserRepository — хранилище учётных записей. Имя пользователя уникально;
+// сравнение точное, нормализацию выполняет сервис.
+type UserRepository interface {
+	// Create сохраняет пользователя; занятое имя — ErrUserExists.
+	Create(u core.User) (int64, error)
+	GetByID(id int64) (*core.User, error)
+	GetByUsername(username string) (*core.User, error)
+}
+
+// SessionRepository — хранилище сессий, ключ — хеш токена.
+type SessionRepository interface {
+	Create(s core.Session) error
+	Get(tokenHash string) (*core.Session, error)
+	Delete(tokenHash string) error
+	// DeleteExpired удаляет сессии, истёкшие к моменту now, и возвращает
+	// их число.
+	DeleteExpired(now time.Time) (int, error)
+}
+
+const usersFileName = "users.json"
+
+// UserRepoMem — in-memory реализация UserRepository. Открытая через
+// OpenUserRepoMem, после каждого изменения записывает пользователей в файл.
+type UserRepoMem struct {
+	mu    sync.RWMutex
+	users map[int64]*core.User
+	next  int64
+	dir   string // "" — без сохранения на диск
+}
+
+func NewUserRepoMem() *UserRepoMem {
+	return &UserRepoMem{
+		users: make(map[int64]*core.User),
+	}
+}
+
+// usersFile — содержимое users.json. PasswordHash не сериализуется
+// в core.User, поэтому у файла своя структура записи.
+type usersFile struct {
+	Next  int64          `json:"next"`
+	Users []userFileItem `json:"users"`
+}
+
+type userFileItem struct {
+	ID           int64     `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash []byte    `json:"passwordHash"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// OpenUserRepoMem загружает пользователей из каталога dir (если файл уже
+// есть) и сохраняет туда каждое изменение. Без сохранения ID
+// пользователей после перезапуска выдавались бы заново, и новый
+// пользователь получил бы чужие заметки.
+func OpenUserRepoMem(dir string) (*UserRepoMem, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	r := NewUserRepoMem()
+	r.dir = dir
+
+	data, err := os.ReadFile(filepath.Join(dir, usersFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f usersFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	r.next = f.Next
+	for _, u := range f.Users {
+		r.users[u.ID] = &core.User{ID: u.ID, Username: u.Username, PasswordHash: u.PasswordHash, CreatedAt: u.CreatedAt}
+		if u.ID > r.next {
+			r.next = u.ID
+		}
+	}
+	return r, nil
+}
+
+// save записывает пользователей в файл. Вызывается под r.mu.
+func (r *UserRepoMem) save() error {
+	if r.dir == "" {
+		return nil
+	}
+	f := usersFile{Next: r.next, Users: make([]userFileItem, 0, len(r.users))}
+	for _, u := range r.users {
+		f.Users = append(f.Users, userFileItem{ID: u.ID, Username: u.Username, PasswordHash: u.PasswordHash, CreatedAt: u.CreatedAt})
+	}
+	sort.Slice(f.Users, func(i, j int) bool { return f.Users[i].ID < f.Users[j].ID })
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.dir, usersFileName, data)
+}
+
+func cloneUser(u *core.User) *core.User {
+	c := *u
+	c.PasswordHash = append([]byte(nil), u.PasswordHash...)
+	return &c
+}
+
+func (r *UserRepoMem) Create(u core.User) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Username == u.Username {
+			return 0, ErrUserExists
+		}
+	}
+	r.next++
+	u.ID = r.next
+	u.CreatedAt = time.Now().UTC()
+	r.users[u.ID] = cloneUser(&u)
+	if err := r.save(); err != nil {
+		delete(r.users, u.ID)
+		return 0, err
+	}
+	return u.ID, nil
+}
+
+func (r *UserRepoMem) GetByID(id int64) (*core.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return cloneUser(u), nil
+}
+
+func (r *UserRepoMem) GetByUsername(username string) (*core.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.Username == username {
+			return cloneUser(u), nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// SessionRepoMem — in-memory реализация SessionRepository. Сессии не
+// переживают перезапуск: пользователям нужно войти заново.
+type SessionRepoMem struct {
+	mu       sync.RWMutex
+	sessions map[string]core.Session
+}
+
+func NewSessionRepoMem() *SessionRepoMem {
+	return &SessionRepoMem{
+		sessions: make(map[string]core.Session),
+	}
+}
+
+func (r *SessionRepoMem) Create(s core.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions[s.TokenHash] = s
+	return nil
+}
+
+func (r *SessionRepoMem) Get(tokenHash string) (*core.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.sessions[tokenHash]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return &s, nil
+}
+
+func (r *SessionRepoMem) Delete(tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sessions[tokenHash]; !ok {
+		return ErrSessionNotFound
+	}
+	delete(r.sessions, tokenHash)
+	return nil
+}
+
+func (r *SessionRepoMem) DeleteExpired(now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for hash, s := range r.sessions {
+		if !s.ExpiresAt.After(now) {
+			delete(r.sessions, hash)
+			n++
+		}
+	}
+	return n, nil
+}
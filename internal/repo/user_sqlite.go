@@ -0,0 +1,143 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"example.com/notes-api/internal/core"
+)
+
+const userSchemaSQLite = `
+CREATE TABLE IF NOT EXISTS users (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	username      TEXT    NOT NULL UNIQUE,
+	password_hash BLOB    NOT NULL,
+	created_at    INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS sessions (
+	token_hash TEXT    PRIMARY KEY,
+	user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	created_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sessions_expires ON sessions (expires_at);`
+
+// UserRepoSQLite — реализация UserRepository поверх SQLite.
+type UserRepoSQLite struct {
+	db *sql.DB
+}
+
+// NewUserRepoSQLite создаёт репозиторий и при необходимости схему
+// пользователей и сессий.
+func NewUserRepoSQLite(db *sql.DB) (*UserRepoSQLite, error) {
+	if _, err := db.Exec(userSchemaSQLite); err != nil {
+		return nil, err
+	}
+	return &UserRepoSQLite{db: db}, nil
+}
+
+func scanUser(s rowScanner) (*core.User, error) {
+	var (
+		u         core.User
+		createdAt int64
+	)
+	if err := s.Scan(&u.ID, &u.Username, &u.PasswordHash, &createdAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	u.CreatedAt = time.Unix(0, createdAt).UTC()
+	return &u, nil
+}
+
+func (r *UserRepoSQLite) Create(u core.User) (int64, error) {
+	res, err := r.db.Exec(
+		`INSERT INTO users (username, password_hash, created_at) VALUES (?, ?, ?)`,
+		u.Username, u.PasswordHash, time.Now().UTC().UnixNano(),
+	)
+	if err != nil {
+		var serr *sqlite.Error
+		if errors.As(err, &serr) && serr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return 0, ErrUserExists
+		}
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *UserRepoSQLite) GetByID(id int64) (*core.User, error) {
+	return scanUser(r.db.QueryRow(`SELECT id, username, password_hash, created_at FROM users WHERE id = ?`, id))
+}
+
+func (r *UserRepoSQLite) GetByUsername(username string) (*core.User, error) {
+	return scanUser(r.db.QueryRow(`SELECT id, username, password_hash, created_at FROM users WHERE username = ?`, username))
+}
+
+// SessionRepoSQLite — реализация SessionRepository поверх SQLite.
+// Схема общая с UserRepoSQLite: сессии ссылаются на пользователей.
+type SessionRepoSQLite struct {
+	db *sql.DB
+}
+
+func NewSessionRepoSQLite(db *sql.DB) (*SessionRepoSQLite, error) {
+	if _, err := db.Exec(userSchemaSQLite); err != nil {
+		return nil, err
+	}
+	return &SessionRepoSQLite{db: db}, nil
+}
+
+func (r *SessionRepoSQLite) Create(s core.Session) error {
+	_, err := r.db.Exec(
+		`INSERT INTO sessions (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		s.TokenHash, s.UserID, s.CreatedAt.UnixNano(), s.ExpiresAt.UnixNano(),
+	)
+	return err
+}
+
+func (r *SessionRepoSQLite) Get(tokenHash string) (*core.Session, error) {
+	var (
+		s                    core.Session
+		createdAt, expiresAt int64
+	)
+	err := r.db.QueryRow(
+		`SELECT token_hash, user_id, created_at, expires_at FROM sessions WHERE token_hash = ?`, tokenHash,
+	).Scan(&s.TokenHash, &s.UserID, &createdAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.CreatedAt = time.Unix(0, createdAt).UTC()
+	s.ExpiresAt = time.Unix(0, expiresAt).UTC()
+	return &s, nil
+}
+
+func (r *SessionRepoSQLite) Delete(tokenHash string) error {
+	res, err := r.db.Exec(`DELETE FROM sessions WHERE token_hash = ?`, tokenHash)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *SessionRepoSQLite) DeleteExpired(now time.Time) (int, error) {
+	res, err := r.db.Exec(`DELETE FROM sessions WHERE expires_at <= ?`, now.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,105 @@
+package repo_test
+
+import (
+	"testing"
+
+	"example.com/notes-api/internal/core"
+	"example.com/notes-api/internal/repo"
+	"example.com/notes-api/internal/repo/repotest"
+)
+
+func TestUserRepoMem(t *testing.T) {
+	repotest.RunUsers(t, func(t *testing.T) (repo.UserRepository, repo.SessionRepository) {
+		return repo.NewUserRepoMem(), repo.NewSessionRepoMem()
+	})
+}
+
+func TestUserRepoMemFile(t *testing.T) {
+	repotest.RunUsers(t, func(t *testing.T) (repo.UserRepository, repo.SessionRepository) {
+		r, err := repo.OpenUserRepoMem(t.TempDir())
+		if err != nil {
+			t.Fatalf("OpenUserRepoMem: %v", err)
+		}
+		return r, repo.NewSessionRepoMem()
+	})
+}
+
+func TestUserRepoMemReload(t *testing.T) {
+	dir := t.TempDir()
+	r, err := repo.OpenUserRepoMem(dir)
+	if err != nil {
+		t.Fatalf("OpenUserRepoMem: %v", err)
+	}
+	id, err := r.Create(core.User{Username: "alice", PasswordHash: []byte("secret-hash")})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	r, err = repo.OpenUserRepoMem(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if u, err := r.GetByUsername("alice"); err != nil || u.ID != id || string(u.PasswordHash) != "secret-hash" {
+		t.Errorf("reloaded user = %+v, %v", u, err)
+	}
+	if next, err := r.Create(core.User{Username: "bob"}); err != nil || next <= id {
+		t.Errorf("Create after reload = %d, %v; want id > %d", next, err, id)
+	}
+}
+
+func TestUserRepoSQLite(t *testing.T) {
+	repotest.RunUsers(t, func(t *testing.T) (repo.UserRepository, repo.SessionRepository) {
+		db := openTestDB(t)
+		users, err := repo.NewUserRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewUserRepoSQLite: %v", err)
+		}
+		sessions, err := repo.NewSessionRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewSessionRepoSQLite: %v", err)
+		}
+		return users, sessions
+	})
+}
+
+func TestAPIKeyRepoMem(t *testing.T) {
+	repotest.RunAPIKeys(t, func(t *testing.T) (repo.UserRepository, repo.APIKeyRepository) {
+		return repo.NewUserRepoMem(), repo.NewAPIKeyRepoMem()
+	})
+}
+
+func TestAPIKeyRepoSQLite(t *testing.T) {
+	repotest.RunAPIKeys(t, func(t *testing.T) (repo.UserRepository, repo.APIKeyRepository) {
+		db := openTestDB(t)
+		users, err := repo.NewUserRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewUserRepoSQLite: %v", err)
+		}
+		keys, err := repo.NewAPIKeyRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewAPIKeyRepoSQLite: %v", err)
+		}
+		return users, keys
+	})
+}
+
+func TestWebhookRepoMem(t *testing.T) {
+	repotest.RunWebhooks(t, func(t *testing.T) (repo.UserRepository, repo.WebhookRepository) {
+		return repo.NewUserRepoMem(), repo.NewWebhookRepoMem()
+	})
+}
+
+func TestWebhookRepoSQLite(t *testing.T) {
+	repotest.RunWebhooks(t, func(t *testing.T) (repo.UserRepository, repo.WebhookRepository) {
+		db := openTestDB(t)
+		users, err := repo.NewUserRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewUserRepoSQLite: %v", err)
+		}
+		hooks, err := repo.NewWebhookRepoSQLite(db)
+		if err != nil {
+			t.Fatalf("NewWebhookRepoSQLite: %v", err)
+		}
+		return users, hooks
+	})
+}
@@ -0,0 +1,224 @@
+package repo
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// WebhookRepository — хранилище веб-хуков и их доставок. Веб-хук
+// принадлежит одному пользователю; его удаление удаляет и доставки.
+type WebhookRepository interface {
+	Create(h core.Webhook) (int64, error)
+	// Get возвращает веб-хук пользователя; чужой — ErrWebhookNotFound.
+	Get(userID, id int64) (*core.Webhook, error)
+	// List возвращает веб-хуки пользователя по возрастанию ID.
+	List(userID int64) ([]core.Webhook, error)
+	Delete(userID, id int64) error
+
+	// AddDelivery сохраняет доставку и возвращает её ID.
+	AddDelivery(d core.WebhookDelivery) (int64, error)
+	// GetDelivery возвращает доставку веб-хука webhookID пользователя.
+	GetDelivery(userID, webhookID, id int64) (*core.WebhookDelivery, error)
+	// UpdateDelivery сохраняет состояние доставки после попытки.
+	UpdateDelivery(d core.WebhookDelivery) error
+	// ListDeliveries возвращает доставки веб-хука от новых к старым.
+	ListDeliveries(q DeliveryQuery) ([]core.WebhookDelivery, error)
+	// DueDeliveries возвращает до limit ожидающих доставок, чья попытка
+	// назначена не позже now, от старых к новым.
+	DueDeliveries(now time.Time, limit int) ([]core.WebhookDelivery, error)
+	// PruneDeliveries удаляет завершённые (доставленные и исчерпавшие
+	// попытки) доставки, последняя попытка которых была раньше before, и
+	// возвращает их число.
+	PruneDeliveries(before time.Time) (int, error)
+}
+
+// DeliveryQuery — выборка журнала доставок одного веб-хука.
+type DeliveryQuery struct {
+	UserID    int64
+	WebhookID int64
+	// Status — только доставки в этом состоянии; "" — все.
+	Status core.DeliveryStatus
+	// Limit — максимум доставок; 0 — без ограничения.
+	Limit int
+}
+
+// WebhookRepoMem — in-memory реализация WebhookRepository.
+type WebhookRepoMem struct {
+	mu           sync.RWMutex
+	hooks        map[int64]*core.Webhook
+	deliveries   map[int64]*core.WebhookDelivery
+	next         int64
+	nextDelivery int64
+}
+
+func NewWebhookRepoMem() *WebhookRepoMem {
+	return &WebhookRepoMem{
+		hooks:      make(map[int64]*core.Webhook),
+		deliveries: make(map[int64]*core.WebhookDelivery),
+	}
+}
+
+func cloneWebhook(h *core.Webhook) *core.Webhook {
+	c := *h
+	c.Events = slices.Clone(h.Events)
+	return &c
+}
+
+func cloneDelivery(d *core.WebhookDelivery) *core.WebhookDelivery {
+	c := *d
+	c.Payload = slices.Clone(d.Payload)
+	if d.NextAttemptAt != nil {
+		t := *d.NextAttemptAt
+		c.NextAttemptAt = &t
+	}
+	if d.LastAttemptAt != nil {
+		t := *d.LastAttemptAt
+		c.LastAttemptAt = &t
+	}
+	return &c
+}
+
+func (r *WebhookRepoMem) Create(h core.Webhook) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.next++
+	h.ID = r.next
+	r.hooks[h.ID] = cloneWebhook(&h)
+	return h.ID, nil
+}
+
+func (r *WebhookRepoMem) Get(userID, id int64) (*core.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	h, ok := r.hooks[id]
+	if !ok || h.UserID != userID {
+		return nil, ErrWebhookNotFound
+	}
+	return cloneWebhook(h), nil
+}
+
+func (r *WebhookRepoMem) List(userID int64) ([]core.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]core.Webhook, 0)
+	for _, h := range r.hooks {
+		if h.UserID == userID {
+			out = append(out, *cloneWebhook(h))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *WebhookRepoMem) Delete(userID, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.hooks[id]
+	if !ok || h.UserID != userID {
+		return ErrWebhookNotFound
+	}
+	delete(r.hooks, id)
+	for did, d := range r.deliveries {
+		if d.WebhookID == id {
+			delete(r.deliveries, did)
+		}
+	}
+	return nil
+}
+
+func (r *WebhookRepoMem) AddDelivery(d core.WebhookDelivery) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.hooks[d.WebhookID]; !ok {
+		return 0, ErrWebhookNotFound
+	}
+	r.nextDelivery++
+	d.ID = r.nextDelivery
+	r.deliveries[d.ID] = cloneDelivery(&d)
+	return d.ID, nil
+}
+
+func (r *WebhookRepoMem) GetDelivery(userID, webhookID, id int64) (*core.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.deliveries[id]
+	if !ok || d.UserID != userID || d.WebhookID != webhookID {
+		return nil, ErrDeliveryNotFound
+	}
+	return cloneDelivery(d), nil
+}
+
+func (r *WebhookRepoMem) UpdateDelivery(d core.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.deliveries[d.ID]; !ok {
+		return ErrDeliveryNotFound
+	}
+	r.deliveries[d.ID] = cloneDelivery(&d)
+	return nil
+}
+
+func (r *WebhookRepoMem) ListDeliveries(q DeliveryQuery) ([]core.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]core.WebhookDelivery, 0)
+	for _, d := range r.deliveries {
+		if d.UserID == q.UserID && d.WebhookID == q.WebhookID && (q.Status == "" || d.Status == q.Status) {
+			out = append(out, *cloneDelivery(d))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out, nil
+}
+
+func (r *WebhookRepoMem) DueDeliveries(now time.Time, limit int) ([]core.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]core.WebhookDelivery, 0)
+	for _, d := range r.deliveries {
+		if d.Status == core.DeliveryPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) {
+			out = append(out, *cloneDelivery(d))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *WebhookRepoMem) PruneDeliveries(before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pruned := 0
+	for id, d := range r.deliveries {
+		if d.Status != core.DeliveryPending && d.LastAttemptAt != nil && d.LastAttemptAt.Before(before) {
+			delete(r.deliveries, id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
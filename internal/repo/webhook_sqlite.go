@@ -0,0 +1,257 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"example.com/notes-api/internal/core"
+)
+
+// События веб-хука хранятся одной строкой через пробел, как области
+// API-ключа.
+const webhookSchemaSQLite = `
+CREATE TABLE IF NOT EXISTS webhooks (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	url        TEXT    NOT NULL,
+	events     TEXT    NOT NULL,
+	secret     TEXT    NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS webhooks_user ON webhooks (user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	webhook_id      INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+	user_id         INTEGER NOT NULL,
+	event           TEXT    NOT NULL,
+	note_id         INTEGER NOT NULL,
+	payload         BLOB    NOT NULL,
+	status          TEXT    NOT NULL,
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	created_at      INTEGER NOT NULL,
+	next_attempt_at INTEGER,
+	last_attempt_at INTEGER,
+	response_status INTEGER NOT NULL DEFAULT 0,
+	last_error      TEXT    NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_hook ON webhook_deliveries (webhook_id, id);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);`
+
+const (
+	webhookColumns  = `id, user_id, url, events, secret, created_at`
+	deliveryColumns = `id, webhook_id, user_id, event, note_id, payload, status, attempts,
+		created_at, next_attempt_at, last_attempt_at, response_status, last_error`
+)
+
+// WebhookRepoSQLite — реализация WebhookRepository поверх SQLite.
+type WebhookRepoSQLite struct {
+	db *sql.DB
+}
+
+// NewWebhookRepoSQLite создаёт репозиторий и при необходимости таблицы
+// веб-хуков и доставок (вместе со схемой пользователей, на которую они
+// ссылаются).
+func NewWebhookRepoSQLite(db *sql.DB) (*WebhookRepoSQLite, error) {
+	if _, err := db.Exec(userSchemaSQLite + webhookSchemaSQLite); err != nil {
+		return nil, err
+	}
+	return &WebhookRepoSQLite{db: db}, nil
+}
+
+func scanWebhook(s rowScanner) (*core.Webhook, error) {
+	var (
+		h         core.Webhook
+		events    string
+		createdAt int64
+	)
+	if err := s.Scan(&h.ID, &h.UserID, &h.URL, &events, &h.Secret, &createdAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	h.Events = strings.Fields(events)
+	h.CreatedAt = time.Unix(0, createdAt).UTC()
+	return &h, nil
+}
+
+func scanDelivery(s rowScanner) (*core.WebhookDelivery, error) {
+	var (
+		d             core.WebhookDelivery
+		status        string
+		createdAt     int64
+		nextAttemptAt sql.NullInt64
+		lastAttemptAt sql.NullInt64
+	)
+	err := s.Scan(&d.ID, &d.WebhookID, &d.UserID, &d.Event, &d.NoteID, &d.Payload, &status, &d.Attempts,
+		&createdAt, &nextAttemptAt, &lastAttemptAt, &d.ResponseStatus, &d.LastError)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	d.Status = core.DeliveryStatus(status)
+	d.CreatedAt = time.Unix(0, createdAt).UTC()
+	d.NextAttemptAt = timeFromNull(nextAttemptAt)
+	d.LastAttemptAt = timeFromNull(lastAttemptAt)
+	return &d, nil
+}
+
+func (r *WebhookRepoSQLite) Create(h core.Webhook) (int64, error) {
+	res, err := r.db.Exec(
+		`INSERT INTO webhooks (user_id, url, events, secret, created_at) VALUES (?, ?, ?, ?, ?)`,
+		h.UserID, h.URL, strings.Join(h.Events, " "), h.Secret, h.CreatedAt.UnixNano(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *WebhookRepoSQLite) Get(userID, id int64) (*core.Webhook, error) {
+	return scanWebhook(r.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = ? AND user_id = ?`, id, userID))
+}
+
+func (r *WebhookRepoSQLite) List(userID int64) ([]core.Webhook, error) {
+	rows, err := r.db.Query(`SELECT `+webhookColumns+` FROM webhooks WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]core.Webhook, 0)
+	for rows.Next() {
+		h, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *h)
+	}
+	return out, rows.Err()
+}
+
+func (r *WebhookRepoSQLite) Delete(userID, id int64) error {
+	return webhookAffected(r.db.Exec(`DELETE FROM webhooks WHERE id = ? AND user_id = ?`, id, userID))
+}
+
+func (r *WebhookRepoSQLite) AddDelivery(d core.WebhookDelivery) (int64, error) {
+	res, err := r.db.Exec(
+		`INSERT INTO webhook_deliveries (webhook_id, user_id, event, note_id, payload, status, attempts,
+			created_at, next_attempt_at, last_attempt_at, response_status, last_error)
+		 SELECT id, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ? FROM webhooks WHERE id = ?`,
+		d.UserID, d.Event, d.NoteID, []byte(d.Payload), string(d.Status), d.Attempts,
+		d.CreatedAt.UnixNano(), nullTime(d.NextAttemptAt), nullTime(d.LastAttemptAt), d.ResponseStatus, d.LastError,
+		d.WebhookID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return 0, err
+	} else if n == 0 {
+		return 0, ErrWebhookNotFound // веб-хук удалили, пока событие готовилось
+	}
+	return res.LastInsertId()
+}
+
+func (r *WebhookRepoSQLite) GetDelivery(userID, webhookID, id int64) (*core.WebhookDelivery, error) {
+	return scanDelivery(r.db.QueryRow(
+		`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = ? AND webhook_id = ? AND user_id = ?`,
+		id, webhookID, userID,
+	))
+}
+
+func (r *WebhookRepoSQLite) UpdateDelivery(d core.WebhookDelivery) error {
+	return deliveryAffected(r.db.Exec(
+		`UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, last_attempt_at = ?,
+			response_status = ?, last_error = ? WHERE id = ?`,
+		string(d.Status), d.Attempts, nullTime(d.NextAttemptAt), nullTime(d.LastAttemptAt),
+		d.ResponseStatus, d.LastError, d.ID,
+	))
+}
+
+func (r *WebhookRepoSQLite) ListDeliveries(q DeliveryQuery) ([]core.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE webhook_id = ? AND user_id = ?`
+	args := []any{q.WebhookID, q.UserID}
+	if q.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, string(q.Status))
+	}
+	query += ` ORDER BY id DESC`
+	if q.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, q.Limit)
+	}
+	return r.queryDeliveries(query, args...)
+}
+
+func (r *WebhookRepoSQLite) DueDeliveries(now time.Time, limit int) ([]core.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ? ORDER BY id`
+	args := []any{string(core.DeliveryPending), now.UnixNano()}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+	return r.queryDeliveries(query, args...)
+}
+
+func (r *WebhookRepoSQLite) queryDeliveries(query string, args ...any) ([]core.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]core.WebhookDelivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
+
+func (r *WebhookRepoSQLite) PruneDeliveries(before time.Time) (int, error) {
+	res, err := r.db.Exec(
+		`DELETE FROM webhook_deliveries WHERE status <> ? AND last_attempt_at < ?`,
+		string(core.DeliveryPending), before.UnixNano(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// webhookAffected превращает «ни одна строка не затронута» в
+// ErrWebhookNotFound.
+func webhookAffected(res sql.Result, err error) error {
+	return rowsAffected(res, err, ErrWebhookNotFound)
+}
+
+// deliveryAffected превращает «ни одна строка не затронута» в
+// ErrDeliveryNotFound.
+func deliveryAffected(res sql.Result, err error) error {
+	return rowsAffected(res, err, ErrDeliveryNotFound)
+}
+
+func rowsAffected(res sql.Result, err error, notFound error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"example.com/notes-api/internal/core"
+)
+
+// Параметры ранжирования BM25; совпадение в заголовке весит как
+// titleBoost совпадений в тексте.
+const (
+	bm25K1     = 1.2
+	bm25B      = 0.75
+	titleBoost = 2.0
+
+	snippetRadius = 12 // слов вокруг первого совпадения
+)
+
+// field — проиндексированный текст одного поля заметки.
+type field struct {
+	text   string
+	tokens []Token
+}
+
+type doc struct {
+	owner   int64
+	title   field
+	content field
+}
+
+func (d *doc) length() int {
+	return len(d.title.tokens) + len(d.content.tokens)
+}
+
+// positions — позиции термина в полях документа.
+type positions struct {
+	title   []int
+	content []int
+}
+
+// MemIndex — инвертированный индекс в памяти с позициями слов
+// (для фраз) и ранжированием BM25.
+type MemIndex struct {
+	mu       sync.RWMutex
+	docs     map[int64]*doc
+	postings map[string]map[int64]*positions
+	totalLen int
+}
+
+// NewMemIndex создаёт пустой индекс.
+func NewMemIndex() *MemIndex {
+	return &MemIndex{
+		docs:     make(map[int64]*doc),
+		postings: make(map[string]map[int64]*positions),
+	}
+}
+
+func (ix *MemIndex) Index(n core.Note) error {
+	d := &doc{
+		owner:   n.OwnerID,
+		title:   field{text: n.Title, tokens: Tokenize(n.Title)},
+		content: field{text: n.Content, tokens: Tokenize(n.Content)},
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.remove(n.ID)
+	ix.docs[n.ID] = d
+	ix.totalLen += d.length()
+	for _, t := range d.title.tokens {
+		p := ix.posting(t.Term, n.ID)
+		p.title = append(p.title, t.Pos)
+	}
+	for _, t := range d.content.tokens {
+		p := ix.posting(t.Term, n.ID)
+		p.content = append(p.content, t.Pos)
+	}
+	return nil
+}
+
+func (ix *MemIndex) Remove(id int64) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.remove(id)
+	return nil
+}
+
+// posting возвращает (создавая при необходимости) позиции термина в документе.
+func (ix *MemIndex) posting(term string, id int64) *positions {
+	docs, ok := ix.postings[term]
+	if !ok {
+		docs = make(map[int64]*positions)
+		ix.postings[term] = docs
+	}
+	p, ok := docs[id]
+	if !ok {
+		p = &positions{}
+		docs[id] = p
+	}
+	return p
+}
+
+func (ix *MemIndex) remove(id int64) {
+	d, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	for _, f := range []field{d.title, d.content} {
+		for _, t := range f.tokens {
+			if docs, ok := ix.postings[t.Term]; ok {
+				delete(docs, id)
+				if len(docs) == 0 {
+					delete(ix.postings, t.Term)
+				}
+			}
+		}
+	}
+	ix.totalLen -= d.length()
+	delete(ix.docs, id)
+}
+
+func (ix *MemIndex) Search(q Query, limit int) ([]Hit, error) {
+	if q.Empty() {
+		return nil, nil
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	candidates := ix.candidates(q)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	avgLen := float64(ix.totalLen) / float64(len(ix.docs))
+	hits := make([]Hit, 0, len(candidates))
+	for _, id := range candidates {
+		d := ix.docs[id]
+		var (
+			score        float64
+			titleMatch   = make(map[int]bool)
+			contentMatch = make(map[int]bool)
+		)
+		for _, c := range q.Clauses {
+			for _, term := range c.Terms {
+				p := ix.postings[term][id]
+				tf := titleBoost*float64(len(p.title)) + float64(len(p.content))
+				idf := math.Log(1 + (float64(len(ix.docs))-float64(len(ix.postings[term]))+0.5)/(float64(len(ix.postings[term]))+0.5))
+				score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(d.length())/avgLen))
+			}
+			markClause(c, positionsIn(ix, id), titleMatch, contentMatch)
+		}
+		hits = append(hits, Hit{
+			NoteID:  id,
+			Score:   score,
+			Title:   highlight(d.title, titleMatch, -1),
+			Snippet: highlight(d.content, contentMatch, snippetRadius),
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].NoteID < hits[j].NoteID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// positionsIn возвращает функцию доступа к позициям терминов в документе id.
+func positionsIn(ix *MemIndex, id int64) func(term string) *positions {
+	return func(term string) *positions {
+		return ix.postings[term][id]
+	}
+}
+
+// candidates — документы, удовлетворяющие всем условиям запроса.
+func (ix *MemIndex) candidates(q Query) []int64 {
+	// начинаем с самого редкого термина, чтобы пересечение было коротким
+	var rarest map[int64]*positions
+	for _, c := range q.Clauses {
+		for _, term := range c.Terms {
+			docs := ix.postings[term]
+			if len(docs) == 0 {
+				return nil
+			}
+			if rarest == nil || len(docs) < len(rarest) {
+				rarest = docs
+			}
+		}
+	}
+
+	var ids []int64
+	for id := range rarest {
+		if q.OwnerID != 0 && ix.docs[id].owner != q.OwnerID {
+			continue
+		}
+		ok := true
+		for _, c := range q.Clauses {
+			if !ix.matchesClause(c, id) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (ix *MemIndex) matchesClause(c Clause, id int64) bool {
+	for _, term := range c.Terms {
+		if _, ok := ix.postings[term][id]; !ok {
+			return false
+		}
+	}
+	if !c.Phrase() {
+		return true
+	}
+	get := positionsIn(ix, id)
+	return len(phraseStarts(c.Terms, func(t string) []int { return get(t).title })) > 0 ||
+		len(phraseStarts(c.Terms, func(t string) []int { return get(t).content })) > 0
+}
+
+// phraseStarts — позиции, с которых в поле идут все термины фразы подряд.
+func phraseStarts(terms []string, pos func(term string) []int) []int {
+	next := make([]map[int]bool, len(terms))
+	for i, t := range terms[1:] {
+		next[i+1] = make(map[int]bool)
+		for _, p := range pos(t) {
+			next[i+1][p] = true
+		}
+	}
+	var starts []int
+	for _, start := range pos(terms[0]) {
+		ok := true
+		for i := 1; i < len(terms); i++ {
+			if !next[i][start+i] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
+
+// markClause отмечает позиции слов, совпавших с условием, для подсветки.
+// У фразы подсвечиваются только вхождения целиком.
+func markClause(c Clause, get func(term string) *positions, title, content map[int]bool) {
+	if !c.Phrase() {
+		for _, pos := range get(c.Terms[0]).title {
+			title[pos] = true
+		}
+		for _, pos := range get(c.Terms[0]).content {
+			content[pos] = true
+		}
+		return
+	}
+	mark := func(field func(term string) []int, dst map[int]bool) {
+		for _, start := range phraseStarts(c.Terms, field) {
+			for i := range c.Terms {
+				dst[start+i] = true
+			}
+		}
+	}
+	mark(func(t string) []int { return get(t).title }, title)
+	mark(func(t string) []int { return get(t).content }, content)
+}
+
+// highlight возвращает экранированный текст поля с <mark> вокруг
+// отмеченных слов. При radius >= 0 берётся только окно в radius слов
+// вокруг первого совпадения (или начало текста, если совпадений нет).
+func highlight(f field, marked map[int]bool, radius int) string {
+	if len(f.tokens) == 0 {
+		return html.EscapeString(f.text)
+	}
+
+	from, to := 0, len(f.tokens)-1
+	if radius >= 0 {
+		first := 0
+		for i := range f.tokens {
+			if marked[i] {
+				first = i
+				break
+			}
+		}
+		from = max(0, first-radius)
+		to = min(len(f.tokens)-1, first+radius)
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	start := 0
+	if from > 0 {
+		start = f.tokens[from].Start
+	}
+	for i := from; i <= to; i++ {
+		t := f.tokens[i]
+		b.WriteString(html.EscapeString(f.text[start:t.Start]))
+		if marked[i] {
+			b.WriteString("<mark>" + html.EscapeString(f.text[t.Start:t.End]) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(f.text[t.Start:t.End]))
+		}
+		start = t.End
+	}
+	if to == len(f.tokens)-1 {
+		b.WriteString(html.EscapeString(f.text[start:]))
+	} else {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"example.com/notes-api/internal/core"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"Заметка", "заметки"},
+		{"заметкой", "ЗАМЕТКАМИ"},
+		{"Ёлка", "елки"},
+		{"Café", "cafe"},
+		{"Meetings", "meeting"},
+		{"stories", "story"},
+	}
+	for _, tt := range tests {
+		if Normalize(tt.a) != Normalize(tt.b) {
+			t.Errorf("Normalize(%q) = %q, Normalize(%q) = %q; want equal",
+				tt.a, Normalize(tt.a), tt.b, Normalize(tt.b))
+		}
+	}
+}
+
+func newTestIndex(t *testing.T, notes ...core.Note) *MemIndex {
+	t.Helper()
+	ix := NewMemIndex()
+	for _, n := range notes {
+		if err := ix.Index(n); err != nil {
+			t.Fatalf("Index(%d): %v", n.ID, err)
+		}
+	}
+	return ix
+}
+
+func searchIDs(t *testing.T, ix *MemIndex, q string) []int64 {
+	t.Helper()
+	hits, err := ix.Search(ParseQuery(q), 0)
+	if err != nil {
+		t.Fatalf("Search(%q): %v", q, err)
+	}
+	ids := make([]int64, len(hits))
+	for i, h := range hits {
+		ids[i] = h.NoteID
+	}
+	return ids
+}
+
+func TestMemIndexSearch(t *testing.T) {
+	ix := newTestIndex(t,
+		core.Note{ID: 1, Title: "План работ", Content: "Составить план работ на неделю"},
+		core.Note{ID: 2, Title: "Отчёт", Content: "Работ много, план не готов"},
+		core.Note{ID: 3, Title: "Meeting notes", Content: "Discussed the release plan"},
+	)
+
+	tests := []struct {
+		q    string
+		want []int64
+	}{
+		{"план", []int64{1, 2}},
+		{`"план работ"`, []int64{1}},
+		{"отчет", []int64{2}},
+		{"PLANS", []int64{3}},
+		{"план отчёт", []int64{2}},
+		{"нет такого", nil},
+	}
+	for _, tt := range tests {
+		got := searchIDs(t, ix, tt.q)
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.q, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q: got %v, want %v", tt.q, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestMemIndexUpdateAndRemove(t *testing.T) {
+	ix := newTestIndex(t, core.Note{ID: 1, Title: "старый заголовок"})
+
+	if err := ix.Index(core.Note{ID: 1, Title: "новый заголовок"}); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if got := searchIDs(t, ix, "старый"); len(got) != 0 {
+		t.Errorf("old title still found: %v", got)
+	}
+	if got := searchIDs(t, ix, "новый"); len(got) != 1 {
+		t.Errorf("new title not found: %v", got)
+	}
+
+	if err := ix.Remove(1); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if got := searchIDs(t, ix, "заголовок"); len(got) != 0 {
+		t.Errorf("removed note still found: %v", got)
+	}
+}
+
+func TestMemIndexHighlight(t *testing.T) {
+	ix := newTestIndex(t, core.Note{
+		ID:      1,
+		Title:   "Отчёт <черновик>",
+		Content: "Нужно подготовить отчёты к пятнице",
+	})
+
+	hits, err := ix.Search(ParseQuery("отчет"), 0)
+	if err != nil || len(hits) != 1 {
+		t.Fatalf("Search: %v, %v", hits, err)
+	}
+	if want := "<mark>Отчёт</mark> &lt;черновик&gt;"; hits[0].Title != want {
+		t.Errorf("Title = %q, want %q", hits[0].Title, want)
+	}
+	if !strings.Contains(hits[0].Snippet, "<mark>отчёты</mark>") {
+		t.Errorf("Snippet = %q, want highlighted match", hits[0].Snippet)
+	}
+}
+
+func TestMemIndexOwner(t *testing.T) {
+	ix := newTestIndex(t,
+		core.Note{ID: 1, OwnerID: 1, Title: "общий план"},
+		core.Note{ID: 2, OwnerID: 2, Title: "план чужой"},
+	)
+
+	q := ParseQuery("план")
+	q.OwnerID = 1
+	hits, err := ix.Search(q, 0)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(hits) != 1 || hits[0].NoteID != 1 {
+		t.Errorf("hits = %+v, want only note 1", hits)
+	}
+	if got := searchIDs(t, ix, "план"); len(got) != 2 {
+		t.Errorf("unscoped search = %v, want both notes", got)
+	}
+}
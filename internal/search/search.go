@@ -0,0 +1,77 @@
+// Package search — полнотекстовый поиск по заголовку и содержимому заметок.
+package search
+
+import (
+	"strings"
+
+	"example.com/notes-api/internal/core"
+)
+
+// Index — полнотекстовый индекс заметок. NoteService обновляет его при
+// каждом создании, изменении и удалении; хранилище с собственным поиском
+// (например, FTS в базе) может предоставить свою реализацию.
+type Index interface {
+	// Index добавляет заметку в индекс или заменяет её прежнюю версию.
+	Index(n core.Note) error
+	// Remove удаляет заметку из индекса; отсутствие заметки — не ошибка.
+	Remove(id int64) error
+	// Search возвращает до limit совпадений, лучшие первыми.
+	Search(q Query, limit int) ([]Hit, error)
+}
+
+// Hit — найденная заметка.
+type Hit struct {
+	NoteID int64
+	Score  float64
+	// Title и Snippet — HTML-экранированные заголовок и фрагмент
+	// содержимого, где совпадения обёрнуты в <mark>…</mark>.
+	Title   string
+	Snippet string
+}
+
+// Query — разобранный поисковый запрос: все условия должны выполняться.
+type Query struct {
+	Clauses []Clause
+	// OwnerID ограничивает поиск заметками одного владельца; 0 — без
+	// ограничения.
+	OwnerID int64
+}
+
+// Clause — одно условие запроса: слово или фраза (слова подряд).
+type Clause struct {
+	Terms []string
+}
+
+// Phrase сообщает, что условие — фраза из нескольких слов.
+func (c Clause) Phrase() bool {
+	return len(c.Terms) > 1
+}
+
+// Empty сообщает, что в запросе нет ни одного слова.
+func (q Query) Empty() bool {
+	return len(q.Clauses) == 0
+}
+
+// ParseQuery разбирает строку запроса. Слова в кавычках ищутся как фраза,
+// остальные — по отдельности; незакрытая кавычка действует до конца строки.
+func ParseQuery(s string) Query {
+	var q Query
+	for i, part := range strings.Split(s, `"`) {
+		tokens := Tokenize(part)
+		if len(tokens) == 0 {
+			continue
+		}
+		if i%2 == 1 { // внутри кавычек
+			c := Clause{Terms: make([]string, len(tokens))}
+			for j, t := range tokens {
+				c.Terms[j] = t.Term
+			}
+			q.Clauses = append(q.Clauses, c)
+			continue
+		}
+		for _, t := range tokens {
+			q.Clauses = append(q.Clauses, Clause{Terms: []string{t.Term}})
+		}
+	}
+	return q
+}
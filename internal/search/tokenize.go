@@ -0,0 +1,153 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Token — нормализованное слово и его место в исходном тексте.
+type Token struct {
+	Term  string // термин после нормализации и стемминга
+	Pos   int    // порядковый номер слова в тексте
+	Start int    // смещение начала слова в байтах
+	End   int    // смещение конца слова в байтах
+}
+
+// Tokenize разбивает текст на слова (последовательности букв и цифр
+// любого алфавита) и нормализует каждое через Normalize.
+func Tokenize(text string) []Token {
+	var (
+		tokens []Token
+		start  = -1
+	)
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		if term := Normalize(text[start:end]); term != "" {
+			tokens = append(tokens, Token{Term: term, Pos: len(tokens), Start: start, End: end})
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+	return tokens
+}
+
+// isWordRune — буквы, цифры и комбинируемые знаки (ударения и т. п.
+// внутри слова не должны его разрывать).
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// Normalize приводит слово к термину индекса: нижний регистр, лёгкий
+// стемминг (русский и английский), затем удаление диакритики
+// (ё → е, é → e). Стемминг идёт до свёртки, чтобы окончания на «й»
+// распознавались до превращения «й» в «и».
+func Normalize(word string) string {
+	word = strings.ToLower(norm.NFC.String(word))
+	word = stem(word)
+	return foldDiacritics(word)
+}
+
+var diacriticsFolder = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+func foldDiacritics(s string) string {
+	out, _, err := transform.String(diacriticsFolder, s)
+	if err != nil {
+		return s
+	}
+	return out
+}
+
+// Русские окончания, отсекаемые стеммером, — от длинных к коротким.
+var ruSuffixes = []string{
+	"иями", "ями", "ами", "ией", "иях", "ого", "его", "ому", "ему",
+	"ыми", "ими", "ешь", "ишь", "ете", "ите", "ать", "ять", "ить", "еть",
+	"ая", "яя", "ое", "ее", "ие", "ые", "ой", "ей", "ий", "ый", "ую", "юю",
+	"ах", "ях", "ом", "ем", "ам", "ям", "ов", "ев", "ию", "ья", "ье", "ьи",
+	"а", "я", "о", "е", "и", "ы", "у", "ю", "ь", "й",
+}
+
+// minStemRunes — слишком короткие слова не стеммятся, иначе «он» и «оно»
+// совпадут с чем угодно.
+const minStemRunes = 3
+
+// stem — упрощённый стеммер. Это не Snowball, но «заметка», «заметки»
+// и «заметкой», как и «meeting» и «meetings», сводятся к одному термину.
+func stem(word string) string {
+	if isCyrillic(word) {
+		return stemRussian(word)
+	}
+	return stemEnglish(word)
+}
+
+// stemRussian отрезает одно окончание, если остаётся не меньше
+// minStemRunes символов.
+func stemRussian(word string) string {
+	for _, suf := range ruSuffixes {
+		if base, ok := trimSuffix(word, suf); ok {
+			return base
+		}
+	}
+	return word
+}
+
+// stemEnglish убирает множественное число, затем -ing/-ed/-ly.
+func stemEnglish(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		if base, ok := trimSuffix(word, "ies"); ok {
+			word = base + "y"
+		}
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		word = strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"):
+		// class, status — не множественное число
+	case strings.HasSuffix(word, "s"):
+		if base, ok := trimSuffix(word, "s"); ok {
+			word = base
+		}
+	}
+	for _, suf := range []string{"ing", "ed", "ly"} {
+		if base, ok := trimSuffix(word, suf); ok {
+			return base
+		}
+	}
+	return word
+}
+
+// trimSuffix отрезает суффикс, только если основа не короче minStemRunes.
+func trimSuffix(word, suffix string) (string, bool) {
+	if !strings.HasSuffix(word, suffix) {
+		return word, false
+	}
+	base := strings.TrimSuffix(word, suffix)
+	if utf8.RuneCountInString(base) < minStemRunes {
+		return word, false
+	}
+	return base, true
+}
+
+func isCyrillic(word string) bool {
+	for _, r := range word {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
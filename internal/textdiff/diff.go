@@ -0,0 +1,128 @@
+// Package textdiff — построчное сравнение текстов.
+package textdiff
+
+import "strings"
+
+// Op — вид строки в результате сравнения.
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Line — строка результата: общая для обоих текстов, добавленная или удалённая.
+type Line struct {
+	Op   Op     `json:"op" example:"insert"`
+	Text string `json:"text" example:"новая строка"`
+}
+
+// maxCells ограничивает таблицу LCS (и память под неё). Если различающаяся
+// часть текстов больше, она выдаётся как «удалено всё, добавлено всё».
+const maxCells = 1 << 22
+
+// Lines сравнивает тексты построчно: результат превращает a в b
+// минимальным числом удалений и вставок строк.
+func Lines(a, b string) []Line {
+	x, y := split(a), split(b)
+
+	// общие начало и конец не участвуют в LCS
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	result := make([]Line, 0, len(x)+len(y))
+	for _, s := range x[:prefix] {
+		result = append(result, Line{Op: Equal, Text: s})
+	}
+	result = append(result, middle(x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])...)
+	for _, s := range x[len(x)-suffix:] {
+		result = append(result, Line{Op: Equal, Text: s})
+	}
+	return result
+}
+
+// Unified записывает результат в привычном виде: « », «+» или «-» в начале строки.
+func Unified(lines []Line) string {
+	var b strings.Builder
+	for _, l := range lines {
+		switch l.Op {
+		case Insert:
+			b.WriteByte('+')
+		case Delete:
+			b.WriteByte('-')
+		default:
+			b.WriteByte(' ')
+		}
+		b.WriteString(l.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// middle сравнивает участки без общих начала и конца через таблицу LCS.
+func middle(x, y []string) []Line {
+	n, m := len(x), len(y)
+	if n*m > maxCells {
+		result := make([]Line, 0, n+m)
+		for _, s := range x {
+			result = append(result, Line{Op: Delete, Text: s})
+		}
+		for _, s := range y {
+			result = append(result, Line{Op: Insert, Text: s})
+		}
+		return result
+	}
+
+	// lcs[i][j] — длина LCS для x[i:] и y[j:]
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	result := make([]Line, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case x[i] == y[j]:
+			result = append(result, Line{Op: Equal, Text: x[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, Line{Op: Delete, Text: x[i]})
+			i++
+		default:
+			result = append(result, Line{Op: Insert, Text: y[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		result = append(result, Line{Op: Delete, Text: x[i]})
+	}
+	for ; j < m; j++ {
+		result = append(result, Line{Op: Insert, Text: y[j]})
+	}
+	return result
+}
@@ -0,0 +1,24 @@
+package textdiff
+
+import "testing"
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "a\nb", "a\nb", " a\n b\n"},
+		{"insert", "a\nc", "a\nb\nc", " a\n+b\n c\n"},
+		{"delete", "a\nb\nc", "a\nc", " a\n-b\n c\n"},
+		{"replace", "a\nb\nc", "a\nx\nc", " a\n-b\n+x\n c\n"},
+		{"fromEmpty", "", "a\nb", "+a\n+b\n"},
+		{"toEmpty", "a", "", "-a\n"},
+		{"move", "a\nb\nc\nd", "c\nd\na\nb", "-a\n-b\n c\n d\n+a\n+b\n"},
+	}
+	for _, tt := range tests {
+		if got := Unified(Lines(tt.a, tt.b)); got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}